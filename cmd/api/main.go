@@ -3,69 +3,276 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // streaks are counted in each user's time zone regardless of the host's zone database
 
 	"github.com/pouyatavakoli/CodeStreaks-web/config"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/bot"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cache"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cli"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/graphapi"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/handler"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/infrastructure/database"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/infrastructure/scheduler"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/logging"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/stream"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
 	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/notifier"
 )
 
+// @title CodeStreaks API
+// @version 1.0
+// @description Daily Codeforces solving streaks, leaderboards, groups and achievements.
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description The admin API key, or on a user's own settings the owner key from a verified handle claim, sent as "Bearer <key>".
 func main() {
 	// Load configuration
 	cfg := config.Load()
-	log.Println("Configuration loaded")
+
+	// Initialize logging; the standard log package is routed through it too
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	slog.SetDefault(logger)
 
 	// Initialize database
-	db, err := database.NewDatabase(&cfg.Database)
+	db, err := database.NewDatabase(&cfg.Database, logger)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal(logger, "Failed to initialize database", err)
 	}
 	defer db.Close()
 
-	// Run migrations
-	if err := db.AutoMigrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// `main migrate ...` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := cli.Migrate(db, os.Args[2:], os.Stdout); err != nil {
+			fatal(logger, "Migration failed", err)
+		}
+		return
+	}
+
+	// Refuse to start against an outdated schema
+	if err := db.CheckSchemaVersion(); err != nil {
+		fatal(logger, "Schema check failed", err)
 	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB)
 	submissionRepo := repository.NewSubmissionRepository(db.DB)
+	snapshotRepo := repository.NewSnapshotRepository(db.DB)
+	achievementRepo := repository.NewAchievementRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	webhookRepo := repository.NewWebhookRepository(db.DB)
+	groupRepo := repository.NewGroupRepository(db.DB)
+	accountRepo := repository.NewAccountRepository(db.DB)
+	claimRepo := repository.NewClaimRepository(db.DB)
+
+	// Initialize judge clients
+	cfClient := codeforces.NewClient(cfg.Codeforces.BaseURL, cfg.Codeforces.RequestsPerSecond)
+	atcoderClient := atcoder.NewClient(cfg.AtCoder.BaseURL, cfg.AtCoder.ProblemsURL, cfg.AtCoder.RequestsPerSecond)
+	leetcodeClient := leetcode.NewClient(cfg.LeetCode.GraphQLURL, cfg.LeetCode.RequestsPerSecond)
+	judges := service.NewJudgeProviders(cfClient, atcoderClient, leetcodeClient)
+	systemClock := clock.System()
 
-	// Initialize Codeforces client
-	cfClient := codeforces.NewClient(cfg.Codeforces.BaseURL)
+	// Initialize response cache
+	responseCache, err := cache.New(
+		cfg.Cache.Backend,
+		cfg.Redis.URL,
+		time.Duration(cfg.Cache.TTL)*time.Second,
+		cfg.Cache.MaxEntries,
+		systemClock,
+	)
+	if err != nil {
+		fatal(logger, "Failed to initialize response cache", err)
+	}
+
+	// Initialize API rate limiting
+	limiter, err := ratelimit.New(cfg.RateLimit.Backend, cfg.Redis.URL, systemClock)
+	if err != nil {
+		fatal(logger, "Failed to initialize rate limiter", err)
+	}
 
 	// Initialize services
-	userService := service.NewUserService(userRepo, submissionRepo, cfClient)
+	webhookService := service.NewWebhookService(webhookRepo, cfg.Webhook.MaxAttempts, systemClock, logger)
+	hub := stream.NewHub(cfg.Stream.Buffer, logger)
+	events := service.MultiPublisher{webhookService, hub}
+	userService := service.NewUserService(
+		userRepo,
+		submissionRepo,
+		snapshotRepo,
+		groupRepo,
+		accountRepo,
+		events,
+		responseCache,
+		judges,
+		systemClock,
+		logger,
+	)
+	groupService := service.NewGroupService(groupRepo, userRepo, responseCache, logger)
+	achievementService := service.NewAchievementService(userRepo, achievementRepo, systemClock, logger)
 	syncService := service.NewSyncService(
 		userRepo,
 		submissionRepo,
-		cfClient,
+		accountRepo,
+		achievementService,
+		events,
+		responseCache,
+		judges,
 		cfg.Codeforces.WorkerPoolSize,
+		systemClock,
+		logger,
+	)
+	claimService := service.NewClaimService(claimRepo, userRepo, userService, cfClient, cfg.Claim.Problems, systemClock, logger)
+	snapshotService := service.NewSnapshotService(userRepo, snapshotRepo, responseCache, cfg.Snapshot.RetentionDays, systemClock, logger)
+
+	// Initialize notifiers; email and Telegram are only offered when configured
+	notifiers := []notifier.Notifier{
+		notifier.NewDiscordNotifier(cfg.Notify.AllowPrivateTargets),
+		notifier.NewWebhookNotifier(cfg.Notify.AllowPrivateTargets),
+	}
+	if cfg.Notify.SMTPHost != "" {
+		notifiers = append(notifiers, notifier.NewSMTPNotifier(
+			cfg.Notify.SMTPHost,
+			cfg.Notify.SMTPPort,
+			cfg.Notify.SMTPUsername,
+			cfg.Notify.SMTPPassword,
+			cfg.Notify.SMTPFrom,
+		))
+	}
+	if cfg.Notify.TelegramBotToken != "" {
+		notifiers = append(notifiers, notifier.NewTelegramNotifier(cfg.Notify.TelegramAPIURL, cfg.Notify.TelegramBotToken))
+	}
+	notificationService := service.NewNotificationService(
+		userRepo,
+		submissionRepo,
+		notificationRepo,
+		notifiers,
+		cfg.Notify.DefaultHoursBefore,
+		systemClock,
+		logger,
 	)
 
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(userService)
-	healthHandler := handler.NewHealthHandler(db)
+	userHandler := handler.NewUserHandler(
+		userService,
+		handler.NewResponseCache(responseCache, time.Duration(cfg.Cache.MaxAge)*time.Second, logger),
+		logger,
+	)
+	achievementHandler := handler.NewAchievementHandler(achievementService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	groupHandler := handler.NewGroupHandler(groupService)
+	healthHandler := handler.NewHealthHandler(
+		db,
+		syncService,
+		cfClient,
+		time.Duration(cfg.Codeforces.UpdateInterval)*time.Second,
+		systemClock,
+		logger,
+	)
+
+	// Initialize chat bot transports
+	chatBot := bot.New(userService, claimService, limiter, ratelimit.Limit{
+		PerMinute: cfg.RateLimit.UpstreamPerMinute,
+		Burst:     cfg.RateLimit.UpstreamBurst,
+	}, logger)
+	var transports []bot.Transport
+	if cfg.Bot.TelegramEnabled && cfg.Notify.TelegramBotToken != "" {
+		transports = append(transports, bot.NewTelegramTransport(cfg.Notify.TelegramAPIURL, cfg.Notify.TelegramBotToken, chatBot, logger))
+	}
+	var discordBot http.Handler
+	if cfg.Bot.DiscordPublicKey != "" {
+		discord, err := bot.NewDiscordTransport(
+			cfg.Bot.DiscordAPIURL,
+			cfg.Bot.DiscordAppID,
+			cfg.Bot.DiscordBotToken,
+			cfg.Bot.DiscordPublicKey,
+			chatBot,
+			logger,
+		)
+		if err != nil {
+			fatal(logger, "Failed to initialize Discord bot", err)
+		}
+		transports = append(transports, discord)
+		discordBot = discord
+	}
 
 	// Setup router
-	router := handler.NewRouter(userHandler, healthHandler)
+	router := handler.NewRouter(
+		userHandler,
+		achievementHandler,
+		notificationHandler,
+		webhookHandler,
+		groupHandler,
+		healthHandler,
+		handler.NewStreamHandler(hub, time.Duration(cfg.Stream.Heartbeat)*time.Second, logger),
+		handler.NewGraphQLHandler(graphapi.New(userService, achievementService, groupService)),
+		handler.NewOpenAPIHandler(cfg.OpenAPI.ValidateRequests, cfg.OpenAPI.ValidateResponses, cfg.OpenAPI.SwaggerUIAssets, logger),
+		handler.NewAdminHandler(userService, syncService),
+		handler.NewBadgeHandler(
+			userService,
+			handler.NewResponseCache(responseCache, time.Duration(cfg.Cache.BadgeMaxAge)*time.Second, logger),
+		),
+		handler.NewClaimHandler(claimService, userService),
+		handler.NewRateLimiter(limiter, map[string]ratelimit.Limit{
+			handler.RateClassRead:     {PerMinute: cfg.RateLimit.ReadPerMinute, Burst: cfg.RateLimit.ReadBurst},
+			handler.RateClassWrite:    {PerMinute: cfg.RateLimit.WritePerMinute, Burst: cfg.RateLimit.WriteBurst},
+			handler.RateClassUpstream: {PerMinute: cfg.RateLimit.UpstreamPerMinute, Burst: cfg.RateLimit.UpstreamBurst},
+		}, cfg.Server.AdminAPIKey, claimService, logger),
+		discordBot,
+		cfg.Server.AdminAPIKey,
+		logger,
+	)
 	engine := router.Setup()
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal(logger, "Invalid TRUSTED_PROXIES", err)
+	}
 
 	// Initialize and start scheduler
-	sched := scheduler.NewScheduler(syncService, cfg.Codeforces.UpdateInterval)
+	sched := scheduler.NewScheduler(
+		syncService,
+		snapshotService,
+		notificationService,
+		webhookService,
+		claimService,
+		cfg.Codeforces.UpdateInterval,
+		cfg.Snapshot.Schedule,
+		cfg.Notify.Schedule,
+		cfg.Webhook.DispatchSchedule,
+		cfg.Claim.CheckSchedule,
+		systemClock,
+		logger,
+	)
 	if err := sched.Start(); err != nil {
-		log.Fatalf("Failed to start scheduler: %v", err)
+		fatal(logger, "Failed to start scheduler", err)
 	}
 	defer sched.Stop()
 
+	// Start chat bot transports
+	botCtx, stopBots := context.WithCancel(context.Background())
+	defer stopBots()
+	for _, transport := range transports {
+		go func(t bot.Transport) {
+			logger.Info("Starting bot", "transport", t.Name())
+			if err := t.Run(botCtx); err != nil {
+				logger.Error("Bot stopped", "transport", t.Name(), "error", err)
+			}
+		}(transport)
+	}
+
 	// Setup HTTP server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -77,9 +284,9 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on port %s", cfg.Server.Port)
+		logger.Info("Starting server", "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal(logger, "Failed to start server", err)
 		}
 	}()
 
@@ -88,15 +295,23 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Streams never finish on their own; end them so Shutdown can drain.
+	hub.Close()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal(logger, "Server forced to shutdown", err)
 	}
 
-	log.Println("Server exited successfully")
+	logger.Info("Server exited")
+}
+
+// fatal logs err and exits; slog has no fatal level.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
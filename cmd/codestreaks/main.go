@@ -0,0 +1,144 @@
+// Command codestreaks is the admin CLI. By default it connects to the
+// database configured by the same environment variables as the server; with
+// -api (or CODESTREAKS_URL) it goes through a running server's HTTP API
+// instead, authenticating with -key (or ADMIN_API_KEY).
+//
+//	codestreaks user add tourist
+//	codestreaks -api https://streaks.example.com sync run -handle tourist
+//	codestreaks export -format json -o leaderboard.json
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // streaks are counted in each user's time zone regardless of the host's zone database
+
+	"github.com/pouyatavakoli/CodeStreaks-web/config"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cache"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cli"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/infrastructure/database"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/logging"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/client"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+)
+
+func main() {
+	flags := flag.NewFlagSet("codestreaks", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, cli.Usage) }
+	apiURL := flags.String("api", os.Getenv("CODESTREAKS_URL"), "server URL; use the HTTP API instead of the database")
+	apiKey := flags.String("key", os.Getenv("ADMIN_API_KEY"), "admin API key for -api")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *apiURL, *apiKey, flags.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "codestreaks:", err)
+		if errors.Is(err, cli.ErrUsage) {
+			fmt.Fprint(os.Stderr, "\n"+cli.Usage)
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, apiURL, apiKey string, args []string) error {
+	if apiURL != "" {
+		c := client.NewClient(apiURL, apiKey, &http.Client{Timeout: 10 * time.Minute})
+		return cli.Run(ctx, cli.NewAPIBackend(c), nil, args, os.Stdout)
+	}
+
+	cfg := config.Load()
+
+	// Log to stderr so command output on stdout stays clean for pipes.
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return fmt.Errorf("invalid logging configuration: %w", err)
+	}
+
+	db, err := database.NewDatabase(&cfg.Database, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	if len(args) == 0 || args[0] != "migrate" {
+		if err := db.CheckSchemaVersion(); err != nil {
+			return err
+		}
+	}
+
+	userRepo := repository.NewUserRepository(db.DB)
+	submissionRepo := repository.NewSubmissionRepository(db.DB)
+	snapshotRepo := repository.NewSnapshotRepository(db.DB)
+	achievementRepo := repository.NewAchievementRepository(db.DB)
+	webhookRepo := repository.NewWebhookRepository(db.DB)
+	groupRepo := repository.NewGroupRepository(db.DB)
+	accountRepo := repository.NewAccountRepository(db.DB)
+
+	judges := service.NewJudgeProviders(
+		codeforces.NewClient(cfg.Codeforces.BaseURL, cfg.Codeforces.RequestsPerSecond),
+		atcoder.NewClient(cfg.AtCoder.BaseURL, cfg.AtCoder.ProblemsURL, cfg.AtCoder.RequestsPerSecond),
+		leetcode.NewClient(cfg.LeetCode.GraphQLURL, cfg.LeetCode.RequestsPerSecond),
+	)
+	systemClock := clock.System()
+
+	// With the Redis backend this drops the server's cached responses after
+	// a sync; the in-memory cache only lives as long as this process.
+	responseCache, err := cache.New(
+		cfg.Cache.Backend,
+		cfg.Redis.URL,
+		time.Duration(cfg.Cache.TTL)*time.Second,
+		cfg.Cache.MaxEntries,
+		systemClock,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to initialize response cache: %w", err)
+	}
+
+	// Events go to the webhook outbox, which the server dispatches.
+	webhookService := service.NewWebhookService(webhookRepo, cfg.Webhook.MaxAttempts, systemClock, logger)
+	userService := service.NewUserService(
+		userRepo,
+		submissionRepo,
+		snapshotRepo,
+		groupRepo,
+		accountRepo,
+		webhookService,
+		responseCache,
+		judges,
+		systemClock,
+		logger,
+	)
+	groupService := service.NewGroupService(groupRepo, userRepo, responseCache, logger)
+	achievementService := service.NewAchievementService(userRepo, achievementRepo, systemClock, logger)
+	syncService := service.NewSyncService(
+		userRepo,
+		submissionRepo,
+		accountRepo,
+		achievementService,
+		webhookService,
+		responseCache,
+		judges,
+		cfg.Codeforces.WorkerPoolSize,
+		systemClock,
+		logger,
+	)
+
+	backend := cli.NewDBBackend(userService, groupService, syncService)
+	return cli.Run(ctx, backend, db, args, os.Stdout)
+}
@@ -0,0 +1,533 @@
+// Command openapi-gen reads the swaggo-style annotations on the HTTP
+// handlers and writes the operation table that pkg/openapi turns into the
+// OpenAPI document served at /api/v1/openapi.json. Run it through
+// go generate after changing an annotation:
+//
+//	go generate ./internal/handler
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	general := flag.String("general", "", "Go file holding the general API annotations (@title, @version, ...)")
+	dir := flag.String("dir", ".", "package directory holding the annotated handlers")
+	out := flag.String("out", "openapi_gen.go", "file to write, relative to -dir")
+	flag.Parse()
+
+	src, err := generate(*general, *dir, *out)
+	if err != nil {
+		log.Fatalf("openapi-gen: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *out), src, 0o644); err != nil {
+		log.Fatalf("openapi-gen: %v", err)
+	}
+}
+
+type info struct {
+	title, version, description string
+}
+
+type securityScheme struct {
+	name, kind, in, header, description string
+}
+
+type param struct {
+	name, in, typ, description string
+	required                   bool
+	defaultValue               string // Go literal, or ""
+}
+
+type response struct {
+	status      int
+	typ         string // Go type, or "" for no body
+	description string
+}
+
+type operation struct {
+	id, method, path, summary, description string
+	tags, consumes, produces, security     []string
+	params                                 []param
+	responses                              []response
+}
+
+// generate returns the formatted source of the operation table.
+func generate(generalFile, dir, out string) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	general, err := parser.ParseFile(fset, generalFile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	apiInfo, schemes, err := parseGeneral(general)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", generalFile, err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var pkgName string
+	imports := map[string]string{} // package name to import path, for the types used
+	var ops []operation
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == out {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkgName = file.Name.Name
+
+		fileImports := map[string]string{}
+		for _, imp := range file.Imports {
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			name := importPath[strings.LastIndex(importPath, "/")+1:]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			fileImports[name] = importPath
+		}
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			fnOps, err := parseOperations(fn.Name.Name, annotations(fn.Doc))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", fset.Position(fn.Pos()), fn.Name.Name, err)
+			}
+			for _, op := range fnOps {
+				for _, typ := range op.types() {
+					for _, qualifier := range qualifiers(typ) {
+						importPath, ok := fileImports[qualifier]
+						if !ok {
+							return nil, fmt.Errorf("%s: %s: unknown package %q in type %s", fset.Position(fn.Pos()), fn.Name.Name, qualifier, typ)
+						}
+						imports[qualifier] = importPath
+					}
+				}
+			}
+			ops = append(ops, fnOps...)
+		}
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].path != ops[j].path {
+			return ops[i].path < ops[j].path
+		}
+		return ops[i].method < ops[j].method
+	})
+	seen := map[string]bool{}
+	for _, op := range ops {
+		if seen[op.id] {
+			return nil, fmt.Errorf("duplicate operation ID %q", op.id)
+		}
+		seen[op.id] = true
+	}
+
+	return render(pkgName, apiInfo, schemes, ops, imports)
+}
+
+// annotations returns the @ lines of a doc comment without the @.
+func annotations(doc *ast.CommentGroup) []string {
+	var lines []string
+	for _, c := range doc.List {
+		line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if strings.HasPrefix(line, "@") {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+func parseGeneral(file *ast.File) (info, []securityScheme, error) {
+	var apiInfo info
+	var schemes []securityScheme
+	var current *securityScheme
+	for _, group := range file.Comments {
+		for _, line := range annotations(group) {
+			key, value, _ := strings.Cut(line, " ")
+			value = strings.TrimSpace(value)
+			switch {
+			case strings.HasPrefix(key, "securityDefinitions."):
+				schemes = append(schemes, securityScheme{
+					name: value,
+					kind: strings.ToLower(strings.TrimPrefix(key, "securityDefinitions.")),
+				})
+				current = &schemes[len(schemes)-1]
+			case current != nil && key == "in":
+				current.in = value
+			case current != nil && key == "name":
+				current.header = value
+			case current != nil && key == "description":
+				current.description = value
+			case key == "title":
+				apiInfo.title = value
+			case key == "version":
+				apiInfo.version = value
+			case key == "description":
+				apiInfo.description = value
+			case key == "BasePath":
+				// Routes are annotated with their full path.
+			default:
+				return info{}, nil, fmt.Errorf("unknown general annotation @%s", key)
+			}
+		}
+	}
+	if apiInfo.title == "" || apiInfo.version == "" {
+		return info{}, nil, fmt.Errorf("@title and @version are required")
+	}
+	return apiInfo, schemes, nil
+}
+
+var (
+	paramPattern    = regexp.MustCompile(`^(\S+)\s+(path|query|header|body)\s+(\S+)\s+(true|false)\s+"([^"]*)"\s*(.*)$`)
+	responsePattern = regexp.MustCompile(`^(\d{3})(?:\s+\{(\w+)\}\s+(\S+))?(?:\s+"([^"]*)")?\s*$`)
+	routerPattern   = regexp.MustCompile(`^(\S+)\s+\[(\w+)\]$`)
+	attrPattern     = regexp.MustCompile(`(\w+)\(([^)]*)\)`)
+	pathVarPattern  = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+// parseOperations turns one handler's annotations into an operation per
+// @Router line. Functions without @Router are not handlers.
+func parseOperations(funcName string, lines []string) ([]operation, error) {
+	var op operation
+	var routes [][2]string
+	for _, line := range lines {
+		key, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Summary":
+			op.summary = value
+		case "Description":
+			op.description = strings.TrimSpace(op.description + "\n" + value)
+		case "Tags":
+			op.tags = append(op.tags, splitList(value)...)
+		case "Accept":
+			for _, mime := range splitList(value) {
+				op.consumes = append(op.consumes, mimeType(mime))
+			}
+		case "Produce":
+			for _, mime := range splitList(value) {
+				op.produces = append(op.produces, mimeType(mime))
+			}
+		case "Security":
+			op.security = append(op.security, value)
+		case "ID":
+			op.id = value
+		case "Param":
+			p, err := parseParam(value)
+			if err != nil {
+				return nil, err
+			}
+			op.params = append(op.params, p)
+		case "Success", "Failure":
+			r, err := parseResponse(value)
+			if err != nil {
+				return nil, err
+			}
+			op.responses = append(op.responses, r)
+		case "Router":
+			m := routerPattern.FindStringSubmatch(value)
+			if m == nil {
+				return nil, fmt.Errorf("malformed @Router %q", value)
+			}
+			routes = append(routes, [2]string{m[1], strings.ToLower(m[2])})
+		default:
+			return nil, fmt.Errorf("unknown annotation @%s", key)
+		}
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+
+	var ops []operation
+	for _, route := range routes {
+		routeOp := op
+		routeOp.path, routeOp.method = route[0], route[1]
+		if routeOp.id == "" {
+			routeOp.id = funcName
+		}
+		if len(routes) > 1 {
+			routeOp.id += strings.ToUpper(route[1][:1]) + route[1][1:]
+		}
+
+		for _, m := range pathVarPattern.FindAllStringSubmatch(routeOp.path, -1) {
+			if !routeOp.hasParam(m[1], "path") {
+				return nil, fmt.Errorf("path parameter %q of %s has no @Param", m[1], routeOp.path)
+			}
+		}
+		for _, p := range routeOp.params {
+			if p.in == "path" && !strings.Contains(routeOp.path, "{"+p.name+"}") {
+				return nil, fmt.Errorf("@Param %s is not in the path %s", p.name, routeOp.path)
+			}
+		}
+		ops = append(ops, routeOp)
+	}
+	return ops, nil
+}
+
+func (op operation) hasParam(name, in string) bool {
+	for _, p := range op.params {
+		if p.name == name && p.in == in {
+			return true
+		}
+	}
+	return false
+}
+
+// types lists the Go types the operation refers to.
+func (op operation) types() []string {
+	var types []string
+	for _, p := range op.params {
+		types = append(types, p.typ)
+	}
+	for _, r := range op.responses {
+		if r.typ != "" {
+			types = append(types, r.typ)
+		}
+	}
+	return types
+}
+
+func parseParam(value string) (param, error) {
+	m := paramPattern.FindStringSubmatch(value)
+	if m == nil {
+		return param{}, fmt.Errorf("malformed @Param %q", value)
+	}
+	p := param{
+		name:        m[1],
+		in:          m[2],
+		typ:         goType(m[3]),
+		required:    m[4] == "true",
+		description: m[5],
+	}
+	for _, attr := range attrPattern.FindAllStringSubmatch(m[6], -1) {
+		switch attr[1] {
+		case "default":
+			literal, err := defaultLiteral(p.typ, attr[2])
+			if err != nil {
+				return param{}, fmt.Errorf("@Param %s: %w", p.name, err)
+			}
+			p.defaultValue = literal
+		case "collectionFormat":
+			if attr[2] != "multi" {
+				return param{}, fmt.Errorf("@Param %s: only collectionFormat(multi) is supported", p.name)
+			}
+		default:
+			return param{}, fmt.Errorf("@Param %s: unknown attribute %s", p.name, attr[1])
+		}
+	}
+	return p, nil
+}
+
+func parseResponse(value string) (response, error) {
+	m := responsePattern.FindStringSubmatch(value)
+	if m == nil {
+		return response{}, fmt.Errorf("malformed response %q", value)
+	}
+	status, _ := strconv.Atoi(m[1])
+	r := response{status: status, description: m[4]}
+	switch m[2] {
+	case "":
+	case "object", "string", "integer", "number", "boolean":
+		r.typ = goType(m[3])
+	case "array":
+		r.typ = "[]" + goType(m[3])
+	default:
+		return response{}, fmt.Errorf("unknown response kind {%s}", m[2])
+	}
+	return r, nil
+}
+
+// goType maps swaggo's type names to Go types; anything else already is
+// one.
+func goType(typ string) string {
+	if elem, ok := strings.CutPrefix(typ, "[]"); ok {
+		return "[]" + goType(elem)
+	}
+	switch typ {
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "file":
+		return "[]byte"
+	}
+	return typ
+}
+
+func defaultLiteral(typ, value string) (string, error) {
+	switch typ {
+	case "int", "int64", "uint":
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("default %q is not an integer", value)
+		}
+		return value, nil
+	case "float64":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("default %q is not a number", value)
+		}
+		return value, nil
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "", fmt.Errorf("default %q is not a boolean", value)
+		}
+		return value, nil
+	case "string":
+		return strconv.Quote(value), nil
+	}
+	return "", fmt.Errorf("defaults are not supported for %s", typ)
+}
+
+func mimeType(alias string) string {
+	switch alias {
+	case "json":
+		return "application/json"
+	case "plain":
+		return "text/plain"
+	case "html":
+		return "text/html"
+	case "xml":
+		return "application/xml"
+	}
+	return alias
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+var qualifierPattern = regexp.MustCompile(`\b([a-z]\w*)\.`)
+
+// qualifiers returns the package names a type expression refers to.
+func qualifiers(typ string) []string {
+	var names []string
+	for _, m := range qualifierPattern.FindAllStringSubmatch(typ, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func render(pkgName string, apiInfo info, schemes []securityScheme, ops []operation, imports map[string]string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by openapi-gen from the handler annotations; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+
+	b.WriteString("import (\n\t\"reflect\"\n\n")
+	importPaths := []string{"github.com/pouyatavakoli/CodeStreaks-web/pkg/openapi"}
+	for name, path := range imports {
+		if name == "openapi" {
+			continue
+		}
+		importPaths = append(importPaths, path)
+	}
+	sort.Strings(importPaths)
+	for _, path := range importPaths {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, "var openAPIInfo = openapi.Info{\n\tTitle: %q,\n\tVersion: %q,\n\tDescription: %q,\n}\n\n",
+		apiInfo.title, apiInfo.version, apiInfo.description)
+
+	b.WriteString("var openAPISecuritySchemes = map[string]*openapi.SecurityScheme{\n")
+	for _, s := range schemes {
+		fmt.Fprintf(&b, "\t%q: {Type: %q, In: %q, Name: %q, Description: %q},\n",
+			s.name, securityType(s.kind), s.in, s.header, s.description)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("var openAPIOperations = []openapi.Operation{\n")
+	for _, op := range ops {
+		b.WriteString("\t{\n")
+		fmt.Fprintf(&b, "\t\tID: %q,\n\t\tMethod: %q,\n\t\tPath: %q,\n", op.id, op.method, op.path)
+		if op.summary != "" {
+			fmt.Fprintf(&b, "\t\tSummary: %q,\n", op.summary)
+		}
+		if op.description != "" {
+			fmt.Fprintf(&b, "\t\tDescription: %q,\n", op.description)
+		}
+		writeStrings(&b, "Tags", op.tags)
+		writeStrings(&b, "Consumes", op.consumes)
+		writeStrings(&b, "Produces", op.produces)
+		writeStrings(&b, "Security", op.security)
+		if len(op.params) > 0 {
+			b.WriteString("\t\tParams: []openapi.Param{\n")
+			for _, p := range op.params {
+				fmt.Fprintf(&b, "\t\t\t{Name: %q, In: %q, Type: reflect.TypeFor[%s](), Required: %t, Description: %q",
+					p.name, p.in, p.typ, p.required, p.description)
+				if p.defaultValue != "" {
+					fmt.Fprintf(&b, ", Default: %s", p.defaultValue)
+				}
+				b.WriteString("},\n")
+			}
+			b.WriteString("\t\t},\n")
+		}
+		b.WriteString("\t\tResponses: []openapi.Response{\n")
+		for _, r := range op.responses {
+			fmt.Fprintf(&b, "\t\t\t{Status: %d", r.status)
+			if r.typ != "" {
+				fmt.Fprintf(&b, ", Type: reflect.TypeFor[%s]()", r.typ)
+			}
+			if r.description != "" {
+				fmt.Fprintf(&b, ", Description: %q", r.description)
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("\t\t},\n\t},\n")
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, b.Bytes())
+	}
+	return src, nil
+}
+
+func securityType(kind string) string {
+	if kind == "apikey" {
+		return "apiKey"
+	}
+	return kind
+}
+
+func writeStrings(b *bytes.Buffer, field string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	fmt.Fprintf(b, "\t\t%s: []string{%s},\n", field, strings.Join(quoted, ", "))
+}
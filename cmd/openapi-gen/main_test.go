@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestGeneratedFileIsCurrent fails when a handler annotation changed
+// without go generate being rerun.
+func TestGeneratedFileIsCurrent(t *testing.T) {
+	got, err := generate("../../cmd/api/main.go", "../../internal/handler", "openapi_gen.go")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	want, err := os.ReadFile("../../internal/handler/openapi_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("internal/handler/openapi_gen.go is stale; run go generate ./internal/handler")
+	}
+}
+
+func TestParseOperationsRejectsUndeclaredPathParam(t *testing.T) {
+	_, err := parseOperations("GetUser", []string{
+		"@Summary Get user",
+		"@Success 200 {object} string",
+		"@Router /users/{handle} [get]",
+	})
+	if err == nil {
+		t.Error("expected an error for the undeclared {handle} parameter")
+	}
+}
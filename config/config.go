@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,26 +13,151 @@ type Config struct {
 	Database   DatabaseConfig
 	Server     ServerConfig
 	Codeforces CodeforcesConfig
+	AtCoder    AtCoderConfig
+	LeetCode   LeetCodeConfig
+	Snapshot   SnapshotConfig
+	Notify     NotifyConfig
+	Webhook    WebhookConfig
+	Claim      ClaimConfig
+	Bot        BotConfig
+	Log        LogConfig
+	Cache      CacheConfig
+	RateLimit  RateLimitConfig
+	Redis      RedisConfig
+	Stream     StreamConfig
+	OpenAPI    OpenAPIConfig
 }
 
 type DatabaseConfig struct {
+	Driver   string // "postgres" or "sqlite"
+	Path     string // SQLite database file, or ":memory:"
 	Host     string
 	Port     int
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+	LogLevel string // GORM log level: silent, error, warn or info (every statement)
+}
+
+type LogConfig struct {
+	Level  string // debug, info, warn or error
+	Format string // text or json
+}
+
+// CacheConfig controls the leaderboard and user response cache.
+type CacheConfig struct {
+	Backend    string // memory, redis or none
+	TTL        int    // seconds; a backstop, entries are dropped on every sync
+	MaxEntries int    // memory backend only
+	MaxAge     int    // seconds, sent as Cache-Control max-age
+	// BadgeMaxAge is MaxAge for SVG badges and cards, which are fetched on
+	// every view of the page that embeds them.
+	BadgeMaxAge int
+}
+
+// RateLimitConfig sizes the token buckets of each API route class. A
+// PerMinute of 0 turns a class's limit off.
+type RateLimitConfig struct {
+	Backend           string // memory, redis or none
+	ReadPerMinute     int
+	ReadBurst         int
+	WritePerMinute    int
+	WriteBurst        int
+	UpstreamPerMinute int // routes that call a judge's API, on top of write
+	UpstreamBurst     int
+}
+
+// StreamConfig tunes the live event stream.
+type StreamConfig struct {
+	Buffer    int // events queued per client before it is dropped
+	Heartbeat int // seconds between keep-alive messages
+}
+
+// OpenAPIConfig controls validation against the generated OpenAPI document
+// and where the Swagger UI loads its assets from.
+type OpenAPIConfig struct {
+	ValidateRequests  bool
+	ValidateResponses bool   // logs mismatches; meant for development and CI
+	SwaggerUIAssets   string // base URL of a swagger-ui-dist copy
+}
+
+// RedisConfig points at a Redis server shared by all replicas.
+type RedisConfig struct {
+	URL string // redis://[:password@]host[:port][/db]
 }
 
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port        string
+	Env         string
+	AdminAPIKey string
+	// TrustedProxies may set the client IP through X-Forwarded-For; empty
+	// trusts none, so the peer address is the client.
+	TrustedProxies []string
 }
 
 type CodeforcesConfig struct {
-	BaseURL        string
-	WorkerPoolSize int
-	UpdateInterval int // seconds
+	BaseURL           string
+	WorkerPoolSize    int
+	UpdateInterval    int     // seconds
+	RequestsPerSecond float64 // shared by all workers; 0 disables the limit
+}
+
+// AtCoderConfig points at atcoder.jp, for profiles, and the AtCoder Problems
+// API, for submissions, which AtCoder itself does not offer.
+type AtCoderConfig struct {
+	BaseURL           string
+	ProblemsURL       string
+	RequestsPerSecond float64 // shared by all workers; 0 disables the limit
+}
+
+type LeetCodeConfig struct {
+	GraphQLURL        string
+	RequestsPerSecond float64 // shared by all workers; 0 disables the limit
+}
+
+type SnapshotConfig struct {
+	Schedule      string // cron spec, seconds field first
+	RetentionDays int
+}
+
+type NotifyConfig struct {
+	Schedule           string // cron spec for the streak-at-risk check
+	DefaultHoursBefore int
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	TelegramAPIURL     string
+	TelegramBotToken   string
+	// AllowPrivateTargets lets webhook and Discord notifications reach
+	// loopback and private addresses, for receivers on the server's own
+	// network. Targets are set by users, so it is off by default.
+	AllowPrivateTargets bool
+}
+
+// BotConfig enables the chat command transports. Telegram reuses the bot
+// token from NotifyConfig.
+type BotConfig struct {
+	TelegramEnabled  bool
+	DiscordAPIURL    string
+	DiscordAppID     string
+	DiscordBotToken  string
+	DiscordPublicKey string
+}
+
+type WebhookConfig struct {
+	DispatchSchedule string // cron spec for draining the outbox
+	MaxAttempts      int
+}
+
+// ClaimConfig controls the challenges that prove a handle's ownership.
+type ClaimConfig struct {
+	// Problems are the problems, such as "4A", a compilation error challenge
+	// picks one from to fail to compile on.
+	Problems      []string
+	CheckSchedule string // cron spec for checking pending claims
 }
 
 func Load() *Config {
@@ -42,26 +168,134 @@ func Load() *Config {
 	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
 	workerPoolSize, _ := strconv.Atoi(getEnv("WORKER_POOL_SIZE", "10"))
 	updateInterval, _ := strconv.Atoi(getEnv("UPDATE_INTERVAL", "60"))
+	requestsPerSecond, _ := strconv.ParseFloat(getEnv("CODEFORCES_REQUESTS_PER_SECOND", "5"), 64)
+	atcoderRequestsPerSecond, _ := strconv.ParseFloat(getEnv("ATCODER_REQUESTS_PER_SECOND", "1"), 64)
+	leetcodeRequestsPerSecond, _ := strconv.ParseFloat(getEnv("LEETCODE_REQUESTS_PER_SECOND", "1"), 64)
+	snapshotRetention, _ := strconv.Atoi(getEnv("SNAPSHOT_RETENTION_DAYS", "90"))
+	notifyHoursBefore, _ := strconv.Atoi(getEnv("NOTIFY_HOURS_BEFORE", "3"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	cacheTTL, _ := strconv.Atoi(getEnv("CACHE_TTL", "300"))
+	cacheMaxEntries, _ := strconv.Atoi(getEnv("CACHE_MAX_ENTRIES", "1000"))
+	cacheMaxAge, _ := strconv.Atoi(getEnv("CACHE_MAX_AGE", "30"))
+	cacheBadgeMaxAge, _ := strconv.Atoi(getEnv("CACHE_BADGE_MAX_AGE", "300"))
+	rateReadPerMinute, _ := strconv.Atoi(getEnv("RATE_LIMIT_READ_PER_MINUTE", "300"))
+	rateReadBurst, _ := strconv.Atoi(getEnv("RATE_LIMIT_READ_BURST", "60"))
+	rateWritePerMinute, _ := strconv.Atoi(getEnv("RATE_LIMIT_WRITE_PER_MINUTE", "60"))
+	rateWriteBurst, _ := strconv.Atoi(getEnv("RATE_LIMIT_WRITE_BURST", "20"))
+	rateUpstreamPerMinute, _ := strconv.Atoi(getEnv("RATE_LIMIT_UPSTREAM_PER_MINUTE", "6"))
+	rateUpstreamBurst, _ := strconv.Atoi(getEnv("RATE_LIMIT_UPSTREAM_BURST", "3"))
+	streamBuffer, _ := strconv.Atoi(getEnv("STREAM_BUFFER", "64"))
+	streamHeartbeat, _ := strconv.Atoi(getEnv("STREAM_HEARTBEAT", "15"))
 
 	return &Config{
 		Database: DatabaseConfig{
+			Driver:   getEnv("DB_DRIVER", "postgres"),
+			Path:     getEnv("DB_PATH", "codestreaks.db"),
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     dbPort,
 			User:     getEnv("DB_USER", "postgres"),
 			Password: getEnv("DB_PASSWORD", "postgres"),
 			DBName:   getEnv("DB_NAME", "CodeStreaks"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			LogLevel: getEnv("DB_LOG_LEVEL", "warn"),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Env:            getEnv("ENV", "development"),
+			AdminAPIKey:    getEnv("ADMIN_API_KEY", ""),
+			TrustedProxies: splitList(getEnv("TRUSTED_PROXIES", "")),
 		},
 		Codeforces: CodeforcesConfig{
-			BaseURL:        getEnv("CODEFORCES_API_URL", "https://codeforces.com/api"),
-			WorkerPoolSize: workerPoolSize,
-			UpdateInterval: updateInterval,
+			BaseURL:           getEnv("CODEFORCES_API_URL", "https://codeforces.com/api"),
+			WorkerPoolSize:    workerPoolSize,
+			UpdateInterval:    updateInterval,
+			RequestsPerSecond: requestsPerSecond,
+		},
+		AtCoder: AtCoderConfig{
+			BaseURL:           getEnv("ATCODER_URL", "https://atcoder.jp"),
+			ProblemsURL:       getEnv("ATCODER_PROBLEMS_API_URL", "https://kenkoooo.com/atcoder"),
+			RequestsPerSecond: atcoderRequestsPerSecond,
+		},
+		LeetCode: LeetCodeConfig{
+			GraphQLURL:        getEnv("LEETCODE_GRAPHQL_URL", "https://leetcode.com/graphql"),
+			RequestsPerSecond: leetcodeRequestsPerSecond,
+		},
+		Snapshot: SnapshotConfig{
+			Schedule:      getEnv("SNAPSHOT_SCHEDULE", "CRON_TZ=Asia/Tehran 0 5 0 * * *"),
+			RetentionDays: snapshotRetention,
+		},
+		Notify: NotifyConfig{
+			Schedule:            getEnv("NOTIFY_SCHEDULE", "0 */15 * * * *"),
+			DefaultHoursBefore:  notifyHoursBefore,
+			SMTPHost:            getEnv("SMTP_HOST", ""),
+			SMTPPort:            smtpPort,
+			SMTPUsername:        getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:        getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:            getEnv("SMTP_FROM", "codestreaks@localhost"),
+			TelegramAPIURL:      getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+			TelegramBotToken:    getEnv("TELEGRAM_BOT_TOKEN", ""),
+			AllowPrivateTargets: getEnv("NOTIFY_ALLOW_PRIVATE_TARGETS", "false") == "true",
+		},
+		Webhook: WebhookConfig{
+			DispatchSchedule: getEnv("WEBHOOK_DISPATCH_SCHEDULE", "@every 10s"),
+			MaxAttempts:      webhookMaxAttempts,
+		},
+		Claim: ClaimConfig{
+			Problems:      splitList(getEnv("CLAIM_PROBLEMS", "4A,1A,71A,158A,231A,263A,282A,339A")),
+			CheckSchedule: getEnv("CLAIM_CHECK_SCHEDULE", "@every 20s"),
 		},
+		Bot: BotConfig{
+			TelegramEnabled:  getEnv("BOT_TELEGRAM_ENABLED", "false") == "true",
+			DiscordAPIURL:    getEnv("DISCORD_API_URL", "https://discord.com/api/v10"),
+			DiscordAppID:     getEnv("DISCORD_APPLICATION_ID", ""),
+			DiscordBotToken:  getEnv("DISCORD_BOT_TOKEN", ""),
+			DiscordPublicKey: getEnv("DISCORD_PUBLIC_KEY", ""),
+		},
+		Log: LogConfig{
+			Level:  getEnv("LOG_LEVEL", "info"),
+			Format: getEnv("LOG_FORMAT", "text"),
+		},
+		Cache: CacheConfig{
+			Backend:     getEnv("CACHE_BACKEND", "memory"),
+			TTL:         cacheTTL,
+			MaxEntries:  cacheMaxEntries,
+			MaxAge:      cacheMaxAge,
+			BadgeMaxAge: cacheBadgeMaxAge,
+		},
+		RateLimit: RateLimitConfig{
+			Backend:           getEnv("RATE_LIMIT_BACKEND", "memory"),
+			ReadPerMinute:     rateReadPerMinute,
+			ReadBurst:         rateReadBurst,
+			WritePerMinute:    rateWritePerMinute,
+			WriteBurst:        rateWriteBurst,
+			UpstreamPerMinute: rateUpstreamPerMinute,
+			UpstreamBurst:     rateUpstreamBurst,
+		},
+		Stream: StreamConfig{
+			Buffer:    streamBuffer,
+			Heartbeat: streamHeartbeat,
+		},
+		OpenAPI: OpenAPIConfig{
+			ValidateRequests:  getEnv("OPENAPI_VALIDATE_REQUESTS", "true") == "true",
+			ValidateResponses: getEnv("OPENAPI_VALIDATE_RESPONSES", "false") == "true",
+			SwaggerUIAssets:   getEnv("SWAGGER_UI_ASSETS_URL", "https://unpkg.com/swagger-ui-dist@5"),
+		},
+		Redis: RedisConfig{
+			URL: getEnv("REDIS_URL", "redis://localhost:6379"),
+		},
+	}
+}
+
+// splitList splits a comma-separated setting, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 func getEnv(key, fallback string) string {
@@ -8,4 +8,4 @@ WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'CodeStreaks')\gexec
 -- Create extensions if needed
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
--- The tables will be created automatically by GORM AutoMigrate
\ No newline at end of file
+-- Tables are created by the versioned migrations: go run cmd/api/main.go migrate up
\ No newline at end of file
@@ -0,0 +1,236 @@
+// Package badge renders a user's streak as SVG images for embedding in
+// READMEs and personal sites: a one-line badge and a larger stat card with
+// an activity heatmap.
+package badge
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// HeatmapDays is how many days of activity the card shows, ending today.
+const HeatmapDays = 12 * 7
+
+// Stats is what the images show.
+type Stats struct {
+	Name            string
+	CurrentStreak   int
+	MaxStreak       int
+	LeaderboardRank int
+	Rating          int
+	Rank            string // Codeforces rank, e.g. "expert"
+	// Heatmap is one entry per day, oldest first, as returned by
+	// UserService.GetActivityHeatmaps. The badge ignores it.
+	Heatmap []domain.HeatmapDay
+}
+
+// Theme is a color scheme.
+type Theme struct {
+	Name       string
+	Background string
+	Border     string
+	Title      string
+	Text       string
+	Muted      string
+	Accent     string    // current streak and the badge's value side
+	Label      string    // the badge's label side
+	Levels     [5]string // heatmap cell colors, from no activity to most
+}
+
+var themes = map[string]Theme{
+	"light": {
+		Name:       "light",
+		Background: "#ffffff",
+		Border:     "#e4e2e2",
+		Title:      "#24292f",
+		Text:       "#24292f",
+		Muted:      "#57606a",
+		Accent:     "#fb8c00",
+		Label:      "#555555",
+		Levels:     [5]string{"#ebedf0", "#ffe0b2", "#ffb74d", "#fb8c00", "#e65100"},
+	},
+	"dark": {
+		Name:       "dark",
+		Background: "#0d1117",
+		Border:     "#30363d",
+		Title:      "#e6edf3",
+		Text:       "#e6edf3",
+		Muted:      "#8b949e",
+		Accent:     "#ffa657",
+		Label:      "#30363d",
+		Levels:     [5]string{"#161b22", "#5a3210", "#9a4e12", "#d9731a", "#ffa657"},
+	},
+}
+
+// ParseTheme looks up a theme by name. The empty name is "light".
+func ParseTheme(name string) (Theme, error) {
+	if name == "" {
+		name = "light"
+	}
+	theme, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (want light or dark)", name)
+	}
+	return theme, nil
+}
+
+// Size scales an image without changing its layout.
+type Size struct {
+	Name  string
+	Scale float64
+}
+
+var sizes = map[string]Size{
+	"small":  {"small", 0.75},
+	"medium": {"medium", 1},
+	"large":  {"large", 1.5},
+}
+
+// ParseSize looks up a size by name. The empty name is "medium".
+func ParseSize(name string) (Size, error) {
+	if name == "" {
+		name = "medium"
+	}
+	size, ok := sizes[strings.ToLower(name)]
+	if !ok {
+		return Size{}, fmt.Errorf("unknown size %q (want small, medium or large)", name)
+	}
+	return size, nil
+}
+
+const fontFamily = "'Segoe UI', Ubuntu, 'Helvetica Neue', Helvetica, Arial, sans-serif"
+
+// Badge renders a shields-style badge: "codestreaks | 12 day streak".
+func Badge(stats Stats, theme Theme, size Size) []byte {
+	label := "codestreaks"
+	value := fmt.Sprintf("%d day streak", stats.CurrentStreak)
+	valueColor := theme.Accent
+	if stats.CurrentStreak == 0 {
+		valueColor = theme.Muted
+	}
+
+	labelWidth := textWidth(label) + 12
+	valueWidth := textWidth(value) + 12
+	width, height := labelWidth+valueWidth, 20
+
+	var b strings.Builder
+	open(&b, width, height, size, fmt.Sprintf("%s: %s, max %d", stats.Name, value, stats.MaxStreak))
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="%d" rx="3"/></clipPath>`, width, height)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="%d" fill="%s"/><rect x="%d" width="%d" height="%d" fill="%s"/></g>`,
+		labelWidth, height, theme.Label, labelWidth, valueWidth, height, valueColor)
+	fmt.Fprintf(&b, `<g fill="#ffffff" font-family="%s" font-size="11" text-anchor="middle">`, fontFamily)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text></g>`,
+		labelWidth/2, escape(label), labelWidth+valueWidth/2, escape(value))
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// Card renders a stat card with the handle, current and max streak,
+// leaderboard rank and a heatmap of the last HeatmapDays days.
+func Card(stats Stats, theme Theme, size Size) []byte {
+	const width, height = 495, 165
+
+	var b strings.Builder
+	open(&b, width, height, size, fmt.Sprintf("%s: %d day streak, max %d, rank %d",
+		stats.Name, stats.CurrentStreak, stats.MaxStreak, stats.LeaderboardRank))
+	fmt.Fprintf(&b, `<rect x="0.5" y="0.5" width="%d" height="%d" rx="4.5" fill="%s" stroke="%s"/>`,
+		width-1, height-1, theme.Background, theme.Border)
+	fmt.Fprintf(&b, `<g font-family="%s">`, fontFamily)
+
+	fmt.Fprintf(&b, `<text x="25" y="35" font-size="18" font-weight="600" fill="%s">%s</text>`, theme.Title, escape(stats.Name))
+	subtitle := "unrated"
+	if stats.Rank != "" {
+		subtitle = fmt.Sprintf("%s · %d", stats.Rank, stats.Rating)
+	}
+	fmt.Fprintf(&b, `<text x="25" y="55" font-size="12" fill="%s">%s</text>`, theme.Muted, escape(subtitle))
+
+	rank := "–"
+	if stats.LeaderboardRank > 0 {
+		rank = fmt.Sprintf("#%d", stats.LeaderboardRank)
+	}
+	for i, stat := range []struct {
+		value, label, color string
+	}{
+		{fmt.Sprint(stats.CurrentStreak), "current streak", theme.Accent},
+		{fmt.Sprint(stats.MaxStreak), "max streak", theme.Text},
+		{rank, "rank", theme.Text},
+	} {
+		x := 25 + i*85
+		fmt.Fprintf(&b, `<text x="%d" y="112" font-size="28" font-weight="700" fill="%s">%s</text>`, x, stat.color, escape(stat.value))
+		fmt.Fprintf(&b, `<text x="%d" y="132" font-size="11" fill="%s">%s</text>`, x, theme.Muted, escape(stat.label))
+	}
+
+	fmt.Fprintf(&b, `<text x="290" y="35" font-size="11" fill="%s">last 12 weeks</text>`, theme.Muted)
+	b.WriteString(`</g>`)
+	heatmap(&b, stats.Heatmap, theme, 290, 47)
+
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// heatmap draws days as a grid with one column per week, Sunday on top,
+// like the contribution graph people know from GitHub.
+func heatmap(b *strings.Builder, days []domain.HeatmapDay, theme Theme, x, y int) {
+	const cell, gap = 11, 3
+	if len(days) == 0 {
+		return
+	}
+
+	offset := 0
+	if first, err := time.Parse(time.DateOnly, days[0].Date); err == nil {
+		offset = int(first.Weekday())
+	}
+
+	fmt.Fprintf(b, `<g transform="translate(%d %d)">`, x, y)
+	for i, day := range days {
+		slot := offset + i
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %d accepted of %d</title></rect>`,
+			slot/7*(cell+gap), slot%7*(cell+gap), cell, cell, theme.Levels[level(day)],
+			escape(day.Date), day.Accepted, day.Submissions)
+	}
+	b.WriteString(`</g>`)
+}
+
+// level buckets a day's activity into a heatmap color: nothing, attempts
+// without a solve, then one, two or three, and four or more solves.
+func level(day domain.HeatmapDay) int {
+	switch {
+	case day.Accepted >= 4:
+		return 4
+	case day.Accepted >= 2:
+		return 3
+	case day.Accepted == 1:
+		return 2
+	case day.Submissions > 0:
+		return 1
+	}
+	return 0
+}
+
+// open writes the <svg> element, sized by size around a viewBox of the
+// unscaled layout, with title as the accessible name.
+func open(b *strings.Builder, width, height int, size Size, title string) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %d %d" role="img" aria-label="%s">`,
+		scaled(width, size), scaled(height, size), width, height, escape(title))
+	fmt.Fprintf(b, `<title>%s</title>`, escape(title))
+}
+
+func scaled(n int, size Size) string {
+	return fmt.Sprintf("%g", float64(n)*size.Scale)
+}
+
+// textWidth estimates the width of text at 11px in the badge font. Badges
+// are short, so an average character width is close enough.
+func textWidth(text string) int {
+	return len([]rune(text))*13/2 + 1
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s)) // writes to a strings.Builder never fail
+	return b.String()
+}
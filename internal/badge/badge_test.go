@@ -0,0 +1,90 @@
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+func wellFormed(t *testing.T, svg []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("malformed SVG: %v\n%s", err, svg)
+		}
+	}
+}
+
+func TestBadge(t *testing.T) {
+	theme, _ := ParseTheme("")
+	size, _ := ParseSize("large")
+	svg := Badge(Stats{Name: "<script>", CurrentStreak: 12, MaxStreak: 30}, theme, size)
+	wellFormed(t, svg)
+
+	for _, want := range []string{"12 day streak", "&lt;script&gt;: 12 day streak, max 30", theme.Accent} {
+		if !bytes.Contains(svg, []byte(want)) {
+			t.Errorf("badge is missing %q:\n%s", want, svg)
+		}
+	}
+	// Large scales the drawn size but not the layout.
+	if !bytes.Contains(svg, []byte(`height="30" viewBox="0 0 `)) {
+		t.Errorf("large badge is not scaled:\n%s", svg)
+	}
+}
+
+func TestCard(t *testing.T) {
+	theme, _ := ParseTheme("dark")
+	size, _ := ParseSize("")
+
+	// 2024-03-05 is a Tuesday, so the first cell is in the third row.
+	heatmap := []domain.HeatmapDay{
+		{Date: "2024-03-05", Submissions: 2},
+		{Date: "2024-03-06", Submissions: 5, Accepted: 5},
+	}
+	svg := Card(Stats{
+		Name:            "tourist",
+		CurrentStreak:   1,
+		MaxStreak:       7,
+		LeaderboardRank: 3,
+		Rating:          3800,
+		Rank:            "legendary grandmaster",
+		Heatmap:         heatmap,
+	}, theme, size)
+	wellFormed(t, svg)
+
+	for _, want := range []string{
+		`width="495" height="165"`,
+		">tourist<",
+		">legendary grandmaster · 3800<",
+		">#3<",
+		">7<",
+		`<rect x="0" y="28" width="11" height="11" rx="2" fill="` + theme.Levels[1] + `"><title>2024-03-05: 0 accepted of 2</title>`,
+		`<rect x="0" y="42" width="11" height="11" rx="2" fill="` + theme.Levels[4] + `">`,
+	} {
+		if !strings.Contains(string(svg), want) {
+			t.Errorf("card is missing %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if theme, err := ParseTheme("Dark"); err != nil || theme.Name != "dark" {
+		t.Errorf("ParseTheme(Dark) = %+v, %v", theme, err)
+	}
+	if _, err := ParseTheme("solarized"); err == nil {
+		t.Error("ParseTheme(solarized) succeeded")
+	}
+	if size, err := ParseSize("small"); err != nil || size.Scale != 0.75 {
+		t.Errorf("ParseSize(small) = %+v, %v", size, err)
+	}
+	if _, err := ParseSize("huge"); err == nil {
+		t.Error("ParseSize(huge) succeeded")
+	}
+}
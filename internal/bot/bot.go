@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
+)
+
+const topSize = 10
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,24}$`)
+
+// Transport connects the bot to a chat platform. Run blocks until ctx is
+// cancelled, passing every inbound command to a Commander.
+type Transport interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+// Commander executes one command line, such as "/streak tourist", sent by
+// caller, and returns the reply text. caller names the sender within its
+// platform, like "telegram:42". Transports depend on this rather than on Bot
+// so they can be exercised on their own.
+type Commander interface {
+	Execute(ctx context.Context, caller, text string) string
+}
+
+// Bot implements the chat command set on top of UserService, registering
+// handles through ClaimService.
+type Bot struct {
+	userService   service.UserService
+	claimService  service.ClaimService
+	limiter       ratelimit.Limiter
+	registerLimit ratelimit.Limit
+	logger        *slog.Logger
+}
+
+// New creates a bot. /register calls Codeforces like the API's upstream
+// routes do, so it takes registerLimit from limiter per caller.
+func New(userService service.UserService, claimService service.ClaimService, limiter ratelimit.Limiter, registerLimit ratelimit.Limit, logger *slog.Logger) *Bot {
+	return &Bot{
+		userService:   userService,
+		claimService:  claimService,
+		limiter:       limiter,
+		registerLimit: registerLimit,
+		logger:        logger,
+	}
+}
+
+func (b *Bot) Execute(ctx context.Context, caller, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return usage()
+	}
+
+	// Telegram appends the bot name in group chats: /streak@CodeStreaksBot
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	args := fields[1:]
+
+	switch command {
+	case "/streak":
+		if len(args) != 1 {
+			return "Usage: /streak <handle>"
+		}
+		return b.streak(ctx, args[0])
+	case "/top":
+		return b.top(ctx)
+	case "/register":
+		if len(args) != 1 {
+			return "Usage: /register <handle>"
+		}
+		return b.register(ctx, caller, args[0])
+	case "/group":
+		if len(args) != 1 {
+			return "Usage: /group <name>"
+		}
+		return b.group(ctx, args[0])
+	default:
+		return usage()
+	}
+}
+
+func (b *Bot) streak(ctx context.Context, handle string) string {
+	if !handlePattern.MatchString(handle) {
+		return "That does not look like a Codeforces handle."
+	}
+
+	owner, err := b.userService.GetUserByAccount(ctx, domain.JudgeCodeforces, handle)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Sprintf("%s is not on the leaderboard. Use /register %s to add them.", handle, handle)
+	}
+	if err != nil {
+		return "Something went wrong, please try again later."
+	}
+	user, err := b.userService.GetUserStanding(ctx, owner.ID)
+	if err != nil {
+		return "Something went wrong, please try again later."
+	}
+
+	return fmt.Sprintf(
+		"%s: %d-day streak (max %d), rank #%d%s",
+		user.Name(), user.CurrentStreak, user.MaxStreak, user.LeaderboardRank, formatRankChange(user.RankChange),
+	)
+}
+
+func (b *Bot) top(ctx context.Context) string {
+	users, _, err := b.userService.GetLeaderboard(ctx, 1, topSize, "", "")
+	if err != nil {
+		return "Something went wrong, please try again later."
+	}
+	return formatLeaderboard("Top streaks", users)
+}
+
+// register opens a first name claim on handle, which adds it once the
+// scheduled claim check sees the token. The owner key is not sent: chats may
+// be public, and the key is only needed for the API, where a claim can be
+// made directly.
+func (b *Bot) register(ctx context.Context, caller, handle string) string {
+	if !handlePattern.MatchString(handle) {
+		return "That does not look like a Codeforces handle."
+	}
+	if _, err := b.userService.GetUserByAccount(ctx, domain.JudgeCodeforces, handle); err == nil {
+		return fmt.Sprintf("%s is already on the leaderboard.", handle)
+	}
+	if wait, ok := b.allowRegister(ctx, caller); !ok {
+		return fmt.Sprintf("Too many registrations, please try again in %d seconds.", wait)
+	}
+
+	claim, err := b.claimService.CreateClaim(ctx, handle, domain.ChallengeFirstName)
+	if errors.Is(err, service.ErrClaimPending) {
+		return fmt.Sprintf("A claim on %s is already pending. Finish it, or try again once it expires.", handle)
+	}
+	if err != nil {
+		return fmt.Sprintf("Could not register %s: %v", handle, err)
+	}
+	return fmt.Sprintf(
+		"To show %s is yours, set the first name on your Codeforces profile to %s within %d minutes. "+
+			"%s joins the leaderboard as soon as the change is seen; you can change the name back afterwards.",
+		claim.Handle, claim.Token, int(claim.ExpiresAt.Sub(claim.CreatedAt).Minutes()), claim.Handle,
+	)
+}
+
+// allowRegister takes a token from the caller's bucket, or reports how many
+// seconds until one is free. Like the API, a broken limiter backend lets the
+// command through.
+func (b *Bot) allowRegister(ctx context.Context, caller string) (int, bool) {
+	if b.registerLimit.Unlimited() {
+		return 0, true
+	}
+
+	allowed, retryAfter, err := b.limiter.Allow(ctx, "upstream:bot:"+caller, b.registerLimit)
+	if err != nil {
+		b.logger.WarnContext(ctx, "Rate limiter failed", "caller", caller, "error", err)
+		return 0, true
+	}
+	return int((retryAfter + time.Second - 1) / time.Second), allowed
+}
+
+func (b *Bot) group(ctx context.Context, name string) string {
+	users, _, err := b.userService.GetLeaderboard(ctx, 1, topSize, name, "")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Sprintf("Group %s does not exist.", name)
+	}
+	if err != nil {
+		return "Something went wrong, please try again later."
+	}
+	return formatLeaderboard("Top streaks in "+name, users)
+}
+
+func formatLeaderboard(title string, users []domain.UserResponse) string {
+	if len(users) == 0 {
+		return title + ": nobody yet."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(title + ":\n")
+	for _, user := range users {
+		fmt.Fprintf(&sb, "%d. %s - %d days%s\n",
+			user.LeaderboardRank, user.Name(), user.CurrentStreak, formatRankChange(user.RankChange))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func formatRankChange(change int) string {
+	switch {
+	case change > 0:
+		return fmt.Sprintf(" (up %d)", change)
+	case change < 0:
+		return fmt.Sprintf(" (down %d)", -change)
+	default:
+		return ""
+	}
+}
+
+func usage() string {
+	return strings.Join([]string{
+		"Commands:",
+		"/streak <handle> - show a user's streak",
+		"/top - show the leaderboard",
+		"/register <handle> - add your Codeforces handle",
+		"/group <name> - show a group's leaderboard",
+	}, "\n")
+}
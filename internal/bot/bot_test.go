@@ -0,0 +1,295 @@
+package bot_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/bot"
+)
+
+// echoCommander replies with the command line it was given and records it
+// with its caller.
+type echoCommander struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func (c *echoCommander) Execute(_ context.Context, caller, text string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.commands = append(c.commands, caller+" "+text)
+	return "ran " + text
+}
+
+func (c *echoCommander) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.commands...)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// fakeBotAPI hands out one batch of updates, then holds later getUpdates
+// calls open the way long polling does, and records sendMessage calls.
+type fakeBotAPI struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	offsets []string
+	sent    []map[string]any
+	replied chan struct{}
+}
+
+func newFakeBotAPI(t *testing.T, token string, updates string) *fakeBotAPI {
+	t.Helper()
+
+	api := &fakeBotAPI{replied: make(chan struct{}, 10)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bot"+token+"/getUpdates", func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		api.offsets = append(api.offsets, r.URL.Query().Get("offset"))
+		first := len(api.offsets) == 1
+		api.mu.Unlock()
+
+		if !first {
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, `{"ok":true,"result":`+updates+`}`)
+	})
+	mux.HandleFunc("POST /bot"+token+"/sendMessage", func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decode sendMessage: %v", err)
+		}
+		api.mu.Lock()
+		api.sent = append(api.sent, msg)
+		api.mu.Unlock()
+		io.WriteString(w, `{"ok":true}`)
+		api.replied <- struct{}{}
+	})
+	api.Server = httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+	return api
+}
+
+func TestTelegramTransport(t *testing.T) {
+	api := newFakeBotAPI(t, "123:abc", `[
+		{"update_id":7,"message":{"text":"/streak tourist","chat":{"id":42}}},
+		{"update_id":8,"message":{"text":"hello there","chat":{"id":42}}},
+		{"update_id":9},
+		{"update_id":10,"message":{"text":"/top@CodeStreaksBot","chat":{"id":-100}}}
+	]`)
+	commander := &echoCommander{}
+	transport := bot.NewTelegramTransport(api.URL, "123:abc", commander, discardLogger())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- transport.Run(ctx) }()
+
+	for range 2 {
+		select {
+		case <-api.replied:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for replies")
+		}
+	}
+	// Wait for the next poll so its offset has been recorded.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		api.mu.Lock()
+		polls := len(api.offsets)
+		api.mu.Unlock()
+		if polls >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run: %v", err)
+	}
+
+	if got := commander.received(); len(got) != 2 || got[0] != "telegram:42 /streak tourist" || got[1] != "telegram:-100 /top@CodeStreaksBot" {
+		t.Errorf("commands = %q, want only the slash commands, with their chats", got)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.sent) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(api.sent))
+	}
+	if api.sent[0]["chat_id"] != float64(42) || api.sent[0]["text"] != "ran /streak tourist" {
+		t.Errorf("first reply = %v", api.sent[0])
+	}
+	if api.sent[1]["chat_id"] != float64(-100) {
+		t.Errorf("second reply went to chat %v, want -100", api.sent[1]["chat_id"])
+	}
+	if len(api.offsets) < 2 || api.offsets[0] != "0" || api.offsets[1] != "11" {
+		t.Errorf("offsets = %v, want 0 then 11", api.offsets)
+	}
+}
+
+func newDiscordTransport(t *testing.T, baseURL string, commander bot.Commander) (*bot.DiscordTransport, ed25519.PrivateKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	transport, err := bot.NewDiscordTransport(baseURL, "app-1", "bot-token", hex.EncodeToString(public), commander, discardLogger())
+	if err != nil {
+		t.Fatalf("new transport: %v", err)
+	}
+	return transport, private
+}
+
+// interact posts an interaction signed with key to the transport.
+func interact(transport http.Handler, key ed25519.PrivateKey, body string) *httptest.ResponseRecorder {
+	const timestamp = "1710491400"
+
+	req := httptest.NewRequest(http.MethodPost, "/bot/discord/interactions", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+
+	rec := httptest.NewRecorder()
+	transport.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDiscordInteractions(t *testing.T) {
+	commander := &echoCommander{}
+	transport, key := newDiscordTransport(t, "http://discord.invalid", commander)
+
+	rec := interact(transport, key, `{"type":1}`)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"type":1}` {
+		t.Errorf("ping = %d %s, want a pong", rec.Code, rec.Body)
+	}
+
+	rec = interact(transport, key, `{"type":2,"member":{"user":{"id":"80351110224678912"}},"data":{"name":"streak","options":[{"name":"handle","type":3,"value":"tourist"}]}}`)
+	var resp struct {
+		Type int `json:"type"`
+		Data struct {
+			Content string `json:"content"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if resp.Type != 4 || resp.Data.Content != "ran /streak tourist" {
+		t.Errorf("command response = %+v", resp)
+	}
+
+	if rec := interact(transport, key, `{"type":99}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown interaction type = %d, want 400", rec.Code)
+	}
+
+	// Requests signed with another key, or not signed at all, are refused
+	// before anything runs.
+	_, otherKey := newDiscordTransport(t, "http://discord.invalid", commander)
+	if rec := interact(transport, otherKey, `{"type":2,"data":{"name":"top"}}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrongly signed interaction = %d, want 401", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/bot/discord/interactions", strings.NewReader(`{"type":1}`))
+	rec = httptest.NewRecorder()
+	transport.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned interaction = %d, want 401", rec.Code)
+	}
+
+	if got := commander.received(); len(got) != 1 || got[0] != "discord:80351110224678912 /streak tourist" {
+		t.Errorf("commands = %q, want only the signed one, with its sender", got)
+	}
+}
+
+// signalWriter reports every log line on a channel.
+type signalWriter chan string
+
+func (w signalWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestDiscordRegistersCommands(t *testing.T) {
+	var mu sync.Mutex
+	var auth string
+	var names []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/applications/app-1/commands" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var commands []struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&commands); err != nil {
+			t.Errorf("decode commands: %v", err)
+		}
+
+		mu.Lock()
+		auth = r.Header.Get("Authorization")
+		for _, c := range commands {
+			names = append(names, c.Name)
+		}
+		mu.Unlock()
+		io.WriteString(w, "[]")
+	}))
+	t.Cleanup(api.Close)
+
+	public, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	logs := make(signalWriter, 1)
+	transport, err := bot.NewDiscordTransport(api.URL, "app-1", "bot-token", hex.EncodeToString(public), &echoCommander{}, slog.New(slog.NewTextHandler(logs, nil)))
+	if err != nil {
+		t.Fatalf("new transport: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- transport.Run(ctx) }()
+
+	select {
+	case line := <-logs:
+		if !strings.Contains(line, "Discord slash commands registered") {
+			t.Errorf("log = %q", line)
+		}
+	case err := <-done:
+		t.Fatalf("run returned early: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for registration")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if auth != "Bot bot-token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if strings.Join(names, ",") != "streak,top,register,group" {
+		t.Errorf("registered commands = %v", names)
+	}
+}
+
+func TestNewDiscordTransportRejectsBadKey(t *testing.T) {
+	if _, err := bot.NewDiscordTransport("http://discord.invalid", "app-1", "bot-token", "not-hex", &echoCommander{}, discardLogger()); err == nil {
+		t.Error("accepted a malformed public key")
+	}
+}
@@ -0,0 +1,221 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Discord interaction and response types used by the transport.
+const (
+	discordInteractionPing    = 1
+	discordInteractionCommand = 2
+	discordResponsePong       = 1
+	discordResponseMessage    = 4
+	discordOptionString       = 3
+)
+
+// DiscordTransport serves Discord slash commands through the interactions
+// endpoint. Discord pushes commands to ServeHTTP; Run only registers the
+// command set and then waits for shutdown.
+type DiscordTransport struct {
+	baseURL       string
+	applicationID string
+	botToken      string
+	publicKey     ed25519.PublicKey
+	commander     Commander
+	httpClient    *http.Client
+	logger        *slog.Logger
+}
+
+// NewDiscordTransport creates an interactions transport. baseURL is normally
+// https://discord.com/api/v10 and publicKey is the application's hex-encoded
+// Ed25519 key used to verify inbound requests.
+func NewDiscordTransport(baseURL, applicationID, botToken, publicKey string, commander Commander, logger *slog.Logger) (*DiscordTransport, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Discord public key")
+	}
+
+	return &DiscordTransport{
+		baseURL:       baseURL,
+		applicationID: applicationID,
+		botToken:      botToken,
+		publicKey:     ed25519.PublicKey(key),
+		commander:     commander,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}, nil
+}
+
+type discordOption struct {
+	Name  string `json:"name"`
+	Type  int    `json:"type"`
+	Value any    `json:"value,omitempty"`
+}
+
+type discordInteraction struct {
+	Type int `json:"type"`
+	// Member is set for commands sent in a server and User for ones sent in
+	// a direct message.
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+	Data struct {
+		Name    string          `json:"name"`
+		Options []discordOption `json:"options"`
+	} `json:"data"`
+}
+
+type discordUser struct {
+	ID string `json:"id"`
+}
+
+type discordCommand struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Options     []discordCommandOption `json:"options,omitempty"`
+}
+
+type discordCommandOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        int    `json:"type"`
+	Required    bool   `json:"required"`
+}
+
+var discordCommands = []discordCommand{
+	{
+		Name:        "streak",
+		Description: "Show a user's streak",
+		Options:     []discordCommandOption{{Name: "handle", Description: "Codeforces handle", Type: discordOptionString, Required: true}},
+	},
+	{
+		Name:        "top",
+		Description: "Show the leaderboard",
+	},
+	{
+		Name:        "register",
+		Description: "Add your Codeforces handle to the leaderboard",
+		Options:     []discordCommandOption{{Name: "handle", Description: "Codeforces handle", Type: discordOptionString, Required: true}},
+	},
+	{
+		Name:        "group",
+		Description: "Show a group's leaderboard",
+		Options:     []discordCommandOption{{Name: "name", Description: "Group name", Type: discordOptionString, Required: true}},
+	},
+}
+
+func (d *DiscordTransport) Name() string {
+	return "discord"
+}
+
+// Run overwrites the application's global slash commands with the bot's
+// command set and blocks until ctx is cancelled.
+func (d *DiscordTransport) Run(ctx context.Context) error {
+	if err := d.registerCommands(ctx); err != nil {
+		return err
+	}
+	d.logger.InfoContext(ctx, "Discord slash commands registered")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (d *DiscordTransport) registerCommands(ctx context.Context) error {
+	data, err := json.Marshal(discordCommands)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/applications/%s/commands", d.baseURL, d.applicationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+d.botToken)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to register Discord commands: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("discord returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// ServeHTTP handles a signed interaction from Discord.
+func (d *DiscordTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+	if err != nil || timestamp == "" || !ed25519.Verify(d.publicKey, append([]byte(timestamp), body...), signature) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	var response any
+	switch interaction.Type {
+	case discordInteractionPing:
+		response = map[string]int{"type": discordResponsePong}
+	case discordInteractionCommand:
+		response = map[string]any{
+			"type": discordResponseMessage,
+			"data": map[string]string{"content": d.commander.Execute(r.Context(), interactionCaller(interaction), interactionText(interaction))},
+		}
+	default:
+		http.Error(w, "unsupported interaction type", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// interactionCaller names the Discord user who sent an interaction.
+func interactionCaller(interaction discordInteraction) string {
+	switch {
+	case interaction.Member != nil:
+		return "discord:" + interaction.Member.User.ID
+	case interaction.User != nil:
+		return "discord:" + interaction.User.ID
+	default:
+		return "discord:"
+	}
+}
+
+// interactionText rebuilds the command line a chat user would have typed.
+func interactionText(interaction discordInteraction) string {
+	parts := []string{"/" + interaction.Data.Name}
+	for _, option := range interaction.Data.Options {
+		parts = append(parts, fmt.Sprint(option.Value))
+	}
+	return strings.Join(parts, " ")
+}
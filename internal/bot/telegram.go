@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/logging"
+)
+
+// pollTimeout is the long-polling window passed to getUpdates, in seconds.
+const pollTimeout = 30
+
+type TelegramTransport struct {
+	baseURL    string
+	token      string
+	commander  Commander
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewTelegramTransport creates a long-polling Bot API transport. baseURL is
+// normally https://api.telegram.org.
+func NewTelegramTransport(baseURL, token string, commander Commander, logger *slog.Logger) *TelegramTransport {
+	return &TelegramTransport{
+		baseURL:   baseURL,
+		token:     token,
+		commander: commander,
+		httpClient: &http.Client{
+			Timeout: (pollTimeout + 10) * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+type telegramUpdate struct {
+	UpdateID int `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+type telegramResponse struct {
+	OK          bool             `json:"ok"`
+	Description string           `json:"description"`
+	Result      []telegramUpdate `json:"result"`
+}
+
+func (t *TelegramTransport) Name() string {
+	return "telegram"
+}
+
+func (t *TelegramTransport) Run(ctx context.Context) error {
+	offset := 0
+	for {
+		updates, err := t.getUpdates(ctx, offset)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			t.logger.WarnContext(ctx, "Telegram polling failed", "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message == nil || !strings.HasPrefix(update.Message.Text, "/") {
+				continue
+			}
+
+			// Each command gets its own ID, like an HTTP request would
+			cmdCtx := logging.WithRequestID(ctx, logging.NewID())
+			reply := t.commander.Execute(cmdCtx, "telegram:"+strconv.FormatInt(update.Message.Chat.ID, 10), update.Message.Text)
+			if err := t.sendMessage(cmdCtx, update.Message.Chat.ID, reply); err != nil {
+				t.logger.WarnContext(cmdCtx, "Telegram reply failed", "error", err)
+			}
+		}
+	}
+}
+
+func (t *TelegramTransport) getUpdates(ctx context.Context, offset int) ([]telegramUpdate, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("timeout", strconv.Itoa(pollTimeout))
+	query.Set("allowed_updates", `["message"]`)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.methodURL("getUpdates")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode updates: %w", err)
+	}
+	if !apiResp.OK {
+		return nil, fmt.Errorf("getUpdates failed: %s", apiResp.Description)
+	}
+
+	return apiResp.Result, nil
+}
+
+func (t *TelegramTransport) sendMessage(ctx context.Context, chatID int64, text string) error {
+	data, err := json.Marshal(map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.methodURL("sendMessage"), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sendMessage returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func (t *TelegramTransport) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", t.baseURL, t.token, method)
+}
@@ -0,0 +1,259 @@
+// Package cache stores rendered API responses between syncs. Entries are
+// dropped wholesale by Invalidate when the underlying data changes, and
+// expire after a TTL as a backstop.
+//
+// Every Invalidate starts a new generation. A response is rendered from the
+// database after reading the generation and stored with it, so a response
+// rendered before an Invalidate but stored after it is discarded instead of
+// outliving the change.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis"
+)
+
+// Backends selectable through CACHE_BACKEND.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendNone   = "none"
+)
+
+// Entry is a cached response body with its ETag.
+type Entry struct {
+	ETag string
+	Body []byte
+}
+
+type Cache interface {
+	// Get returns the entry for key; ok is false on a miss.
+	Get(ctx context.Context, key string) (entry Entry, ok bool, err error)
+	// Generation returns the current generation, to be read before the
+	// entry is rendered and passed to Set.
+	Generation(ctx context.Context) (uint64, error)
+	// Set stores entry unless the cache was invalidated since generation.
+	Set(ctx context.Context, key string, generation uint64, entry Entry) error
+	// Invalidate drops every entry and starts a new generation.
+	Invalidate(ctx context.Context) error
+}
+
+// New builds the cache for a backend name. redisURL is only used by the
+// redis backend.
+func New(backend, redisURL string, ttl time.Duration, maxEntries int, clock clock.Clock) (Cache, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemory(ttl, maxEntries, clock), nil
+	case BackendRedis:
+		client, err := redis.NewClient(redisURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedis(client, ttl), nil
+	case BackendNone:
+		return Disabled(), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", backend)
+	}
+}
+
+type memoryCache struct {
+	ttl        time.Duration
+	maxEntries int
+	clock      clock.Clock
+
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// NewMemory keeps up to maxEntries entries in process. When full, expired
+// entries are dropped first and then arbitrary ones, which bounds memory
+// however many page and group combinations are requested.
+func NewMemory(ttl time.Duration, maxEntries int, clock clock.Clock) Cache {
+	return &memoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		clock:      clock,
+		entries:    make(map[string]memoryEntry),
+	}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (Entry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	if !c.clock.Now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return Entry{}, false, nil
+	}
+	return e.Entry, true, nil
+}
+
+func (c *memoryCache) Generation(context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, generation uint64, entry Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return nil
+	}
+	now := c.clock.Now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = memoryEntry{Entry: entry, expiresAt: now.Add(c.ttl)}
+	return nil
+}
+
+// evict makes room for one entry. c.mu must be held.
+func (c *memoryCache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
+
+func (c *memoryCache) Invalidate(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.entries)
+	return nil
+}
+
+// redisCache namespaces entries by a generation counter shared by all
+// replicas. Invalidate bumps the counter, so one INCR invalidates every
+// replica's view at once and the orphaned entries age out through their TTL.
+// An entry stored with an old generation lands in such an orphaned namespace.
+type redisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+const redisGenerationKey = "codestreaks:cache:generation"
+
+func NewRedis(client *redis.Client, ttl time.Duration) Cache {
+	return &redisCache{client: client, ttl: ttl}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) (Entry, bool, error) {
+	generation, err := c.Generation(ctx)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	namespaced := namespacedKey(generation, key)
+	value, err := c.client.Get(ctx, namespaced)
+	if errors.Is(err, redis.ErrNil) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	entry, ok := decodeEntry(value)
+	if !ok {
+		return Entry{}, false, fmt.Errorf("malformed cache entry %q", namespaced)
+	}
+	return entry, true, nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, generation uint64, entry Entry) error {
+	return c.client.Set(ctx, namespacedKey(generation, key), encodeEntry(entry), c.ttl)
+}
+
+func (c *redisCache) Invalidate(ctx context.Context) error {
+	_, err := c.client.Incr(ctx, redisGenerationKey)
+	return err
+}
+
+func (c *redisCache) Generation(ctx context.Context) (uint64, error) {
+	value, err := c.client.Get(ctx, redisGenerationKey)
+	if errors.Is(err, redis.ErrNil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	generation, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed cache generation %q", value)
+	}
+	return generation, nil
+}
+
+func namespacedKey(generation uint64, key string) string {
+	return "codestreaks:cache:" + strconv.FormatUint(generation, 10) + ":" + key
+}
+
+// encodeEntry stores the ETag on the first line, followed by the body.
+func encodeEntry(entry Entry) []byte {
+	b := make([]byte, 0, len(entry.ETag)+1+len(entry.Body))
+	b = append(b, entry.ETag...)
+	b = append(b, '\n')
+	return append(b, entry.Body...)
+}
+
+func decodeEntry(value []byte) (Entry, bool) {
+	for i, ch := range value {
+		if ch == '\n' {
+			return Entry{ETag: string(value[:i]), Body: value[i+1:]}, true
+		}
+	}
+	return Entry{}, false
+}
+
+type disabledCache struct{}
+
+// Disabled returns a cache that never stores anything. Responses still get
+// ETags, so conditional requests keep working.
+func Disabled() Cache {
+	return disabledCache{}
+}
+
+func (disabledCache) Get(context.Context, string) (Entry, bool, error) {
+	return Entry{}, false, nil
+}
+
+func (disabledCache) Generation(context.Context) (uint64, error) {
+	return 0, nil
+}
+
+func (disabledCache) Set(context.Context, string, uint64, Entry) error {
+	return nil
+}
+
+func (disabledCache) Invalidate(context.Context) error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cache"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis/redistest"
+)
+
+func mustGet(t *testing.T, c cache.Cache, key string) (cache.Entry, bool) {
+	t.Helper()
+
+	entry, ok, err := c.Get(t.Context(), key)
+	if err != nil {
+		t.Fatalf("get %s: %v", key, err)
+	}
+	return entry, ok
+}
+
+func mustGeneration(t *testing.T, c cache.Cache) uint64 {
+	t.Helper()
+
+	generation, err := c.Generation(t.Context())
+	if err != nil {
+		t.Fatalf("generation: %v", err)
+	}
+	return generation
+}
+
+func mustSet(t *testing.T, c cache.Cache, key string, entry cache.Entry) {
+	t.Helper()
+
+	if err := c.Set(t.Context(), key, mustGeneration(t, c), entry); err != nil {
+		t.Fatalf("set %s: %v", key, err)
+	}
+}
+
+// testSetAfterInvalidate renders an entry at one generation and stores it
+// after an Invalidate, as a request racing a sync would.
+func testSetAfterInvalidate(t *testing.T, c cache.Cache) {
+	t.Helper()
+
+	generation := mustGeneration(t, c)
+	if err := c.Invalidate(t.Context()); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if err := c.Set(t.Context(), "stale", generation, cache.Entry{ETag: `"old"`, Body: []byte("old")}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got, ok := mustGet(t, c, "stale"); ok {
+		t.Errorf("entry rendered before Invalidate was stored: %+v", got)
+	}
+
+	mustSet(t, c, "fresh", cache.Entry{ETag: `"new"`, Body: []byte("new")})
+	if _, ok := mustGet(t, c, "fresh"); !ok {
+		t.Error("entry rendered after Invalidate was not stored")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC))
+	c := cache.NewMemory(time.Minute, 2, fake)
+
+	entry := cache.Entry{ETag: `"a"`, Body: []byte(`{"page":1}`)}
+	mustSet(t, c, "a", entry)
+	if got, ok := mustGet(t, c, "a"); !ok || got.ETag != entry.ETag || string(got.Body) != string(entry.Body) {
+		t.Errorf("get a = %+v, %v; want %+v", got, ok, entry)
+	}
+
+	fake.Advance(time.Minute)
+	if _, ok := mustGet(t, c, "a"); ok {
+		t.Error("entry survived its TTL")
+	}
+
+	mustSet(t, c, "a", entry)
+	mustSet(t, c, "b", entry)
+	mustSet(t, c, "c", entry)
+	present := 0
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok := mustGet(t, c, key); ok {
+			present++
+		}
+	}
+	if present != 2 {
+		t.Errorf("%d entries kept, want the limit of 2", present)
+	}
+	if _, ok := mustGet(t, c, "c"); !ok {
+		t.Error("the newest entry was evicted")
+	}
+
+	if err := c.Invalidate(t.Context()); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if _, ok := mustGet(t, c, "c"); ok {
+		t.Error("entry survived Invalidate")
+	}
+}
+
+func TestMemoryCacheSetAfterInvalidate(t *testing.T) {
+	testSetAfterInvalidate(t, cache.NewMemory(time.Minute, 10, clock.System()))
+}
+
+func TestRedisCacheSetAfterInvalidate(t *testing.T) {
+	srv := redistest.NewServer()
+	t.Cleanup(srv.Close)
+	client, err := redis.NewClient(srv.URL())
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	testSetAfterInvalidate(t, cache.NewRedis(client, time.Minute))
+}
+
+func TestRedisCacheSharedAcrossReplicas(t *testing.T) {
+	srv := redistest.NewServer()
+	t.Cleanup(srv.Close)
+
+	newReplica := func() cache.Cache {
+		client, err := redis.NewClient(srv.URL())
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return cache.NewRedis(client, time.Minute)
+	}
+	a, b := newReplica(), newReplica()
+
+	entry := cache.Entry{ETag: `"etag"`, Body: []byte("line one\nline two")}
+	mustSet(t, a, "leaderboard", entry)
+
+	got, ok := mustGet(t, b, "leaderboard")
+	if !ok || got.ETag != entry.ETag || string(got.Body) != string(entry.Body) {
+		t.Fatalf("replica b got %+v, %v; want %+v", got, ok, entry)
+	}
+
+	if err := b.Invalidate(t.Context()); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if _, ok := mustGet(t, a, "leaderboard"); ok {
+		t.Error("replica a still sees the entry after b invalidated")
+	}
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	srv := redistest.NewServer()
+	client, err := redis.NewClient(srv.URL())
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	srv.Close()
+
+	c := cache.NewRedis(client, time.Minute)
+	if _, _, err := c.Get(t.Context(), "key"); err == nil {
+		t.Error("get against a stopped server succeeded")
+	}
+}
+
+func TestNewUnknownBackend(t *testing.T) {
+	if _, err := cache.New("memcached", "", time.Minute, 10, clock.System()); err == nil {
+		t.Error("New accepted an unknown backend")
+	}
+}
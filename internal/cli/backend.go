@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/client"
+	"gorm.io/gorm"
+)
+
+// Backend is what the commands run against: the services over a direct
+// database connection, or a server through its HTTP API. Both report
+// missing users and groups as client.ErrNotFound.
+type Backend interface {
+	// AddUser starts tracking the person handle on judge belongs to.
+	AddUser(ctx context.Context, judge, handle string) (*client.User, error)
+	RemoveUser(ctx context.Context, id uint) error
+	GetUser(ctx context.Context, id uint) (*client.User, error)
+	// Leaderboard iterates over every ranked user, or only the members of
+	// group when it is not empty.
+	Leaderboard(ctx context.Context, group string) iter.Seq2[client.User, error]
+	// Sync syncs every active user, or only the user with ID id when it is
+	// not 0.
+	Sync(ctx context.Context, id uint) error
+	RecomputeStreaks(ctx context.Context) (int, error)
+	CreateGroup(ctx context.Context, name, description string) (*client.Group, error)
+	AddGroupMember(ctx context.Context, group string, id uint) error
+}
+
+// exportPageSize is the largest page the API serves.
+const exportPageSize = 100
+
+type apiBackend struct {
+	*client.Client
+}
+
+// NewAPIBackend runs commands through the HTTP API. Admin commands need the
+// client to carry the admin API key.
+func NewAPIBackend(c *client.Client) Backend {
+	return apiBackend{Client: c}
+}
+
+func (b apiBackend) Leaderboard(ctx context.Context, group string) iter.Seq2[client.User, error] {
+	return b.Client.Leaderboard(ctx, group, client.ListOptions{PageSize: exportPageSize})
+}
+
+type dbBackend struct {
+	userService  service.UserService
+	groupService service.GroupService
+	syncService  service.SyncService
+}
+
+// NewDBBackend runs commands directly against the services, for use where
+// the database is reachable but the server may not be running.
+func NewDBBackend(userService service.UserService, groupService service.GroupService, syncService service.SyncService) Backend {
+	return &dbBackend{
+		userService:  userService,
+		groupService: groupService,
+		syncService:  syncService,
+	}
+}
+
+func (b *dbBackend) AddUser(ctx context.Context, judge, handle string) (*client.User, error) {
+	user, err := b.userService.AddUser(ctx, judge, handle)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse(0)
+	return toClientUser(&response), nil
+}
+
+func (b *dbBackend) RemoveUser(ctx context.Context, id uint) error {
+	return notFound(b.userService.RemoveUser(ctx, id), fmt.Sprintf("user %d", id))
+}
+
+func (b *dbBackend) GetUser(ctx context.Context, id uint) (*client.User, error) {
+	user, err := b.userService.GetUserStanding(ctx, id)
+	if err != nil {
+		return nil, notFound(err, fmt.Sprintf("user %d", id))
+	}
+	return toClientUser(user), nil
+}
+
+func (b *dbBackend) Leaderboard(ctx context.Context, group string) iter.Seq2[client.User, error] {
+	return func(yield func(client.User, error) bool) {
+		for page := 1; ; page++ {
+			users, total, err := b.userService.GetLeaderboard(ctx, page, exportPageSize, group, "")
+			if err != nil {
+				yield(client.User{}, notFound(err, "group "+group))
+				return
+			}
+			for i := range users {
+				if !yield(*toClientUser(&users[i]), nil) {
+					return
+				}
+			}
+			if len(users) == 0 || int64(page*exportPageSize) >= total {
+				return
+			}
+		}
+	}
+}
+
+func (b *dbBackend) Sync(ctx context.Context, id uint) error {
+	if id == 0 {
+		return b.syncService.SyncAllUsers(ctx)
+	}
+
+	user, err := b.userService.GetUser(ctx, id)
+	if err != nil {
+		return notFound(err, fmt.Sprintf("user %d", id))
+	}
+	return b.syncService.SyncUser(ctx, user)
+}
+
+func (b *dbBackend) RecomputeStreaks(ctx context.Context) (int, error) {
+	return b.syncService.RecomputeStreaks(ctx)
+}
+
+func (b *dbBackend) CreateGroup(ctx context.Context, name, description string) (*client.Group, error) {
+	group, err := b.groupService.CreateGroup(ctx, name, description)
+	if err != nil {
+		return nil, err
+	}
+	return &client.Group{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		CreatedAt:   group.CreatedAt,
+	}, nil
+}
+
+func (b *dbBackend) AddGroupMember(ctx context.Context, group string, id uint) error {
+	// The service already names the missing group or user.
+	return notFound(b.groupService.AddMember(ctx, group, id), "")
+}
+
+// notFound makes a missing record match client.ErrNotFound, which is what
+// the API backend reports. what, if not empty, names the record.
+func notFound(err error, what string) error {
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if what != "" {
+		err = fmt.Errorf("%s: %w", what, err)
+	}
+	return notFoundError{err}
+}
+
+type notFoundError struct {
+	err error
+}
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == client.ErrNotFound }
+
+func toClientUser(u *domain.UserResponse) *client.User {
+	accounts := make([]client.Account, len(u.Accounts))
+	for i, account := range u.Accounts {
+		accounts[i] = client.Account{
+			Judge:  account.Judge,
+			Handle: account.Handle,
+			Rating: account.Rating,
+			Rank:   account.Rank,
+		}
+	}
+	return &client.User{
+		ID:               u.ID,
+		DisplayName:      u.DisplayName,
+		Accounts:         accounts,
+		CurrentStreak:    u.CurrentStreak,
+		MaxStreak:        u.MaxStreak,
+		LastSubmissionAt: u.LastSubmissionAt,
+		Rating:           u.Rating,
+		Rank:             u.Rank,
+		TotalSubmissions: u.TotalSubmissions,
+		LeaderboardRank:  u.LeaderboardRank,
+		RankChange:       u.RankChange,
+		StreakChange:     u.StreakChange,
+	}
+}
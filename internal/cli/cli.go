@@ -0,0 +1,304 @@
+// Package cli implements the codestreaks admin command. Every command runs
+// against a Backend, so the same command line works on the database
+// directly or on a running server through its HTTP API.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/export"
+)
+
+// Usage lists the commands Run understands.
+const Usage = `usage: codestreaks [-api URL] [-key KEY] <command> [arguments]
+
+commands:
+  user add [-judge name] <handle>   start tracking the person a judge handle
+                                    belongs to (default judge: codeforces)
+  user remove <id>                  stop tracking a user and delete their data
+  user list [-group name]           print the leaderboard
+  user show <id>                    print one user's standing
+  sync run [-user id]               sync every active user, or just one
+  streak recompute                  recalculate streaks from stored submissions
+  group create [-description text] <name>
+  group add-member <group> <id>
+  export [-format csv|json|xlsx] [-group name] [-o file]
+                                    write the leaderboard as CSV, JSON or XLSX
+  migrate up | down [N] | status    manage the database schema
+`
+
+// ErrUsage reports a command line Run does not understand.
+var ErrUsage = errors.New("invalid command line")
+
+// Run executes one command, e.g. []string{"user", "add", "tourist"}, and
+// writes its output to w. migrator is nil when the backend is the HTTP API,
+// which cannot manage the schema.
+func Run(ctx context.Context, backend Backend, migrator Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return usageError("missing command")
+	}
+
+	switch args[0] {
+	case "user":
+		return runUser(ctx, backend, args[1:], w)
+	case "sync":
+		return runSync(ctx, backend, args[1:], w)
+	case "streak":
+		return runStreak(ctx, backend, args[1:], w)
+	case "group":
+		return runGroup(ctx, backend, args[1:], w)
+	case "export":
+		return runExport(ctx, backend, args[1:], w)
+	case "migrate":
+		if migrator == nil {
+			return errors.New("migrate needs direct database access; run it without -api")
+		}
+		return Migrate(migrator, args[1:], w)
+	default:
+		return usageError("unknown command %q", args[0])
+	}
+}
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
+
+// parse parses a subcommand's flags and checks it got exactly want
+// positional arguments.
+func parse(fs *flag.FlagSet, args []string, want int, names string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, usageError("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() != want {
+		if names == "" {
+			return nil, usageError("%s takes no arguments", fs.Name())
+		}
+		return nil, usageError("usage: %s %s", fs.Name(), names)
+	}
+	return fs.Args(), nil
+}
+
+// parseID reads a user ID given on the command line.
+func parseID(arg string) (uint, error) {
+	id, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil || id == 0 {
+		return 0, usageError("invalid user ID %q", arg)
+	}
+	return uint(id), nil
+}
+
+func runUser(ctx context.Context, backend Backend, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return usageError("usage: user add|remove|list|show")
+	}
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("user add", flag.ContinueOnError)
+		judge := fs.String("judge", "codeforces", "the judge the handle is on")
+		rest, err := parse(fs, args[1:], 1, "[-judge name] <handle>")
+		if err != nil {
+			return err
+		}
+		user, err := backend.AddUser(ctx, *judge, rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Added %s as user %d (rating %d, %s)\n", user.Name(), user.ID, user.Rating, user.Rank)
+		return nil
+
+	case "remove":
+		rest, err := parse(flag.NewFlagSet("user remove", flag.ContinueOnError), args[1:], 1, "<id>")
+		if err != nil {
+			return err
+		}
+		id, err := parseID(rest[0])
+		if err != nil {
+			return err
+		}
+		if err := backend.RemoveUser(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed user %d\n", id)
+		return nil
+
+	case "list":
+		fs := flag.NewFlagSet("user list", flag.ContinueOnError)
+		group := fs.String("group", "", "only list members of this group")
+		if _, err := parse(fs, args[1:], 0, ""); err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "RANK\tID\tNAME\tSTREAK\tMAX STREAK\tRATING")
+		rank := 0
+		for user, err := range backend.Leaderboard(ctx, *group) {
+			if err != nil {
+				return err
+			}
+			rank++
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%d\t%d\n", rank, user.ID, user.Name(), user.CurrentStreak, user.MaxStreak, user.Rating)
+		}
+		return tw.Flush()
+
+	case "show":
+		rest, err := parse(flag.NewFlagSet("user show", flag.ContinueOnError), args[1:], 1, "<id>")
+		if err != nil {
+			return err
+		}
+		id, err := parseID(rest[0])
+		if err != nil {
+			return err
+		}
+		user, err := backend.GetUser(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		lastSubmission := "never"
+		if user.LastSubmissionAt != nil {
+			lastSubmission = user.LastSubmissionAt.Format(time.RFC3339)
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "Name:\t%s\n", user.Name())
+		fmt.Fprintf(tw, "Accounts:\t%s\n", accountList(user.Accounts))
+		fmt.Fprintf(tw, "Rating:\t%d (%s)\n", user.Rating, user.Rank)
+		fmt.Fprintf(tw, "Streak:\t%d (max %d)\n", user.CurrentStreak, user.MaxStreak)
+		fmt.Fprintf(tw, "Leaderboard rank:\t%d\n", user.LeaderboardRank)
+		fmt.Fprintf(tw, "Last submission:\t%s\n", lastSubmission)
+		return tw.Flush()
+
+	default:
+		return usageError("unknown user command %q", args[0])
+	}
+}
+
+func runSync(ctx context.Context, backend Backend, args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "run" {
+		return usageError("usage: sync run [-user id]")
+	}
+
+	fs := flag.NewFlagSet("sync run", flag.ContinueOnError)
+	id := fs.Uint("user", 0, "only sync the user with this ID")
+	if _, err := parse(fs, args[1:], 0, ""); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if err := backend.Sync(ctx, *id); err != nil {
+		return err
+	}
+
+	what := "all users"
+	if *id != 0 {
+		what = fmt.Sprintf("user %d", *id)
+	}
+	fmt.Fprintf(w, "Synced %s in %s\n", what, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func runStreak(ctx context.Context, backend Backend, args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "recompute" {
+		return usageError("usage: streak recompute")
+	}
+	if _, err := parse(flag.NewFlagSet("streak recompute", flag.ContinueOnError), args[1:], 0, ""); err != nil {
+		return err
+	}
+
+	updated, err := backend.RecomputeStreaks(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Recomputed streaks: %d users changed\n", updated)
+	return nil
+}
+
+func runGroup(ctx context.Context, backend Backend, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return usageError("usage: group create|add-member")
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("group create", flag.ContinueOnError)
+		description := fs.String("description", "", "group description")
+		rest, err := parse(fs, args[1:], 1, "[-description text] <name>")
+		if err != nil {
+			return err
+		}
+		group, err := backend.CreateGroup(ctx, rest[0], *description)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Created group %s\n", group.Name)
+		return nil
+
+	case "add-member":
+		rest, err := parse(flag.NewFlagSet("group add-member", flag.ContinueOnError), args[1:], 2, "<group> <id>")
+		if err != nil {
+			return err
+		}
+		id, err := parseID(rest[1])
+		if err != nil {
+			return err
+		}
+		if err := backend.AddGroupMember(ctx, rest[0], id); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Added user %d to %s\n", id, rest[0])
+		return nil
+
+	default:
+		return usageError("unknown group command %q", args[0])
+	}
+}
+
+func runExport(ctx context.Context, backend Backend, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := fs.String("format", "csv", "csv, json or xlsx")
+	group := fs.String("group", "", "only export members of this group")
+	out := fs.String("o", "", "file to write instead of standard output")
+	if _, err := parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	// Collect first so a failed fetch never leaves a truncated file.
+	var rows []exportRow
+	for user, err := range backend.Leaderboard(ctx, *group) {
+		if err != nil {
+			return err
+		}
+		rows = append(rows, newExportRow(len(rows)+1, user))
+	}
+
+	if *out == "" {
+		return writeExport(w, format, rows)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeExport(f, format, rows); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported %d users to %s\n", len(rows), *out)
+	return nil
+}
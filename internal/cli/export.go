@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/export"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/client"
+)
+
+type exportRow struct {
+	Rank             int        `json:"rank"`
+	UserID           uint       `json:"user_id"`
+	Name             string     `json:"name"`
+	Accounts         string     `json:"accounts"`
+	CurrentStreak    int        `json:"current_streak"`
+	MaxStreak        int        `json:"max_streak"`
+	Rating           int        `json:"rating"`
+	CodeforcesRank   string     `json:"codeforces_rank"`
+	LastSubmissionAt *time.Time `json:"last_submission_at"`
+}
+
+func newExportRow(rank int, u client.User) exportRow {
+	return exportRow{
+		Rank:             rank,
+		UserID:           u.ID,
+		Name:             u.Name(),
+		Accounts:         accountList(u.Accounts),
+		CurrentStreak:    u.CurrentStreak,
+		MaxStreak:        u.MaxStreak,
+		Rating:           u.Rating,
+		CodeforcesRank:   u.Rank,
+		LastSubmissionAt: u.LastSubmissionAt,
+	}
+}
+
+// accountList formats accounts as judge:handle pairs separated by spaces,
+// the way the API's leaderboard export does.
+func accountList(accounts []client.Account) string {
+	pairs := make([]string, len(accounts))
+	for i, account := range accounts {
+		pairs[i] = account.Judge + ":" + account.Handle
+	}
+	return strings.Join(pairs, " ")
+}
+
+// writeExport writes rows to w in format.
+func writeExport(w io.Writer, format export.Format, rows []exportRow) error {
+	ew, err := export.NewWriter[exportRow](format, w, "Leaderboard")
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := ew.Write(row); err != nil {
+			return err
+		}
+	}
+	return ew.Close()
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Migrator manages the database schema; *database.Database implements it.
+type Migrator interface {
+	MigrateUp() error
+	MigrateDown(steps int) error
+	SchemaVersion() (int, error)
+	LatestVersion() (int, error)
+}
+
+// Migrate implements the migrate command:
+//
+//	migrate up          apply all pending migrations
+//	migrate down [N]    roll back the last N migrations (default 1)
+//	migrate status      print the current and expected schema version
+func Migrate(m Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return usageError("usage: migrate up|down [N]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		return m.MigrateUp()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count: %s", args[1])
+			}
+			steps = n
+		}
+		return m.MigrateDown(steps)
+	case "status":
+		current, err := m.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		latest, err := m.LatestVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "schema version: %d (latest: %d)\n", current, latest)
+		return nil
+	default:
+		return usageError("unknown migrate command: %s", args[0])
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Judges the sync knows how to read.
+const (
+	JudgeCodeforces = "codeforces"
+	JudgeAtCoder    = "atcoder"
+	JudgeLeetCode   = "leetcode"
+)
+
+// ErrHandleNotFound is returned by judge clients for a handle the judge does
+// not know.
+var ErrHandleNotFound = errors.New("handle not found on judge")
+
+// VerdictAccepted is the verdict every judge's accepted submissions are
+// stored with, whatever the judge itself calls it.
+const VerdictAccepted = "OK"
+
+// JudgeAccount links a user to a handle on one judge. Every user has at
+// least one account, and solves on any of them count towards their streak.
+type JudgeAccount struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"index;not null" json:"-"`
+	Judge         string     `gorm:"not null" json:"judge"`
+	Handle        string     `gorm:"not null" json:"handle"`
+	Rating        int        `gorm:"default:0" json:"rating"`
+	Rank          string     `json:"rank"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// JudgeProfile is what a judge reports about a handle.
+type JudgeProfile struct {
+	Handle string
+	Rating int
+	Rank   string
+}
+
+// JudgeSubmission is a submission on any judge, in the form the streak and
+// achievement rules work on.
+type JudgeSubmission struct {
+	Judge string
+	ID    int64
+	// ProblemID identifies the problem on its judge, e.g. "1850A" or
+	// "abc300_a"; empty when the judge did not say.
+	ProblemID string
+	// ProblemRating is the problem's Codeforces difficulty, or 0 on judges
+	// without one.
+	ProblemRating int
+	Language      string
+	// Verdict is VerdictAccepted for accepted submissions and the judge's
+	// own verdict otherwise.
+	Verdict     string
+	SubmittedAt time.Time
+}
+
+func (s JudgeSubmission) Accepted() bool {
+	return s.Verdict == VerdictAccepted
+}
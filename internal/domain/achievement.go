@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Achievement is a catalogue entry. The catalogue itself lives in code; only
+// awards are persisted.
+type Achievement struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UserAchievement struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `gorm:"uniqueIndex:idx_user_achievement;not null" json:"user_id"`
+	AchievementCode string    `gorm:"uniqueIndex:idx_user_achievement;not null" json:"achievement_code"`
+	AwardedAt       time.Time `gorm:"not null" json:"awarded_at"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// EarnedAchievement is a catalogue entry together with when a user earned it.
+type EarnedAchievement struct {
+	Achievement
+	AwardedAt time.Time `json:"awarded_at"`
+}
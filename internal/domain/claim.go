@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// Challenges a claimant can complete to prove they own a Codeforces handle.
+const (
+	// ChallengeCompilationError asks for a submission that fails to compile
+	// on a given problem while the claim is open.
+	ChallengeCompilationError = "compilation_error"
+	// ChallengeFirstName asks for the profile's first name to be set to a
+	// token.
+	ChallengeFirstName = "first_name"
+)
+
+// Claim statuses.
+const (
+	ClaimPending  = "pending"
+	ClaimVerified = "verified"
+	ClaimExpired  = "expired"
+)
+
+// HandleClaim is someone's claim to own a Codeforces handle. Once its
+// challenge is completed the claim is verified, and its key identifies the
+// handle's owner until a newer claim is verified.
+type HandleClaim struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Handle string `gorm:"index;not null" json:"handle"`
+	// UserID is set on verification; an unknown handle is added as a user
+	// then.
+	UserID *uint  `gorm:"index" json:"user_id,omitempty"`
+	Method string `gorm:"not null" json:"method"`
+	// Problem is the problem to fail to compile on, such as "4A", for
+	// ChallengeCompilationError; Token is the first name to set for
+	// ChallengeFirstName.
+	Problem    string     `json:"problem,omitempty"`
+	Token      string     `json:"token,omitempty"`
+	KeyHash    string     `gorm:"not null" json:"-"`
+	Status     string     `gorm:"index;not null;default:'pending'" json:"status"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Key is the owner key, only known to the service right after the
+	// claim is created; the database keeps its hash.
+	Key string `gorm:"-" json:"-"`
+}
@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+// Event types published to webhook subscribers.
+const (
+	EventStreakExtended       = "streak.extended"
+	EventStreakBroken         = "streak.broken"
+	EventUserAdded            = "user.added"
+	EventAchievementUnlocked  = "achievement.unlocked"
+	EventLeaderboardRankMoved = "leaderboard.rank_changed"
+	EventLeaderboardEntry     = "leaderboard.entry_updated"
+)
+
+// EventTypes lists every event a subscriber may ask for.
+var EventTypes = []string{
+	EventStreakExtended,
+	EventStreakBroken,
+	EventUserAdded,
+	EventAchievementUnlocked,
+	EventLeaderboardRankMoved,
+	EventLeaderboardEntry,
+}
+
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Events name the person they are about by ID, with Name for display: their
+// display name, or the handle of their first account.
+
+type StreakEventData struct {
+	UserID         uint   `json:"user_id"`
+	Name           string `json:"name"`
+	PreviousStreak int    `json:"previous_streak"`
+	CurrentStreak  int    `json:"current_streak"`
+	MaxStreak      int    `json:"max_streak"`
+}
+
+type UserAddedEventData struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Rating int    `json:"rating"`
+	Rank   string `json:"rank"`
+}
+
+type AchievementEventData struct {
+	UserID      uint        `json:"user_id"`
+	Name        string      `json:"name"`
+	Achievement Achievement `json:"achievement"`
+}
+
+type RankChangedEventData struct {
+	UserID       uint   `json:"user_id"`
+	Name         string `json:"name"`
+	PreviousRank int    `json:"previous_rank"`
+	CurrentRank  int    `json:"current_rank"`
+}
+
+// LeaderboardEntryEventData is sent after a sync changes a user's row. Other
+// users' ranks may shift too; clients re-sort by streak, max streak and
+// rating.
+type LeaderboardEntryEventData struct {
+	UserID        uint   `json:"user_id"`
+	Name          string `json:"name"`
+	PreviousRank  int    `json:"previous_rank"`
+	CurrentRank   int    `json:"current_rank"`
+	CurrentStreak int    `json:"current_streak"`
+	MaxStreak     int    `json:"max_streak"`
+	Rating        int    `json:"rating"`
+}
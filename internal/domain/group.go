@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Group is a named subset of users, such as a class or club, with its own
+// leaderboard.
+type Group struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type GroupMember struct {
+	GroupID  uint      `gorm:"primaryKey" json:"group_id"`
+	UserID   uint      `gorm:"primaryKey;index" json:"user_id"`
+	JoinedAt time.Time `gorm:"autoCreateTime" json:"joined_at"`
+
+	Group Group `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE" json:"-"`
+	User  User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// GroupStanding is a member's position on a group leaderboard. Rank is zero
+// for inactive members, who are not ranked.
+type GroupStanding struct {
+	Group   Group `json:"group"`
+	Rank    int   `json:"rank"`
+	Members int   `json:"members"`
+}
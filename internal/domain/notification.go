@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// NotificationPreference configures streak-at-risk reminders for one user on
+// one channel. Target is channel specific: an email address, a Telegram chat
+// ID or a webhook URL. HoursBefore counts back from midnight in the user's
+// time zone.
+type NotificationPreference struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	UserID      uint   `gorm:"uniqueIndex:idx_notification_user_channel;not null" json:"user_id"`
+	Channel     string `gorm:"uniqueIndex:idx_notification_user_channel;not null" json:"channel"`
+	Target      string `gorm:"not null" json:"target"`
+	HoursBefore int    `gorm:"not null;default:3" json:"hours_before"`
+	Enabled     bool   `gorm:"default:true" json:"enabled"`
+	// LastNotifiedOn is the user's local date (YYYY-MM-DD) of the last
+	// reminder, so each user is alerted at most once per day per channel.
+	LastNotifiedOn string    `json:"last_notified_on,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// LeaderboardSnapshot records a user's standing at the close of a streak day.
+// SnapshotDate holds the calendar day (at UTC midnight) the row describes.
+type LeaderboardSnapshot struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `gorm:"uniqueIndex:idx_snapshot_user_date;not null" json:"user_id"`
+	SnapshotDate    time.Time `gorm:"type:date;uniqueIndex:idx_snapshot_user_date;index;not null" json:"snapshot_date"`
+	LeaderboardRank int       `gorm:"not null" json:"leaderboard_rank"`
+	CurrentStreak   int       `gorm:"default:0" json:"current_streak"`
+	MaxStreak       int       `gorm:"default:0" json:"max_streak"`
+	Rating          int       `gorm:"default:0" json:"rating"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
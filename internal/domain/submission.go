@@ -5,12 +5,20 @@ import "time"
 type Submission struct {
 	ID                     uint      `gorm:"primaryKey" json:"id"`
 	UserID                 uint      `gorm:"index;not null" json:"user_id"`
-	CodeforcesSubmissionID int64     `gorm:"uniqueIndex;not null" json:"codeforces_submission_id"`
+	Judge                  string    `gorm:"not null;default:codeforces" json:"judge"`
+	// CodeforcesSubmissionID is the submission's ID on its judge; the name
+	// predates judges other than Codeforces.
+	CodeforcesSubmissionID int64     `gorm:"not null" json:"codeforces_submission_id"`
 	//ProblemName            string    `json:"problem_name"`
 	//ContestID              int       `json:"contest_id"`
 	//ProblemIndex           string    `json:"problem_index"`
 	Verdict                string    `json:"verdict"`
 	//ProgrammingLanguage    string    `json:"programming_language"`
+	// ProblemID, ProblemRating and Language are as in JudgeSubmission, and
+	// empty on submissions stored before they were kept.
+	ProblemID              string    `json:"problem_id,omitempty"`
+	ProblemRating          int       `gorm:"default:0" json:"problem_rating,omitempty"`
+	Language               string    `json:"language,omitempty"`
 	SubmittedAt            time.Time `gorm:"index;not null" json:"submitted_at"`
 	CreatedAt              time.Time `gorm:"autoCreateTime" json:"created_at"`
 
@@ -20,23 +28,23 @@ type Submission struct {
 // CodeforcesSubmission represents the API response structure
 type CodeforcesSubmission struct {
 	ID                  int               `json:"id"`
-	//ContestID           int               `json:"contestId"`
+	ContestID           int               `json:"contestId"`
 	CreationTimeSeconds int64             `json:"creationTimeSeconds"`
+	Problem             CodeforcesProblem `json:"problem"`
+	ProgrammingLanguage string            `json:"programmingLanguage"`
+	Verdict             string            `json:"verdict"`
 	//ProblemsetName      string            `json:"problemsetName"`
-	//Problem             CodeforcesProblem `json:"problem"`
 	//Author              CodeforcesAuthor  `json:"author"`
-	//ProgrammingLanguage string            `json:"programmingLanguage"`
-	Verdict             string            `json:"verdict"`
 }
 
-// type CodeforcesProblem struct {
-// 	ContestID int      `json:"contestId"`
-// 	Index     string   `json:"index"`
-// 	Name      string   `json:"name"`
-// 	Type      string   `json:"type"`
-// 	Rating    int      `json:"rating"`
-// 	Tags      []string `json:"tags"`
-// }
+type CodeforcesProblem struct {
+	ContestID int      `json:"contestId"`
+	Index     string   `json:"index"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Rating    int      `json:"rating"`
+	Tags      []string `json:"tags"`
+}
 
 // type CodeforcesAuthor struct {
 // 	ContestID        int      `json:"contestId"`
@@ -57,6 +65,7 @@ type CodeforcesUserInfo struct {
 	MaxRating int    `json:"maxRating"`
 	Rank      string `json:"rank"`
 	Country   string `json:"country"`
+	FirstName string `json:"firstName"`
 }
 
 type CodeforcesAPIResponse struct {
@@ -68,3 +77,11 @@ type CodeforcesUserAPIResponse struct {
 	Status string               `json:"status"`
 	Result []CodeforcesUserInfo `json:"result"`
 }
+
+// HeatmapDay counts one user's submissions on one calendar day in the
+// streak time zone.
+type HeatmapDay struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	Submissions int    `json:"submissions"`
+	Accepted    int    `json:"accepted"`
+}
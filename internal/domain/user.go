@@ -1,12 +1,22 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
+// User is a person on the leaderboard, addressed by ID everywhere. They own
+// one JudgeAccount per judge handle, on Codeforces or any other judge, and
+// their streak counts solves on all of them, by days in their own time zone.
+//
+// Handles live only on accounts, which are unique per judge and handle.
+// Rating and Rank are those of the person's first Codeforces account, and
+// zero for someone without one.
 type User struct {
 	ID               uint       `gorm:"primaryKey" json:"id"`
-	CodeforcesHandle string     `gorm:"uniqueIndex;not null" json:"codeforces_handle"`
+	DisplayName      string     `json:"display_name"`
+	AvatarURL        string     `json:"avatar_url"`
+	TimeZone         string     `gorm:"not null;default:'Asia/Tehran'" json:"time_zone"`
 	CurrentStreak    int        `gorm:"default:0" json:"current_streak"`
 	MaxStreak        int        `gorm:"default:0" json:"max_streak"`
 	LastSubmissionAt *time.Time `json:"last_submission_at"`
@@ -17,24 +27,73 @@ type User struct {
 	LastCheckedAt    *time.Time `json:"last_checked_at"`
 	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Accounts are in the order they were linked.
+	Accounts []JudgeAccount `gorm:"foreignKey:UserID" json:"accounts"`
+}
+
+// Name is what the person is called in messages: their display name, or the
+// handle of their first account without one.
+func (u *User) Name() string {
+	return personName(u.ID, u.DisplayName, u.Accounts)
 }
 
+func personName(id uint, displayName string, accounts []JudgeAccount) string {
+	if displayName != "" {
+		return displayName
+	}
+	if len(accounts) > 0 {
+		return accounts[0].Handle
+	}
+	return fmt.Sprintf("user %d", id)
+}
+
+// Leaderboard orders. SortCurrentStreak is the default, and the order
+// leaderboard ranks are stored in by the daily snapshot.
+const (
+	SortCurrentStreak = "current_streak"
+	SortMaxStreak     = "max_streak"
+	SortRating        = "rating"
+)
+
+var LeaderboardSorts = []string{SortCurrentStreak, SortMaxStreak, SortRating}
+
 type UserResponse struct {
-	ID               uint       `json:"id"`
-	CodeforcesHandle string     `json:"codeforces_handle"`
-	CurrentStreak    int        `json:"current_streak"`
-	MaxStreak        int        `json:"max_streak"`
-	LastSubmissionAt *time.Time `json:"last_submission_at"`
-	Rating           int        `json:"rating"`
-	Rank             string     `json:"rank"`
-	TotalSubmissions int        `json:"total_submissions"`
-	LeaderboardRank  int        `json:"leaderboard_rank"`
+	ID               uint           `json:"id"`
+	DisplayName      string         `json:"display_name"`
+	Accounts         []JudgeAccount `json:"accounts"`
+	AvatarURL        string         `json:"avatar_url"`
+	TimeZone         string         `json:"time_zone"`
+	CurrentStreak    int            `json:"current_streak"`
+	MaxStreak        int            `json:"max_streak"`
+	LastSubmissionAt *time.Time     `json:"last_submission_at"`
+	Rating           int            `json:"rating"`
+	Rank             string         `json:"rank"`
+	TotalSubmissions int            `json:"total_submissions"`
+	LeaderboardRank  int            `json:"leaderboard_rank"`
+	// RankChange is positive when the user moved up since the last daily
+	// snapshot and negative when they dropped.
+	RankChange   int `json:"rank_change"`
+	StreakChange int `json:"streak_change"`
+}
+
+// Name is the person's display name, or the handle of their first account
+// without one.
+func (r UserResponse) Name() string {
+	return personName(r.ID, r.DisplayName, r.Accounts)
 }
 
 func (u *User) ToResponse(rank int) UserResponse {
+	accounts := u.Accounts
+	if accounts == nil {
+		accounts = []JudgeAccount{}
+	}
 	return UserResponse{
 		ID:               u.ID,
-		CodeforcesHandle: u.CodeforcesHandle,
+		DisplayName:      u.DisplayName,
+		Accounts:         accounts,
+		AvatarURL:        u.AvatarURL,
+		TimeZone:         u.TimeZone,
 		CurrentStreak:    u.CurrentStreak,
 		MaxStreak:        u.MaxStreak,
 		LastSubmissionAt: u.LastSubmissionAt,
@@ -43,3 +102,10 @@ func (u *User) ToResponse(rank int) UserResponse {
 		LeaderboardRank:  rank,
 	}
 }
+
+// ProfileUpdate changes the fields of a user's profile that are not nil.
+type ProfileUpdate struct {
+	DisplayName *string
+	AvatarURL   *string
+	TimeZone    *string
+}
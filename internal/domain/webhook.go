@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+// Outbox statuses.
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	URL         string `gorm:"not null" json:"url"`
+	Secret      string `gorm:"not null" json:"-"`
+	Description string `json:"description"`
+	// Events is a comma-separated list of event types; empty means all.
+	Events    string    `json:"events"`
+	IsActive  bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// WebhookOutbox holds one pending event for one subscription. Rows are written
+// when the event happens and drained by the dispatcher, so deliveries survive
+// restarts and subscriber outages.
+type WebhookOutbox struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"index;not null" json:"subscription_id"`
+	EventID        string     `gorm:"not null" json:"event_id"`
+	EventType      string     `gorm:"not null" json:"event_type"`
+	Payload        string     `gorm:"type:text;not null" json:"-"`
+	Status         string     `gorm:"index:idx_outbox_due;not null;default:'pending'" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_outbox_due;not null" json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// WebhookDelivery is the log entry for a single delivery attempt.
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OutboxID       uint      `gorm:"index;not null" json:"outbox_id"`
+	SubscriptionID uint      `gorm:"index;not null" json:"subscription_id"`
+	EventType      string    `gorm:"not null" json:"event_type"`
+	Attempt        int       `gorm:"not null" json:"attempt"`
+	StatusCode     int       `json:"status_code"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
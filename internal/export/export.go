@@ -0,0 +1,244 @@
+// Package export writes tables of rows as CSV, JSON or XLSX. Rows are
+// written as they arrive, so an export never has to fit in memory.
+//
+// A row is a struct; its exported fields are the columns, named by their
+// json tags. The JSON format is an array of the rows as encoding/json
+// renders them; CSV and XLSX start with a header row of the column names.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/xlsx"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+	XLSX Format = "xlsx"
+)
+
+// ParseFormat parses a format name, case-insensitively.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case CSV, JSON, XLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (want csv, json or xlsx)", name)
+}
+
+// ContentType returns the MIME type of files in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSON:
+		return "application/json; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Writer writes rows of type T in one format. Call Write for each row, then
+// Close; nothing is complete until Close returns.
+type Writer[T any] struct {
+	table   table
+	columns []int // field indexes of T, in column order
+}
+
+// table is one format's encoding.
+type table interface {
+	writeRow(row reflect.Value, columns []int) error
+	flush() error
+	close() error
+}
+
+// NewWriter starts a table on w. name titles the sheet in an XLSX workbook
+// and is otherwise unused. T must be a struct type.
+func NewWriter[T any](format Format, w io.Writer, name string) (*Writer[T], error) {
+	rowType := reflect.TypeFor[T]()
+	if rowType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("export: row type %s is not a struct", rowType)
+	}
+
+	var columns []int
+	var header []string
+	for i := range rowType.NumField() {
+		field := rowType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, i)
+		header = append(header, name)
+	}
+
+	var t table
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		t = &csvTable{w: cw}
+	case JSON:
+		bw := bufio.NewWriter(w)
+		if _, err := bw.WriteString("["); err != nil {
+			return nil, err
+		}
+		t = &jsonTable{w: bw}
+	case XLSX:
+		xw, err := xlsx.NewWriter(w, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := xw.WriteRow(stringsToAny(header)...); err != nil {
+			return nil, err
+		}
+		t = &xlsxTable{w: xw}
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	return &Writer[T]{table: t, columns: columns}, nil
+}
+
+// Write appends a row.
+func (w *Writer[T]) Write(row T) error {
+	return w.table.writeRow(reflect.ValueOf(row), w.columns)
+}
+
+// Flush pushes buffered rows to the underlying writer, for callers that
+// want a slow export to show progress.
+func (w *Writer[T]) Flush() error {
+	return w.table.flush()
+}
+
+// Close finishes the table. It does not close the underlying writer.
+func (w *Writer[T]) Close() error {
+	return w.table.close()
+}
+
+type csvTable struct {
+	w *csv.Writer
+}
+
+func (t *csvTable) writeRow(row reflect.Value, columns []int) error {
+	record := make([]string, len(columns))
+	for i, index := range columns {
+		record[i] = formatCell(row.Field(index).Interface())
+	}
+	return t.w.Write(record)
+}
+
+func (t *csvTable) flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+func (t *csvTable) close() error {
+	return t.flush()
+}
+
+// formatCell renders a CSV cell. Times are RFC 3339 in UTC so every row
+// uses the same zone; a nil pointer is an empty cell.
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		value = rv.Elem().Interface()
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonTable writes one row per line so the output stays readable and
+// line-oriented tools can work on it.
+type jsonTable struct {
+	w    *bufio.Writer
+	rows int
+}
+
+func (t *jsonTable) writeRow(row reflect.Value, _ []int) error {
+	data, err := json.Marshal(row.Interface())
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if t.rows == 0 {
+		sep = "\n"
+	}
+	t.rows++
+	t.w.WriteString(sep)
+	_, err = t.w.Write(data)
+	return err
+}
+
+func (t *jsonTable) flush() error {
+	return t.w.Flush()
+}
+
+func (t *jsonTable) close() error {
+	if t.rows > 0 {
+		t.w.WriteString("\n")
+	}
+	t.w.WriteString("]\n")
+	return t.w.Flush()
+}
+
+type xlsxTable struct {
+	w *xlsx.Writer
+}
+
+func (t *xlsxTable) writeRow(row reflect.Value, columns []int) error {
+	values := make([]any, len(columns))
+	for i, index := range columns {
+		values[i] = row.Field(index).Interface()
+	}
+	return t.w.WriteRow(values...)
+}
+
+func (t *xlsxTable) flush() error {
+	return t.w.Flush()
+}
+
+func (t *xlsxTable) close() error {
+	return t.w.Close()
+}
+
+func stringsToAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type row struct {
+	Handle string     `json:"handle"`
+	Streak int        `json:"current_streak"`
+	Last   *time.Time `json:"last_submission_at"`
+	Secret string     `json:"-"`
+	Plain  bool
+}
+
+func TestWriter(t *testing.T) {
+	last := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("IRST", 3*3600+1800))
+	rows := []row{
+		{Handle: "tourist", Streak: 3, Last: &last, Secret: "x", Plain: true},
+		{Handle: "a,b", Streak: 0},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{CSV, "handle,current_streak,last_submission_at,Plain\n" +
+			"tourist,3,2024-03-01T08:30:00Z,true\n" +
+			"\"a,b\",0,,false\n"},
+		{JSON, "[\n" +
+			`{"handle":"tourist","current_streak":3,"last_submission_at":"2024-03-01T12:00:00+03:30","Plain":true},` + "\n" +
+			`{"handle":"a,b","current_streak":0,"last_submission_at":null,"Plain":false}` + "\n" +
+			"]\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := NewWriter[row](tt.format, &buf, "Test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range rows {
+			if err := w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s export =\n%s\nwant\n%s", tt.format, buf.String(), tt.want)
+		}
+		if tt.format == JSON && !json.Valid(buf.Bytes()) {
+			t.Errorf("JSON export is not valid JSON")
+		}
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	for format, want := range map[Format]string{
+		CSV:  "handle,current_streak,last_submission_at,Plain\n",
+		JSON: "[]\n",
+	} {
+		var buf bytes.Buffer
+		w, err := NewWriter[row](format, &buf, "Test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("empty %s export = %q, want %q", format, buf.String(), want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"csv", "JSON", "Xlsx"} {
+		if f, err := ParseFormat(name); err != nil || string(f) != strings.ToLower(name) {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, f, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) succeeded")
+	}
+}
@@ -0,0 +1,480 @@
+// Package graphapi is the GraphQL schema of the public API. It lets a client
+// fetch a user together with their submissions, activity heatmap, badges
+// and group standings in one round trip. Every per-user field goes through
+// a request-scoped loader, so asking for them across a whole leaderboard
+// page costs one query per field rather than one per user.
+package graphapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/graphql"
+	"gorm.io/gorm"
+)
+
+const (
+	maxDepth           = 6
+	maxUsers           = 100
+	maxPageSize        = 100
+	maxRecentLimit     = 100
+	maxHeatmapDays     = 366
+	defaultRecentLimit = 10
+	defaultHeatmapDays = 365
+)
+
+type API struct {
+	schema             *graphql.Schema
+	userService        service.UserService
+	achievementService service.AchievementService
+	groupService       service.GroupService
+}
+
+func New(
+	userService service.UserService,
+	achievementService service.AchievementService,
+	groupService service.GroupService,
+) *API {
+	api := &API{
+		userService:        userService,
+		achievementService: achievementService,
+		groupService:       groupService,
+	}
+	api.schema = api.buildSchema()
+	return api
+}
+
+// Execute runs one GraphQL request with a fresh set of loaders.
+func (a *API) Execute(ctx context.Context, req graphql.Request) *graphql.Response {
+	ctx = context.WithValue(ctx, loadersKey{}, a.newLoaders())
+	return a.schema.Execute(ctx, req)
+}
+
+// SDL returns the schema definition.
+func (a *API) SDL() string {
+	return a.schema.SDL()
+}
+
+type loadersKey struct{}
+
+type recentKey struct {
+	userID uint
+	limit  int
+}
+
+// heatmapKey carries the whole user because heatmap days are counted in the
+// user's time zone.
+type heatmapKey struct {
+	user *domain.User
+	days int
+}
+
+type loaders struct {
+	users       *graphql.Loader[uint, *domain.User]
+	ranks       *graphql.Loader[uint, int]
+	submissions *graphql.Loader[recentKey, []domain.Submission]
+	heatmaps    *graphql.Loader[heatmapKey, []domain.HeatmapDay]
+	badges      *graphql.Loader[uint, []domain.EarnedAchievement]
+	standings   *graphql.Loader[uint, []domain.GroupStanding]
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (a *API) newLoaders() *loaders {
+	return &loaders{
+		users: graphql.NewLoader(func(ctx context.Context, ids []uint) (map[uint]*domain.User, error) {
+			users, err := a.userService.GetUsersByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[uint]*domain.User, len(users))
+			for i := range users {
+				byID[users[i].ID] = &users[i]
+			}
+			return byID, nil
+		}),
+		ranks: graphql.NewLoader(a.userService.GetLeaderboardRanks),
+		submissions: graphql.NewLoader(func(ctx context.Context, keys []recentKey) (map[recentKey][]domain.Submission, error) {
+			out := make(map[recentKey][]domain.Submission, len(keys))
+			for limit, userIDs := range groupKeys(keys, func(k recentKey) (int, uint) { return k.limit, k.userID }) {
+				byUser, err := a.userService.GetRecentSubmissions(ctx, userIDs, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, id := range userIDs {
+					out[recentKey{id, limit}] = byUser[id]
+				}
+			}
+			return out, nil
+		}),
+		heatmaps: graphql.NewLoader(func(ctx context.Context, keys []heatmapKey) (map[heatmapKey][]domain.HeatmapDay, error) {
+			out := make(map[heatmapKey][]domain.HeatmapDay, len(keys))
+			byDays := make(map[int][]*domain.User)
+			for _, key := range keys {
+				byDays[key.days] = append(byDays[key.days], key.user)
+			}
+			for days, users := range byDays {
+				byUser, err := a.userService.GetActivityHeatmaps(ctx, users, days)
+				if err != nil {
+					return nil, err
+				}
+				for _, user := range users {
+					out[heatmapKey{user, days}] = byUser[user.ID]
+				}
+			}
+			return out, nil
+		}),
+		badges:    graphql.NewLoader(a.achievementService.GetAchievementsForUsers),
+		standings: graphql.NewLoader(a.groupService.GetStandingsForUsers),
+	}
+}
+
+// groupKeys splits loader keys that carry a field argument by that
+// argument, so each distinct value is fetched with one query.
+func groupKeys[K any](keys []K, split func(K) (int, uint)) map[int][]uint {
+	groups := make(map[int][]uint)
+	for _, key := range keys {
+		arg, userID := split(key)
+		groups[arg] = append(groups[arg], userID)
+	}
+	return groups
+}
+
+func (a *API) buildSchema() *graphql.Schema {
+	group := &graphql.Object{
+		Name:        "Group",
+		Description: "A named subset of users with its own leaderboard.",
+		Fields: graphql.Fields{
+			"name":        {Type: graphql.NonNull(graphql.String), Resolve: groupField(func(g domain.Group) any { return g.Name })},
+			"description": {Type: graphql.String, Resolve: groupField(func(g domain.Group) any { return g.Description })},
+		},
+	}
+
+	groupStanding := &graphql.Object{
+		Name:        "GroupStanding",
+		Description: "A user's position on the leaderboard of a group they belong to.",
+		Fields: graphql.Fields{
+			"group": {Type: graphql.NonNull(group), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(domain.GroupStanding).Group, nil
+			}},
+			"rank": {Type: graphql.Int, Description: "Null for inactive users, who are not ranked.", Resolve: func(p graphql.ResolveParams) (any, error) {
+				if rank := p.Source.(domain.GroupStanding).Rank; rank > 0 {
+					return rank, nil
+				}
+				return nil, nil
+			}},
+			"members": {Type: graphql.NonNull(graphql.Int), Description: "Ranked members of the group.", Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(domain.GroupStanding).Members, nil
+			}},
+		},
+	}
+
+	submission := &graphql.Object{
+		Name: "Submission",
+		Fields: graphql.Fields{
+			"id": {Type: graphql.NonNull(graphql.ID), Resolve: submissionField(func(s domain.Submission) any { return s.ID })},
+			"codeforcesId": {Type: graphql.NonNull(graphql.ID), Resolve: submissionField(func(s domain.Submission) any {
+				return s.CodeforcesSubmissionID
+			})},
+			"verdict":     {Type: graphql.NonNull(graphql.String), Resolve: submissionField(func(s domain.Submission) any { return s.Verdict })},
+			"submittedAt": {Type: graphql.NonNull(graphql.DateTime), Resolve: submissionField(func(s domain.Submission) any { return s.SubmittedAt })},
+		},
+	}
+
+	heatmapDay := &graphql.Object{
+		Name:        "HeatmapDay",
+		Description: "Submissions on one calendar day in the streak time zone.",
+		Fields: graphql.Fields{
+			"date":        {Type: graphql.NonNull(graphql.String), Resolve: heatmapField(func(d domain.HeatmapDay) any { return d.Date })},
+			"submissions": {Type: graphql.NonNull(graphql.Int), Resolve: heatmapField(func(d domain.HeatmapDay) any { return d.Submissions })},
+			"accepted":    {Type: graphql.NonNull(graphql.Int), Resolve: heatmapField(func(d domain.HeatmapDay) any { return d.Accepted })},
+		},
+	}
+
+	badge := &graphql.Object{
+		Name:        "Badge",
+		Description: "An achievement a user has earned.",
+		Fields: graphql.Fields{
+			"code":        {Type: graphql.NonNull(graphql.String), Resolve: badgeField(func(b domain.EarnedAchievement) any { return b.Code })},
+			"name":        {Type: graphql.NonNull(graphql.String), Resolve: badgeField(func(b domain.EarnedAchievement) any { return b.Name })},
+			"description": {Type: graphql.NonNull(graphql.String), Resolve: badgeField(func(b domain.EarnedAchievement) any { return b.Description })},
+			"awardedAt":   {Type: graphql.NonNull(graphql.DateTime), Resolve: badgeField(func(b domain.EarnedAchievement) any { return b.AwardedAt })},
+		},
+	}
+
+	account := &graphql.Object{
+		Name:        "Account",
+		Description: "A handle on one judge whose solves count towards its user's streak.",
+		Fields: graphql.Fields{
+			"judge":  {Type: graphql.NonNull(graphql.String), Resolve: accountField(func(a domain.JudgeAccount) any { return a.Judge })},
+			"handle": {Type: graphql.NonNull(graphql.String), Resolve: accountField(func(a domain.JudgeAccount) any { return a.Handle })},
+			"rating": {Type: graphql.NonNull(graphql.Int), Resolve: accountField(func(a domain.JudgeAccount) any { return a.Rating })},
+			"rank":   {Type: graphql.String, Resolve: accountField(func(a domain.JudgeAccount) any { return a.Rank })},
+		},
+	}
+
+	user := &graphql.Object{
+		Name:        "User",
+		Description: "A person on the leaderboard, with streaks counted across all of their accounts.",
+		Fields: graphql.Fields{
+			"id":   {Type: graphql.NonNull(graphql.ID), Resolve: userField(func(u *domain.User) any { return u.ID })},
+			"name": {Type: graphql.NonNull(graphql.String), Description: "Display name, or the handle of the first account.", Resolve: userField(func(u *domain.User) any { return u.Name() })},
+			"accounts": {Type: graphql.NonNull(graphql.List(graphql.NonNull(account))), Description: "In the order they were linked.", Resolve: userField(func(u *domain.User) any {
+				return u.Accounts
+			})},
+			"displayName":      {Type: graphql.NonNull(graphql.String), Resolve: userField(func(u *domain.User) any { return u.DisplayName })},
+			"avatarUrl":        {Type: graphql.NonNull(graphql.String), Resolve: userField(func(u *domain.User) any { return u.AvatarURL })},
+			"timeZone":         {Type: graphql.NonNull(graphql.String), Description: "Time zone the user's streak days are counted in.", Resolve: userField(func(u *domain.User) any { return u.TimeZone })},
+			"rating":           {Type: graphql.NonNull(graphql.Int), Resolve: userField(func(u *domain.User) any { return u.Rating })},
+			"rank":             {Type: graphql.String, Description: "Codeforces rank title, if the user has a Codeforces account.", Resolve: userField(func(u *domain.User) any { return u.Rank })},
+			"currentStreak":    {Type: graphql.NonNull(graphql.Int), Resolve: userField(func(u *domain.User) any { return u.CurrentStreak })},
+			"maxStreak":        {Type: graphql.NonNull(graphql.Int), Resolve: userField(func(u *domain.User) any { return u.MaxStreak })},
+			"lastSubmissionAt": {Type: graphql.DateTime, Resolve: userField(func(u *domain.User) any { return u.LastSubmissionAt })},
+			"leaderboardRank": {
+				Type:        graphql.Int,
+				Description: "Position on the global leaderboard; null for inactive users.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					u := p.Source.(*domain.User)
+					thunk := loadersFrom(p.Context).ranks.Load(p.Context, u.ID)
+					return graphql.Thunk(func() (any, error) {
+						rank, err := thunk()
+						if err != nil || rank.(int) == 0 {
+							return nil, err
+						}
+						return rank, nil
+					}), nil
+				},
+			},
+			"recentSubmissions": {
+				Type:        graphql.NonNull(graphql.List(graphql.NonNull(submission))),
+				Description: "Newest submissions first.",
+				Args:        []graphql.Arg{{Name: "limit", Type: graphql.Int, Default: defaultRecentLimit}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					limit := p.Args["limit"].(int)
+					if limit < 1 || limit > maxRecentLimit {
+						return nil, fmt.Errorf("limit must be between 1 and %d", maxRecentLimit)
+					}
+					key := recentKey{userID: p.Source.(*domain.User).ID, limit: limit}
+					return loadersFrom(p.Context).submissions.Load(p.Context, key), nil
+				},
+			},
+			"heatmap": {
+				Type:        graphql.NonNull(graphql.List(graphql.NonNull(heatmapDay))),
+				Description: "One entry per day, oldest first, ending today.",
+				Args:        []graphql.Arg{{Name: "days", Type: graphql.Int, Default: defaultHeatmapDays}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					days := p.Args["days"].(int)
+					if days < 1 || days > maxHeatmapDays {
+						return nil, fmt.Errorf("days must be between 1 and %d", maxHeatmapDays)
+					}
+					key := heatmapKey{user: p.Source.(*domain.User), days: days}
+					return loadersFrom(p.Context).heatmaps.Load(p.Context, key), nil
+				},
+			},
+			"badges": {
+				Type: graphql.NonNull(graphql.List(graphql.NonNull(badge))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return loadersFrom(p.Context).badges.Load(p.Context, p.Source.(*domain.User).ID), nil
+				},
+			},
+			"groups": {
+				Type: graphql.NonNull(graphql.List(graphql.NonNull(groupStanding))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return loadersFrom(p.Context).standings.Load(p.Context, p.Source.(*domain.User).ID), nil
+				},
+			},
+		},
+	}
+
+	leaderboardEntry := &graphql.Object{
+		Name: "LeaderboardEntry",
+		Fields: graphql.Fields{
+			"rank": {Type: graphql.NonNull(graphql.Int), Resolve: entryField(func(e domain.UserResponse) any { return e.LeaderboardRank })},
+			"rankChange": {Type: graphql.NonNull(graphql.Int), Description: "Places gained since the last daily snapshot.", Resolve: entryField(func(e domain.UserResponse) any {
+				return e.RankChange
+			})},
+			"streakChange": {Type: graphql.NonNull(graphql.Int), Resolve: entryField(func(e domain.UserResponse) any { return e.StreakChange })},
+			"user": {Type: graphql.NonNull(user), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return loadersFrom(p.Context).users.Load(p.Context, p.Source.(domain.UserResponse).ID), nil
+			}},
+		},
+	}
+
+	leaderboardPage := &graphql.Object{
+		Name: "LeaderboardPage",
+		Fields: graphql.Fields{
+			"entries":  {Type: graphql.NonNull(graphql.List(graphql.NonNull(leaderboardEntry)))},
+			"total":    {Type: graphql.NonNull(graphql.Int)},
+			"page":     {Type: graphql.NonNull(graphql.Int)},
+			"pageSize": {Type: graphql.NonNull(graphql.Int)},
+		},
+	}
+	for name, field := range leaderboardPage.Fields {
+		field.Resolve = func(p graphql.ResolveParams) (any, error) {
+			return p.Source.(map[string]any)[name], nil
+		}
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": {
+				Type: user,
+				Args: []graphql.Arg{{Name: "id", Type: graphql.NonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, ok := parseUserID(p.Args["id"])
+					if !ok {
+						return nil, nil
+					}
+					return loadersFrom(p.Context).users.Load(p.Context, id), nil
+				},
+			},
+			"userByAccount": {
+				Type:        user,
+				Description: "The user a handle on a judge is linked to.",
+				Args: []graphql.Arg{
+					{Name: "judge", Type: graphql.NonNull(graphql.String)},
+					{Name: "handle", Type: graphql.NonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					user, err := a.userService.GetUserByAccount(p.Context, p.Args["judge"].(string), p.Args["handle"].(string))
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return nil, nil
+					}
+					return user, err
+				},
+			},
+			"users": {
+				Type:        graphql.NonNull(graphql.List(user)),
+				Description: "Users in the order asked for, with null for unknown IDs.",
+				Args:        []graphql.Arg{{Name: "ids", Type: graphql.NonNull(graphql.List(graphql.NonNull(graphql.ID)))}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					ids := p.Args["ids"].([]any)
+					if len(ids) > maxUsers {
+						return nil, fmt.Errorf("at most %d users can be requested", maxUsers)
+					}
+					users := loadersFrom(p.Context).users
+					thunks := make([]graphql.Thunk, len(ids))
+					for i, arg := range ids {
+						id, ok := parseUserID(arg)
+						if !ok {
+							thunks[i] = func() (any, error) { return nil, nil }
+							continue
+						}
+						thunks[i] = users.Load(p.Context, id)
+					}
+					return graphql.Thunk(func() (any, error) {
+						out := make([]any, len(thunks))
+						for i, thunk := range thunks {
+							user, err := thunk()
+							if err != nil {
+								return nil, err
+							}
+							out[i] = user
+						}
+						return out, nil
+					}), nil
+				},
+			},
+			"leaderboard": {
+				Type: graphql.NonNull(leaderboardPage),
+				Args: []graphql.Arg{
+					{Name: "page", Type: graphql.Int, Default: 1},
+					{Name: "pageSize", Type: graphql.Int, Default: 50},
+					{Name: "group", Type: graphql.String},
+				},
+				Resolve: a.resolveLeaderboard,
+			},
+			"groups": {
+				Type: graphql.NonNull(graphql.List(graphql.NonNull(group))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return a.groupService.ListGroups(p.Context)
+				},
+			},
+		},
+	}
+
+	return &graphql.Schema{Query: query, MaxDepth: maxDepth}
+}
+
+func (a *API) resolveLeaderboard(p graphql.ResolveParams) (any, error) {
+	page := p.Args["page"].(int)
+	pageSize := p.Args["pageSize"].(int)
+	group, _ := p.Args["group"].(string)
+	if page < 1 {
+		return nil, errors.New("page must be at least 1")
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		return nil, fmt.Errorf("pageSize must be between 1 and %d", maxPageSize)
+	}
+
+	entries, total, err := a.userService.GetLeaderboard(p.Context, page, pageSize, group, "")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("group %q not found", group)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"entries":  entries,
+		"total":    int(total),
+		"page":     page,
+		"pageSize": pageSize,
+	}, nil
+}
+
+// parseUserID reads a user ID argument. IDs that are not positive numbers
+// name no user.
+func parseUserID(arg any) (uint, bool) {
+	id, err := strconv.ParseUint(arg.(string), 10, 0)
+	return uint(id), err == nil && id != 0
+}
+
+func userField(get func(*domain.User) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(*domain.User)), nil
+	}
+}
+
+func entryField(get func(domain.UserResponse) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(domain.UserResponse)), nil
+	}
+}
+
+func accountField(get func(domain.JudgeAccount) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(domain.JudgeAccount)), nil
+	}
+}
+
+func groupField(get func(domain.Group) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(domain.Group)), nil
+	}
+}
+
+func submissionField(get func(domain.Submission) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(domain.Submission)), nil
+	}
+}
+
+func heatmapField(get func(domain.HeatmapDay) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(domain.HeatmapDay)), nil
+	}
+}
+
+func badgeField(get func(domain.EarnedAchievement) any) func(graphql.ResolveParams) (any, error) {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(domain.EarnedAchievement)), nil
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+)
+
+type AchievementHandler struct {
+	achievementService service.AchievementService
+}
+
+func NewAchievementHandler(achievementService service.AchievementService) *AchievementHandler {
+	return &AchievementHandler{
+		achievementService: achievementService,
+	}
+}
+
+// ListAchievements godoc
+// @Summary List achievements
+// @Description List every achievement that can be earned
+// @Tags achievements
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/achievements [get]
+func (h *AchievementHandler) ListAchievements(c *gin.Context) {
+	c.JSON(http.StatusOK, SuccessResponse{
+		Data: h.achievementService.Catalogue(),
+	})
+}
+
+// GetUserAchievements godoc
+// @Summary Get user achievements
+// @Description Get the achievements a user has earned
+// @Tags achievements
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/users/{id}/achievements [get]
+func (h *AchievementHandler) GetUserAchievements(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	achievements, err := h.achievementService.GetUserAchievements(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Data: achievements,
+	})
+}
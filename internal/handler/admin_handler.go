@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
+)
+
+// AdminHandler runs maintenance jobs on demand that otherwise only run on
+// the scheduler.
+type AdminHandler struct {
+	userService service.UserService
+	syncService service.SyncService
+}
+
+func NewAdminHandler(userService service.UserService, syncService service.SyncService) *AdminHandler {
+	return &AdminHandler{
+		userService: userService,
+		syncService: syncService,
+	}
+}
+
+type RecomputeStreaksResponse struct {
+	Updated int `json:"updated"`
+}
+
+// Sync godoc
+// @Summary Run a sync
+// @Description Sync every active user with their judges now, or only the given user. Responds once the sync has finished.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user query int false "Only sync the user with this ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/sync [post]
+func (h *AdminHandler) Sync(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if c.Query("user") == "" {
+		if err := h.syncService.SyncAllUsers(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Sync completed"})
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Query("user"), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "user must be a user ID"})
+		return
+	}
+
+	user, err := h.userService.GetUser(ctx, uint(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+	if err == nil {
+		err = h.syncService.SyncUser(ctx, user)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Sync completed",
+		Data:    user,
+	})
+}
+
+// RecomputeStreaks godoc
+// @Summary Recompute streaks
+// @Description Recalculate every user's current and longest streak from the stored submissions, without calling Codeforces
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/streaks/recompute [post]
+func (h *AdminHandler) RecomputeStreaks(c *gin.Context) {
+	updated, err := h.syncService.RecomputeStreaks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Streaks recomputed",
+		Data:    RecomputeStreaksResponse{Updated: updated},
+	})
+}
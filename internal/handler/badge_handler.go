@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/badge"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
+)
+
+// BadgeHandler serves embeddable SVG images of a user's streak. They are
+// cached like the JSON API, so a sync refreshes them.
+type BadgeHandler struct {
+	userService   service.UserService
+	responseCache *ResponseCache
+}
+
+func NewBadgeHandler(userService service.UserService, responseCache *ResponseCache) *BadgeHandler {
+	return &BadgeHandler{
+		userService:   userService,
+		responseCache: responseCache,
+	}
+}
+
+// Badge godoc
+// @Summary Streak badge
+// @Description A one-line SVG badge with the user's current streak, for READMEs and personal sites
+// @Tags badges
+// @Produce image/svg+xml,json
+// @Param id path string true "User ID, followed by .svg"
+// @Param theme query string false "light or dark" default(light)
+// @Param size query string false "small, medium or large" default(medium)
+// @Param If-None-Match header string false "ETag of a previously fetched image"
+// @Success 200 {string} string "SVG image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /badge/{id} [get]
+func (h *BadgeHandler) Badge(c *gin.Context) {
+	h.serve(c, "badge", false, badge.Badge)
+}
+
+// Card godoc
+// @Summary Streak card
+// @Description An SVG stat card with the user's current and max streak, leaderboard rank and a heatmap of the last 12 weeks
+// @Tags badges
+// @Produce image/svg+xml,json
+// @Param id path string true "User ID, followed by .svg"
+// @Param theme query string false "light or dark" default(light)
+// @Param size query string false "small, medium or large" default(medium)
+// @Param If-None-Match header string false "ETag of a previously fetched image"
+// @Success 200 {string} string "SVG image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /card/{id} [get]
+func (h *BadgeHandler) Card(c *gin.Context) {
+	h.serve(c, "card", true, badge.Card)
+}
+
+func (h *BadgeHandler) serve(c *gin.Context, kind string, withHeatmap bool, render func(badge.Stats, badge.Theme, badge.Size) []byte) {
+	id, ok := strings.CutSuffix(c.Param("id"), ".svg")
+	userID, err := strconv.ParseUint(id, 10, 0)
+	if !ok || err != nil || userID == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found"})
+		return
+	}
+
+	theme, err := badge.ParseTheme(c.Query("theme"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	size, err := badge.ParseSize(c.Query("size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	key := fmt.Sprintf("%s:%d:theme=%s:size=%s", kind, userID, theme.Name, size.Name)
+	h.responseCache.ServeContent(c, key, "image/svg+xml; charset=utf-8", func() []byte {
+		ctx := c.Request.Context()
+
+		user, err := h.userService.GetUserStanding(ctx, uint(userID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			return nil
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+
+		stats := badge.Stats{
+			Name:            user.Name(),
+			CurrentStreak:   user.CurrentStreak,
+			MaxStreak:       user.MaxStreak,
+			LeaderboardRank: user.LeaderboardRank,
+			Rating:          user.Rating,
+			Rank:            user.Rank,
+		}
+		if withHeatmap {
+			// The heatmap only needs the user's ID and time zone.
+			owner := &domain.User{ID: user.ID, TimeZone: user.TimeZone}
+			heatmaps, err := h.userService.GetActivityHeatmaps(ctx, []*domain.User{owner}, badge.HeatmapDays)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+				return nil
+			}
+			stats.Heatmap = heatmaps[user.ID]
+		}
+
+		return render(stats, theme, size)
+	})
+}
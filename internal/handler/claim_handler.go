@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
+)
+
+// ClaimHandler serves self-registration: claiming a Codeforces handle and
+// proving ownership of it. It also guards the routes only a handle's owner
+// may use.
+type ClaimHandler struct {
+	claimService service.ClaimService
+	userService  service.UserService
+}
+
+func NewClaimHandler(claimService service.ClaimService, userService service.UserService) *ClaimHandler {
+	return &ClaimHandler{
+		claimService: claimService,
+		userService:  userService,
+	}
+}
+
+type CreateClaimRequest struct {
+	CodeforcesHandle string `json:"codeforces_handle" binding:"required"`
+	// Method is compilation_error or first_name.
+	Method string `json:"method" binding:"required"`
+}
+
+// CreateClaimResponse is the only place a claim's owner key is returned.
+type CreateClaimResponse struct {
+	*domain.HandleClaim
+	Key string `json:"key"`
+}
+
+// CreateClaim godoc
+// @Summary Claim a handle
+// @Description Start registering a Codeforces handle by proving you own it. With compilation_error, submit code that fails to compile on the returned problem before expires_at; with first_name, set the first name on your Codeforces profile to the returned token. The challenge is checked every few seconds, or on demand through the verify endpoint. Once verified, the returned key, shown only here, lets you change the settings of the person the handle is linked to; the handle is added to the leaderboard as a new person if it is not tracked yet. A handle has one open claim at a time.
+// @Tags claims
+// @Accept json
+// @Produce json
+// @Param claim body CreateClaimRequest true "Handle and challenge"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/claims [post]
+func (h *ClaimHandler) CreateClaim(c *gin.Context) {
+	var req CreateClaimRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	claim, err := h.claimService.CreateClaim(c.Request.Context(), req.CodeforcesHandle, req.Method)
+	switch {
+	case errors.Is(err, service.ErrInvalidChallenge):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, domain.ErrHandleNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, service.ErrClaimPending):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Claim created; complete the challenge before it expires",
+		Data:    CreateClaimResponse{HandleClaim: claim, Key: claim.Key},
+	})
+}
+
+// GetClaim godoc
+// @Summary Get a claim
+// @Description Get a handle claim and whether it is pending, verified or expired
+// @Tags claims
+// @Produce json
+// @Param id path int true "Claim ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/claims/{id} [get]
+func (h *ClaimHandler) GetClaim(c *gin.Context) {
+	h.serveClaim(c, h.claimService.GetClaim)
+}
+
+// VerifyClaim godoc
+// @Summary Verify a claim
+// @Description Check a pending claim's challenge now instead of waiting for the next scheduled check
+// @Tags claims
+// @Produce json
+// @Param id path int true "Claim ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/claims/{id}/verify [post]
+func (h *ClaimHandler) VerifyClaim(c *gin.Context) {
+	h.serveClaim(c, h.claimService.VerifyClaim)
+}
+
+func (h *ClaimHandler) serveClaim(c *gin.Context, load func(context.Context, uint) (*domain.HandleClaim, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid claim id"})
+		return
+	}
+
+	claim, err := load(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Claim not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Data: claim,
+	})
+}
+
+// RequireOwner guards routes that change a user's own settings. It accepts
+// the owner key of the latest verified claim on the :id user in the path, or
+// the admin key.
+func (h *ClaimHandler) RequireOwner(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.authorizeOwner(c, adminKey, http.StatusNotFound, func(context.Context) (uint, error) {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+			if err != nil || id == 0 {
+				return 0, gorm.ErrRecordNotFound
+			}
+			return uint(id), nil
+		})
+	}
+}
+
+// RequireClaimedHandle guards adding users. It accepts the admin key, or the
+// owner key of a verified claim on the person the judge handle in the body
+// is linked to, so nobody adds a handle they have not proven is theirs.
+// Anyone else registers through a claim, whose verification adds the handle.
+func (h *ClaimHandler) RequireClaimedHandle(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req AddUserRequest
+		if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		// An untracked handle has no verified claim.
+		h.authorizeOwner(c, adminKey, http.StatusForbidden, func(ctx context.Context) (uint, error) {
+			user, err := h.userService.GetUserByAccount(ctx, req.judge(), req.Handle)
+			if err != nil {
+				return 0, err
+			}
+			return user.ID, nil
+		})
+	}
+}
+
+// authorizeOwner lets the request through with the admin key or the owner
+// key of the user resolve finds, and aborts it otherwise. untracked is the
+// status for a user that does not exist.
+func (h *ClaimHandler) authorizeOwner(c *gin.Context, adminKey string, untracked int, resolve func(context.Context) (uint, error)) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid or missing API key"})
+		return
+	}
+	if adminKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminKey)) == 1 {
+		c.Next()
+		return
+	}
+
+	userID, err := resolve(c.Request.Context())
+	if err == nil {
+		err = h.claimService.AuthorizeOwner(c.Request.Context(), userID, token)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) && untracked == http.StatusNotFound:
+		c.AbortWithStatusJSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, service.ErrNotOwner):
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: service.ErrNotOwner.Error()})
+	case err != nil:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	default:
+		c.Next()
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/graphapi"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/graphql"
+)
+
+type GraphQLHandler struct {
+	api *graphapi.API
+}
+
+func NewGraphQLHandler(api *graphapi.API) *GraphQLHandler {
+	return &GraphQLHandler{
+		api: api,
+	}
+}
+
+// Query godoc
+// @Summary GraphQL query over GET
+// @Description Run a GraphQL query given as URL parameters, with variables JSON-encoded. See the POST form for details.
+// @Tags graphql
+// @Produce json
+// @Param query query string true "GraphQL query"
+// @Param operationName query string false "Operation to run"
+// @Param variables query string false "JSON-encoded variables"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} graphql.Response
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/graphql [get]
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	req := graphql.Request{
+		Query:         c.Query("query"),
+		OperationName: c.Query("operationName"),
+	}
+	if variables := c.Query("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, graphql.Response{Errors: []*graphql.Error{{Message: "variables must be a JSON object"}}})
+			return
+		}
+	}
+	h.execute(c, req)
+}
+
+// QueryJSON godoc
+// @Summary GraphQL query
+// @Description Run a GraphQL query, for example a user with their recent submissions, heatmap, badges and group standings in one round trip. Requests that fail to parse or validate get 400; field errors come back with 200 alongside the data.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body graphql.Request true "GraphQL request"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} graphql.Response
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/graphql [post]
+func (h *GraphQLHandler) QueryJSON(c *gin.Context) {
+	var req graphql.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, graphql.Response{Errors: []*graphql.Error{{Message: err.Error()}}})
+		return
+	}
+	h.execute(c, req)
+}
+
+func (h *GraphQLHandler) execute(c *gin.Context, req graphql.Request) {
+	resp := h.api.Execute(c.Request.Context(), req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, resp)
+}
+
+// Schema godoc
+// @Summary GraphQL schema
+// @Description The GraphQL schema in SDL form, for code generators and browsing.
+// @Tags graphql
+// @Produce plain
+// @Success 200 {string} string "schema"
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/graphql/schema [get]
+func (h *GraphQLHandler) Schema(c *gin.Context) {
+	c.String(http.StatusOK, h.api.SDL())
+}
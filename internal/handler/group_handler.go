@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
+)
+
+type GroupHandler struct {
+	groupService service.GroupService
+}
+
+func NewGroupHandler(groupService service.GroupService) *GroupHandler {
+	return &GroupHandler{
+		groupService: groupService,
+	}
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type AddGroupMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// ListGroups godoc
+// @Summary List groups
+// @Description List every group
+// @Tags groups
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/groups [get]
+func (h *GroupHandler) ListGroups(c *gin.Context) {
+	groups, err := h.groupService.ListGroups(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Data: groups,
+	})
+}
+
+// CreateGroup godoc
+// @Summary Create a group
+// @Description Create a named group with its own leaderboard
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param group body CreateGroupRequest true "Group"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/groups [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req CreateGroupRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(c.Request.Context(), req.Name, req.Description)
+	switch {
+	case errors.Is(err, service.ErrInvalidGroupName):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, service.ErrGroupExists):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Group created successfully",
+		Data:    group,
+	})
+}
+
+// AddMember godoc
+// @Summary Add a group member
+// @Description Add a tracked user to a group
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Group name"
+// @Param member body AddGroupMemberRequest true "Member's user ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/groups/{name}/members [post]
+func (h *GroupHandler) AddMember(c *gin.Context) {
+	var req AddGroupMemberRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.groupService.AddMember(c.Request.Context(), c.Param("name"), req.UserID); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Member added successfully",
+	})
+}
+
+// RemoveMember godoc
+// @Summary Remove a group member
+// @Description Remove a user from a group. Members can leave with the owner key from a verified handle claim; the admin key removes anyone.
+// @Tags groups
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Group name"
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/groups/{name}/members/{id} [delete]
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.RemoveMember(c.Request.Context(), c.Param("name"), userID); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Member removed successfully",
+	})
+}
+
+func writeGroupError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+}
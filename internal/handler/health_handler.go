@@ -1,18 +1,70 @@
 package handler
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/infrastructure/database"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+)
+
+// Component and overall readiness states. A down component makes the
+// instance unready; a degraded one is reported but still serves traffic,
+// since the leaderboard can be read while Codeforces is unavailable.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+const (
+	// A sync is stale once this many update intervals pass without a
+	// successful run, which leaves room for one slow or failed run.
+	staleSyncIntervals = 3
+
+	// Codeforces is probed at most this often so that frequent readiness
+	// probes do not eat into the API rate limit.
+	codeforcesProbeTTL     = 30 * time.Second
+	codeforcesProbeTimeout = 2 * time.Second
 )
 
 type HealthHandler struct {
-	db *database.Database
+	db             *database.Database
+	syncService    service.SyncService
+	cfClient       *codeforces.Client
+	updateInterval time.Duration
+	clock          clock.Clock
+	startedAt      time.Time
+	logger         *slog.Logger
+
+	probeMu   sync.Mutex
+	lastProbe *ComponentHealth
 }
 
-func NewHealthHandler(db *database.Database) *HealthHandler {
-	return &HealthHandler{db: db}
+func NewHealthHandler(
+	db *database.Database,
+	syncService service.SyncService,
+	cfClient *codeforces.Client,
+	updateInterval time.Duration,
+	clock clock.Clock,
+	logger *slog.Logger,
+) *HealthHandler {
+	return &HealthHandler{
+		db:             db,
+		syncService:    syncService,
+		cfClient:       cfClient,
+		updateInterval: updateInterval,
+		clock:          clock,
+		startedAt:      clock.Now(),
+		logger:         logger,
+	}
 }
 
 type HealthResponse struct {
@@ -20,6 +72,27 @@ type HealthResponse struct {
 	Database string `json:"database"`
 }
 
+type ComponentHealth struct {
+	Status    string     `json:"status"`
+	Detail    string     `json:"detail,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	// LastSuccessAt is only set for the sync component.
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+}
+
+type ReadinessComponents struct {
+	Database   ComponentHealth `json:"database"`
+	Migrations ComponentHealth `json:"migrations"`
+	Sync       ComponentHealth `json:"sync"`
+	Codeforces ComponentHealth `json:"codeforces"`
+}
+
+type ReadinessResponse struct {
+	Status     string              `json:"status"`
+	Components ReadinessComponents `json:"components"`
+}
+
 // Health godoc
 // @Summary Health check
 // @Description Check if the service is healthy
@@ -46,3 +119,132 @@ func (h *HealthHandler) Health(c *gin.Context) {
 		Database: dbStatus,
 	})
 }
+
+// Livez godoc
+// @Summary Liveness probe
+// @Description Report that the process is running. Dependencies are not checked, so an outage elsewhere does not get the instance restarted.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Check the database, schema version, sync freshness and Codeforces reachability. Returns 503 when any component is down; an unreachable Codeforces API only degrades the status.
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	components := ReadinessComponents{
+		Database:   h.checkDatabase(),
+		Migrations: h.checkMigrations(),
+		Sync:       h.checkSync(),
+		Codeforces: h.checkCodeforces(c.Request.Context()),
+	}
+
+	status := StatusOK
+	for _, component := range []ComponentHealth{components.Database, components.Migrations, components.Sync, components.Codeforces} {
+		switch {
+		case component.Status == StatusDown:
+			status = StatusDown
+		case component.Status == StatusDegraded && status == StatusOK:
+			status = StatusDegraded
+		}
+	}
+
+	statusCode := http.StatusOK
+	if status == StatusDown {
+		statusCode = http.StatusServiceUnavailable
+		h.logger.WarnContext(c.Request.Context(), "Instance not ready", "components", components)
+	}
+
+	c.JSON(statusCode, ReadinessResponse{
+		Status:     status,
+		Components: components,
+	})
+}
+
+func (h *HealthHandler) checkDatabase() ComponentHealth {
+	if err := h.db.HealthCheck(); err != nil {
+		return ComponentHealth{Status: StatusDown, Error: err.Error()}
+	}
+	return ComponentHealth{Status: StatusOK}
+}
+
+func (h *HealthHandler) checkMigrations() ComponentHealth {
+	latest, err := h.db.LatestVersion()
+	if err != nil {
+		return ComponentHealth{Status: StatusDown, Error: err.Error()}
+	}
+
+	current, err := h.db.SchemaVersion()
+	if err != nil {
+		return ComponentHealth{Status: StatusDown, Error: err.Error()}
+	}
+
+	health := ComponentHealth{Status: StatusOK, Detail: fmt.Sprintf("schema version %d, binary expects %d", current, latest)}
+	if current < latest {
+		health.Status = StatusDown
+		health.Error = "pending migrations"
+	}
+	return health
+}
+
+// checkSync measures the age of the last successful sync, or of the process
+// while the first sync is still running.
+func (h *HealthHandler) checkSync() ComponentHealth {
+	maxAge := staleSyncIntervals * h.updateInterval
+	now := h.clock.Now()
+
+	last, ok := h.syncService.LastSuccessfulSync()
+	if !ok {
+		if age := now.Sub(h.startedAt); age > maxAge {
+			return ComponentHealth{
+				Status: StatusDown,
+				Error:  fmt.Sprintf("no successful sync in %s since startup", age.Round(time.Second)),
+			}
+		}
+		return ComponentHealth{Status: StatusOK, Detail: "first sync pending"}
+	}
+
+	age := now.Sub(last)
+	health := ComponentHealth{
+		Status:        StatusOK,
+		Detail:        fmt.Sprintf("last successful sync %s ago", age.Round(time.Second)),
+		LastSuccessAt: &last,
+	}
+	if age > maxAge {
+		health.Status = StatusDown
+		health.Error = fmt.Sprintf("sync is stale: older than %s", maxAge)
+	}
+	return health
+}
+
+// checkCodeforces returns the cached probe result, probing again once it is
+// older than codeforcesProbeTTL. Concurrent callers share one probe.
+func (h *HealthHandler) checkCodeforces(ctx context.Context) ComponentHealth {
+	h.probeMu.Lock()
+	defer h.probeMu.Unlock()
+
+	now := h.clock.Now()
+	if h.lastProbe != nil && now.Sub(*h.lastProbe.CheckedAt) < codeforcesProbeTTL {
+		return *h.lastProbe
+	}
+
+	// The result is cached, so a caller hanging up must not cancel the probe.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), codeforcesProbeTimeout)
+	defer cancel()
+
+	health := ComponentHealth{Status: StatusOK, CheckedAt: &now}
+	if err := h.cfClient.Ping(ctx); err != nil {
+		health.Status = StatusDegraded
+		health.Error = err.Error()
+	}
+	h.lastProbe = &health
+	return health
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+type NotificationPreferenceRequest struct {
+	Channel     string `json:"channel" binding:"required"`
+	Target      string `json:"target" binding:"required"`
+	HoursBefore int    `json:"hours_before"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Get a user's streak-at-risk notification preferences. They hold contact details, so this needs the owner key from a verified handle claim, or the admin key.
+// @Tags notifications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/notifications [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Data: prefs,
+	})
+}
+
+// SetPreference godoc
+// @Summary Set a notification preference
+// @Description Create or replace a user's preference for one notification channel. Needs the owner key from a verified handle claim, or the admin key.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param preference body NotificationPreferenceRequest true "Notification preference"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/notifications [put]
+func (h *NotificationHandler) SetPreference(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req NotificationPreferenceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pref := &domain.NotificationPreference{
+		Channel:     req.Channel,
+		Target:      req.Target,
+		HoursBefore: req.HoursBefore,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+
+	if err := h.notificationService.SetPreference(c.Request.Context(), userID, pref); err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Notification preference saved",
+		Data:    pref,
+	})
+}
+
+// DeletePreference godoc
+// @Summary Delete a notification preference
+// @Description Stop sending a user notifications on one channel. Needs the owner key from a verified handle claim, or the admin key.
+// @Tags notifications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param channel path string true "Notification channel"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/notifications/{channel} [delete]
+func (h *NotificationHandler) DeletePreference(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.DeletePreference(c.Request.Context(), userID, c.Param("channel")); err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Notification preference deleted",
+	})
+}
+
+func writeNotificationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found"})
+	case errors.Is(err, service.ErrUnsupportedChannel), errors.Is(err, service.ErrInvalidPreference):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
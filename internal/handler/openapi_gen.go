@@ -0,0 +1,824 @@
+// Code generated by openapi-gen from the handler annotations; DO NOT EDIT.
+
+package handler
+
+import (
+	"reflect"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/graphql"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/openapi"
+)
+
+var openAPIInfo = openapi.Info{
+	Title:       "CodeStreaks API",
+	Version:     "1.0",
+	Description: "Daily Codeforces solving streaks, leaderboards, groups and achievements.",
+}
+
+var openAPISecuritySchemes = map[string]*openapi.SecurityScheme{
+	"ApiKeyAuth": {Type: "apiKey", In: "header", Name: "Authorization", Description: "The admin API key, or on a user's own settings the owner key from a verified handle claim, sent as \"Bearer <key>\"."},
+}
+
+var openAPIOperations = []openapi.Operation{
+	{
+		ID:          "GetUserByAccount",
+		Method:      "get",
+		Path:        "/api/v1/accounts/{judge}/{handle}",
+		Summary:     "Find user by judge handle",
+		Description: "Get the leaderboard row of the person a handle on a judge is linked to",
+		Tags:        []string{"users"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "judge", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Judge, such as codeforces"},
+			{Name: "handle", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Handle on the judge"},
+			{Name: "If-None-Match", In: "header", Type: reflect.TypeFor[string](), Required: false, Description: "ETag of a previously fetched response"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 304, Description: "Not modified"},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "ListAchievements",
+		Method:      "get",
+		Path:        "/api/v1/achievements",
+		Summary:     "List achievements",
+		Description: "List every achievement that can be earned",
+		Tags:        []string{"achievements"},
+		Produces:    []string{"application/json"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "RecomputeStreaks",
+		Method:      "post",
+		Path:        "/api/v1/admin/streaks/recompute",
+		Summary:     "Recompute streaks",
+		Description: "Recalculate every user's current and longest streak from the stored submissions, without calling Codeforces",
+		Tags:        []string{"admin"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Sync",
+		Method:      "post",
+		Path:        "/api/v1/admin/sync",
+		Summary:     "Run a sync",
+		Description: "Sync every active user with their judges now, or only the given user. Responds once the sync has finished.",
+		Tags:        []string{"admin"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "user", In: "query", Type: reflect.TypeFor[int](), Required: false, Description: "Only sync the user with this ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "CreateClaim",
+		Method:      "post",
+		Path:        "/api/v1/claims",
+		Summary:     "Claim a handle",
+		Description: "Start registering a Codeforces handle by proving you own it. With compilation_error, submit code that fails to compile on the returned problem before expires_at; with first_name, set the first name on your Codeforces profile to the returned token. The challenge is checked every few seconds, or on demand through the verify endpoint. Once verified, the returned key, shown only here, lets you change the settings of the person the handle is linked to; the handle is added to the leaderboard as a new person if it is not tracked yet. A handle has one open claim at a time.",
+		Tags:        []string{"claims"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "claim", In: "body", Type: reflect.TypeFor[CreateClaimRequest](), Required: true, Description: "Handle and challenge"},
+		},
+		Responses: []openapi.Response{
+			{Status: 201, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 409, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetClaim",
+		Method:      "get",
+		Path:        "/api/v1/claims/{id}",
+		Summary:     "Get a claim",
+		Description: "Get a handle claim and whether it is pending, verified or expired",
+		Tags:        []string{"claims"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "Claim ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "VerifyClaim",
+		Method:      "post",
+		Path:        "/api/v1/claims/{id}/verify",
+		Summary:     "Verify a claim",
+		Description: "Check a pending claim's challenge now instead of waiting for the next scheduled check",
+		Tags:        []string{"claims"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "Claim ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "UI",
+		Method:      "get",
+		Path:        "/api/v1/docs",
+		Summary:     "API explorer",
+		Description: "Swagger UI for the OpenAPI document.",
+		Tags:        []string{"docs"},
+		Produces:    []string{"text/html"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[string](), Description: "Swagger UI page"},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Query",
+		Method:      "get",
+		Path:        "/api/v1/graphql",
+		Summary:     "GraphQL query over GET",
+		Description: "Run a GraphQL query given as URL parameters, with variables JSON-encoded. See the POST form for details.",
+		Tags:        []string{"graphql"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "query", In: "query", Type: reflect.TypeFor[string](), Required: true, Description: "GraphQL query"},
+			{Name: "operationName", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "Operation to run"},
+			{Name: "variables", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "JSON-encoded variables"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[graphql.Response]()},
+			{Status: 400, Type: reflect.TypeFor[graphql.Response]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "QueryJSON",
+		Method:      "post",
+		Path:        "/api/v1/graphql",
+		Summary:     "GraphQL query",
+		Description: "Run a GraphQL query, for example a user with their recent submissions, heatmap, badges and group standings in one round trip. Requests that fail to parse or validate get 400; field errors come back with 200 alongside the data.",
+		Tags:        []string{"graphql"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "request", In: "body", Type: reflect.TypeFor[graphql.Request](), Required: true, Description: "GraphQL request"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[graphql.Response]()},
+			{Status: 400, Type: reflect.TypeFor[graphql.Response]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Schema",
+		Method:      "get",
+		Path:        "/api/v1/graphql/schema",
+		Summary:     "GraphQL schema",
+		Description: "The GraphQL schema in SDL form, for code generators and browsing.",
+		Tags:        []string{"graphql"},
+		Produces:    []string{"text/plain"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[string](), Description: "schema"},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "ListGroups",
+		Method:      "get",
+		Path:        "/api/v1/groups",
+		Summary:     "List groups",
+		Description: "List every group",
+		Tags:        []string{"groups"},
+		Produces:    []string{"application/json"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "CreateGroup",
+		Method:      "post",
+		Path:        "/api/v1/groups",
+		Summary:     "Create a group",
+		Description: "Create a named group with its own leaderboard",
+		Tags:        []string{"groups"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "group", In: "body", Type: reflect.TypeFor[CreateGroupRequest](), Required: true, Description: "Group"},
+		},
+		Responses: []openapi.Response{
+			{Status: 201, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 409, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "AddMember",
+		Method:      "post",
+		Path:        "/api/v1/groups/{name}/members",
+		Summary:     "Add a group member",
+		Description: "Add a tracked user to a group",
+		Tags:        []string{"groups"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "name", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Group name"},
+			{Name: "member", In: "body", Type: reflect.TypeFor[AddGroupMemberRequest](), Required: true, Description: "Member's user ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "RemoveMember",
+		Method:      "delete",
+		Path:        "/api/v1/groups/{name}/members/{id}",
+		Summary:     "Remove a group member",
+		Description: "Remove a user from a group. Members can leave with the owner key from a verified handle claim; the admin key removes anyone.",
+		Tags:        []string{"groups"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "name", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Group name"},
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetLeaderboard",
+		Method:      "get",
+		Path:        "/api/v1/leaderboard",
+		Summary:     "Get leaderboard",
+		Description: "Get paginated leaderboard of users sorted by current streak, or by another sort. Rank changes are only reported for the whole leaderboard in the default order.",
+		Tags:        []string{"leaderboard"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "page", In: "query", Type: reflect.TypeFor[int](), Required: false, Description: "Page number", Default: 1},
+			{Name: "page_size", In: "query", Type: reflect.TypeFor[int](), Required: false, Description: "Page size", Default: 50},
+			{Name: "group", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "Only rank members of this group"},
+			{Name: "sort", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "current_streak, max_streak or rating", Default: "current_streak"},
+			{Name: "If-None-Match", In: "header", Type: reflect.TypeFor[string](), Required: false, Description: "ETag of a previously fetched page"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[LeaderboardResponse]()},
+			{Status: 304, Description: "Not modified"},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "ExportLeaderboard",
+		Method:      "get",
+		Path:        "/api/v1/leaderboard/export",
+		Summary:     "Export leaderboard",
+		Description: "Download the whole leaderboard as CSV, JSON or an XLSX workbook. The file is streamed as it is read from the database, in user ID order so a sync running meanwhile cannot skip or repeat anyone, and each row carries its rank. It takes the same group and sort parameters as the leaderboard; there is no time window, since the leaderboard has none.",
+		Tags:        []string{"leaderboard"},
+		Produces:    []string{"text/csv", "application/json", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		Params: []openapi.Param{
+			{Name: "format", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "csv, json or xlsx", Default: "csv"},
+			{Name: "group", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "Only export members of this group"},
+			{Name: "sort", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "current_streak, max_streak or rating", Default: "current_streak"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[[]LeaderboardExportRow]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Spec",
+		Method:      "get",
+		Path:        "/api/v1/openapi.json",
+		Summary:     "OpenAPI document",
+		Description: "The OpenAPI 3 description of this API, generated from the handler annotations.",
+		Tags:        []string{"docs"},
+		Produces:    []string{"application/json"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[openapi.Document]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Stream",
+		Method:      "get",
+		Path:        "/api/v1/stream",
+		Summary:     "Live event stream",
+		Description: "Server-Sent Events carrying leaderboard row updates, streak changes and the other webhook events as syncs run. Each SSE event is named after the event type and its data is the event JSON. A client that falls behind is sent a \"reconnect\" event and disconnected; it should refetch the leaderboard after reconnecting.",
+		Tags:        []string{"stream"},
+		Produces:    []string{"text/event-stream"},
+		Params: []openapi.Param{
+			{Name: "type", In: "query", Type: reflect.TypeFor[[]string](), Required: false, Description: "Only these event types"},
+			{Name: "user", In: "query", Type: reflect.TypeFor[[]int](), Required: false, Description: "Only events about the users with these IDs"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[string](), Description: "event stream"},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "WebSocket",
+		Method:      "get",
+		Path:        "/api/v1/stream/ws",
+		Summary:     "Live event stream over WebSocket",
+		Description: "The events of /api/v1/stream as one JSON text frame each. A client that falls behind receives a {\"type\":\"reconnect\"} frame before the connection closes.",
+		Tags:        []string{"stream"},
+		Params: []openapi.Param{
+			{Name: "type", In: "query", Type: reflect.TypeFor[[]string](), Required: false, Description: "Only these event types"},
+			{Name: "user", In: "query", Type: reflect.TypeFor[[]int](), Required: false, Description: "Only events about the users with these IDs"},
+		},
+		Responses: []openapi.Response{
+			{Status: 101, Description: "Switching Protocols"},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "AddUser",
+		Method:      "post",
+		Path:        "/api/v1/users",
+		Summary:     "Add a new user",
+		Description: "Add a person by a handle on any judge, which becomes their first account; a handle that is already tracked returns its person. Needs the admin key, or the owner key from a verified claim on the person's Codeforces handle; anyone else registers by claiming their handle.",
+		Tags:        []string{"users"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "user", In: "body", Type: reflect.TypeFor[AddUserRequest](), Required: true, Description: "Judge and handle"},
+		},
+		Responses: []openapi.Response{
+			{Status: 201, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "RemoveUser",
+		Method:      "delete",
+		Path:        "/api/v1/users/{id}",
+		Summary:     "Remove a user",
+		Description: "Stop tracking a user and delete their submissions, achievements and group memberships",
+		Tags:        []string{"users"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetUser",
+		Method:      "get",
+		Path:        "/api/v1/users/{id}",
+		Summary:     "Get user",
+		Description: "Get a person's leaderboard row, with their linked accounts",
+		Tags:        []string{"users"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "If-None-Match", In: "header", Type: reflect.TypeFor[string](), Required: false, Description: "ETag of a previously fetched response"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 304, Description: "Not modified"},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "UpdateProfile",
+		Method:      "patch",
+		Path:        "/api/v1/users/{id}",
+		Summary:     "Update a user's profile",
+		Description: "Change a user's display name, avatar or time zone; fields left out are kept. Streak days run midnight to midnight in the user's time zone, so changing it recalculates their streaks. Needs the owner key from a verified handle claim, or the admin key.",
+		Tags:        []string{"users"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "profile", In: "body", Type: reflect.TypeFor[UpdateProfileRequest](), Required: true, Description: "Profile fields to change"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetAccounts",
+		Method:      "get",
+		Path:        "/api/v1/users/{id}/accounts",
+		Summary:     "List judge accounts",
+		Description: "List the judge accounts whose solves count towards a user's streak, in the order they were linked",
+		Tags:        []string{"users"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[AccountsResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "LinkAccount",
+		Method:      "post",
+		Path:        "/api/v1/users/{id}/accounts",
+		Summary:     "Link a judge account",
+		Description: "Link a handle on another judge, such as AtCoder, to a user. Its solves count towards the user's streak from the next sync on. Needs the owner key from a verified handle claim, or the admin key.",
+		Tags:        []string{"users"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "account", In: "body", Type: reflect.TypeFor[LinkAccountRequest](), Required: true, Description: "Judge and handle"},
+		},
+		Responses: []openapi.Response{
+			{Status: 201, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 409, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "UnlinkAccount",
+		Method:      "delete",
+		Path:        "/api/v1/users/{id}/accounts/{judge}/{account}",
+		Summary:     "Unlink a judge account",
+		Description: "Stop counting a judge account towards a user's streak. Submissions already synced from it are kept. A user's only account cannot be unlinked. Needs the owner key from a verified handle claim, or the admin key.",
+		Tags:        []string{"users"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "judge", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Judge, such as atcoder"},
+			{Name: "account", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Handle on the judge"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetUserAchievements",
+		Method:      "get",
+		Path:        "/api/v1/users/{id}/achievements",
+		Summary:     "Get user achievements",
+		Description: "Get the achievements a user has earned",
+		Tags:        []string{"achievements"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetPreferences",
+		Method:      "get",
+		Path:        "/api/v1/users/{id}/notifications",
+		Summary:     "Get notification preferences",
+		Description: "Get a user's streak-at-risk notification preferences. They hold contact details, so this needs the owner key from a verified handle claim, or the admin key.",
+		Tags:        []string{"notifications"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "SetPreference",
+		Method:      "put",
+		Path:        "/api/v1/users/{id}/notifications",
+		Summary:     "Set a notification preference",
+		Description: "Create or replace a user's preference for one notification channel. Needs the owner key from a verified handle claim, or the admin key.",
+		Tags:        []string{"notifications"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "preference", In: "body", Type: reflect.TypeFor[NotificationPreferenceRequest](), Required: true, Description: "Notification preference"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "DeletePreference",
+		Method:      "delete",
+		Path:        "/api/v1/users/{id}/notifications/{channel}",
+		Summary:     "Delete a notification preference",
+		Description: "Stop sending a user notifications on one channel. Needs the owner key from a verified handle claim, or the admin key.",
+		Tags:        []string{"notifications"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "channel", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "Notification channel"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 403, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetUserSubmissions",
+		Method:      "get",
+		Path:        "/api/v1/users/{id}/submissions",
+		Summary:     "Get user submissions",
+		Description: "Get a paginated list of a user's synced submissions, newest first",
+		Tags:        []string{"users"},
+		Produces:    []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "page", In: "query", Type: reflect.TypeFor[int](), Required: false, Description: "Page number", Default: 1},
+			{Name: "page_size", In: "query", Type: reflect.TypeFor[int](), Required: false, Description: "Page size", Default: 50},
+			{Name: "If-None-Match", In: "header", Type: reflect.TypeFor[string](), Required: false, Description: "ETag of a previously fetched page"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SubmissionsResponse]()},
+			{Status: 304, Description: "Not modified"},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "ExportUserSubmissions",
+		Method:      "get",
+		Path:        "/api/v1/users/{id}/submissions/export",
+		Summary:     "Export user submissions",
+		Description: "Download all of a user's synced submissions on every linked judge, newest first, as CSV, JSON or an XLSX workbook. The file is streamed as it is read from the database.",
+		Tags:        []string{"users"},
+		Produces:    []string{"text/csv", "application/json", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "User ID"},
+			{Name: "format", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "csv, json or xlsx", Default: "csv"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[[]SubmissionExportRow]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "ListWebhooks",
+		Method:      "get",
+		Path:        "/api/v1/webhooks",
+		Summary:     "List webhooks",
+		Description: "List registered webhook subscriptions",
+		Tags:        []string{"webhooks"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "CreateWebhook",
+		Method:      "post",
+		Path:        "/api/v1/webhooks",
+		Summary:     "Register a webhook",
+		Description: "Subscribe a URL to signed event deliveries. The signing secret is only returned here.",
+		Tags:        []string{"webhooks"},
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "webhook", In: "body", Type: reflect.TypeFor[CreateWebhookRequest](), Required: true, Description: "Webhook subscription"},
+		},
+		Responses: []openapi.Response{
+			{Status: 201, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "DeleteWebhook",
+		Method:      "delete",
+		Path:        "/api/v1/webhooks/{id}",
+		Summary:     "Delete a webhook",
+		Description: "Remove a subscription and drop its undelivered events",
+		Tags:        []string{"webhooks"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "Webhook ID"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "GetDeliveries",
+		Method:      "get",
+		Path:        "/api/v1/webhooks/{id}/deliveries",
+		Summary:     "Get webhook deliveries",
+		Description: "Get the most recent delivery attempts for a subscription",
+		Tags:        []string{"webhooks"},
+		Produces:    []string{"application/json"},
+		Security:    []string{"ApiKeyAuth"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true, Description: "Webhook ID"},
+			{Name: "limit", In: "query", Type: reflect.TypeFor[int](), Required: false, Description: "Number of attempts", Default: 50},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[SuccessResponse]()},
+			{Status: 401, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 429, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Badge",
+		Method:      "get",
+		Path:        "/badge/{id}",
+		Summary:     "Streak badge",
+		Description: "A one-line SVG badge with the user's current streak, for READMEs and personal sites",
+		Tags:        []string{"badges"},
+		Produces:    []string{"image/svg+xml", "application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "User ID, followed by .svg"},
+			{Name: "theme", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "light or dark", Default: "light"},
+			{Name: "size", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "small, medium or large", Default: "medium"},
+			{Name: "If-None-Match", In: "header", Type: reflect.TypeFor[string](), Required: false, Description: "ETag of a previously fetched image"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[string](), Description: "SVG image"},
+			{Status: 304, Description: "Not modified"},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Card",
+		Method:      "get",
+		Path:        "/card/{id}",
+		Summary:     "Streak card",
+		Description: "An SVG stat card with the user's current and max streak, leaderboard rank and a heatmap of the last 12 weeks",
+		Tags:        []string{"badges"},
+		Produces:    []string{"image/svg+xml", "application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[string](), Required: true, Description: "User ID, followed by .svg"},
+			{Name: "theme", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "light or dark", Default: "light"},
+			{Name: "size", In: "query", Type: reflect.TypeFor[string](), Required: false, Description: "small, medium or large", Default: "medium"},
+			{Name: "If-None-Match", In: "header", Type: reflect.TypeFor[string](), Required: false, Description: "ETag of a previously fetched image"},
+		},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[string](), Description: "SVG image"},
+			{Status: 304, Description: "Not modified"},
+			{Status: 400, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 404, Type: reflect.TypeFor[ErrorResponse]()},
+			{Status: 500, Type: reflect.TypeFor[ErrorResponse]()},
+		},
+	},
+	{
+		ID:          "Health",
+		Method:      "get",
+		Path:        "/health",
+		Summary:     "Health check",
+		Description: "Check if the service is healthy",
+		Tags:        []string{"health"},
+		Produces:    []string{"application/json"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[HealthResponse]()},
+		},
+	},
+	{
+		ID:          "Livez",
+		Method:      "get",
+		Path:        "/livez",
+		Summary:     "Liveness probe",
+		Description: "Report that the process is running. Dependencies are not checked, so an outage elsewhere does not get the instance restarted.",
+		Tags:        []string{"health"},
+		Produces:    []string{"application/json"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[map[string]string]()},
+		},
+	},
+	{
+		ID:          "Readyz",
+		Method:      "get",
+		Path:        "/readyz",
+		Summary:     "Readiness probe",
+		Description: "Check the database, schema version, sync freshness and Codeforces reachability. Returns 503 when any component is down; an unreachable Codeforces API only degrades the status.",
+		Tags:        []string{"health"},
+		Produces:    []string{"application/json"},
+		Responses: []openapi.Response{
+			{Status: 200, Type: reflect.TypeFor[ReadinessResponse]()},
+			{Status: 503, Type: reflect.TypeFor[ReadinessResponse]()},
+		},
+	},
+}
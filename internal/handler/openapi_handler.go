@@ -0,0 +1,144 @@
+package handler
+
+//go:generate go run ../../cmd/openapi-gen -general ../../cmd/api/main.go -dir . -out openapi_gen.go
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/openapi"
+)
+
+const openAPIPath = "/api/v1/openapi.json"
+
+// OpenAPIHandler serves the OpenAPI document generated from the handler
+// annotations, a Swagger UI for it, and the middleware that validates
+// traffic against it.
+type OpenAPIHandler struct {
+	spec              []byte
+	ui                []byte
+	validator         *openapi.Validator
+	validateRequests  bool
+	validateResponses bool
+	logger            *slog.Logger
+}
+
+// NewOpenAPIHandler builds the document. Invalid requests are rejected with
+// 400 when validateRequests is set; responses that do not match the
+// document are only logged, and only when validateResponses is set, since
+// checking them costs a copy of every JSON body.
+func NewOpenAPIHandler(validateRequests, validateResponses bool, uiAssetsURL string, logger *slog.Logger) *OpenAPIHandler {
+	doc := OpenAPIDocument()
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		panic(err) // the document only holds strings, maps and slices
+	}
+	return &OpenAPIHandler{
+		spec:              spec,
+		ui:                openapi.UI(doc.Info.Title, openAPIPath, strings.TrimSuffix(uiAssetsURL, "/")),
+		validator:         openapi.NewValidator(doc),
+		validateRequests:  validateRequests,
+		validateResponses: validateResponses,
+		logger:            logger,
+	}
+}
+
+// OpenAPIDocument returns the API description generated from the handler
+// annotations.
+func OpenAPIDocument() *openapi.Document {
+	return openapi.Build(openAPIInfo, openAPISecuritySchemes, openAPIOperations)
+}
+
+// Spec godoc
+// @Summary OpenAPI document
+// @Description The OpenAPI 3 description of this API, generated from the handler annotations.
+// @Tags docs
+// @Produce json
+// @Success 200 {object} openapi.Document
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/openapi.json [get]
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// UI godoc
+// @Summary API explorer
+// @Description Swagger UI for the OpenAPI document.
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "Swagger UI page"
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/docs [get]
+func (h *OpenAPIHandler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", h.ui)
+}
+
+// Validate checks requests, and optionally responses, of the routes the
+// document describes against it. Other routes pass through untouched.
+func (h *OpenAPIHandler) Validate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gin writes path parameters as :name, OpenAPI as {name}.
+		segments := strings.Split(c.FullPath(), "/")
+		for i, segment := range segments {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				segments[i] = "{" + name + "}"
+			}
+		}
+		op := h.validator.Operation(c.Request.Method, strings.Join(segments, "/"))
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		if h.validateRequests {
+			if err := h.validator.ValidateRequest(op, c.Request, c.Param); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request: " + err.Error()})
+				return
+			}
+		}
+
+		if !h.validateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &jsonRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		err := h.validator.ValidateResponse(op, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			h.logger.WarnContext(c.Request.Context(), "Response does not match the OpenAPI document",
+				"method", c.Request.Method, "route", c.FullPath(), "status", recorder.Status(), "error", err)
+		}
+	}
+}
+
+// jsonRecorder keeps a copy of JSON response bodies. Anything else, such as
+// an event stream, is passed through without being buffered.
+type jsonRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *jsonRecorder) Write(data []byte) (int, error) {
+	if mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); mediaType == "application/json" {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *jsonRecorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the connection, so streamed
+// responses can still clear their write deadline.
+func (w *jsonRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
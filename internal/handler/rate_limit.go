@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+)
+
+// Route classes, each with its own bucket per client.
+const (
+	RateClassRead  = "read"
+	RateClassWrite = "write"
+	// RateClassUpstream is for routes that call a judge's API while the
+	// client waits. It is taken on top of the read or write bucket.
+	RateClassUpstream = "upstream"
+)
+
+// RateLimiter meters API requests per client and route class. Clients are
+// told apart by IP address, except that requests authenticated with a key,
+// the admin key or the owner key of the :handle in the path, get buckets of
+// their own keyed by a hash of that key, so a key holder does not share a
+// budget with whoever is behind the same address. Keys that do not check out
+// do not earn separate buckets, or sending made-up keys would dodge the limit.
+type RateLimiter struct {
+	limiter      ratelimit.Limiter
+	limits       map[string]ratelimit.Limit
+	adminKey     string
+	claimService service.ClaimService
+	logger       *slog.Logger
+}
+
+// NewRateLimiter takes the limit of each route class; classes without one
+// are not limited. claimService checks owner keys.
+func NewRateLimiter(limiter ratelimit.Limiter, limits map[string]ratelimit.Limit, adminKey string, claimService service.ClaimService, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		limiter:      limiter,
+		limits:       limits,
+		adminKey:     adminKey,
+		claimService: claimService,
+		logger:       logger,
+	}
+}
+
+// LimitByMethod takes from the read bucket for GET and HEAD requests and
+// from the write bucket for everything else.
+func (rl *RateLimiter) LimitByMethod() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := RateClassWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			class = RateClassRead
+		}
+		rl.take(c, class)
+	}
+}
+
+// Limit takes from the bucket of a route class.
+func (rl *RateLimiter) Limit(class string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rl.take(c, class)
+	}
+}
+
+// take answers 429 with Retry-After when the client's bucket is empty. A
+// broken limiter backend lets requests through rather than take the API
+// down with it.
+func (rl *RateLimiter) take(c *gin.Context, class string) {
+	limit, ok := rl.limits[class]
+	if !ok || limit.Unlimited() {
+		c.Next()
+		return
+	}
+
+	ctx := c.Request.Context()
+	allowed, retryAfter, err := rl.limiter.Allow(ctx, class+":"+rl.client(c), limit)
+	if err != nil {
+		rl.logger.WarnContext(ctx, "Rate limiter failed", "class", class, "error", err)
+		c.Next()
+		return
+	}
+	if !allowed {
+		seconds := int((retryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{
+			Error: fmt.Sprintf("rate limit exceeded, retry in %d seconds", seconds),
+		})
+		return
+	}
+	c.Next()
+}
+
+// rateLimitClientKey caches a request's client in the gin context, so the
+// owner key is looked up once however many classes the route takes from.
+const rateLimitClientKey = "rateLimitClient"
+
+func (rl *RateLimiter) client(c *gin.Context) string {
+	if client := c.GetString(rateLimitClientKey); client != "" {
+		return client
+	}
+
+	client := "ip:" + c.ClientIP()
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && token != "" && rl.authenticated(c, token) {
+		sum := sha256.Sum256([]byte(token))
+		client = "key:" + hex.EncodeToString(sum[:])
+	}
+	c.Set(rateLimitClientKey, client)
+	return client
+}
+
+// authenticated reports whether token is the admin key or the owner key of
+// the user the route is about.
+func (rl *RateLimiter) authenticated(c *gin.Context, token string) bool {
+	if rl.adminKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(rl.adminKey)) == 1 {
+		return true
+	}
+	userID, ok := routeUserID(c)
+	return ok && rl.claimService.AuthorizeOwner(c.Request.Context(), userID, token) == nil
+}
+
+// routeUserID returns the user an owner key may act for on this route: the
+// :id of the /users/:id routes and of group memberships. Elsewhere :id names
+// a claim or a webhook, and ok is false.
+func routeUserID(c *gin.Context) (id uint, ok bool) {
+	path := c.FullPath()
+	if !strings.HasPrefix(path, "/api/v1/users/:id") && !strings.HasSuffix(path, "/members/:id") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	return uint(n), err == nil && n != 0
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cache"
+)
+
+// CacheStatusHeader reports whether a response came from the cache.
+const CacheStatusHeader = "X-Cache"
+
+// ResponseCache serves GET responses from a cache and answers
+// conditional requests. Entries live until the next write invalidates them;
+// maxAge only bounds how long browsers and CDNs reuse a response without
+// revalidating.
+type ResponseCache struct {
+	cache  cache.Cache
+	maxAge time.Duration
+	logger *slog.Logger
+}
+
+func NewResponseCache(cache cache.Cache, maxAge time.Duration, logger *slog.Logger) *ResponseCache {
+	return &ResponseCache{
+		cache:  cache,
+		maxAge: maxAge,
+		logger: logger,
+	}
+}
+
+// Serve writes the response cached under key, or the JSON encoding of what
+// load returns. load writes its own error response and returns nil on
+// failure; errors are never cached. A broken cache backend only costs the
+// hit: the response is built from the database as if it were a miss.
+func (rc *ResponseCache) Serve(c *gin.Context, key string, load func() any) {
+	rc.ServeContent(c, key, "application/json; charset=utf-8", func() []byte {
+		data := load()
+		if data == nil {
+			return nil
+		}
+
+		body, err := json.Marshal(data)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+		return body
+	})
+}
+
+// ServeContent is Serve for bodies that are not JSON: load returns the body
+// itself, which is sent as contentType.
+func (rc *ResponseCache) ServeContent(c *gin.Context, key, contentType string, load func() []byte) {
+	ctx := c.Request.Context()
+
+	entry, hit, err := rc.cache.Get(ctx, key)
+	if err != nil {
+		rc.logger.WarnContext(ctx, "Response cache read failed", "key", key, "error", err)
+	}
+
+	if hit {
+		c.Header(CacheStatusHeader, "HIT")
+	} else {
+		// The generation is read before loading so that an invalidation
+		// while the body is built keeps it out of the cache.
+		generation, generationErr := rc.cache.Generation(ctx)
+		body := load()
+		if body == nil {
+			return
+		}
+
+		entry = cache.Entry{ETag: etagFor(body), Body: body}
+		if generationErr != nil {
+			rc.logger.WarnContext(ctx, "Response cache read failed", "key", key, "error", generationErr)
+		} else if err := rc.cache.Set(ctx, key, generation, entry); err != nil {
+			rc.logger.WarnContext(ctx, "Response cache write failed", "key", key, "error", err)
+		}
+		c.Header(CacheStatusHeader, "MISS")
+	}
+
+	c.Header("ETag", entry.ETag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(rc.maxAge.Seconds())))
+
+	if etagMatches(c.GetHeader("If-None-Match"), entry.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, entry.Body)
+}
+
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison If-None-Match calls for: a W/
+// prefix on either side is ignored, and "*" matches anything.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
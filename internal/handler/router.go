@@ -1,34 +1,82 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/metrics"
 )
 
 type Router struct {
-	userHandler   *UserHandler
-	healthHandler *HealthHandler
+	userHandler         *UserHandler
+	achievementHandler  *AchievementHandler
+	notificationHandler *NotificationHandler
+	webhookHandler      *WebhookHandler
+	groupHandler        *GroupHandler
+	healthHandler       *HealthHandler
+	streamHandler       *StreamHandler
+	graphQLHandler      *GraphQLHandler
+	openAPIHandler      *OpenAPIHandler
+	adminHandler        *AdminHandler
+	badgeHandler        *BadgeHandler
+	claimHandler        *ClaimHandler
+	rateLimiter         *RateLimiter
+	discordBot          http.Handler // nil when Discord is not configured
+	adminAPIKey         string
+	logger              *slog.Logger
 }
 
-func NewRouter(userHandler *UserHandler, healthHandler *HealthHandler) *Router {
+func NewRouter(
+	userHandler *UserHandler,
+	achievementHandler *AchievementHandler,
+	notificationHandler *NotificationHandler,
+	webhookHandler *WebhookHandler,
+	groupHandler *GroupHandler,
+	healthHandler *HealthHandler,
+	streamHandler *StreamHandler,
+	graphQLHandler *GraphQLHandler,
+	openAPIHandler *OpenAPIHandler,
+	adminHandler *AdminHandler,
+	badgeHandler *BadgeHandler,
+	claimHandler *ClaimHandler,
+	rateLimiter *RateLimiter,
+	discordBot http.Handler,
+	adminAPIKey string,
+	logger *slog.Logger,
+) *Router {
 	return &Router{
-		userHandler:   userHandler,
-		healthHandler: healthHandler,
+		userHandler:         userHandler,
+		achievementHandler:  achievementHandler,
+		notificationHandler: notificationHandler,
+		webhookHandler:      webhookHandler,
+		groupHandler:        groupHandler,
+		healthHandler:       healthHandler,
+		streamHandler:       streamHandler,
+		graphQLHandler:      graphQLHandler,
+		openAPIHandler:      openAPIHandler,
+		adminHandler:        adminHandler,
+		badgeHandler:        badgeHandler,
+		claimHandler:        claimHandler,
+		rateLimiter:         rateLimiter,
+		discordBot:          discordBot,
+		adminAPIKey:         adminAPIKey,
+		logger:              logger,
 	}
 }
 
 func (r *Router) Setup() *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
+	router.Use(RequestID(), LogRequests(r.logger), gin.Recovery(), RecordMetrics(), r.openAPIHandler.Validate())
 
 	// CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -40,19 +88,93 @@ func (r *Router) Setup() *gin.Engine {
 	// Serve static assets (images, css, js, favicon, etc.)
 	router.Static("/assets", "./frontend/assets")
 
-	// Health check
+	// Health checks
 	router.GET("/health", r.healthHandler.Health)
+	router.GET("/livez", r.healthHandler.Livez)
+	router.GET("/readyz", r.healthHandler.Readyz)
 
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	// Embeddable streak images
+	router.GET("/badge/:id", r.badgeHandler.Badge)
+	router.GET("/card/:id", r.badgeHandler.Card)
+
+	// Prometheus scrape endpoint
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Chat bot webhooks
+	if r.discordBot != nil {
+		router.POST("/bot/discord/interactions", gin.WrapH(r.discordBot))
+	}
+
+	// API v1 routes; routes that call a judge while the client waits also
+	// take from the stricter upstream bucket
+	v1 := router.Group("/api/v1", r.rateLimiter.LimitByMethod())
+	upstream := r.rateLimiter.Limit(RateClassUpstream)
 	{
 		users := v1.Group("/users")
 		{
-			users.POST("", r.userHandler.AddUser)
-			users.GET("/:handle", r.userHandler.GetUserByHandle)
+			users.POST("", r.claimHandler.RequireClaimedHandle(r.adminAPIKey), upstream, r.userHandler.AddUser)
+			users.GET("/:id", r.userHandler.GetUser)
+			users.PATCH("/:id", r.claimHandler.RequireOwner(r.adminAPIKey), r.userHandler.UpdateProfile)
+			users.DELETE("/:id", RequireAdminKey(r.adminAPIKey), r.userHandler.RemoveUser)
+			users.GET("/:id/submissions", r.userHandler.GetUserSubmissions)
+			users.GET("/:id/submissions/export", r.userHandler.ExportUserSubmissions)
+			users.GET("/:id/accounts", r.userHandler.GetAccounts)
+			users.POST("/:id/accounts", r.claimHandler.RequireOwner(r.adminAPIKey), upstream, r.userHandler.LinkAccount)
+			users.DELETE("/:id/accounts/:judge/:account", r.claimHandler.RequireOwner(r.adminAPIKey), r.userHandler.UnlinkAccount)
+			users.GET("/:id/achievements", r.achievementHandler.GetUserAchievements)
+			users.GET("/:id/notifications", r.claimHandler.RequireOwner(r.adminAPIKey), r.notificationHandler.GetPreferences)
+			users.PUT("/:id/notifications", r.claimHandler.RequireOwner(r.adminAPIKey), r.notificationHandler.SetPreference)
+			users.DELETE("/:id/notifications/:channel", r.claimHandler.RequireOwner(r.adminAPIKey), r.notificationHandler.DeletePreference)
 		}
 
+		v1.GET("/accounts/:judge/:handle", r.userHandler.GetUserByAccount)
+
 		v1.GET("/leaderboard", r.userHandler.GetLeaderboard)
+		v1.GET("/leaderboard/export", r.userHandler.ExportLeaderboard)
+		v1.GET("/achievements", r.achievementHandler.ListAchievements)
+
+		v1.GET("/stream", r.streamHandler.Stream)
+		v1.GET("/stream/ws", r.streamHandler.WebSocket)
+
+		v1.GET("/graphql", r.graphQLHandler.Query)
+		v1.POST("/graphql", r.graphQLHandler.QueryJSON)
+		v1.GET("/graphql/schema", r.graphQLHandler.Schema)
+
+		v1.GET("/openapi.json", r.openAPIHandler.Spec)
+		v1.GET("/docs", r.openAPIHandler.UI)
+
+		groups := v1.Group("/groups")
+		{
+			groups.GET("", r.groupHandler.ListGroups)
+
+			admin := groups.Group("", RequireAdminKey(r.adminAPIKey))
+			admin.POST("", r.groupHandler.CreateGroup)
+			admin.POST("/:name/members", r.groupHandler.AddMember)
+
+			// Members may leave a group themselves.
+			groups.DELETE("/:name/members/:id", r.claimHandler.RequireOwner(r.adminAPIKey), r.groupHandler.RemoveMember)
+		}
+
+		claims := v1.Group("/claims")
+		{
+			claims.POST("", upstream, r.claimHandler.CreateClaim)
+			claims.GET("/:id", r.claimHandler.GetClaim)
+			claims.POST("/:id/verify", upstream, r.claimHandler.VerifyClaim)
+		}
+
+		webhooks := v1.Group("/webhooks", RequireAdminKey(r.adminAPIKey))
+		{
+			webhooks.POST("", r.webhookHandler.CreateWebhook)
+			webhooks.GET("", r.webhookHandler.ListWebhooks)
+			webhooks.DELETE("/:id", r.webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", r.webhookHandler.GetDeliveries)
+		}
+
+		admin := v1.Group("/admin", RequireAdminKey(r.adminAPIKey))
+		{
+			admin.POST("/sync", r.adminHandler.Sync)
+			admin.POST("/streaks/recompute", r.adminHandler.RecomputeStreaks)
+		}
 	}
 
 	// Optional: SPA fallback - serve index.html for any unknown route (except API)
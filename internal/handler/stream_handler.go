@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/stream"
+	"golang.org/x/net/websocket"
+)
+
+type StreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+	logger    *slog.Logger
+}
+
+func NewStreamHandler(hub *stream.Hub, heartbeat time.Duration, logger *slog.Logger) *StreamHandler {
+	return &StreamHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+		logger:    logger,
+	}
+}
+
+// streamFilter reads the repeatable type and user query parameters.
+func streamFilter(c *gin.Context) (stream.Filter, error) {
+	filter := stream.Filter{
+		Types: c.QueryArray("type"),
+	}
+	for _, eventType := range filter.Types {
+		if !slices.Contains(domain.EventTypes, eventType) {
+			return stream.Filter{}, fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	for _, user := range c.QueryArray("user") {
+		id, err := strconv.ParseUint(user, 10, 0)
+		if err != nil {
+			return stream.Filter{}, fmt.Errorf("invalid user ID %q", user)
+		}
+		filter.UserIDs = append(filter.UserIDs, uint(id))
+	}
+	return filter, nil
+}
+
+// Stream godoc
+// @Summary Live event stream
+// @Description Server-Sent Events carrying leaderboard row updates, streak changes and the other webhook events as syncs run. Each SSE event is named after the event type and its data is the event JSON. A client that falls behind is sent a "reconnect" event and disconnected; it should refetch the leaderboard after reconnecting.
+// @Tags stream
+// @Produce text/event-stream
+// @Param type query []string false "Only these event types" collectionFormat(multi)
+// @Param user query []int false "Only events about the users with these IDs" collectionFormat(multi)
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	filter, err := streamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// The server's WriteTimeout would otherwise cut the stream off.
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.WarnContext(c.Request.Context(), "Could not clear stream write deadline", "error", err)
+	}
+
+	sub := h.hub.Subscribe(filter)
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	write := func(format string, args ...any) bool {
+		if _, err := fmt.Fprintf(c.Writer, format, args...); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	if !write("retry: 5000\n\n") {
+		return
+	}
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case msg, ok := <-sub.C:
+			if !ok {
+				write("event: reconnect\ndata: {\"reason\":%q}\n\n", sub.Err().Error())
+				return
+			}
+			if !write("id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data) {
+				return
+			}
+		}
+	}
+}
+
+// WebSocket godoc
+// @Summary Live event stream over WebSocket
+// @Description The events of /api/v1/stream as one JSON text frame each. A client that falls behind receives a {"type":"reconnect"} frame before the connection closes.
+// @Tags stream
+// @Param type query []string false "Only these event types" collectionFormat(multi)
+// @Param user query []int false "Only events about the users with these IDs" collectionFormat(multi)
+// @Success 101 "Switching Protocols"
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/stream/ws [get]
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	filter, err := streamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// websocket.Server skips the same-origin check that websocket.Handler
+	// makes; CORS already allows every origin.
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serveWebSocket(c, ws, filter)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *StreamHandler) serveWebSocket(c *gin.Context, ws *websocket.Conn, filter stream.Filter) {
+	defer ws.Close()
+
+	sub := h.hub.Subscribe(filter)
+	defer h.hub.Unsubscribe(sub)
+
+	// Clients do not send anything; reading only notices when they leave.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	send := func(frame string) bool {
+		if err := ws.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+			return false
+		}
+		return websocket.Message.Send(ws, frame) == nil
+	}
+
+	for {
+		select {
+		case <-gone:
+			return
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !send(`{"type":"ping"}`) {
+				return
+			}
+		case msg, ok := <-sub.C:
+			if !ok {
+				send(fmt.Sprintf(`{"type":"reconnect","data":{"reason":%q}}`, sub.Err().Error()))
+				return
+			}
+			if !send(string(msg.Data)) {
+				return
+			}
+		}
+	}
+}
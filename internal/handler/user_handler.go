@@ -1,25 +1,52 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/export"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-	userService service.UserService
+	userService   service.UserService
+	responseCache *ResponseCache
+	logger        *slog.Logger
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, responseCache *ResponseCache, logger *slog.Logger) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:   userService,
+		responseCache: responseCache,
+		logger:        logger,
 	}
 }
 
+// AddUserRequest names the judge account a new person is tracked by. Judge
+// defaults to codeforces.
 type AddUserRequest struct {
-	CodeforcesHandle string `json:"codeforces_handle" binding:"required"`
+	Judge  string `json:"judge"`
+	Handle string `json:"handle" binding:"required"`
+}
+
+// judge returns the request's judge, codeforces if it names none.
+func (r AddUserRequest) judge() string {
+	if r.Judge == "" {
+		return domain.JudgeCodeforces
+	}
+	return r.Judge
 }
 
 type ErrorResponse struct {
@@ -41,25 +68,38 @@ type LeaderboardResponse struct {
 
 // AddUser godoc
 // @Summary Add a new user
-// @Description Add a user by their Codeforces handle
+// @Description Add a person by a handle on any judge, which becomes their first account; a handle that is already tracked returns its person. Needs the admin key, or the owner key from a verified claim on the person's Codeforces handle; anyone else registers by claiming their handle.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param user body AddUserRequest true "User handle"
+// @Security ApiKeyAuth
+// @Param user body AddUserRequest true "Judge and handle"
 // @Success 201 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/users [post]
 func (h *UserHandler) AddUser(c *gin.Context) {
 	var req AddUserRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// RequireClaimedHandle has read the body already.
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	user, err := h.userService.AddUser(req.CodeforcesHandle)
-	if err != nil {
+	user, err := h.userService.AddUser(c.Request.Context(), req.judge(), req.Handle)
+	switch {
+	case errors.Is(err, service.ErrUnknownJudge):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, domain.ErrHandleNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -70,20 +110,488 @@ func (h *UserHandler) AddUser(c *gin.Context) {
 	})
 }
 
+type SubmissionsResponse struct {
+	Submissions []domain.Submission `json:"submissions"`
+	Page        int                 `json:"page"`
+	PageSize    int                 `json:"page_size"`
+	Total       int64               `json:"total"`
+	TotalPages  int                 `json:"total_pages"`
+}
+
+// RemoveUser godoc
+// @Summary Remove a user
+// @Description Stop tracking a user and delete their submissions, achievements and group memberships
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id} [delete]
+func (h *UserHandler) RemoveUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.userService.RemoveUser(c.Request.Context(), userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "User removed successfully",
+	})
+}
+
+// UpdateProfileRequest changes the profile fields that are present.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	TimeZone    *string `json:"time_zone"`
+}
+
+// UpdateProfile godoc
+// @Summary Update a user's profile
+// @Description Change a user's display name, avatar or time zone; fields left out are kept. Streak days run midnight to midnight in the user's time zone, so changing it recalculates their streaks. Needs the owner key from a verified handle claim, or the admin key.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param profile body UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id} [patch]
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateProfileRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, domain.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		TimeZone:    req.TimeZone,
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	case errors.Is(err, service.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Profile updated successfully",
+		Data:    user,
+	})
+}
+
 // GetLeaderboard godoc
 // @Summary Get leaderboard
-// @Description Get paginated leaderboard of users sorted by streak
+// @Description Get paginated leaderboard of users sorted by current streak, or by another sort. Rank changes are only reported for the whole leaderboard in the default order.
 // @Tags leaderboard
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(50)
+// @Param group query string false "Only rank members of this group"
+// @Param sort query string false "current_streak, max_streak or rating" default(current_streak)
+// @Param If-None-Match header string false "ETag of a previously fetched page"
 // @Success 200 {object} LeaderboardResponse
+// @Success 304 "Not modified"
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/leaderboard [get]
 func (h *UserHandler) GetLeaderboard(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	page, pageSize := pagination(c)
+	group := c.Query("group")
+	sort := c.DefaultQuery("sort", domain.SortCurrentStreak)
+
+	// The key uses the normalised parameters, so ?page=0 and ?page=1 share
+	// an entry.
+	key := fmt.Sprintf("leaderboard:page=%d:size=%d:sort=%s:group=%s", page, pageSize, url.QueryEscape(sort), url.QueryEscape(group))
+	h.responseCache.Serve(c, key, func() any {
+		users, total, err := h.userService.GetLeaderboard(c.Request.Context(), page, pageSize, group, sort)
+		if errors.Is(err, service.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Group not found"})
+			return nil
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+
+		return LeaderboardResponse{
+			Users:      users,
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: totalPages(total, pageSize),
+		}
+	})
+}
+
+// GetUser godoc
+// @Summary Get user
+// @Description Get a person's leaderboard row, with their linked accounts
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Success 200 {object} SuccessResponse
+// @Success 304 "Not modified"
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	h.responseCache.Serve(c, fmt.Sprintf("user:%d", userID), func() any {
+		user, err := h.userService.GetUserStanding(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			return nil
+		}
+
+		return SuccessResponse{
+			Data: user,
+		}
+	})
+}
+
+// GetUserByAccount godoc
+// @Summary Find user by judge handle
+// @Description Get the leaderboard row of the person a handle on a judge is linked to
+// @Tags users
+// @Produce json
+// @Param judge path string true "Judge, such as codeforces"
+// @Param handle path string true "Handle on the judge"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Success 200 {object} SuccessResponse
+// @Success 304 "Not modified"
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/accounts/{judge}/{handle} [get]
+func (h *UserHandler) GetUserByAccount(c *gin.Context) {
+	judge, handle := c.Param("judge"), c.Param("handle")
+
+	key := fmt.Sprintf("account:%s:%s", url.PathEscape(judge), url.PathEscape(handle))
+	h.responseCache.Serve(c, key, func() any {
+		user, err := h.userService.GetUserByAccount(c.Request.Context(), judge, handle)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			return nil
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+
+		standing, err := h.userService.GetUserStanding(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+
+		return SuccessResponse{
+			Data: standing,
+		}
+	})
+}
+
+// GetUserSubmissions godoc
+// @Summary Get user submissions
+// @Description Get a paginated list of a user's synced submissions, newest first
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(50)
+// @Param If-None-Match header string false "ETag of a previously fetched page"
+// @Success 200 {object} SubmissionsResponse
+// @Success 304 "Not modified"
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/submissions [get]
+func (h *UserHandler) GetUserSubmissions(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	page, pageSize := pagination(c)
+
+	key := fmt.Sprintf("submissions:%d:page=%d:size=%d", userID, page, pageSize)
+	h.responseCache.Serve(c, key, func() any {
+		submissions, total, err := h.userService.GetUserSubmissions(c.Request.Context(), userID, page, pageSize)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			return nil
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return nil
+		}
+
+		return SubmissionsResponse{
+			Submissions: submissions,
+			Page:        page,
+			PageSize:    pageSize,
+			Total:       total,
+			TotalPages:  totalPages(total, pageSize),
+		}
+	})
+}
+
+// LeaderboardExportRow is one user in a leaderboard export. Accounts lists
+// their judge accounts as judge:handle, separated by spaces.
+type LeaderboardExportRow struct {
+	Rank             int        `json:"rank"`
+	UserID           uint       `json:"user_id"`
+	Name             string     `json:"name"`
+	Accounts         string     `json:"accounts"`
+	CurrentStreak    int        `json:"current_streak"`
+	MaxStreak        int        `json:"max_streak"`
+	Rating           int        `json:"rating"`
+	CodeforcesRank   string     `json:"codeforces_rank"`
+	LastSubmissionAt *time.Time `json:"last_submission_at"`
+	RankChange       int        `json:"rank_change"`
+	StreakChange     int        `json:"streak_change"`
+}
+
+// SubmissionExportRow is one submission in a submissions export.
+// SubmissionID is the submission's ID on Judge.
+type SubmissionExportRow struct {
+	Judge        string    `json:"judge"`
+	SubmissionID int64     `json:"submission_id"`
+	Verdict      string    `json:"verdict"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
+
+// ExportLeaderboard godoc
+// @Summary Export leaderboard
+// @Description Download the whole leaderboard as CSV, JSON or an XLSX workbook. The file is streamed as it is read from the database, in user ID order so a sync running meanwhile cannot skip or repeat anyone, and each row carries its rank. It takes the same group and sort parameters as the leaderboard; there is no time window, since the leaderboard has none.
+// @Tags leaderboard
+// @Produce text/csv,json,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv, json or xlsx" default(csv)
+// @Param group query string false "Only export members of this group"
+// @Param sort query string false "current_streak, max_streak or rating" default(current_streak)
+// @Success 200 {array} LeaderboardExportRow
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/leaderboard/export [get]
+func (h *UserHandler) ExportLeaderboard(c *gin.Context) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", "csv"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filename := "leaderboard"
+	if group := c.Query("group"); group != "" {
+		filename += "-" + group
+	}
+
+	users := h.userService.ExportLeaderboard(c.Request.Context(), c.Query("group"), c.Query("sort"))
+	rows := func(yield func(LeaderboardExportRow, error) bool) {
+		for user, err := range users {
+			row := LeaderboardExportRow{
+				Rank:             user.LeaderboardRank,
+				UserID:           user.ID,
+				Name:             user.Name(),
+				Accounts:         accountList(user.Accounts),
+				CurrentStreak:    user.CurrentStreak,
+				MaxStreak:        user.MaxStreak,
+				Rating:           user.Rating,
+				CodeforcesRank:   user.Rank,
+				LastSubmissionAt: user.LastSubmissionAt,
+				RankChange:       user.RankChange,
+				StreakChange:     user.StreakChange,
+			}
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+	streamExport(c, h.logger, format, filename, "Leaderboard", "Group not found", rows)
+}
+
+// ExportUserSubmissions godoc
+// @Summary Export user submissions
+// @Description Download all of a user's synced submissions on every linked judge, newest first, as CSV, JSON or an XLSX workbook. The file is streamed as it is read from the database.
+// @Tags users
+// @Produce text/csv,json,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path int true "User ID"
+// @Param format query string false "csv, json or xlsx" default(csv)
+// @Success 200 {array} SubmissionExportRow
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/submissions/export [get]
+func (h *UserHandler) ExportUserSubmissions(c *gin.Context) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", "csv"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	submissions := h.userService.ExportUserSubmissions(c.Request.Context(), userID)
+	rows := func(yield func(SubmissionExportRow, error) bool) {
+		for submission, err := range submissions {
+			row := SubmissionExportRow{
+				Judge:        submission.Judge,
+				SubmissionID: submission.CodeforcesSubmissionID,
+				Verdict:      submission.Verdict,
+				SubmittedAt:  submission.SubmittedAt,
+			}
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+	streamExport(c, h.logger, format, fmt.Sprintf("user-%d-submissions", userID), "Submissions", "User not found", rows)
+}
+
+// exportFlushRows is how often an export pushes what it has written to the
+// client, so large downloads show progress.
+const exportFlushRows = 500
+
+// streamExport writes rows to the response as filename in format. Headers
+// are only sent with the first row, so an error before then, such as a
+// missing record (reported as notFound) or an invalid sort, still gets a JSON
+// error response. An error after that can no longer change the status; the
+// export is left unterminated so the client sees a broken file rather than a
+// short one.
+func streamExport[T any](c *gin.Context, logger *slog.Logger, format export.Format, filename, sheet, notFound string, rows iter.Seq2[T, error]) {
+	// The server's WriteTimeout would otherwise cut large exports off.
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.WarnContext(c.Request.Context(), "Could not clear export write deadline", "error", err)
+	}
+
+	var w *export.Writer[T]
+	start := func() error {
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": filename + "." + string(format),
+		}))
+		c.Status(http.StatusOK)
+
+		var err error
+		w, err = export.NewWriter[T](format, c.Writer, sheet)
+		return err
+	}
+
+	written := 0
+	for row, err := range rows {
+		if err == nil && w == nil {
+			err = start()
+		}
+		if err == nil {
+			err = w.Write(row)
+		}
+		if err != nil {
+			if w == nil && errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: notFound})
+			} else if w == nil && errors.Is(err, service.ErrInvalidSort) {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			} else if w == nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			} else {
+				logger.ErrorContext(c.Request.Context(), "Export failed", "route", c.FullPath(), "rows", written, "error", err)
+			}
+			return
+		}
+
+		written++
+		if written%exportFlushRows == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+
+	if w == nil {
+		if err := start(); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Export failed", "route", c.FullPath(), "rows", written, "error", err)
+	}
+}
+
+// accountList formats accounts as judge:handle pairs separated by spaces.
+func accountList(accounts []domain.JudgeAccount) string {
+	pairs := make([]string, len(accounts))
+	for i, account := range accounts {
+		pairs[i] = account.Judge + ":" + account.Handle
+	}
+	return strings.Join(pairs, " ")
+}
+
+// userIDParam reads the user ID from the :id path parameter. Anything but a
+// positive number names no user, so it is answered with 404 like a missing
+// one and ok is false.
+func userIDParam(c *gin.Context) (id uint, ok bool) {
+	n, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil || n == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// pagination reads the page and page_size query parameters, falling back to
+// the first page of 50 for missing or out-of-range values.
+func pagination(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
 
 	if page < 1 {
 		page = 1
@@ -91,47 +599,140 @@ func (h *UserHandler) GetLeaderboard(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 50
 	}
+	return page, pageSize
+}
+
+func totalPages(total int64, pageSize int) int {
+	pages := int(total) / pageSize
+	if int(total)%pageSize != 0 {
+		pages++
+	}
+	return pages
+}
+
+type LinkAccountRequest struct {
+	Judge  string `json:"judge" binding:"required"`
+	Handle string `json:"handle" binding:"required"`
+}
+
+type AccountsResponse struct {
+	Accounts []domain.JudgeAccount `json:"accounts"`
+}
+
+// GetAccounts godoc
+// @Summary List judge accounts
+// @Description List the judge accounts whose solves count towards a user's streak, in the order they were linked
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} AccountsResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/accounts [get]
+func (h *UserHandler) GetAccounts(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
 
-	users, total, err := h.userService.GetLeaderboard(page, pageSize)
+	accounts, err := h.userService.GetAccounts(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		writeAccountError(c, err)
 		return
 	}
 
-	totalPages := int(total) / pageSize
-	if int(total)%pageSize != 0 {
-		totalPages++
+	c.JSON(http.StatusOK, AccountsResponse{Accounts: accounts})
+}
+
+// LinkAccount godoc
+// @Summary Link a judge account
+// @Description Link a handle on another judge, such as AtCoder, to a user. Its solves count towards the user's streak from the next sync on. Needs the owner key from a verified handle claim, or the admin key.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param account body LinkAccountRequest true "Judge and handle"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{id}/accounts [post]
+func (h *UserHandler) LinkAccount(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, LeaderboardResponse{
-		Users:      users,
-		Page:       page,
-		PageSize:   pageSize,
-		Total:      total,
-		TotalPages: totalPages,
+	var req LinkAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	account, err := h.userService.LinkAccount(c.Request.Context(), userID, req.Judge, req.Handle)
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Account linked successfully",
+		Data:    account,
 	})
 }
 
-// GetUserByHandle godoc
-// @Summary Get user by handle
-// @Description Get a specific user by their Codeforces handle
+// UnlinkAccount godoc
+// @Summary Unlink a judge account
+// @Description Stop counting a judge account towards a user's streak. Submissions already synced from it are kept. A user's only account cannot be unlinked. Needs the owner key from a verified handle claim, or the admin key.
 // @Tags users
 // @Produce json
-// @Param handle path string true "Codeforces handle"
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param judge path string true "Judge, such as atcoder"
+// @Param account path string true "Handle on the judge"
 // @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
-// @Router /api/v1/users/{handle} [get]
-func (h *UserHandler) GetUserByHandle(c *gin.Context) {
-	handle := c.Param("handle")
+// @Router /api/v1/users/{id}/accounts/{judge}/{account} [delete]
+func (h *UserHandler) UnlinkAccount(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
 
-	user, err := h.userService.GetUserByHandle(handle)
+	err := h.userService.UnlinkAccount(c.Request.Context(), userID, c.Param("judge"), c.Param("account"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		writeAccountError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, SuccessResponse{
-		Data: user,
+		Message: "Account unlinked successfully",
 	})
 }
+
+func writeAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User or account not found"})
+	case errors.Is(err, domain.ErrHandleNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUnknownJudge), errors.Is(err, service.ErrLastAccount):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrAccountLinked):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
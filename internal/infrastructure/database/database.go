@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pouyatavakoli/CodeStreaks-web/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Supported values for DatabaseConfig.Driver.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+type Database struct {
+	DB     *gorm.DB
+	Driver string
+	logger *slog.Logger
+}
+
+func NewDatabase(cfg *config.DatabaseConfig, logger *slog.Logger) (*Database, error) {
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	gormLogger, err := newGormLogger(logger, cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: gormLogger,
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := registerQueryMetrics(db); err != nil {
+		return nil, fmt.Errorf("failed to register query metrics: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	// Connection pool settings
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	if cfg.Driver == DriverSQLite {
+		// SQLite allows a single writer, and every connection to ":memory:"
+		// would otherwise see its own empty database.
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	logger.Info("Database connected", "driver", cfg.Driver)
+
+	return &Database{DB: db, Driver: cfg.Driver, logger: logger}, nil
+}
+
+// openDialector picks the GORM driver for cfg.Driver. SQLite stores
+// timestamps as text, so code must keep times it compares in SQL in UTC.
+func openDialector(cfg *config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverPostgres:
+		dsn := fmt.Sprintf(
+			"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
+		)
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		dsn := cfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %q", cfg.Driver)
+	}
+}
+
+func (d *Database) Close() error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (d *Database) HealthCheck() error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger sends GORM's output to slog. Statements run with a request
+// context therefore carry that request's ID.
+type gormLogger struct {
+	logger *slog.Logger
+	level  logger.LogLevel
+}
+
+func newGormLogger(l *slog.Logger, level string) (logger.Interface, error) {
+	levels := map[string]logger.LogLevel{
+		"silent": logger.Silent,
+		"error":  logger.Error,
+		"warn":   logger.Warn,
+		"info":   logger.Info,
+	}
+
+	lvl, ok := levels[level]
+	if !ok {
+		return nil, fmt.Errorf("invalid database log level %q", level)
+	}
+	return &gormLogger{logger: l, level: lvl}, nil
+}
+
+func (g *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+func (g *gormLogger) Info(ctx context.Context, msg string, args ...any) {
+	if g.level >= logger.Info {
+		g.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *gormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if g.level >= logger.Warn {
+		g.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *gormLogger) Error(ctx context.Context, msg string, args ...any) {
+	if g.level >= logger.Error {
+		g.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= logger.Error:
+		sql, rows := fc()
+		g.logger.ErrorContext(ctx, "Query failed", "error", err, "sql", sql, "rows", rows, "duration", elapsed)
+	case elapsed > slowQueryThreshold && g.level >= logger.Warn:
+		sql, rows := fc()
+		g.logger.WarnContext(ctx, "Slow query", "sql", sql, "rows", rows, "duration", elapsed)
+	case g.level >= logger.Info:
+		sql, rows := fc()
+		g.logger.InfoContext(ctx, "Query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
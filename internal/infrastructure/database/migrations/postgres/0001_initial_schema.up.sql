@@ -0,0 +1,131 @@
+-- Baseline schema. Statements use IF NOT EXISTS so databases created by the
+-- old GORM AutoMigrate are adopted as version 1 without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id                 BIGSERIAL PRIMARY KEY,
+    codeforces_handle  TEXT        NOT NULL,
+    current_streak     BIGINT      DEFAULT 0,
+    max_streak         BIGINT      DEFAULT 0,
+    last_submission_at TIMESTAMPTZ,
+    rating             BIGINT      DEFAULT 0,
+    rank               TEXT,
+    total_submissions  BIGINT      DEFAULT 0,
+    is_active          BOOLEAN     DEFAULT true,
+    last_checked_at    TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_codeforces_handle ON users (codeforces_handle);
+
+CREATE TABLE IF NOT EXISTS submissions (
+    id                       BIGSERIAL PRIMARY KEY,
+    user_id                  BIGINT      NOT NULL,
+    codeforces_submission_id BIGINT      NOT NULL,
+    verdict                  TEXT,
+    submitted_at             TIMESTAMPTZ NOT NULL,
+    created_at               TIMESTAMPTZ,
+    CONSTRAINT fk_submissions_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_submissions_user_id ON submissions (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_submissions_codeforces_submission_id ON submissions (codeforces_submission_id);
+CREATE INDEX IF NOT EXISTS idx_submissions_submitted_at ON submissions (submitted_at);
+
+CREATE TABLE IF NOT EXISTS leaderboard_snapshots (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT NOT NULL,
+    snapshot_date    DATE   NOT NULL,
+    leaderboard_rank BIGINT NOT NULL,
+    current_streak   BIGINT DEFAULT 0,
+    max_streak       BIGINT DEFAULT 0,
+    rating           BIGINT DEFAULT 0,
+    created_at       TIMESTAMPTZ,
+    CONSTRAINT fk_leaderboard_snapshots_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snapshot_user_date ON leaderboard_snapshots (user_id, snapshot_date);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_snapshots_snapshot_date ON leaderboard_snapshots (snapshot_date);
+
+CREATE TABLE IF NOT EXISTS user_achievements (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT      NOT NULL,
+    achievement_code TEXT        NOT NULL,
+    awarded_at       TIMESTAMPTZ NOT NULL,
+    created_at       TIMESTAMPTZ,
+    CONSTRAINT fk_user_achievements_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_achievement ON user_achievements (user_id, achievement_code);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT  NOT NULL,
+    channel          TEXT    NOT NULL,
+    target           TEXT    NOT NULL,
+    time_zone        TEXT    NOT NULL DEFAULT 'Asia/Tehran',
+    hours_before     BIGINT  NOT NULL DEFAULT 3,
+    enabled          BOOLEAN DEFAULT true,
+    last_notified_on TEXT,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    CONSTRAINT fk_notification_preferences_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_user_channel ON notification_preferences (user_id, channel);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          BIGSERIAL PRIMARY KEY,
+    url         TEXT NOT NULL,
+    secret      TEXT NOT NULL,
+    description TEXT,
+    events      TEXT,
+    is_active   BOOLEAN DEFAULT true,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_outboxes (
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT      NOT NULL,
+    event_id        TEXT        NOT NULL,
+    event_type      TEXT        NOT NULL,
+    payload         TEXT        NOT NULL,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    attempts        BIGINT      DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ,
+    CONSTRAINT fk_webhook_outboxes_subscription FOREIGN KEY (subscription_id)
+        REFERENCES webhook_subscriptions (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_outboxes_subscription_id ON webhook_outboxes (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON webhook_outboxes (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    outbox_id       BIGINT NOT NULL,
+    subscription_id BIGINT NOT NULL,
+    event_type      TEXT   NOT NULL,
+    attempt         BIGINT NOT NULL,
+    status_code     BIGINT,
+    error           TEXT,
+    duration_ms     BIGINT,
+    created_at      TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_outbox_id ON webhook_deliveries (outbox_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id          BIGSERIAL PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_name ON groups (name);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id  BIGINT NOT NULL,
+    user_id   BIGINT NOT NULL,
+    joined_at TIMESTAMPTZ,
+    PRIMARY KEY (group_id, user_id),
+    CONSTRAINT fk_group_members_group FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_group_members_user_id ON group_members (user_id);
@@ -0,0 +1 @@
+DROP TABLE handle_claims;
@@ -0,0 +1,19 @@
+-- Handle claims: self-registration with proof of owning a Codeforces handle.
+
+CREATE TABLE handle_claims (
+    id          BIGSERIAL PRIMARY KEY,
+    handle      TEXT        NOT NULL,
+    user_id     BIGINT,
+    method      TEXT        NOT NULL,
+    problem     TEXT,
+    token       TEXT,
+    key_hash    TEXT        NOT NULL,
+    status      TEXT        NOT NULL DEFAULT 'pending',
+    expires_at  TIMESTAMPTZ NOT NULL,
+    verified_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ,
+    CONSTRAINT fk_handle_claims_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_handle_claims_handle ON handle_claims (handle);
+CREATE INDEX idx_handle_claims_user_id ON handle_claims (user_id);
+CREATE INDEX idx_handle_claims_status ON handle_claims (status);
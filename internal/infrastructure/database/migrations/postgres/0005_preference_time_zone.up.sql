@@ -0,0 +1,4 @@
+-- Streak-at-risk alerts follow the user's own time zone, which is where
+-- their streak days end; preferences no longer keep a zone of their own.
+
+ALTER TABLE notification_preferences DROP COLUMN time_zone;
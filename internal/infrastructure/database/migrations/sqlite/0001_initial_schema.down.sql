@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_outboxes;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS user_achievements;
+DROP TABLE IF EXISTS leaderboard_snapshots;
+DROP TABLE IF EXISTS submissions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,123 @@
+-- Baseline schema for SQLite. Mirrors postgres/0001_initial_schema.up.sql;
+-- timestamps are declared DATETIME so the driver scans them into time.Time.
+
+CREATE TABLE IF NOT EXISTS users (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    codeforces_handle  TEXT     NOT NULL,
+    current_streak     INTEGER  DEFAULT 0,
+    max_streak         INTEGER  DEFAULT 0,
+    last_submission_at DATETIME,
+    rating             INTEGER  DEFAULT 0,
+    rank               TEXT,
+    total_submissions  INTEGER  DEFAULT 0,
+    is_active          BOOLEAN  DEFAULT true,
+    last_checked_at    DATETIME,
+    created_at         DATETIME,
+    updated_at         DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_codeforces_handle ON users (codeforces_handle);
+
+CREATE TABLE IF NOT EXISTS submissions (
+    id                       INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id                  INTEGER  NOT NULL REFERENCES users (id),
+    codeforces_submission_id INTEGER  NOT NULL,
+    verdict                  TEXT,
+    submitted_at             DATETIME NOT NULL,
+    created_at               DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_submissions_user_id ON submissions (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_submissions_codeforces_submission_id ON submissions (codeforces_submission_id);
+CREATE INDEX IF NOT EXISTS idx_submissions_submitted_at ON submissions (submitted_at);
+
+CREATE TABLE IF NOT EXISTS leaderboard_snapshots (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id          INTEGER NOT NULL REFERENCES users (id),
+    snapshot_date    DATE    NOT NULL,
+    leaderboard_rank INTEGER NOT NULL,
+    current_streak   INTEGER DEFAULT 0,
+    max_streak       INTEGER DEFAULT 0,
+    rating           INTEGER DEFAULT 0,
+    created_at       DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snapshot_user_date ON leaderboard_snapshots (user_id, snapshot_date);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_snapshots_snapshot_date ON leaderboard_snapshots (snapshot_date);
+
+CREATE TABLE IF NOT EXISTS user_achievements (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id          INTEGER  NOT NULL REFERENCES users (id),
+    achievement_code TEXT     NOT NULL,
+    awarded_at       DATETIME NOT NULL,
+    created_at       DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_achievement ON user_achievements (user_id, achievement_code);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id          INTEGER NOT NULL REFERENCES users (id),
+    channel          TEXT    NOT NULL,
+    target           TEXT    NOT NULL,
+    time_zone        TEXT    NOT NULL DEFAULT 'Asia/Tehran',
+    hours_before     INTEGER NOT NULL DEFAULT 3,
+    enabled          BOOLEAN DEFAULT true,
+    last_notified_on TEXT,
+    created_at       DATETIME,
+    updated_at       DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_user_channel ON notification_preferences (user_id, channel);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    url         TEXT NOT NULL,
+    secret      TEXT NOT NULL,
+    description TEXT,
+    events      TEXT,
+    is_active   BOOLEAN DEFAULT true,
+    created_at  DATETIME,
+    updated_at  DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS webhook_outboxes (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER  NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        TEXT     NOT NULL,
+    event_type      TEXT     NOT NULL,
+    payload         TEXT     NOT NULL,
+    status          TEXT     NOT NULL DEFAULT 'pending',
+    attempts        INTEGER  DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error      TEXT,
+    delivered_at    DATETIME,
+    created_at      DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_outboxes_subscription_id ON webhook_outboxes (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON webhook_outboxes (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    outbox_id       INTEGER NOT NULL,
+    subscription_id INTEGER NOT NULL,
+    event_type      TEXT    NOT NULL,
+    attempt         INTEGER NOT NULL,
+    status_code     INTEGER,
+    error           TEXT,
+    duration_ms     INTEGER,
+    created_at      DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_outbox_id ON webhook_deliveries (outbox_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT NOT NULL,
+    description TEXT,
+    created_at  DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_name ON groups (name);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id  INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    joined_at DATETIME,
+    PRIMARY KEY (group_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_members_user_id ON group_members (user_id);
@@ -0,0 +1,6 @@
+DELETE FROM submissions WHERE judge <> 'codeforces';
+DROP INDEX idx_submissions_judge_submission_id;
+CREATE UNIQUE INDEX idx_submissions_codeforces_submission_id ON submissions (codeforces_submission_id);
+ALTER TABLE submissions DROP COLUMN judge;
+
+DROP TABLE judge_accounts;
@@ -0,0 +1,23 @@
+-- Judge accounts: a user's handles on Codeforces and other judges. Every
+-- existing user gets the Codeforces account they were added with.
+
+CREATE TABLE judge_accounts (
+    id              INTEGER  PRIMARY KEY AUTOINCREMENT,
+    user_id         INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    judge           TEXT     NOT NULL,
+    handle          TEXT     NOT NULL,
+    rating          INTEGER  DEFAULT 0,
+    rank            TEXT,
+    last_checked_at DATETIME,
+    created_at      DATETIME
+);
+CREATE INDEX idx_judge_accounts_user_id ON judge_accounts (user_id);
+CREATE UNIQUE INDEX idx_judge_accounts_judge_handle ON judge_accounts (judge, handle);
+
+INSERT INTO judge_accounts (user_id, judge, handle, rating, rank, last_checked_at, created_at)
+SELECT id, 'codeforces', codeforces_handle, rating, rank, last_checked_at, created_at FROM users;
+
+-- Submission IDs are only unique on their own judge.
+ALTER TABLE submissions ADD COLUMN judge TEXT NOT NULL DEFAULT 'codeforces';
+DROP INDEX idx_submissions_codeforces_submission_id;
+CREATE UNIQUE INDEX idx_submissions_judge_submission_id ON submissions (judge, codeforces_submission_id);
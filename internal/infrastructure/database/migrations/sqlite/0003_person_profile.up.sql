@@ -0,0 +1,6 @@
+-- Person profiles: a user is a person who owns judge accounts, with their
+-- own name, avatar and the time zone their streak days are counted in.
+
+ALTER TABLE users ADD COLUMN display_name TEXT;
+ALTER TABLE users ADD COLUMN avatar_url TEXT;
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'Asia/Tehran';
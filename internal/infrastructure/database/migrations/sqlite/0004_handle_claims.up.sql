@@ -0,0 +1,18 @@
+-- Handle claims: self-registration with proof of owning a Codeforces handle.
+
+CREATE TABLE handle_claims (
+    id          INTEGER  PRIMARY KEY AUTOINCREMENT,
+    handle      TEXT     NOT NULL,
+    user_id     INTEGER  REFERENCES users (id) ON DELETE CASCADE,
+    method      TEXT     NOT NULL,
+    problem     TEXT,
+    token       TEXT,
+    key_hash    TEXT     NOT NULL,
+    status      TEXT     NOT NULL DEFAULT 'pending',
+    expires_at  DATETIME NOT NULL,
+    verified_at DATETIME,
+    created_at  DATETIME
+);
+CREATE INDEX idx_handle_claims_handle ON handle_claims (handle);
+CREATE INDEX idx_handle_claims_user_id ON handle_claims (user_id);
+CREATE INDEX idx_handle_claims_status ON handle_claims (status);
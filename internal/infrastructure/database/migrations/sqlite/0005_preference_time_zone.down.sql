@@ -0,0 +1,3 @@
+ALTER TABLE notification_preferences ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'Asia/Tehran';
+UPDATE notification_preferences
+SET time_zone = (SELECT users.time_zone FROM users WHERE users.id = notification_preferences.user_id);
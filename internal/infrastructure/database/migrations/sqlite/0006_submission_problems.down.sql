@@ -0,0 +1,3 @@
+ALTER TABLE submissions DROP COLUMN language;
+ALTER TABLE submissions DROP COLUMN problem_rating;
+ALTER TABLE submissions DROP COLUMN problem_id;
@@ -0,0 +1,6 @@
+-- Achievements are evaluated over the stored history, so submissions keep
+-- what the rules look at. Rows stored before this have them empty.
+
+ALTER TABLE submissions ADD COLUMN problem_id TEXT;
+ALTER TABLE submissions ADD COLUMN problem_rating INTEGER DEFAULT 0;
+ALTER TABLE submissions ADD COLUMN language TEXT;
@@ -0,0 +1,21 @@
+-- People without a Codeforces account had no place before this migration
+-- and are removed with everything stored for them. SQLite cannot add NOT
+-- NULL to an existing column, so codeforces_handle stays nullable here.
+
+ALTER TABLE users ADD COLUMN codeforces_handle TEXT;
+UPDATE users SET codeforces_handle = (
+    SELECT handle FROM judge_accounts
+    WHERE judge_accounts.user_id = users.id AND judge_accounts.judge = 'codeforces'
+    ORDER BY judge_accounts.id LIMIT 1
+);
+
+DELETE FROM submissions WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM leaderboard_snapshots WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM user_achievements WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM notification_preferences WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM judge_accounts WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM group_members WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM handle_claims WHERE user_id IN (SELECT id FROM users WHERE codeforces_handle IS NULL);
+DELETE FROM users WHERE codeforces_handle IS NULL;
+
+CREATE UNIQUE INDEX idx_users_codeforces_handle ON users (codeforces_handle);
@@ -0,0 +1,5 @@
+-- Persons: a user is keyed by id alone and judge handles live only in
+-- judge_accounts, so a person may have no Codeforces account at all.
+
+DROP INDEX idx_users_codeforces_handle;
+ALTER TABLE users DROP COLUMN codeforces_handle;
@@ -1,83 +0,0 @@
-package database
-
-import (
-	"fmt"
-	"log"
-	"time"
-
-	"github.com/pouyatavakoli/CodeStreaks-web/config"
-	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-)
-
-type Database struct {
-	DB *gorm.DB
-}
-
-func NewDatabase(cfg *config.DatabaseConfig) (*Database, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
-	)
-
-	logLevel := logger.Silent
-	if cfg.SSLMode == "disable" {
-		logLevel = logger.Info
-	}
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
-		NowFunc: func() time.Time {
-			return time.Now().UTC()
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
-
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get database instance: %w", err)
-	}
-
-	// Connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	log.Println("Database connected successfully")
-
-	return &Database{DB: db}, nil
-}
-
-func (d *Database) AutoMigrate() error {
-	log.Println("Running database migrations...")
-
-	if err := d.DB.AutoMigrate(
-		&domain.User{},
-		&domain.Submission{},
-	); err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
-	}
-
-	log.Println("Database migrations completed successfully")
-	return nil
-}
-
-func (d *Database) Close() error {
-	sqlDB, err := d.DB.DB()
-	if err != nil {
-		return err
-	}
-	return sqlDB.Close()
-}
-
-func (d *Database) HealthCheck() error {
-	sqlDB, err := d.DB.DB()
-	if err != nil {
-		return err
-	}
-	return sqlDB.Ping()
-}
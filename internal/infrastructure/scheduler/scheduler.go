@@ -1,65 +1,164 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
 
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
 	"github.com/robfig/cron/v3"
 )
 
 type Scheduler struct {
-	cron        *cron.Cron
-	syncService service.SyncService
-	interval    int // seconds
+	cron                *cron.Cron
+	syncService         service.SyncService
+	snapshotService     service.SnapshotService
+	notificationService service.NotificationService
+	webhookService      service.WebhookService
+	claimService        service.ClaimService
+	interval            int    // seconds
+	snapshotSpec        string // cron spec for the daily snapshot
+	notifySpec          string // cron spec for the streak-at-risk check
+	webhookSpec         string // cron spec for draining the webhook outbox
+	claimSpec           string // cron spec for checking pending handle claims
+	clock               clock.Clock
+	logger              *slog.Logger
 }
 
-func NewScheduler(syncService service.SyncService, interval int) *Scheduler {
+func NewScheduler(
+	syncService service.SyncService,
+	snapshotService service.SnapshotService,
+	notificationService service.NotificationService,
+	webhookService service.WebhookService,
+	claimService service.ClaimService,
+	interval int,
+	snapshotSpec string,
+	notifySpec string,
+	webhookSpec string,
+	claimSpec string,
+	clock clock.Clock,
+	logger *slog.Logger,
+) *Scheduler {
 	return &Scheduler{
-		cron:        cron.New(cron.WithSeconds()),
-		syncService: syncService,
-		interval:    interval,
+		cron:                cron.New(cron.WithSeconds(), cron.WithLogger(cronLogger{logger})),
+		syncService:         syncService,
+		snapshotService:     snapshotService,
+		notificationService: notificationService,
+		webhookService:      webhookService,
+		claimService:        claimService,
+		interval:            interval,
+		snapshotSpec:        snapshotSpec,
+		notifySpec:          notifySpec,
+		webhookSpec:         webhookSpec,
+		claimSpec:           claimSpec,
+		clock:               clock,
+		logger:              logger,
 	}
 }
 
+// cronLogger adapts slog to cron.Logger. Cron reports every wake-up at info
+// level, which is only interesting when debugging.
+type cronLogger struct {
+	logger *slog.Logger
+}
+
+func (l cronLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Debug(msg, keysAndValues...)
+}
+
+func (l cronLogger) Error(err error, msg string, keysAndValues ...any) {
+	l.logger.Error(msg, append(keysAndValues, "error", err)...)
+}
+
 func (s *Scheduler) Start() error {
 	// Convert seconds to cron expression
 	cronExpr := fmt.Sprintf("@every %ds", s.interval)
 
-	_, err := s.cron.AddFunc(cronExpr, func() {
-		log.Println("Starting scheduled sync...")
-		startTime := time.Now()
+	ctx := context.Background()
 
-		if err := s.syncService.SyncAllUsers(); err != nil {
-			log.Printf("Scheduled sync failed: %v", err)
+	// A sync that outlasts the interval, or the initial sync, must not
+	// overlap the next one.
+	syncJob := cron.NewChain(cron.SkipIfStillRunning(cronLogger{s.logger})).Then(cron.FuncJob(func() {
+		s.logger.Info("Starting scheduled sync")
+		startTime := s.clock.Now()
+
+		if err := s.syncService.SyncAllUsers(ctx); err != nil {
+			s.logger.Error("Scheduled sync failed", "error", err)
 		} else {
-			duration := time.Since(startTime)
-			log.Printf("Scheduled sync completed successfully in %v", duration)
+			s.logger.Info("Scheduled sync completed", "duration", s.clock.Now().Sub(startTime))
+		}
+	}))
+
+	if _, err := s.cron.AddJob(cronExpr, syncJob); err != nil {
+		return fmt.Errorf("failed to schedule sync job: %w", err)
+	}
+
+	_, err := s.cron.AddFunc(s.snapshotSpec, func() {
+		s.logger.Info("Taking daily leaderboard snapshot")
+
+		if err := s.snapshotService.TakeDailySnapshot(ctx); err != nil {
+			s.logger.Error("Leaderboard snapshot failed", "error", err)
+			return
+		}
+
+		if err := s.snapshotService.PruneSnapshots(ctx); err != nil {
+			s.logger.Error("Snapshot pruning failed", "error", err)
 		}
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to schedule sync job: %w", err)
+		return fmt.Errorf("failed to schedule snapshot job: %w", err)
 	}
 
-	s.cron.Start()
-	log.Printf("Scheduler started with interval: %d seconds", s.interval)
+	_, err = s.cron.AddFunc(s.notifySpec, func() {
+		if err := s.notificationService.NotifyStreaksAtRisk(ctx); err != nil {
+			s.logger.Error("Streak-at-risk notifications failed", "error", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to schedule notification job: %w", err)
+	}
 
-	// Run initial sync
-	log.Println("Running initial sync...")
-	go func() {
-		if err := s.syncService.SyncAllUsers(); err != nil {
-			log.Printf("Initial sync failed: %v", err)
+	// A slow subscriber must not cause overlapping dispatch runs that would
+	// deliver the same outbox rows twice.
+	dispatch := cron.NewChain(cron.SkipIfStillRunning(cronLogger{s.logger})).Then(cron.FuncJob(func() {
+		if err := s.webhookService.DeliverPending(ctx); err != nil {
+			s.logger.Error("Webhook dispatch failed", "error", err)
 		}
-	}()
+	}))
+
+	if _, err := s.cron.AddJob(s.webhookSpec, dispatch); err != nil {
+		return fmt.Errorf("failed to schedule webhook dispatch job: %w", err)
+	}
+
+	// Compilation error challenges are only open for minutes, so pending
+	// claims are checked far more often than users are synced.
+	claims := cron.NewChain(cron.SkipIfStillRunning(cronLogger{s.logger})).Then(cron.FuncJob(func() {
+		if err := s.claimService.VerifyPendingClaims(ctx); err != nil {
+			s.logger.Error("Handle claim check failed", "error", err)
+		}
+	}))
+
+	if _, err := s.cron.AddJob(s.claimSpec, claims); err != nil {
+		return fmt.Errorf("failed to schedule handle claim job: %w", err)
+	}
+
+	s.cron.Start()
+	s.logger.Info("Scheduler started", "interval_seconds", s.interval)
+
+	// Run initial sync through the same job, so the first scheduled run is
+	// skipped if it is still going.
+	s.logger.Info("Running initial sync")
+	go syncJob.Run()
 
 	return nil
 }
 
 func (s *Scheduler) Stop() {
-	log.Println("Stopping scheduler...")
+	s.logger.Info("Stopping scheduler")
 	ctx := s.cron.Stop()
 	<-ctx.Done()
-	log.Println("Scheduler stopped")
+	s.logger.Info("Scheduler stopped")
 }
@@ -0,0 +1,338 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder/atcodertest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode/leetcodetest"
+)
+
+func TestSyncUserCountsSolvesOnLinkedAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "tourist", 3800,
+		codeforcestest.Accepted(1, daysAgo(0)),
+		codeforcestest.Submission(2, daysAgo(1), "WRONG_ANSWER"),
+		codeforcestest.Accepted(3, daysAgo(2)),
+	)
+	// The AtCoder submission IDs overlap the Codeforces ones on purpose.
+	env.ac.AddUser("tourist_ac", 3500,
+		atcodertest.Accepted(1, daysAgo(1)),
+		atcodertest.Submission(2, daysAgo(3), "WA"),
+	)
+	if _, err := env.userService.LinkAccount(t.Context(), user.ID, "AtCoder", "tourist_ac"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	got := env.reloadUser(t, "tourist")
+	if got.CurrentStreak != 3 || got.MaxStreak != 3 {
+		t.Errorf("streak = %d/%d, want 3/3", got.CurrentStreak, got.MaxStreak)
+	}
+	if got.TotalSubmissions != 5 {
+		t.Errorf("total submissions = %d, want 5", got.TotalSubmissions)
+	}
+	// Rating and rank stay those of the Codeforces account.
+	if got.Rating != 3800 || got.Rank != "expert" {
+		t.Errorf("rating = %d %q, want the Codeforces profile", got.Rating, got.Rank)
+	}
+
+	stored, err := env.submissionRepo.GetUserSubmissions(t.Context(), user.ID, 100)
+	if err != nil {
+		t.Fatalf("get submissions: %v", err)
+	}
+	perJudge := map[string]int{}
+	for _, sub := range stored {
+		perJudge[sub.Judge]++
+	}
+	if perJudge[domain.JudgeCodeforces] != 3 || perJudge[domain.JudgeAtCoder] != 2 {
+		t.Errorf("stored submissions per judge = %v, want 3 codeforces and 2 atcoder", perJudge)
+	}
+
+	accounts, err := env.accountRepo.FindByUser(t.Context(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[1].Rating != 3500 || accounts[1].Rank != "red" || accounts[1].LastCheckedAt == nil {
+		t.Errorf("accounts = %+v", accounts)
+	}
+
+	// A second sync stores nothing twice.
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if again, _ := env.submissionRepo.GetUserSubmissions(t.Context(), user.ID, 100); len(again) != 5 {
+		t.Errorf("stored %d submissions after a second sync, want 5", len(again))
+	}
+
+	// Recomputing from the stored submissions agrees.
+	if changed, err := env.syncService.RecomputeStreaks(t.Context()); err != nil || changed != 0 {
+		t.Errorf("recompute = %d, %v, want no changes", changed, err)
+	}
+}
+
+func TestSyncUserSkipsAFailingLinkedAccount(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "petr", 3500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.ac.AddUser("petr_ac", 0, atcodertest.Accepted(1, daysAgo(1)))
+	if _, err := env.userService.LinkAccount(t.Context(), user.ID, "atcoder", "petr_ac"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	env.ac.FailNext(atcodertest.EndpointSubmissions, http.StatusInternalServerError)
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync with AtCoder down: %v", err)
+	}
+	if got := env.reloadUser(t, "petr"); got.CurrentStreak != 1 || got.LastCheckedAt == nil {
+		t.Errorf("Codeforces solves were not synced: streak %d, checked %v", got.CurrentStreak, got.LastCheckedAt)
+	}
+	if records := env.logs.records(t, "Could not fetch submissions"); len(records) != 1 || records[0]["judge"] != "atcoder" {
+		t.Errorf("failure log = %v, want one for AtCoder", records)
+	}
+	accounts, err := env.accountRepo.FindByUser(t.Context(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range accounts {
+		if checked := account.LastCheckedAt != nil; checked != (account.Judge == domain.JudgeCodeforces) {
+			t.Errorf("%s account checked = %v", account.Judge, checked)
+		}
+	}
+
+	// The next sync picks the AtCoder solves up.
+	if err := env.syncService.SyncUser(t.Context(), env.reloadUser(t, "petr")); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := env.reloadUser(t, "petr"); got.CurrentStreak != 2 {
+		t.Errorf("streak after AtCoder recovered = %d, want 2", got.CurrentStreak)
+	}
+}
+
+func TestSyncUserFailsWhenNoAccountCanBeRead(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "petr", 3500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.ac.AddUser("petr_ac", 0)
+	if _, err := env.userService.LinkAccount(t.Context(), user.ID, "atcoder", "petr_ac"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	env.cf.FailNext(codeforcestest.MethodUserStatus, http.StatusInternalServerError)
+	env.ac.FailNext(atcodertest.EndpointSubmissions, http.StatusInternalServerError)
+
+	if err := env.syncService.SyncUser(t.Context(), user); err == nil {
+		t.Fatal("sync succeeded with every judge down")
+	}
+	if got := env.reloadUser(t, "petr"); got.CurrentStreak != 0 || got.LastCheckedAt != nil {
+		t.Errorf("user changed by a failed sync: %+v", got)
+	}
+	if stored, _ := env.submissionRepo.GetUserSubmissions(t.Context(), user.ID, 100); len(stored) != 0 {
+		t.Errorf("stored %d submissions from a failed sync", len(stored))
+	}
+}
+
+func TestAccountsAPI(t *testing.T) {
+	env := newTestEnv(t)
+	auth := []string{"Authorization", "Bearer " + testAdminKey}
+	alice := env.addUser(t, "alice", 1900)
+	bob := env.addUser(t, "bob", 1500)
+	env.ac.AddUser("alice_ac", 1200)
+	alicePath := fmt.Sprintf("/api/v1/users/%d", alice.ID)
+	bobPath := fmt.Sprintf("/api/v1/users/%d", bob.ID)
+
+	rec := env.do(http.MethodPost, alicePath+"/accounts", `{"judge":"atcoder","handle":"alice_ac"}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("link without key = %d, want 401", rec.Code)
+	}
+
+	rec = env.do(http.MethodPost, alicePath+"/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, auth...)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("link = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = env.do(http.MethodGet, alicePath+"/accounts", "")
+	var listed struct {
+		Accounts []domain.JudgeAccount `json:"accounts"`
+	}
+	decode(t, rec.Body.Bytes(), &listed)
+	if len(listed.Accounts) != 2 ||
+		listed.Accounts[0].Judge != domain.JudgeCodeforces || listed.Accounts[0].Handle != "alice" ||
+		listed.Accounts[1].Judge != domain.JudgeAtCoder || listed.Accounts[1].Handle != "alice_ac" {
+		t.Errorf("accounts = %+v", listed.Accounts)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		code         int
+	}{
+		{"relinking is a no-op", http.MethodPost, alicePath + "/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, http.StatusCreated},
+		{"linked to someone else", http.MethodPost, bobPath + "/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, http.StatusConflict},
+		{"unknown judge", http.MethodPost, alicePath + "/accounts", `{"judge":"topcoder","handle":"alice"}`, http.StatusBadRequest},
+		{"unknown handle", http.MethodPost, alicePath + "/accounts", `{"judge":"atcoder","handle":"nobody"}`, http.StatusNotFound},
+		{"unknown user", http.MethodPost, "/api/v1/users/999/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, http.StatusNotFound},
+		{"not an ID", http.MethodPost, "/api/v1/users/alice/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, http.StatusBadRequest},
+		{"last account", http.MethodDelete, bobPath + "/accounts/codeforces/bob", "", http.StatusBadRequest},
+		{"unlink", http.MethodDelete, alicePath + "/accounts/atcoder/alice_ac", "", http.StatusOK},
+		{"unlink again", http.MethodDelete, alicePath + "/accounts/atcoder/alice_ac", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := env.do(tt.method, tt.path, tt.body, auth...); rec.Code != tt.code {
+			t.Errorf("%s: %s %s = %d, want %d: %s", tt.name, tt.method, tt.path, rec.Code, tt.code, rec.Body)
+		}
+	}
+
+	// Removing a user removes their accounts, so the handle can be linked
+	// again.
+	env.do(http.MethodPost, alicePath+"/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, auth...)
+	if rec := env.do(http.MethodDelete, alicePath, "", auth...); rec.Code != http.StatusOK {
+		t.Fatalf("remove user = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := env.do(http.MethodPost, bobPath+"/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, auth...); rec.Code != http.StatusCreated {
+		t.Errorf("link after removing the owner = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAccountsWithOwnerKey(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "alice", 1900)
+	env.addUser(t, "bob", 1500)
+	env.ac.AddUser("alice_ac", 1200)
+	alice := []string{"Authorization", "Bearer " + env.ownerKey(t, "alice")}
+	bob := []string{"Authorization", "Bearer " + env.ownerKey(t, "bob")}
+	alicePath := env.userPath(t, "alice")
+
+	link := `{"judge":"atcoder","handle":"alice_ac"}`
+	if rec := env.do(http.MethodPost, alicePath+"/accounts", link, bob...); rec.Code != http.StatusForbidden {
+		t.Errorf("link with another user's key = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, alicePath+"/accounts", link, alice...); rec.Code != http.StatusCreated {
+		t.Fatalf("link with the owner key = %d: %s", rec.Code, rec.Body)
+	}
+
+	unlink := alicePath + "/accounts/atcoder/alice_ac"
+	if rec := env.do(http.MethodDelete, unlink, "", bob...); rec.Code != http.StatusForbidden {
+		t.Errorf("unlink with another user's key = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodDelete, unlink, "", alice...); rec.Code != http.StatusOK {
+		t.Errorf("unlink with the owner key = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+// TestUserWithoutCodeforcesAccount checks that a person can be tracked by
+// an AtCoder handle alone, found by it, and ranked on their AtCoder solves.
+func TestUserWithoutCodeforcesAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "alice", 1900, codeforcestest.Accepted(1, daysAgo(0)))
+	env.ac.AddUser("bob_ac", 1600,
+		atcodertest.Accepted(1, daysAgo(0)),
+		atcodertest.Accepted(2, daysAgo(1)),
+	)
+
+	rec := env.do(http.MethodPost, "/api/v1/users", `{"judge":"atcoder","handle":"bob_ac"}`, "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add = %d: %s", rec.Code, rec.Body)
+	}
+	var added struct {
+		Data domain.UserResponse `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &added)
+	if len(added.Data.Accounts) != 1 || added.Data.Accounts[0].Judge != domain.JudgeAtCoder ||
+		added.Data.Rating != 0 || added.Data.Name() != "bob_ac" {
+		t.Errorf("added = %+v, want one AtCoder account and no rating", added.Data)
+	}
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	rec = env.do(http.MethodGet, "/api/v1/accounts/atcoder/bob_ac", "")
+	var found struct {
+		Data domain.UserResponse `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &found)
+	if rec.Code != http.StatusOK || found.Data.ID != added.Data.ID ||
+		found.Data.CurrentStreak != 2 || found.Data.LeaderboardRank != 1 {
+		t.Errorf("find by account = %d %+v, want user %d first with a streak of 2", rec.Code, found.Data, added.Data.ID)
+	}
+	if rec := env.do(http.MethodGet, "/api/v1/accounts/codeforces/bob_ac", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("find by an unlinked handle = %d, want 404", rec.Code)
+	}
+}
+
+// TestUnlinkingTheCodeforcesAccount checks that a user whose Codeforces
+// account is unlinked stays tracked by their other accounts and loses the
+// rating it gave them.
+func TestUnlinkingTheCodeforcesAccount(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "alice", 1900)
+	env.ac.AddUser("alice_ac", 1200)
+	if _, err := env.userService.LinkAccount(t.Context(), user.ID, "atcoder", "alice_ac"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	if err := env.userService.UnlinkAccount(t.Context(), user.ID, "codeforces", "alice"); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	got, err := env.userService.GetUserByAccount(t.Context(), "atcoder", "alice_ac")
+	if err != nil {
+		t.Fatalf("find by remaining account: %v", err)
+	}
+	if got.ID != user.ID || got.Rating != 0 || got.Rank != "" || got.Name() != "alice_ac" {
+		t.Errorf("user = %+v, want user %d without a rating", got, user.ID)
+	}
+	if err := env.userService.UnlinkAccount(t.Context(), user.ID, "atcoder", "alice_ac"); !errors.Is(err, service.ErrLastAccount) {
+		t.Errorf("unlink the last account = %v, want ErrLastAccount", err)
+	}
+
+	// The Codeforces handle is free to start tracking someone new.
+	if _, err := env.userService.AddUser(t.Context(), domain.JudgeCodeforces, "alice"); err != nil {
+		t.Fatalf("add the unlinked handle: %v", err)
+	}
+	if fresh := env.reloadUser(t, "alice"); fresh.ID == user.ID {
+		t.Error("the unlinked handle still points at its old user")
+	}
+}
+
+// TestSyncUserKeepsSolvesOlderThanTheJudgeReports checks that LeetCode
+// solves keep counting once they fall out of the recent submissions LeetCode
+// reports.
+func TestSyncUserKeepsSolvesOlderThanTheJudgeReports(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "alice", 1900)
+	env.lc.AddUser("alice_lc", 1700,
+		leetcodetest.Accepted(1, daysAgo(2)),
+		leetcodetest.Accepted(2, daysAgo(1)),
+	)
+	if _, err := env.userService.LinkAccount(t.Context(), user.ID, "leetcode", "alice_lc"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	for i := range leetcode.RecentLimit {
+		env.lc.AddSubmissions("alice_lc", leetcodetest.Accepted(int64(100+i), daysAgo(0).Add(time.Duration(i)*time.Second)))
+	}
+	user = env.reloadUser(t, "alice")
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+
+	got := env.reloadUser(t, "alice")
+	if got.CurrentStreak != 3 {
+		t.Errorf("current streak = %d, want 3", got.CurrentStreak)
+	}
+	if got.TotalSubmissions != leetcode.RecentLimit+2 {
+		t.Errorf("total submissions = %d, want %d", got.TotalSubmissions, leetcode.RecentLimit+2)
+	}
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func TestBadgeAndCard(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.addUser(t, "alice", 1900,
+		codeforcestest.Accepted(1, daysAgo(0)),
+		codeforcestest.Accepted(2, daysAgo(1)),
+		codeforcestest.Submission(3, daysAgo(1), "WRONG_ANSWER"),
+	)
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	badgePath := fmt.Sprintf("/badge/%d.svg", alice.ID)
+	cardPath := fmt.Sprintf("/card/%d.svg", alice.ID)
+	badge := env.do(http.MethodGet, badgePath, "")
+	if badge.Code != http.StatusOK || badge.Header().Get("Content-Type") != "image/svg+xml; charset=utf-8" {
+		t.Fatalf("badge = %d %q: %s", badge.Code, badge.Header().Get("Content-Type"), badge.Body)
+	}
+	if !strings.Contains(badge.Body.String(), "2 day streak") {
+		t.Errorf("badge does not show the streak:\n%s", badge.Body)
+	}
+	etag := badge.Header().Get("ETag")
+	if etag == "" || badge.Header().Get("Cache-Control") != "public, max-age=300" {
+		t.Errorf("badge ETag %q, Cache-Control %q", etag, badge.Header().Get("Cache-Control"))
+	}
+	if rec := env.do(http.MethodGet, badgePath, "", "If-None-Match", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("conditional badge GET = %d, want 304", rec.Code)
+	}
+
+	card := env.do(http.MethodGet, cardPath+"?theme=dark&size=small", "")
+	if card.Code != http.StatusOK {
+		t.Fatalf("card = %d: %s", card.Code, card.Body)
+	}
+	body := card.Body.String()
+	for _, want := range []string{">alice<", ">#1<", `width="371.25"`, "1 accepted of 2</title>", "#0d1117"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("card is missing %q:\n%s", want, body)
+		}
+	}
+	if got := strings.Count(body, "<title>") - 1; got != 84 {
+		t.Errorf("card heatmap has %d days, want 84", got)
+	}
+
+	// Themes and sizes are cached separately, and a sync refreshes them.
+	if rec := env.do(http.MethodGet, cardPath, ""); rec.Header().Get("X-Cache") != "MISS" || strings.Contains(rec.Body.String(), "#0d1117") {
+		t.Errorf("light card X-Cache = %q", rec.Header().Get("X-Cache"))
+	}
+	env.clock.Advance(24 * time.Hour)
+	env.cf.AddSubmissions("alice", codeforcestest.Accepted(4, env.clock.Now()))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	after := env.do(http.MethodGet, badgePath, "", "If-None-Match", etag)
+	if after.Code != http.StatusOK || !strings.Contains(after.Body.String(), "3 day streak") {
+		t.Errorf("badge after sync = %d:\n%s", after.Code, after.Body)
+	}
+
+	for path, code := range map[string]int{
+		"/badge/999.svg":                      http.StatusNotFound,
+		"/badge/alice.svg":                    http.StatusNotFound,
+		strings.TrimSuffix(badgePath, ".svg"): http.StatusNotFound,
+		cardPath + "?theme=neon":              http.StatusBadRequest,
+		badgePath + "?size=giant":             http.StatusBadRequest,
+	} {
+		if rec := env.do(http.MethodGet, path, ""); rec.Code != code {
+			t.Errorf("GET %s = %d, want %d: %s", path, rec.Code, code, rec.Body)
+		}
+	}
+}
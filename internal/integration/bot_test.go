@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/bot"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+)
+
+var firstNameToken = regexp.MustCompile(`first name on your Codeforces profile to ([a-z]+) within 60 minutes`)
+
+func TestBotRegisterOpensClaim(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "alice", Rating: 1500})
+	chatBot := bot.New(env.userService, env.claimService, ratelimit.NewMemory(env.clock), ratelimit.Limit{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	reply := chatBot.Execute(t.Context(), "telegram:1", "/register alice")
+	match := firstNameToken.FindStringSubmatch(reply)
+	if match == nil {
+		t.Fatalf("register = %q, want first name instructions", reply)
+	}
+	if _, err := env.userRepo.FindByAccount(t.Context(), domain.JudgeCodeforces, "alice"); err == nil {
+		t.Fatal("register added the handle before the claim was verified")
+	}
+
+	// Someone else cannot start a competing claim.
+	if reply := chatBot.Execute(t.Context(), "telegram:2", "/register alice"); !strings.Contains(reply, "already pending") {
+		t.Errorf("second register while pending = %q", reply)
+	}
+
+	env.cf.SetFirstName("alice", match[1])
+	if err := env.claimService.VerifyPendingClaims(t.Context()); err != nil {
+		t.Fatalf("verify claims: %v", err)
+	}
+	if _, err := env.userRepo.FindByAccount(t.Context(), domain.JudgeCodeforces, "alice"); err != nil {
+		t.Fatalf("alice after the claim was verified: %v", err)
+	}
+
+	if reply := chatBot.Execute(t.Context(), "telegram:2", "/register alice"); reply != "alice is already on the leaderboard." {
+		t.Errorf("register a tracked handle = %q", reply)
+	}
+}
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func TestLeaderboardCaching(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "cached", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	first := env.do(http.MethodGet, "/api/v1/leaderboard", "")
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first GET = %d, X-Cache %q; want 200 MISS", first.Code, first.Header().Get("X-Cache"))
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" || !strings.Contains(first.Header().Get("Cache-Control"), "max-age=30") {
+		t.Errorf("ETag %q, Cache-Control %q", etag, first.Header().Get("Cache-Control"))
+	}
+
+	// Normalised parameters share the entry.
+	second := env.do(http.MethodGet, "/api/v1/leaderboard?page=0&page_size=50", "")
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() {
+		t.Errorf("second GET X-Cache = %q, body changed: %v", second.Header().Get("X-Cache"), second.Body.String() != first.Body.String())
+	}
+
+	other := env.do(http.MethodGet, "/api/v1/leaderboard?page_size=10", "")
+	if other.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("other page size X-Cache = %q, want MISS", other.Header().Get("X-Cache"))
+	}
+
+	notModified := env.do(http.MethodGet, "/api/v1/leaderboard", "", "If-None-Match", `"stale", W/`+etag)
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Errorf("conditional GET = %d with %d byte body, want an empty 304", notModified.Code, notModified.Body.Len())
+	}
+	if notModified.Header().Get("ETag") != etag {
+		t.Errorf("304 ETag = %q, want %q", notModified.Header().Get("ETag"), etag)
+	}
+
+	// A sync that changes a streak invalidates the cache and the ETag.
+	env.clock.Advance(24 * time.Hour)
+	env.cf.AddSubmissions("cached", codeforcestest.Accepted(2, env.clock.Now()))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	after := env.do(http.MethodGet, "/api/v1/leaderboard", "", "If-None-Match", etag)
+	if after.Code != http.StatusOK || after.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("GET after sync = %d, X-Cache %q; want 200 MISS", after.Code, after.Header().Get("X-Cache"))
+	}
+	if after.Header().Get("ETag") == etag {
+		t.Error("ETag unchanged after the streak changed")
+	}
+	var body leaderboardBody
+	decode(t, after.Body.Bytes(), &body)
+	if len(body.Users) != 1 || body.Users[0].CurrentStreak != 2 {
+		t.Errorf("leaderboard after sync = %+v, want a streak of 2", body.Users)
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	env := newTestEnv(t)
+
+	for i := 0; i < 2; i++ {
+		rec := env.do(http.MethodGet, "/api/v1/leaderboard?group=missing", "")
+		if rec.Code != http.StatusNotFound || rec.Header().Get("X-Cache") != "" || rec.Header().Get("ETag") != "" {
+			t.Errorf("GET unknown group = %d, X-Cache %q, ETag %q; want an uncached 404",
+				rec.Code, rec.Header().Get("X-Cache"), rec.Header().Get("ETag"))
+		}
+	}
+
+	// The first user added gets ID 1.
+	if rec := env.do(http.MethodGet, "/api/v1/users/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET unknown user = %d, want 404", rec.Code)
+	}
+	env.addUser(t, "nobody", 1500)
+	if rec := env.do(http.MethodGet, "/api/v1/users/1", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET user after adding = %d, want 200", rec.Code)
+	}
+}
+
+func TestLeaderboardSortIsPartOfTheCacheKey(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "streaky", 1200, codeforcestest.Accepted(1, daysAgo(0)), codeforcestest.Accepted(2, daysAgo(1)))
+	env.addUser(t, "rated", 2400, codeforcestest.Accepted(3, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	order := func(rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		var body leaderboardBody
+		decode(t, rec.Body.Bytes(), &body)
+		handles := make([]string, len(body.Users))
+		for i, u := range body.Users {
+			handles[i] = u.Name()
+		}
+		return strings.Join(handles, ",")
+	}
+
+	byStreak := env.do(http.MethodGet, "/api/v1/leaderboard", "")
+	if got := order(byStreak); got != "streaky,rated" {
+		t.Errorf("default order = %s, want streaky,rated", got)
+	}
+	byRating := env.do(http.MethodGet, "/api/v1/leaderboard?sort=rating", "")
+	if byRating.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("sort=rating X-Cache = %q, want MISS", byRating.Header().Get("X-Cache"))
+	}
+	if got := order(byRating); got != "rated,streaky" {
+		t.Errorf("sort=rating order = %s, want rated,streaky", got)
+	}
+	if byRating.Header().Get("ETag") == byStreak.Header().Get("ETag") {
+		t.Error("both orders share an ETag")
+	}
+
+	// The default sort, spelled out, shares the default entry.
+	if rec := env.do(http.MethodGet, "/api/v1/leaderboard?sort=current_streak", ""); rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("sort=current_streak X-Cache = %q, want HIT", rec.Header().Get("X-Cache"))
+	}
+
+	if rec := env.do(http.MethodGet, "/api/v1/leaderboard?sort=bogus", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort = %d, want 400", rec.Code)
+	}
+}
+
+func TestWritesInvalidateCachedResponses(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.addUser(t, "alice", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "bob", Rating: 1600})
+	env.ac.AddUser("alice_ac", 0)
+	if _, err := env.groupService.CreateGroup(t.Context(), "team", ""); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	env.syncAll(t)
+
+	admin := []string{"Authorization", "Bearer " + testAdminKey}
+	writes := []struct {
+		name  string
+		write func() int
+	}{
+		{"add user", func() int {
+			return env.do(http.MethodPost, "/api/v1/users", `{"handle":"bob"}`, admin...).Code
+		}},
+		{"update profile", func() int {
+			return env.do(http.MethodPatch, env.userPath(t, "alice"), `{"display_name":"Alice"}`, admin...).Code
+		}},
+		{"add group member", func() int {
+			return env.do(http.MethodPost, "/api/v1/groups/team/members", fmt.Sprintf(`{"user_id":%d}`, alice.ID), admin...).Code
+		}},
+		{"remove group member", func() int {
+			return env.do(http.MethodDelete, fmt.Sprintf("/api/v1/groups/team/members/%d", alice.ID), "", admin...).Code
+		}},
+		{"link account", func() int {
+			return env.do(http.MethodPost, env.userPath(t, "alice")+"/accounts", `{"judge":"atcoder","handle":"alice_ac"}`, admin...).Code
+		}},
+		{"unlink account", func() int {
+			return env.do(http.MethodDelete, env.userPath(t, "alice")+"/accounts/atcoder/alice_ac", "", admin...).Code
+		}},
+		{"daily snapshot", func() int {
+			env.takeSnapshot(t)
+			return http.StatusOK
+		}},
+		{"remove user", func() int {
+			return env.do(http.MethodDelete, env.userPath(t, "bob"), "", admin...).Code
+		}},
+	}
+
+	cached := []string{"/api/v1/leaderboard", "/api/v1/leaderboard?group=team", env.userPath(t, "alice")}
+	for _, w := range writes {
+		for _, path := range cached {
+			env.do(http.MethodGet, path, "")
+			if rec := env.do(http.MethodGet, path, ""); rec.Header().Get("X-Cache") != "HIT" {
+				t.Fatalf("before %s: GET %s X-Cache = %q, want HIT", w.name, path, rec.Header().Get("X-Cache"))
+			}
+		}
+
+		if code := w.write(); code >= 300 {
+			t.Fatalf("%s = %d", w.name, code)
+		}
+
+		for _, path := range cached {
+			if rec := env.do(http.MethodGet, path, ""); rec.Header().Get("X-Cache") != "MISS" {
+				t.Errorf("after %s: GET %s X-Cache = %q, want MISS", w.name, path, rec.Header().Get("X-Cache"))
+			}
+		}
+	}
+}
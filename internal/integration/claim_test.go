@@ -0,0 +1,299 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+type claimResponse struct {
+	ID      uint   `json:"id"`
+	Handle  string `json:"handle"`
+	Method  string `json:"method"`
+	Problem string `json:"problem"`
+	Token   string `json:"token"`
+	Status  string `json:"status"`
+	Key     string `json:"key"`
+}
+
+func (env *testEnv) claim(t *testing.T, handle, method string) claimResponse {
+	t.Helper()
+
+	rec := env.do(http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"`+handle+`","method":"`+method+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("claim %s = %d: %s", handle, rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data claimResponse `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &resp)
+	return resp.Data
+}
+
+func (env *testEnv) claimStatus(t *testing.T, id uint, verify bool) string {
+	t.Helper()
+
+	method, path := http.MethodGet, "/api/v1/claims/"+strconv.FormatUint(uint64(id), 10)
+	if verify {
+		method, path = http.MethodPost, path+"/verify"
+	}
+	rec := env.do(method, path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s %s = %d: %s", method, path, rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data claimResponse `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &resp)
+	return resp.Data.Status
+}
+
+// ownerKey verifies a first_name claim on a tracked handle and returns its
+// owner key.
+func (env *testEnv) ownerKey(t *testing.T, handle string) string {
+	t.Helper()
+
+	claim := env.claim(t, handle, "first_name")
+	env.cf.SetFirstName(handle, claim.Token)
+	if status := env.claimStatus(t, claim.ID, true); status != domain.ClaimVerified {
+		t.Fatalf("claim on %s = %q, want verified", handle, status)
+	}
+	return claim.Key
+}
+
+// compilationError is a failed submission on a problem given as contest ID
+// and index, such as "4A".
+func compilationError(id int, at time.Time, problem string) domain.CodeforcesSubmission {
+	split := strings.IndexFunc(problem, func(r rune) bool { return r < '0' || r > '9' })
+	contestID, _ := strconv.Atoi(problem[:split])
+	return codeforcestest.CompilationError(id, at, contestID, problem[split:])
+}
+
+func TestSelfRegistrationWithCompilationError(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "Carol", Rating: 1600})
+
+	claim := env.claim(t, "carol", "compilation_error")
+	if claim.Handle != "Carol" || !slices.Contains(testClaimProblems, claim.Problem) || claim.Status != domain.ClaimPending || claim.Key == "" {
+		t.Fatalf("claim = %+v", claim)
+	}
+	// Compilation errors from before the claim do not count.
+	env.cf.AddSubmissions("carol", compilationError(1, testNow.Add(-time.Minute), claim.Problem))
+	if status := env.claimStatus(t, claim.ID, true); status != domain.ClaimPending {
+		t.Fatalf("status before the challenge = %q, want pending", status)
+	}
+
+	env.clock.Advance(2 * time.Minute)
+	env.cf.AddSubmissions("carol",
+		codeforcestest.CompilationError(2, env.clock.Now().Add(-30*time.Second), 5, "A"),
+		compilationError(3, env.clock.Now(), claim.Problem),
+	)
+	if err := env.claimService.VerifyPendingClaims(t.Context()); err != nil {
+		t.Fatalf("verify pending: %v", err)
+	}
+	if status := env.claimStatus(t, claim.ID, false); status != domain.ClaimVerified {
+		t.Fatalf("status after the challenge = %q, want verified", status)
+	}
+
+	// Verifying registered the handle.
+	if user := env.reloadUser(t, "Carol"); user.Rating != 1600 {
+		t.Errorf("registered user = %+v", user)
+	}
+
+	owner := []string{"Authorization", "Bearer " + claim.Key}
+	carol := env.userPath(t, "Carol")
+	pref := `{"channel":"webhook","target":"https://example.com/hook"}`
+	if rec := env.do(http.MethodPut, carol+"/notifications", pref); rec.Code != http.StatusUnauthorized {
+		t.Errorf("set preference without a key = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodPut, carol+"/notifications", pref, "Authorization", "Bearer wrong"); rec.Code != http.StatusForbidden {
+		t.Errorf("set preference with a wrong key = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPut, carol+"/notifications", pref, owner...); rec.Code != http.StatusOK {
+		t.Errorf("set preference as owner = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := env.do(http.MethodGet, carol+"/notifications", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("get preferences without a key = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, carol+"/notifications", "", owner...); rec.Code != http.StatusOK {
+		t.Errorf("get preferences as owner = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := env.do(http.MethodDelete, carol+"/notifications/webhook", "", owner...); rec.Code != http.StatusOK {
+		t.Errorf("delete preference as owner = %d: %s", rec.Code, rec.Body)
+	}
+
+	profile := `{"display_name":"Carol","time_zone":"Europe/Paris"}`
+	if rec := env.do(http.MethodPatch, carol, profile, "Authorization", "Bearer wrong"); rec.Code != http.StatusForbidden {
+		t.Errorf("update profile with a wrong key = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPatch, carol, profile, owner...); rec.Code != http.StatusOK {
+		t.Errorf("update profile as owner = %d: %s", rec.Code, rec.Body)
+	}
+	if got := env.reloadUser(t, "Carol"); got.DisplayName != "Carol" || got.TimeZone != "Europe/Paris" {
+		t.Errorf("profile after the owner's update = %q, %q", got.DisplayName, got.TimeZone)
+	}
+
+	// The key only speaks for its own handle.
+	dave := fmt.Sprintf("/api/v1/users/%d", env.addUser(t, "dave", 1500).ID)
+	if rec := env.do(http.MethodPut, dave+"/notifications", pref, owner...); rec.Code != http.StatusForbidden {
+		t.Errorf("set someone else's preference = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPatch, dave, profile, owner...); rec.Code != http.StatusForbidden {
+		t.Errorf("update someone else's profile = %d, want 403", rec.Code)
+	}
+
+	if _, err := env.groupService.CreateGroup(t.Context(), "club", ""); err != nil {
+		t.Fatal(err)
+	}
+	members := map[string]uint{}
+	for _, handle := range []string{"Carol", "dave"} {
+		members[handle] = env.reloadUser(t, handle).ID
+		if err := env.groupService.AddMember(t.Context(), "club", members[handle]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rec := env.do(http.MethodDelete, fmt.Sprintf("/api/v1/groups/club/members/%d", members["dave"]), "", owner...); rec.Code != http.StatusForbidden {
+		t.Errorf("remove someone else from a group = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodDelete, fmt.Sprintf("/api/v1/groups/club/members/%d", members["Carol"]), "", owner...); rec.Code != http.StatusOK {
+		t.Errorf("leave group = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestClaimExpiresWithoutTheChallenge(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "erin", 1400)
+
+	claim := env.claim(t, "erin", "compilation_error")
+	env.clock.Advance(6 * time.Minute)
+	// Too late: the window closed a minute ago.
+	env.cf.AddSubmissions("erin", compilationError(1, env.clock.Now(), claim.Problem))
+
+	if status := env.claimStatus(t, claim.ID, true); status != domain.ClaimExpired {
+		t.Errorf("status = %q, want expired", status)
+	}
+	if rec := env.do(http.MethodPut, env.userPath(t, "erin")+"/notifications", `{"channel":"webhook","target":"https://example.com/hook"}`,
+		"Authorization", "Bearer "+claim.Key); rec.Code != http.StatusForbidden {
+		t.Errorf("set preference with an expired claim's key = %d, want 403", rec.Code)
+	}
+}
+
+func TestClaimWithFirstName(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "frank", 1700)
+
+	first := env.claim(t, "frank", "first_name")
+	if first.Token == "" || first.Problem != "" {
+		t.Fatalf("claim = %+v", first)
+	}
+	env.cf.SetFirstName("frank", "Frank")
+	if status := env.claimStatus(t, first.ID, true); status != domain.ClaimPending {
+		t.Fatalf("status with another name = %q, want pending", status)
+	}
+	env.cf.SetFirstName("frank", first.Token)
+	if status := env.claimStatus(t, first.ID, true); status != domain.ClaimVerified {
+		t.Fatalf("status with the token = %q, want verified", status)
+	}
+
+	// A newer verified claim replaces the owner key.
+	second := env.claim(t, "frank", "first_name")
+	env.cf.SetFirstName("frank", second.Token)
+	env.clock.Advance(time.Minute)
+	if status := env.claimStatus(t, second.ID, true); status != domain.ClaimVerified {
+		t.Fatalf("second claim status = %q, want verified", status)
+	}
+	pref := `{"channel":"webhook","target":"https://example.com/hook"}`
+	if rec := env.do(http.MethodPut, env.userPath(t, "frank")+"/notifications", pref, "Authorization", "Bearer "+first.Key); rec.Code != http.StatusForbidden {
+		t.Errorf("old key = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPut, env.userPath(t, "frank")+"/notifications", pref, "Authorization", "Bearer "+second.Key); rec.Code != http.StatusOK {
+		t.Errorf("new key = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCompetingClaims(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "grace", Rating: 1800})
+
+	owner := env.claim(t, "grace", "compilation_error")
+	rec := env.do(http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"grace","method":"compilation_error"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second claim while one is pending = %d, want 409: %s", rec.Code, rec.Body)
+	}
+
+	// Two claims slipped in side by side, both asking for the same problem,
+	// so one submission completes both: only the first is verified.
+	attackerKey := "attacker-key"
+	sum := sha256.Sum256([]byte(attackerKey))
+	attacker := domain.HandleClaim{
+		Handle:    "grace",
+		Method:    domain.ChallengeCompilationError,
+		Problem:   owner.Problem,
+		KeyHash:   hex.EncodeToString(sum[:]),
+		Status:    domain.ClaimPending,
+		ExpiresAt: env.clock.Now().Add(5 * time.Minute),
+		CreatedAt: env.clock.Now(),
+	}
+	if err := repository.NewClaimRepository(env.db.DB).Create(t.Context(), &attacker); err != nil {
+		t.Fatalf("create competing claim: %v", err)
+	}
+
+	env.clock.Advance(time.Minute)
+	env.cf.AddSubmissions("grace", compilationError(1, env.clock.Now(), owner.Problem))
+	if err := env.claimService.VerifyPendingClaims(t.Context()); err != nil {
+		t.Fatalf("verify pending: %v", err)
+	}
+	if status := env.claimStatus(t, owner.ID, false); status != domain.ClaimVerified {
+		t.Errorf("owner's claim = %q, want verified", status)
+	}
+	if status := env.claimStatus(t, attacker.ID, false); status != domain.ClaimExpired {
+		t.Errorf("competing claim = %q, want expired", status)
+	}
+
+	pref := `{"channel":"webhook","target":"https://example.com/hook"}`
+	if rec := env.do(http.MethodPut, env.userPath(t, "grace")+"/notifications", pref, "Authorization", "Bearer "+attackerKey); rec.Code != http.StatusForbidden {
+		t.Errorf("competing key = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPut, env.userPath(t, "grace")+"/notifications", pref, "Authorization", "Bearer "+owner.Key); rec.Code != http.StatusOK {
+		t.Errorf("owner key = %d: %s", rec.Code, rec.Body)
+	}
+
+	// A later claim asks for another problem, so the owner's submission
+	// does not complete it.
+	next := env.claim(t, "grace", "compilation_error")
+	if next.Problem == owner.Problem {
+		t.Errorf("next claim reuses problem %s", next.Problem)
+	}
+	if status := env.claimStatus(t, next.ID, true); status != domain.ClaimPending {
+		t.Errorf("next claim = %q, want pending", status)
+	}
+}
+
+func TestClaimRequestErrors(t *testing.T) {
+	env := newTestEnv(t)
+
+	tests := []struct {
+		name, method, path, body string
+		code                     int
+	}{
+		{"unknown challenge", http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"tourist","method":"telepathy"}`, http.StatusBadRequest},
+		{"unknown handle", http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"nobody","method":"first_name"}`, http.StatusNotFound},
+		{"unknown claim", http.MethodGet, "/api/v1/claims/42", "", http.StatusNotFound},
+		{"invalid claim id", http.MethodPost, "/api/v1/claims/abc/verify", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := env.do(tt.method, tt.path, tt.body); rec.Code != tt.code {
+			t.Errorf("%s: %s %s = %d, want %d: %s", tt.name, tt.method, tt.path, rec.Code, tt.code, rec.Body)
+		}
+	}
+}
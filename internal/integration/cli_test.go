@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cli"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/client"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+// TestCLI runs the same commands against the database and through the API
+// and expects the same results.
+func TestCLI(t *testing.T) {
+	for _, mode := range []string{"database", "api"} {
+		t.Run(mode, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := t.Context()
+
+			backend := cli.NewDBBackend(env.userService, env.groupService, env.syncService)
+			var migrator cli.Migrator = env.db
+			if mode == "api" {
+				srv := httptest.NewServer(env.router)
+				t.Cleanup(srv.Close)
+				backend = cli.NewAPIBackend(client.NewClient(srv.URL, testAdminKey, srv.Client()))
+				migrator = nil
+			}
+
+			run := func(args ...string) (string, error) {
+				var out bytes.Buffer
+				err := cli.Run(ctx, backend, migrator, args, &out)
+				return out.String(), err
+			}
+			mustRun := func(args ...string) string {
+				t.Helper()
+				out, err := run(args...)
+				if err != nil {
+					t.Fatalf("%s: %v", strings.Join(args, " "), err)
+				}
+				return out
+			}
+
+			env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "alice", Rating: 1900, Rank: "candidate master"},
+				codeforcestest.Accepted(1, daysAgo(0)),
+				codeforcestest.Accepted(2, daysAgo(1)),
+				codeforcestest.Accepted(3, daysAgo(2)),
+			)
+			env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "bob", Rating: 1500, Rank: "specialist"},
+				codeforcestest.Accepted(4, daysAgo(0)),
+			)
+
+			if out := mustRun("user", "add", "alice"); out != "Added alice as user 1 (rating 1900, candidate master)\n" {
+				t.Errorf("user add output = %q", out)
+			}
+			mustRun("user", "add", "-judge", "codeforces", "bob")
+			if out := mustRun("sync", "run"); !strings.HasPrefix(out, "Synced all users in ") {
+				t.Errorf("sync run output = %q", out)
+			}
+			if out := mustRun("sync", "run", "-user", "2"); !strings.HasPrefix(out, "Synced user 2 in ") {
+				t.Errorf("sync run -user output = %q", out)
+			}
+
+			list := strings.Split(strings.TrimSpace(mustRun("user", "list")), "\n")
+			if len(list) != 3 || !strings.HasPrefix(list[0], "RANK") ||
+				strings.Join(strings.Fields(list[1]), " ") != "1 1 alice 3 3 1900" ||
+				strings.Join(strings.Fields(list[2]), " ") != "2 2 bob 1 1 1500" {
+				t.Errorf("user list:\n%s", strings.Join(list, "\n"))
+			}
+
+			show := mustRun("user", "show", "1")
+			for _, want := range []string{"Name:", "alice", "Accounts:", "codeforces:alice", "1900 (candidate master)", "3 (max 3)", "Leaderboard rank:  1"} {
+				if !strings.Contains(show, want) {
+					t.Errorf("user show is missing %q:\n%s", want, show)
+				}
+			}
+
+			mustRun("group", "create", "-description", "Chess club", "club")
+			if out := mustRun("group", "add-member", "club", "2"); out != "Added user 2 to club\n" {
+				t.Errorf("group add-member output = %q", out)
+			}
+			if out := mustRun("user", "list", "-group", "club"); strings.Contains(out, "alice") || !strings.Contains(out, "bob") {
+				t.Errorf("user list -group club:\n%s", out)
+			}
+
+			var exported []struct {
+				Rank          int    `json:"rank"`
+				Name          string `json:"name"`
+				Accounts      string `json:"accounts"`
+				CurrentStreak int    `json:"current_streak"`
+			}
+			decode(t, []byte(mustRun("export", "-format", "json")), &exported)
+			if len(exported) != 2 || exported[0].Name != "alice" || exported[0].Accounts != "codeforces:alice" || exported[0].CurrentStreak != 3 || exported[1].Rank != 2 {
+				t.Errorf("json export = %+v", exported)
+			}
+			records, err := csv.NewReader(strings.NewReader(mustRun("export", "-group", "club"))).ReadAll()
+			if err != nil || len(records) != 2 || records[0][2] != "name" || records[1][2] != "bob" {
+				t.Errorf("csv export = %v, %v", records, err)
+			}
+
+			// Recompute restores streaks from the stored submissions.
+			alice := env.reloadUser(t, "alice")
+			alice.CurrentStreak, alice.MaxStreak = 0, 0
+			if err := env.userRepo.Update(ctx, alice); err != nil {
+				t.Fatal(err)
+			}
+			if out := mustRun("streak", "recompute"); out != "Recomputed streaks: 1 users changed\n" {
+				t.Errorf("streak recompute output = %q", out)
+			}
+			if alice := env.reloadUser(t, "alice"); alice.CurrentStreak != 3 || alice.MaxStreak != 3 {
+				t.Errorf("after recompute alice streak = %d, max %d", alice.CurrentStreak, alice.MaxStreak)
+			}
+
+			mustRun("user", "remove", "2")
+			if _, err := run("user", "show", "2"); !errors.Is(err, client.ErrNotFound) {
+				t.Errorf("user show of a removed user error = %v", err)
+			}
+			if out := mustRun("user", "list", "-group", "club"); strings.Contains(out, "bob") {
+				t.Errorf("removed user still listed in group:\n%s", out)
+			}
+			if _, err := run("group", "add-member", "club", "2"); !errors.Is(err, client.ErrNotFound) {
+				t.Errorf("group add-member of a missing user error = %v", err)
+			}
+
+			for _, args := range [][]string{{}, {"frobnicate"}, {"user", "add"}, {"user", "show", "alice"}, {"export", "-format", "xml"}, {"sync"}} {
+				if _, err := run(args...); !errors.Is(err, cli.ErrUsage) {
+					t.Errorf("%q error = %v, want a usage error", args, err)
+				}
+			}
+
+			out, err := run("migrate", "status")
+			if mode == "api" {
+				if err == nil {
+					t.Error("migrate over the API succeeded")
+				}
+			} else if err != nil || out != "schema version: 7 (latest: 7)\n" {
+				t.Errorf("migrate status = %q, %v", out, err)
+			}
+		})
+	}
+}
+
+func TestRemoveUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "alice", 1900, codeforcestest.Accepted(1, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if rec := env.do(http.MethodDelete, env.userPath(t, "alice"), ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("DELETE without key = %d", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/v1/admin/streaks/recompute", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("recompute without key = %d", rec.Code)
+	}
+	if rec := env.do(http.MethodDelete, env.userPath(t, "alice"), "", "Authorization", "Bearer "+testAdminKey); rec.Code != http.StatusOK {
+		t.Errorf("DELETE = %d: %s", rec.Code, rec.Body)
+	}
+	var count int64
+	env.db.DB.Model(&domain.Submission{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d submissions left after removing the only user", count)
+	}
+}
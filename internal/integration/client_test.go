@@ -0,0 +1,224 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/handler"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/client"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func newClient(t *testing.T, env *testEnv, apiKey string) *client.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+	return client.NewClient(srv.URL+"/", apiKey, srv.Client())
+}
+
+func TestClientUsersAndSubmissions(t *testing.T) {
+	env := newTestEnv(t)
+	c := newClient(t, env, testAdminKey)
+	ctx := t.Context()
+
+	var submissions []domain.CodeforcesSubmission
+	for i := range 5 {
+		submissions = append(submissions, codeforcestest.Accepted(i+1, daysAgo(i)))
+	}
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "alice", Rating: 1900}, submissions...)
+
+	user, err := c.AddUser(ctx, "codeforces", "alice")
+	if err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	if user.Name() != "alice" || user.Rating != 1900 || user.ID == 0 ||
+		len(user.Accounts) != 1 || user.Accounts[0] != (client.Account{Judge: "codeforces", Handle: "alice", Rating: 1900}) {
+		t.Errorf("AddUser = %+v", user)
+	}
+	id := user.ID
+	if err := env.syncService.SyncAllUsers(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	user, err = c.GetUser(ctx, id)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.CurrentStreak != 5 || user.LeaderboardRank != 1 {
+		t.Errorf("GetUser = %+v", user)
+	}
+	if found, err := c.FindUser(ctx, "codeforces", "alice"); err != nil || found.ID != id || found.CurrentStreak != 5 {
+		t.Errorf("FindUser = %+v, %v", found, err)
+	}
+	if _, err := c.FindUser(ctx, "atcoder", "alice"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("FindUser(unlinked handle) error = %v", err)
+	}
+
+	page, err := c.SubmissionsPage(ctx, id, client.ListOptions{Page: 2, PageSize: 2})
+	if err != nil {
+		t.Fatalf("SubmissionsPage: %v", err)
+	}
+	if page.Total != 5 || page.TotalPages != 3 || len(page.Submissions) != 2 || page.Submissions[0].CodeforcesSubmissionID != 3 {
+		t.Errorf("SubmissionsPage = %+v", page)
+	}
+
+	// The iterator walks every page, newest first.
+	var ids []int64
+	for sub, err := range c.Submissions(ctx, id, client.ListOptions{PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("Submissions: %v", err)
+		}
+		ids = append(ids, sub.CodeforcesSubmissionID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("Submissions ids = %v", ids)
+	}
+
+	_, err = c.GetUser(ctx, 999)
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Message != "User not found" {
+		t.Errorf("GetUser(nobody) error = %v", err)
+	}
+	for _, err := range c.Submissions(ctx, 999, client.ListOptions{}) {
+		if !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("Submissions(nobody) error = %v", err)
+		}
+	}
+}
+
+func TestClientLeaderboardIterator(t *testing.T) {
+	env := newTestEnv(t)
+	c := newClient(t, env, "")
+	ctx := t.Context()
+
+	for i := range 5 {
+		env.addUser(t, fmt.Sprintf("user%d", i), 1500+i)
+	}
+
+	var handles []string
+	for user, err := range c.Leaderboard(ctx, "", client.ListOptions{PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("Leaderboard: %v", err)
+		}
+		handles = append(handles, user.Name())
+	}
+	if len(handles) != 5 {
+		t.Errorf("Leaderboard visited %v", handles)
+	}
+
+	// Breaking out of the loop stops fetching.
+	before := len(env.logs.records(t, "HTTP request"))
+	for range c.Leaderboard(ctx, "", client.ListOptions{PageSize: 2}) {
+		break
+	}
+	if requests := len(env.logs.records(t, "HTTP request")) - before; requests != 1 {
+		t.Errorf("breaking after the first user made %d requests, want 1", requests)
+	}
+
+	_, err := c.LeaderboardPage(ctx, "missing", client.ListOptions{})
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("LeaderboardPage(missing group) error = %v", err)
+	}
+}
+
+func TestClientLeaderboardSort(t *testing.T) {
+	env := newTestEnv(t)
+	c := newClient(t, env, "")
+	env.addUser(t, "streaky", 1200, codeforcestest.Accepted(1, daysAgo(0)), codeforcestest.Accepted(2, daysAgo(1)))
+	env.addUser(t, "rated", 2400, codeforcestest.Accepted(3, daysAgo(0)))
+	env.syncAll(t)
+
+	for sort, want := range map[string]string{"": "streaky", client.SortRating: "rated"} {
+		page, err := c.LeaderboardPage(t.Context(), "", client.ListOptions{Sort: sort})
+		if err != nil {
+			t.Fatalf("LeaderboardPage(sort %q): %v", sort, err)
+		}
+		if len(page.Users) != 2 || page.Users[0].Name() != want {
+			t.Errorf("LeaderboardPage(sort %q) = %+v, want %s first", sort, page.Users, want)
+		}
+	}
+
+	_, err := c.LeaderboardPage(t.Context(), "", client.ListOptions{Sort: "bogus"})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("LeaderboardPage(sort bogus) error = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestClientRateLimited(t *testing.T) {
+	env := newTestEnv(t)
+	// A token every 30 seconds.
+	env.rateLimits[handler.RateClassRead] = ratelimit.Limit{PerMinute: 2, Burst: 1}
+	c := newClient(t, env, "")
+
+	if _, err := c.LeaderboardPage(t.Context(), "", client.ListOptions{}); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	_, err := c.LeaderboardPage(t.Context(), "", client.ListOptions{})
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrRateLimited) || !errors.As(err, &apiErr) {
+		t.Fatalf("second request error = %v, want ErrRateLimited", err)
+	}
+	if apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", apiErr.RetryAfter)
+	}
+}
+
+func TestClientGroups(t *testing.T) {
+	env := newTestEnv(t)
+	admin := newClient(t, env, testAdminKey)
+	ctx := t.Context()
+	alice := env.addUser(t, "alice", 1900)
+	env.addUser(t, "bob", 1800)
+
+	if _, err := newClient(t, env, "").CreateGroup(ctx, "club", ""); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("CreateGroup without key error = %v", err)
+	}
+
+	group, err := admin.CreateGroup(ctx, "club", "Chess club")
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if group.Name != "club" || group.Description != "Chess club" {
+		t.Errorf("CreateGroup = %+v", group)
+	}
+	if _, err := admin.CreateGroup(ctx, "club", ""); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("duplicate CreateGroup error = %v", err)
+	}
+
+	if err := admin.AddGroupMember(ctx, "club", alice.ID); err != nil {
+		t.Fatalf("AddGroupMember: %v", err)
+	}
+	if err := admin.AddGroupMember(ctx, "club", 999); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("AddGroupMember(nobody) error = %v", err)
+	}
+
+	page, err := admin.LeaderboardPage(ctx, "club", client.ListOptions{})
+	if err != nil {
+		t.Fatalf("LeaderboardPage: %v", err)
+	}
+	if page.Total != 1 || page.Users[0].ID != alice.ID {
+		t.Errorf("group leaderboard = %+v", page)
+	}
+
+	if err := admin.RemoveGroupMember(ctx, "club", alice.ID); err != nil {
+		t.Fatalf("RemoveGroupMember: %v", err)
+	}
+	groups, err := admin.ListGroups(ctx)
+	if err != nil {
+		t.Fatalf("ListGroups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Name != "club" {
+		t.Errorf("ListGroups = %+v", groups)
+	}
+
+	// The server's own validation errors come back as ErrBadRequest.
+	if _, err := admin.CreateGroup(ctx, "", ""); !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("CreateGroup(\"\") error = %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+// Package integration runs the services, repositories and HTTP router
+// together against SQLite and a fake Codeforces API.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/config"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/cache"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/graphapi"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/handler"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/infrastructure/database"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/logging"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/stream"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder/atcodertest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode/leetcodetest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/notifier"
+)
+
+const testAdminKey = "test-admin-key"
+
+// testNow is midday in the streak time zone, so days relative to it never
+// straddle a midnight.
+var testNow = time.Date(2024, time.March, 15, 12, 0, 0, 0, mustLoadLocation("Asia/Tehran"))
+
+// testClaimProblems are the problems compilation error challenges pick from.
+var testClaimProblems = []string{"4A", "71A"}
+
+// testProxy is the peer address of every test request, trusted to set the
+// client IP through X-Forwarded-For.
+const testProxy = "192.0.2.1"
+
+// testUpdateInterval is the sync interval readiness checks measure against.
+const testUpdateInterval = time.Minute
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+type testEnv struct {
+	cf     *codeforcestest.Server
+	ac     *atcodertest.Server
+	lc     *leetcodetest.Server
+	clock  *clock.Fake
+	logs   *syncBuffer
+	db     *database.Database
+	events *recordingPublisher
+	hub    *stream.Hub
+
+	userRepo       repository.UserRepository
+	submissionRepo repository.SubmissionRepository
+	snapshotRepo   repository.SnapshotRepository
+	groupRepo      repository.GroupRepository
+	accountRepo    repository.AccountRepository
+
+	userService        service.UserService
+	groupService       service.GroupService
+	syncService        service.SyncService
+	snapshotService    service.SnapshotService
+	claimService       service.ClaimService
+	achievementService service.AchievementService
+
+	notificationService service.NotificationService
+	webhookService      service.WebhookService
+
+	// rateLimits is read on every request and starts empty, so nothing is
+	// limited unless a test sets a limit.
+	rateLimits map[string]ratelimit.Limit
+
+	router http.Handler
+}
+
+// newTestEnv wires the application the way cmd/api does, with a fresh
+// migrated SQLite database, the judge clients pointed at fakes and the clock
+// stopped at testNow.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	logs := &syncBuffer{}
+	logger, err := logging.New(logs, "debug", logging.FormatJSON)
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+
+	cf := codeforcestest.NewServer()
+	t.Cleanup(cf.Close)
+	ac := atcodertest.NewServer()
+	t.Cleanup(ac.Close)
+	lc := leetcodetest.NewServer()
+	t.Cleanup(lc.Close)
+
+	db, err := database.NewDatabase(&config.DatabaseConfig{
+		Driver:   database.DriverSQLite,
+		Path:     filepath.Join(t.TempDir(), "test.db"),
+		LogLevel: "info",
+	}, logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.MigrateUp(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	env := &testEnv{
+		cf:             cf,
+		ac:             ac,
+		lc:             lc,
+		clock:          clock.NewFake(testNow),
+		logs:           logs,
+		db:             db,
+		events:         &recordingPublisher{},
+		userRepo:       repository.NewUserRepository(db.DB),
+		submissionRepo: repository.NewSubmissionRepository(db.DB),
+		snapshotRepo:   repository.NewSnapshotRepository(db.DB),
+		groupRepo:      repository.NewGroupRepository(db.DB),
+		accountRepo:    repository.NewAccountRepository(db.DB),
+		rateLimits:     make(map[string]ratelimit.Limit),
+	}
+
+	achievementRepo := repository.NewAchievementRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	webhookRepo := repository.NewWebhookRepository(db.DB)
+
+	cfClient := codeforces.NewClient(cf.URL(), 0)
+	judges := service.NewJudgeProviders(cfClient, atcoder.NewClient(ac.URL(), ac.URL(), 0), leetcode.NewClient(lc.URL(), 0))
+	responseCache := cache.NewMemory(time.Hour, 100, env.clock)
+	env.hub = stream.NewHub(4, logger)
+	t.Cleanup(env.hub.Close)
+	events := service.MultiPublisher{env.events, env.hub}
+
+	env.webhookService = service.NewWebhookService(webhookRepo, 3, env.clock, logger)
+	env.achievementService = service.NewAchievementService(env.userRepo, achievementRepo, env.clock, logger)
+	env.notificationService = service.NewNotificationService(
+		env.userRepo, env.submissionRepo, notificationRepo,
+		[]notifier.Notifier{notifier.NewWebhookNotifier(true)}, 3, env.clock, logger,
+	)
+
+	env.userService = service.NewUserService(env.userRepo, env.submissionRepo, env.snapshotRepo, env.groupRepo, env.accountRepo, events, responseCache, judges, env.clock, logger)
+	env.groupService = service.NewGroupService(env.groupRepo, env.userRepo, responseCache, logger)
+	env.syncService = service.NewSyncService(env.userRepo, env.submissionRepo, env.accountRepo, env.achievementService, events, responseCache, judges, 2, env.clock, logger)
+	env.snapshotService = service.NewSnapshotService(env.userRepo, env.snapshotRepo, responseCache, 90, env.clock, logger)
+	env.claimService = service.NewClaimService(repository.NewClaimRepository(db.DB), env.userRepo, env.userService, cfClient, testClaimProblems, env.clock, logger)
+
+	gin.SetMode(gin.TestMode)
+	engine := handler.NewRouter(
+		handler.NewUserHandler(env.userService, handler.NewResponseCache(responseCache, 30*time.Second, logger), logger),
+		handler.NewAchievementHandler(env.achievementService),
+		handler.NewNotificationHandler(env.notificationService),
+		handler.NewWebhookHandler(env.webhookService),
+		handler.NewGroupHandler(env.groupService),
+		handler.NewHealthHandler(db, env.syncService, cfClient, testUpdateInterval, env.clock, logger),
+		handler.NewStreamHandler(env.hub, time.Hour, logger),
+		handler.NewGraphQLHandler(graphapi.New(env.userService, env.achievementService, env.groupService)),
+		handler.NewOpenAPIHandler(true, true, "/swagger-ui", logger),
+		handler.NewAdminHandler(env.userService, env.syncService),
+		handler.NewBadgeHandler(env.userService, handler.NewResponseCache(responseCache, 5*time.Minute, logger)),
+		handler.NewClaimHandler(env.claimService, env.userService),
+		handler.NewRateLimiter(ratelimit.NewMemory(env.clock), env.rateLimits, testAdminKey, env.claimService, logger),
+		nil,
+		testAdminKey,
+		logger,
+	).Setup()
+	if err := engine.SetTrustedProxies([]string{testProxy}); err != nil {
+		t.Fatalf("trusted proxies: %v", err)
+	}
+	env.router = engine
+
+	return env
+}
+
+// addUser registers handle on the fake API and through UserService, and
+// returns the stored user.
+func (env *testEnv) addUser(t *testing.T, handle string, rating int, submissions ...domain.CodeforcesSubmission) *domain.User {
+	t.Helper()
+
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: handle, Rating: rating, Rank: "expert"}, submissions...)
+	user, err := env.userService.AddUser(t.Context(), domain.JudgeCodeforces, handle)
+	if err != nil {
+		t.Fatalf("add user %s: %v", handle, err)
+	}
+	return user
+}
+
+// reloadUser returns the stored user the Codeforces handle is linked to.
+func (env *testEnv) reloadUser(t *testing.T, handle string) *domain.User {
+	t.Helper()
+
+	user, err := env.userRepo.FindByAccount(t.Context(), domain.JudgeCodeforces, handle)
+	if err != nil {
+		t.Fatalf("find user %s: %v", handle, err)
+	}
+	return user
+}
+
+// userPath returns the API path of the user the Codeforces handle is
+// linked to.
+func (env *testEnv) userPath(t *testing.T, handle string) string {
+	t.Helper()
+	return fmt.Sprintf("/api/v1/users/%d", env.reloadUser(t, handle).ID)
+}
+
+func (env *testEnv) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// daysAgo returns a moment on the n-th calendar day before testNow.
+func daysAgo(n int) time.Time {
+	return testNow.AddDate(0, 0, -n)
+}
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event domain.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+}
+
+func (p *recordingPublisher) ofType(eventType string) []domain.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var matched []domain.Event
+	for _, event := range p.events {
+		if event.Type == eventType {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// syncBuffer collects log output from concurrent sync workers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// records decodes every JSON log record whose message is msg.
+func (b *syncBuffer) records(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var matched []map[string]any
+	for _, line := range bytes.Split(b.buf.Bytes(), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("decode log line %s: %v", line, err)
+		}
+		if record["msg"] == msg {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
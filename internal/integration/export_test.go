@@ -0,0 +1,336 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/handler"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder/atcodertest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func TestExportLeaderboard(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := t.Context()
+	env.addUser(t, "alice", 1900,
+		codeforcestest.Accepted(1, daysAgo(0)),
+		codeforcestest.Accepted(2, daysAgo(1)),
+		codeforcestest.Accepted(3, daysAgo(2)),
+	)
+	bob := env.addUser(t, "bob", 1500, codeforcestest.Accepted(4, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, err := env.groupService.CreateGroup(ctx, "club", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.groupService.AddMember(ctx, "club", bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		rec := env.do(http.MethodGet, "/api/v1/leaderboard/export", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if cd := rec.Header().Get("Content-Disposition"); cd != "attachment; filename=leaderboard.csv" {
+			t.Errorf("Content-Disposition = %q", cd)
+		}
+
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]string{
+			{"rank", "user_id", "name", "accounts", "current_streak", "max_streak", "rating", "codeforces_rank", "last_submission_at", "rank_change", "streak_change"},
+			{"1", "1", "alice", "codeforces:alice", "3", "3", "1900"},
+			{"2", "2", "bob", "codeforces:bob", "1", "1", "1500"},
+		}
+		if len(records) != len(want) {
+			t.Fatalf("got %d records, want %d: %v", len(records), len(want), records)
+		}
+		for i, row := range want {
+			if got := records[i][:len(row)]; strings.Join(got, ",") != strings.Join(row, ",") {
+				t.Errorf("record %d = %v, want prefix %v", i, records[i], row)
+			}
+		}
+	})
+
+	t.Run("json with group", func(t *testing.T) {
+		rec := env.do(http.MethodGet, "/api/v1/leaderboard/export?format=json&group=club", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if cd := rec.Header().Get("Content-Disposition"); cd != "attachment; filename=leaderboard-club.json" {
+			t.Errorf("Content-Disposition = %q", cd)
+		}
+		var rows []handler.LeaderboardExportRow
+		decode(t, rec.Body.Bytes(), &rows)
+		if len(rows) != 1 || rows[0].UserID != bob.ID || rows[0].Name != "bob" || rows[0].Accounts != "codeforces:bob" || rows[0].Rank != 1 || rows[0].LastSubmissionAt == nil {
+			t.Errorf("rows = %+v", rows)
+		}
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		rec := env.do(http.MethodGet, "/api/v1/leaderboard/export?format=XLSX", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		sheet := readSheet(t, rec.Body.Bytes())
+		for _, want := range []string{">name<", ">alice<", ">bob<", `<v>1900</v>`} {
+			if !strings.Contains(sheet, want) {
+				t.Errorf("sheet is missing %s: %s", want, sheet)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			path string
+			code int
+		}{
+			{"/api/v1/leaderboard/export?format=xml", http.StatusBadRequest},
+			{"/api/v1/leaderboard/export?group=nobody", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			rec := env.do(http.MethodGet, tt.path, "")
+			if rec.Code != tt.code {
+				t.Errorf("GET %s = %d, want %d: %s", tt.path, rec.Code, tt.code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("GET %s Content-Type = %q, want a JSON error", tt.path, ct)
+			}
+		}
+	})
+}
+
+// TestExportLeaderboardBatches exports more users than one database batch
+// holds and checks none are lost or repeated at the batch boundaries.
+func TestExportLeaderboardBatches(t *testing.T) {
+	env := newTestEnv(t)
+	const users = 1201
+	for i := range users {
+		user := &domain.User{CurrentStreak: i % 7, Rating: i}
+		account := &domain.JudgeAccount{Judge: domain.JudgeCodeforces, Handle: fmt.Sprintf("user%04d", i)}
+		if err := env.userRepo.Create(t.Context(), user, account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := env.do(http.MethodGet, "/api/v1/leaderboard/export", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != users+1 {
+		t.Fatalf("got %d rows, want %d", len(records)-1, users)
+	}
+
+	// Rows come in user ID order, each with its rank.
+	ranks := map[string]string{}
+	for i, record := range records[1:] {
+		if want := fmt.Sprintf("user%04d", i); record[2] != want {
+			t.Fatalf("row %d is %s, want %s", i+1, record[2], want)
+		}
+		ranks[record[0]] = record[2]
+	}
+	if len(ranks) != users {
+		t.Errorf("%d distinct ranks, want %d", len(ranks), users)
+	}
+
+	// Ranking is by streak, then rating.
+	if ranks["1"] != "user1196" || ranks[strconv.Itoa(users)] != "user0000" {
+		t.Errorf("first and last ranked = %s, %s", ranks["1"], ranks[strconv.Itoa(users)])
+	}
+}
+
+// TestExportLeaderboardDuringSync reorders the leaderboard between two export
+// batches and checks every user is still exported exactly once.
+func TestExportLeaderboardDuringSync(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := t.Context()
+	const users = 600
+	created := make([]*domain.User, users)
+	for i := range users {
+		created[i] = &domain.User{CurrentStreak: users - i}
+		account := &domain.JudgeAccount{Judge: domain.JudgeCodeforces, Handle: fmt.Sprintf("user%04d", i)}
+		if err := env.userRepo.Create(ctx, created[i], account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[uint]int{}
+	for user, err := range env.userService.ExportLeaderboard(ctx, "", "") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[user.ID]++
+		if len(seen) == 1 {
+			// The first batch has been read. Move the last-ranked user, who
+			// is in the second batch, to the top.
+			last := created[users-1]
+			last.CurrentStreak = users + 1
+			if err := env.userRepo.Update(ctx, last); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if len(seen) != users {
+		t.Errorf("exported %d users, want %d", len(seen), users)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("user %d exported %d times", id, n)
+		}
+	}
+}
+
+func TestExportLeaderboardSort(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "streaky", 1200, codeforcestest.Accepted(1, daysAgo(0)), codeforcestest.Accepted(2, daysAgo(1)))
+	env.addUser(t, "rated", 2400, codeforcestest.Accepted(3, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	rec := env.do(http.MethodGet, "/api/v1/leaderboard/export?format=json&sort=rating", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var rows []handler.LeaderboardExportRow
+	decode(t, rec.Body.Bytes(), &rows)
+	ranks := map[string]int{}
+	for _, row := range rows {
+		ranks[row.Name] = row.Rank
+	}
+	if ranks["rated"] != 1 || ranks["streaky"] != 2 {
+		t.Errorf("ranks by rating = %v", ranks)
+	}
+
+	rec = env.do(http.MethodGet, "/api/v1/leaderboard/export?sort=bogus", "")
+	if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("unknown sort = %d %s, want a JSON 400", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+// TestExportOutlastsWriteTimeout serves exports from a server with a short
+// WriteTimeout and a handler that only starts once it has passed.
+func TestExportOutlastsWriteTimeout(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "alice", 1900, codeforcestest.Accepted(1, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	const writeTimeout = 50 * time.Millisecond
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * writeTimeout)
+		env.router.ServeHTTP(w, r)
+	}))
+	srv.Config.WriteTimeout = writeTimeout
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	for _, path := range []string{"/api/v1/leaderboard/export", env.userPath(t, "alice") + "/submissions/export"} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK || len(records) != 2 {
+			t.Errorf("GET %s = %d with %d records, error %v; want the whole file", path, resp.StatusCode, len(records), err)
+		}
+	}
+}
+
+func TestExportUserSubmissions(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.addUser(t, "alice", 1900,
+		codeforcestest.Accepted(1, daysAgo(2)),
+		codeforcestest.Submission(2, daysAgo(1), "WRONG_ANSWER"),
+		codeforcestest.Accepted(3, daysAgo(0)),
+	)
+	// The AtCoder submission shares its ID with a Codeforces one.
+	env.ac.AddUser("alice_ac", 1200, atcodertest.Accepted(2, daysAgo(1).Add(-time.Hour)))
+	if _, err := env.userService.LinkAccount(t.Context(), alice.ID, "atcoder", "alice_ac"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	rec := env.do(http.MethodGet, env.userPath(t, "alice")+"/submissions/export", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != fmt.Sprintf("attachment; filename=user-%d-submissions.csv", alice.ID) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"judge,submission_id,verdict,submitted_at",
+		"codeforces,3,OK," + daysAgo(0).UTC().Format(time.RFC3339),
+		"codeforces,2,WRONG_ANSWER," + daysAgo(1).UTC().Format(time.RFC3339),
+		"atcoder,2,OK," + daysAgo(1).Add(-time.Hour).UTC().Format(time.RFC3339),
+		"codeforces,1,OK," + daysAgo(2).UTC().Format(time.RFC3339),
+	}
+	var got []string
+	for _, record := range records {
+		got = append(got, strings.Join(record, ","))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("export =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A user without submissions still gets a header-only file.
+	env.addUser(t, "bob", 1500)
+	rec = env.do(http.MethodGet, env.userPath(t, "bob")+"/submissions/export?format=json", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Errorf("empty json export = %d %q", rec.Code, rec.Body)
+	}
+
+	if rec := env.do(http.MethodGet, "/api/v1/users/999/submissions/export", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+// readSheet returns the worksheet XML of an XLSX workbook.
+func readSheet(t *testing.T, workbook []byte) string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func graphQLBody(t *testing.T, query string, variables map[string]any) string {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	return string(body)
+}
+
+func TestGraphQLUserInOneRoundTrip(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.addUser(t, "alice", 1800,
+		codeforcestest.Accepted(1, daysAgo(0)),
+		codeforcestest.Submission(2, daysAgo(0), "WRONG_ANSWER"),
+		codeforcestest.Accepted(3, daysAgo(1)),
+	)
+	env.addUser(t, "bob", 1500, codeforcestest.Accepted(4, daysAgo(0)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+	if _, err := env.groupService.CreateGroup(t.Context(), "club", ""); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	for _, handle := range []string{"alice", "bob"} {
+		if err := env.groupService.AddMember(t.Context(), "club", env.reloadUser(t, handle).ID); err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+	}
+
+	rec := env.do(http.MethodPost, "/api/v1/graphql", graphQLBody(t, `
+		query Profile($id: ID!) {
+			user(id: $id) {
+				id
+				name
+				accounts { judge handle rating }
+				currentStreak
+				leaderboardRank
+				recentSubmissions(limit: 2) { codeforcesId verdict }
+				heatmap(days: 3) { date submissions accepted }
+				badges { code }
+				groups { group { name } rank members }
+			}
+			missing: user(id: "999") { name }
+			bob: userByAccount(judge: "codeforces", handle: "bob") { name }
+		}`, map[string]any{"id": fmt.Sprint(alice.ID)}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /graphql = %d: %s", rec.Code, rec.Body)
+	}
+
+	want := `{"data":{"user":{` +
+		`"id":"1","name":"alice","accounts":[{"judge":"codeforces","handle":"alice","rating":1800}],` +
+		`"currentStreak":2,"leaderboardRank":1,` +
+		`"recentSubmissions":[{"codeforcesId":"2","verdict":"WRONG_ANSWER"},{"codeforcesId":"1","verdict":"OK"}],` +
+		`"heatmap":[{"date":"2024-03-13","submissions":0,"accepted":0},{"date":"2024-03-14","submissions":1,"accepted":1},{"date":"2024-03-15","submissions":2,"accepted":1}],` +
+		`"badges":[],` +
+		`"groups":[{"group":{"name":"club"},"rank":1,"members":2}]},` +
+		`"missing":null,"bob":{"name":"bob"}}}`
+	if rec.Body.String() != want {
+		t.Errorf("body = %s\nwant   %s", rec.Body, want)
+	}
+}
+
+func TestGraphQLBatchesAcrossLeaderboard(t *testing.T) {
+	env := newTestEnv(t)
+	for i, handle := range []string{"u1", "u2", "u3", "u4"} {
+		env.addUser(t, handle, 1500+i, codeforcestest.Accepted(i+1, daysAgo(0)))
+	}
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	query := graphQLBody(t, `{
+		leaderboard(pageSize: 10) {
+			total
+			entries {
+				rank
+				user {
+					name
+					leaderboardRank
+					recentSubmissions { verdict }
+					heatmap(days: 7) { accepted }
+					badges { name }
+					groups { rank }
+				}
+			}
+		}
+	}`, nil)
+	rec := env.do(http.MethodPost, "/api/v1/graphql", query, "X-Request-ID", "gql-batch")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /graphql = %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp struct {
+		Data struct {
+			Leaderboard struct {
+				Total   int
+				Entries []struct {
+					Rank int
+					User struct {
+						Name              string
+						LeaderboardRank   int
+						RecentSubmissions []struct{ Verdict string }
+					}
+				}
+			}
+		}
+		Errors []any
+	}
+	decode(t, rec.Body.Bytes(), &resp)
+	if len(resp.Errors) > 0 || resp.Data.Leaderboard.Total != 4 || len(resp.Data.Leaderboard.Entries) != 4 {
+		t.Fatalf("response = %s", rec.Body)
+	}
+	for _, entry := range resp.Data.Leaderboard.Entries {
+		if entry.User.LeaderboardRank != entry.Rank || len(entry.User.RecentSubmissions) != 1 {
+			t.Errorf("entry = %+v", entry)
+		}
+	}
+
+	// Four queries for the leaderboard page (rows, their accounts, count,
+	// snapshots), two for the users and their accounts, and one per other
+	// user field, however many users the page holds. Groups take a single
+	// query here because nobody belongs to one.
+	var queries int
+	for _, record := range env.logs.records(t, "Query") {
+		if record["request_id"] == "gql-batch" {
+			queries++
+		}
+	}
+	if queries != 11 {
+		t.Errorf("request ran %d queries, want 11", queries)
+	}
+}
+
+func TestGraphQLRequestErrors(t *testing.T) {
+	env := newTestEnv(t)
+
+	invalid := env.do(http.MethodPost, "/api/v1/graphql", graphQLBody(t, `{ user(id: "1") { password } }`, nil))
+	if invalid.Code != http.StatusBadRequest || !strings.Contains(invalid.Body.String(), `cannot query field \"password\" on type \"User\"`) {
+		t.Errorf("invalid query = %d: %s", invalid.Code, invalid.Body)
+	}
+
+	get := env.do(http.MethodGet, `/api/v1/graphql?query=%7Bgroups%7Bname%7D%7D`, "")
+	if get.Code != http.StatusOK || get.Body.String() != `{"data":{"groups":[]}}` {
+		t.Errorf("GET query = %d: %s", get.Code, get.Body)
+	}
+
+	fieldErr := env.do(http.MethodPost, "/api/v1/graphql", graphQLBody(t, `{ leaderboard(group: "nope") { total } }`, nil))
+	if fieldErr.Code != http.StatusOK || !strings.Contains(fieldErr.Body.String(), `group \"nope\" not found`) {
+		t.Errorf("unknown group = %d: %s", fieldErr.Code, fieldErr.Body)
+	}
+
+	schema := env.do(http.MethodGet, "/api/v1/graphql/schema", "")
+	if schema.Code != http.StatusOK || !strings.Contains(schema.Body.String(), "heatmap(days: Int = 365): [HeatmapDay!]!") {
+		t.Errorf("schema = %d: %s", schema.Code, schema.Body)
+	}
+}
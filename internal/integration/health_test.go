@@ -0,0 +1,155 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/handler"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func (env *testEnv) readyz(t *testing.T, wantCode int) handler.ReadinessResponse {
+	t.Helper()
+
+	rec := env.do(http.MethodGet, "/readyz", "")
+	if rec.Code != wantCode {
+		t.Fatalf("GET /readyz = %d, want %d: %s", rec.Code, wantCode, rec.Body)
+	}
+	var body handler.ReadinessResponse
+	decode(t, rec.Body.Bytes(), &body)
+	return body
+}
+
+func TestLivez(t *testing.T) {
+	env := newTestEnv(t)
+	env.db.Close()
+
+	if rec := env.do(http.MethodGet, "/livez", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /livez with the database closed = %d, want 200", rec.Code)
+	}
+}
+
+func TestReadyzAllComponentsOK(t *testing.T) {
+	env := newTestEnv(t)
+
+	body := env.readyz(t, http.StatusOK)
+	if body.Status != handler.StatusOK {
+		t.Errorf("status = %q, want ok: %+v", body.Status, body.Components)
+	}
+	if body.Components.Sync.Detail != "first sync pending" {
+		t.Errorf("sync detail = %q, want the first sync to be pending", body.Components.Sync.Detail)
+	}
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+	body = env.readyz(t, http.StatusOK)
+	if got := body.Components.Sync.LastSuccessAt; got == nil || !got.Equal(testNow) {
+		t.Errorf("sync last_success_at = %v, want %v", got, testNow)
+	}
+}
+
+func TestReadyzCodeforcesDownIsDegraded(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.FailNext(codeforcestest.MethodUserInfo, http.StatusBadGateway)
+
+	body := env.readyz(t, http.StatusOK)
+	if body.Status != handler.StatusDegraded || body.Components.Codeforces.Status != handler.StatusDegraded {
+		t.Fatalf("status = %q, codeforces = %+v, want both degraded", body.Status, body.Components.Codeforces)
+	}
+	if body.Components.Database.Status != handler.StatusOK {
+		t.Errorf("database = %+v, want ok", body.Components.Database)
+	}
+
+	// The probe result is cached rather than repeated on every call.
+	calls := env.cf.Calls(codeforcestest.MethodUserInfo)
+	if body := env.readyz(t, http.StatusOK); body.Components.Codeforces.Status != handler.StatusDegraded {
+		t.Errorf("cached codeforces = %+v, want degraded", body.Components.Codeforces)
+	}
+	if got := env.cf.Calls(codeforcestest.MethodUserInfo); got != calls {
+		t.Errorf("Codeforces probed %d more times within the cache period", got-calls)
+	}
+
+	env.clock.Advance(time.Minute)
+	if body := env.readyz(t, http.StatusOK); body.Status != handler.StatusOK {
+		t.Errorf("after recovery status = %q, want ok: %+v", body.Status, body.Components)
+	}
+}
+
+func TestReadyzStaleSync(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.clock.Advance(3*testUpdateInterval + time.Second)
+	body := env.readyz(t, http.StatusServiceUnavailable)
+	if body.Components.Sync.Status != handler.StatusDown {
+		t.Errorf("sync = %+v, want down when no sync ever succeeded", body.Components.Sync)
+	}
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+	env.readyz(t, http.StatusOK)
+
+	env.clock.Advance(3*testUpdateInterval + time.Second)
+	body = env.readyz(t, http.StatusServiceUnavailable)
+	if body.Components.Sync.Status != handler.StatusDown || body.Components.Database.Status != handler.StatusOK {
+		t.Errorf("sync = %+v, database = %+v, want only sync down", body.Components.Sync, body.Components.Database)
+	}
+}
+
+// TestReadyzSyncFailingForEveryone checks that runs in which every user
+// fails do not count as successful syncs.
+func TestReadyzSyncFailingForEveryone(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "alice", 1500)
+	env.addUser(t, "bob", 1500)
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	for range 4 {
+		env.clock.Advance(testUpdateInterval)
+		env.cf.FailNext(codeforcestest.MethodUserStatus, http.StatusBadGateway)
+		env.cf.FailNext(codeforcestest.MethodUserStatus, http.StatusBadGateway)
+		if err := env.syncService.SyncAllUsers(t.Context()); err == nil {
+			t.Fatal("a sync in which every user failed succeeded")
+		}
+	}
+
+	body := env.readyz(t, http.StatusServiceUnavailable)
+	if body.Components.Sync.Status != handler.StatusDown {
+		t.Errorf("sync = %+v, want down while every user fails", body.Components.Sync)
+	}
+	if got := body.Components.Sync.LastSuccessAt; got == nil || !got.Equal(testNow) {
+		t.Errorf("sync last_success_at = %v, want the first run at %v", got, testNow)
+	}
+}
+
+func TestReadyzPendingMigrations(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.db.MigrateDown(1); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+
+	body := env.readyz(t, http.StatusServiceUnavailable)
+	if body.Components.Migrations.Status != handler.StatusDown {
+		t.Errorf("migrations = %+v, want down", body.Components.Migrations)
+	}
+	if body.Components.Database.Status != handler.StatusOK {
+		t.Errorf("database = %+v, want ok", body.Components.Database)
+	}
+}
+
+func TestReadyzDatabaseDown(t *testing.T) {
+	env := newTestEnv(t)
+	env.db.Close()
+
+	body := env.readyz(t, http.StatusServiceUnavailable)
+	if body.Status != handler.StatusDown || body.Components.Database.Status != handler.StatusDown {
+		t.Errorf("status = %q, database = %+v, want down", body.Status, body.Components.Database)
+	}
+	if body.Components.Codeforces.Status != handler.StatusOK {
+		t.Errorf("codeforces = %+v, want ok", body.Components.Codeforces)
+	}
+}
@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func TestRequestIDReachesServiceAndRepositoryLogs(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "traced"})
+
+	rec := env.do(http.MethodPost, "/api/v1/users", `{"handle":"traced"}`, "X-Request-ID", "req-123", "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /users = %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("response X-Request-ID = %q, want req-123", got)
+	}
+
+	for _, msg := range []string{"HTTP request", "Added new user", "Query"} {
+		found := false
+		for _, record := range env.logs.records(t, msg) {
+			if record["request_id"] == "req-123" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no %q log record with request_id req-123", msg)
+		}
+	}
+}
+
+func TestGeneratedRequestID(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := env.do(http.MethodGet, "/health", "", "X-Request-ID", "not a valid id!")
+	id := rec.Header().Get("X-Request-ID")
+	if id == "" || id == "not a valid id!" {
+		t.Errorf("response X-Request-ID = %q, want a generated ID", id)
+	}
+}
+
+func TestSyncRunIDOnWorkerLogs(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "ok", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.addUser(t, "gone", 1500)
+	env.cf.Rename("gone", "elsewhere")
+
+	for run := 0; run < 2; run++ {
+		if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+			t.Fatalf("sync all: %v", err)
+		}
+	}
+
+	completed := env.logs.records(t, "Sync completed")
+	failed := env.logs.records(t, "Error syncing user")
+	if len(completed) != 2 || len(failed) != 2 {
+		t.Fatalf("got %d completed and %d failed records, want 2 each", len(completed), len(failed))
+	}
+
+	for i := range completed {
+		id, _ := completed[i]["sync_run_id"].(string)
+		if id == "" || failed[i]["sync_run_id"] != id {
+			t.Errorf("run %d: sync_run_id %q on completion, %v on the worker error", i, id, failed[i]["sync_run_id"])
+		}
+	}
+	if completed[0]["sync_run_id"] == completed[1]["sync_run_id"] {
+		t.Error("two sync runs share a sync_run_id")
+	}
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/notifier"
+)
+
+// notificationReceiver collects webhook notifications by request path.
+type notificationReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received map[string][]notifier.Message
+}
+
+func newNotificationReceiver(t *testing.T) *notificationReceiver {
+	t.Helper()
+
+	nr := &notificationReceiver{received: map[string][]notifier.Message{}}
+	nr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg notifier.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decode notification: %v", err)
+		}
+		nr.mu.Lock()
+		nr.received[r.URL.Path] = append(nr.received[r.URL.Path], msg)
+		nr.mu.Unlock()
+	}))
+	t.Cleanup(nr.Close)
+	return nr
+}
+
+func (nr *notificationReceiver) messages(handle string) []notifier.Message {
+	nr.mu.Lock()
+	defer nr.mu.Unlock()
+	return nr.received["/"+handle]
+}
+
+// subscribe syncs user and points their webhook preference at the receiver,
+// under the user's name.
+func (nr *notificationReceiver) subscribe(t *testing.T, env *testEnv, user *domain.User) {
+	t.Helper()
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync %s: %v", user.Name(), err)
+	}
+	body := `{"channel":"webhook","target":"` + nr.URL + "/" + user.Name() + `"}`
+	rec := env.do(http.MethodPut, fmt.Sprintf("/api/v1/users/%d/notifications", user.ID), body, "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set %s's preference = %d: %s", user.Name(), rec.Code, rec.Body)
+	}
+}
+
+func (env *testEnv) notifyStreaksAtRisk(t *testing.T) {
+	t.Helper()
+	if err := env.notificationService.NotifyStreaksAtRisk(t.Context()); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+}
+
+func TestStreakAtRiskNotifications(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newNotificationReceiver(t)
+
+	// alice has not solved anything today; bob has.
+	receiver.subscribe(t, env, env.addUser(t, "alice", 1500, codeforcestest.Accepted(1, daysAgo(1)), codeforcestest.Accepted(2, daysAgo(2))))
+	receiver.subscribe(t, env, env.addUser(t, "bob", 1500, codeforcestest.Accepted(3, daysAgo(0)), codeforcestest.Accepted(4, daysAgo(1))))
+
+	// At noon midnight is further off than the default three hours.
+	env.notifyStreaksAtRisk(t)
+	if got := receiver.messages("alice"); len(got) != 0 {
+		t.Errorf("notified at noon: %v", got)
+	}
+
+	env.clock.Advance(10 * time.Hour)
+	env.notifyStreaksAtRisk(t)
+	env.notifyStreaksAtRisk(t)
+
+	if got := receiver.messages("alice"); len(got) != 1 {
+		t.Errorf("alice was notified %d times, want once", len(got))
+	} else if got[0].Subject != "Your 2-day streak is at risk" || !strings.HasPrefix(got[0].Body, "Hi alice,") {
+		t.Errorf("message = %q: %q", got[0].Subject, got[0].Body)
+	}
+	if got := receiver.messages("bob"); len(got) != 0 {
+		t.Errorf("bob, who solved a problem today, was notified: %v", got)
+	}
+}
+
+// TestStreakAtRiskFollowsTheUserTimeZone checks that reminders count down to
+// midnight where the user's streak day ends, not in the server's zone.
+func TestStreakAtRiskFollowsTheUserTimeZone(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newNotificationReceiver(t)
+
+	env.addUser(t, "alice", 1500, codeforcestest.Accepted(1, daysAgo(1)))
+	if rec := env.do(http.MethodPatch, env.userPath(t, "alice"), `{"time_zone":"Europe/London"}`, "Authorization", "Bearer "+testAdminKey); rec.Code != http.StatusOK {
+		t.Fatalf("set time zone = %d: %s", rec.Code, rec.Body)
+	}
+	receiver.subscribe(t, env, env.reloadUser(t, "alice"))
+
+	// 22:00 in Tehran is 18:30 in London.
+	env.clock.Advance(10 * time.Hour)
+	env.notifyStreaksAtRisk(t)
+	if got := receiver.messages("alice"); len(got) != 0 {
+		t.Fatalf("notified five and a half hours before London midnight: %v", got)
+	}
+
+	// 01:30 in Tehran is 22:00 in London, still the same streak day.
+	env.clock.Advance(3*time.Hour + 30*time.Minute)
+	env.notifyStreaksAtRisk(t)
+	if got := receiver.messages("alice"); len(got) != 1 {
+		t.Errorf("alice was notified %d times two hours before London midnight, want once", len(got))
+	}
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/openapi"
+)
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := env.do(http.MethodGet, "/api/v1/openapi.json", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", rec.Code)
+	}
+	var doc openapi.Document
+	decode(t, rec.Body.Bytes(), &doc)
+	if doc.OpenAPI != openapi.Version || doc.Info.Title != "CodeStreaks API" {
+		t.Errorf("openapi = %q, title %q", doc.OpenAPI, doc.Info.Title)
+	}
+	if scheme := doc.Components.SecuritySchemes["ApiKeyAuth"]; scheme == nil || scheme.Name != "Authorization" {
+		t.Errorf("ApiKeyAuth scheme = %+v", scheme)
+	}
+
+	// Every API and health route is documented.
+	for _, route := range env.router.(*gin.Engine).Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") && !strings.HasPrefix(route.Path, "/health") &&
+			route.Path != "/livez" && route.Path != "/readyz" {
+			continue
+		}
+		path := strings.NewReplacer(":handle", "{handle}", ":name", "{name}", ":id", "{id}", ":channel", "{channel}", ":judge", "{judge}", ":account", "{account}").Replace(route.Path)
+		item := doc.Paths[path]
+		if item == nil || (*item)[strings.ToLower(route.Method)] == nil {
+			t.Errorf("%s %s is not in the OpenAPI document", route.Method, path)
+		}
+	}
+
+	addUser := (*doc.Paths["/api/v1/users"])["post"]
+	body := addUser.RequestBody.Content["application/json"].Schema
+	if body.Ref != "#/components/schemas/AddUserRequest" || !addUser.RequestBody.Required {
+		t.Errorf("POST /users body = %+v", addUser.RequestBody)
+	}
+	if schema := doc.Components.Schemas["AddUserRequest"]; len(schema.Required) != 1 || schema.Required[0] != "handle" {
+		t.Errorf("AddUserRequest schema = %+v", schema)
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := env.do(http.MethodGet, "/api/v1/docs", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /docs = %d, %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{`src="/swagger-ui/swagger-ui-bundle.js"`, `"/api/v1/openapi.json"`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("UI page is missing %s", want)
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	env := newTestEnv(t)
+	auth := []string{"Authorization", "Bearer " + testAdminKey}
+
+	tests := []struct {
+		method, path, body string
+		want               string
+	}{
+		{http.MethodPost, "/api/v1/users", `{"handle":5}`, "body.handle: must be a string, not a number"},
+		{http.MethodPost, "/api/v1/users", `{"handle":""}`, "body.handle: must not be empty"},
+		{http.MethodPost, "/api/v1/users", `{}`, `body: missing required property "handle"`},
+		{http.MethodPost, "/api/v1/users", `{"handle":`, "request body is not valid JSON: unexpected EOF"},
+		{http.MethodGet, "/api/v1/leaderboard?page=first", "", `query parameter "page": "first" is not a number`},
+		{http.MethodGet, "/api/v1/leaderboard?page_size=2.5", "", `query parameter "page_size": page_size: 2.5 is not an integer`},
+		{http.MethodGet, "/api/v1/webhooks/abc/deliveries", "", `path parameter "id": "abc" is not a number`},
+		{http.MethodGet, "/api/v1/users/alice", "", `path parameter "id": "alice" is not a number`},
+		{http.MethodGet, "/api/v1/graphql", "", `missing required query parameter "query"`},
+	}
+	for _, tt := range tests {
+		rec := env.do(tt.method, tt.path, tt.body, auth...)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s = %d, want 400", tt.method, tt.path, rec.Code)
+			continue
+		}
+		var body struct{ Error string }
+		decode(t, rec.Body.Bytes(), &body)
+		if body.Error != "invalid request: "+tt.want {
+			t.Errorf("%s %s error = %q, want %q", tt.method, tt.path, body.Error, "invalid request: "+tt.want)
+		}
+	}
+
+	// Valid requests and undocumented routes pass through.
+	for _, path := range []string{"/api/v1/leaderboard?page=2&page_size=10", "/metrics"} {
+		if rec := env.do(http.MethodGet, path, ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+}
+
+// TestResponsesMatchDocument exercises the API with response validation on
+// and fails on any response the document does not describe.
+func TestResponsesMatchDocument(t *testing.T) {
+	env := newTestEnv(t)
+	auth := []string{"Authorization", "Bearer " + testAdminKey}
+	env.addUser(t, "alice", 1900, codeforcestest.Accepted(1, daysAgo(0)))
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "bob"}, codeforcestest.Accepted(2, daysAgo(0)))
+	env.ac.AddUser("alice_ac", 1200)
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+	alice := env.userPath(t, "alice")
+
+	requests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/api/v1/users", `{"handle":"bob"}`},
+		{http.MethodGet, alice, ""},
+		{http.MethodGet, "/api/v1/users/999", ""},
+		{http.MethodGet, alice + "/achievements", ""},
+		{http.MethodGet, alice + "/submissions?page_size=1", ""},
+		{http.MethodGet, "/api/v1/leaderboard", ""},
+		{http.MethodGet, "/api/v1/leaderboard/export?format=json", ""},
+		{http.MethodGet, alice + "/submissions/export?format=json", ""},
+		{http.MethodGet, "/api/v1/achievements", ""},
+		{http.MethodPost, alice + "/accounts", `{"judge":"atcoder","handle":"alice_ac"}`},
+		{http.MethodPost, alice + "/accounts", `{"judge":"topcoder","handle":"alice"}`},
+		{http.MethodGet, alice + "/accounts", ""},
+		{http.MethodPatch, alice, `{"display_name":"Alice","time_zone":"UTC"}`},
+		{http.MethodPatch, alice, `{"time_zone":"Mars/Olympus"}`},
+		{http.MethodDelete, alice + "/accounts/atcoder/alice_ac", ""},
+		{http.MethodDelete, alice + "/accounts/codeforces/alice", ""},
+		{http.MethodGet, "/api/v1/accounts/codeforces/alice", ""},
+		{http.MethodGet, "/api/v1/accounts/atcoder/nobody", ""},
+		{http.MethodGet, "/badge/1.svg?theme=dark", ""},
+		{http.MethodGet, "/card/999.svg", ""},
+		{http.MethodPost, "/api/v1/groups", `{"name":"club","description":"Chess club"}`},
+		{http.MethodPost, "/api/v1/groups/club/members", `{"user_id":1}`},
+		{http.MethodGet, "/api/v1/groups", ""},
+		{http.MethodDelete, "/api/v1/groups/club/members/1", ""},
+		{http.MethodPut, alice + "/notifications", `{"channel":"webhook","target":"https://example.com/hook"}`},
+		{http.MethodGet, alice + "/notifications", ""},
+		{http.MethodDelete, alice + "/notifications/webhook", ""},
+		{http.MethodPost, "/api/v1/webhooks", `{"url":"https://example.com/events"}`},
+		{http.MethodGet, "/api/v1/webhooks", ""},
+		{http.MethodGet, "/api/v1/webhooks/1/deliveries", ""},
+		{http.MethodDelete, "/api/v1/webhooks/1", ""},
+		{http.MethodPost, "/api/v1/graphql", `{"query":"{ user(id: \"1\") { name accounts { judge handle } badges { name } } }"}`},
+		{http.MethodGet, "/api/v1/graphql/schema", ""},
+		{http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"alice","method":"first_name"}`},
+		{http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"alice","method":"compilation_error"}`},
+		{http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"alice","method":"telepathy"}`},
+		{http.MethodGet, "/api/v1/claims/1", ""},
+		{http.MethodPost, "/api/v1/claims/1/verify", ""},
+		{http.MethodGet, "/api/v1/claims/42", ""},
+		{http.MethodGet, "/health", ""},
+		{http.MethodGet, "/livez", ""},
+		{http.MethodGet, "/readyz", ""},
+		{http.MethodGet, "/api/v1/openapi.json", ""},
+	}
+	for _, r := range requests {
+		if rec := env.do(r.method, r.path, r.body, auth...); rec.Code >= 500 {
+			t.Errorf("%s %s = %d: %s", r.method, r.path, rec.Code, rec.Body)
+		}
+	}
+
+	for _, record := range env.logs.records(t, "Response does not match the OpenAPI document") {
+		t.Errorf("%s %s %v: %s", record["method"], record["route"], record["status"], record["error"])
+	}
+}
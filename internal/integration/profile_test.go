@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+func TestProfileAPI(t *testing.T) {
+	env := newTestEnv(t)
+	auth := []string{"Authorization", "Bearer " + testAdminKey}
+	env.addUser(t, "alice", 1900)
+
+	body := `{"display_name":"  Alice Liddell ","avatar_url":"https://example.com/alice.png","time_zone":"Europe/London"}`
+	if rec := env.do(http.MethodPatch, env.userPath(t, "alice"), body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("update without key = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodPatch, env.userPath(t, "alice"), body, auth...); rec.Code != http.StatusOK {
+		t.Fatalf("update = %d: %s", rec.Code, rec.Body)
+	}
+
+	// Fields left out are kept.
+	if rec := env.do(http.MethodPatch, env.userPath(t, "alice"), `{"avatar_url":""}`, auth...); rec.Code != http.StatusOK {
+		t.Fatalf("clear avatar = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := env.do(http.MethodGet, env.userPath(t, "alice"), "")
+	var got struct {
+		Data domain.UserResponse `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &got)
+	if got.Data.DisplayName != "Alice Liddell" || got.Data.AvatarURL != "" || got.Data.TimeZone != "Europe/London" {
+		t.Errorf("profile = %+v", got.Data)
+	}
+
+	tests := []struct {
+		name, path, body string
+		code             int
+	}{
+		{"unknown time zone", env.userPath(t, "alice"), `{"time_zone":"Mars/Olympus"}`, http.StatusBadRequest},
+		{"empty time zone", env.userPath(t, "alice"), `{"time_zone":""}`, http.StatusBadRequest},
+		{"avatar not a URL", env.userPath(t, "alice"), `{"avatar_url":"javascript:alert(1)"}`, http.StatusBadRequest},
+		{"display name too long", env.userPath(t, "alice"), `{"display_name":"` + strings.Repeat("a", 65) + `"}`, http.StatusBadRequest},
+		{"unknown user", "/api/v1/users/999", `{"display_name":"Nobody"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := env.do(http.MethodPatch, tt.path, tt.body, auth...); rec.Code != tt.code {
+			t.Errorf("%s: PATCH %s = %d, want %d: %s", tt.name, tt.path, rec.Code, tt.code, rec.Body)
+		}
+	}
+}
+
+// TestStreakDaysFollowTheUserTimeZone checks that a user's streak and heatmap
+// days run midnight to midnight in their own time zone.
+func TestStreakDaysFollowTheUserTimeZone(t *testing.T) {
+	env := newTestEnv(t)
+	auth := []string{"Authorization", "Bearer " + testAdminKey}
+	tehran := testNow.Location()
+	// Early on the 14th in Tehran is still the 13th in UTC.
+	user := env.addUser(t, "alice", 1900,
+		codeforcestest.Accepted(1, time.Date(2024, time.March, 14, 2, 0, 0, 0, tehran)),
+		codeforcestest.Accepted(2, time.Date(2024, time.March, 15, 10, 0, 0, 0, tehran)),
+	)
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := env.reloadUser(t, "alice"); got.CurrentStreak != 2 || got.MaxStreak != 2 {
+		t.Fatalf("streak in Tehran = %d/%d, want 2/2", got.CurrentStreak, got.MaxStreak)
+	}
+
+	if rec := env.do(http.MethodPatch, env.userPath(t, "alice"), `{"time_zone":"UTC"}`, auth...); rec.Code != http.StatusOK {
+		t.Fatalf("update = %d: %s", rec.Code, rec.Body)
+	}
+	if got := env.reloadUser(t, "alice"); got.CurrentStreak != 1 || got.MaxStreak != 1 {
+		t.Errorf("streak in UTC = %d/%d, want 1/1", got.CurrentStreak, got.MaxStreak)
+	}
+
+	// The next sync and a recompute agree with the new time zone.
+	if err := env.syncService.SyncUser(t.Context(), env.reloadUser(t, "alice")); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if got := env.reloadUser(t, "alice"); got.CurrentStreak != 1 {
+		t.Errorf("streak after syncing in UTC = %d, want 1", got.CurrentStreak)
+	}
+	if changed, err := env.syncService.RecomputeStreaks(t.Context()); err != nil || changed != 0 {
+		t.Errorf("recompute = %d, %v, want no changes", changed, err)
+	}
+
+	rec := env.do(http.MethodPost, "/api/v1/graphql", graphQLBody(t,
+		`{ userByAccount(judge: "codeforces", handle: "alice") { timeZone heatmap(days: 3) { date accepted } } }`, nil))
+	want := `{"data":{"userByAccount":{"timeZone":"UTC","heatmap":[` +
+		`{"date":"2024-03-13","accepted":1},` +
+		`{"date":"2024-03-14","accepted":0},` +
+		`{"date":"2024-03-15","accepted":1}]}}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("heatmap = %s, want %s", got, want)
+	}
+}
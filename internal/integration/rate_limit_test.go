@@ -0,0 +1,176 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/bot"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/handler"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+)
+
+func TestRateLimitProtectsCodeforces(t *testing.T) {
+	env := newTestEnv(t)
+	// A token every 10 seconds, up to 2 at once.
+	env.rateLimits[handler.RateClassUpstream] = ratelimit.Limit{PerMinute: 6, Burst: 2}
+	for _, handle := range []string{"alice", "bob", "carol", "dave"} {
+		env.cf.AddUser(domain.CodeforcesUserInfo{Handle: handle})
+	}
+
+	claim := func(handle string, headers ...string) *http.Response {
+		return env.do(http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"`+handle+`","method":"first_name"}`, headers...).Result()
+	}
+
+	for _, handle := range []string{"alice", "bob"} {
+		if resp := claim(handle); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("claim %s = %d", handle, resp.StatusCode)
+		}
+	}
+	calls := env.cf.Calls("user.info")
+
+	resp := claim("carol")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "10" {
+		t.Errorf("third claim = %d, Retry-After %q; want 429, 10", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if got := env.cf.Calls("user.info"); got != calls {
+		t.Errorf("a limited request called Codeforces %d times", got-calls)
+	}
+
+	// Other clients, and the admin key, have buckets of their own.
+	if resp := claim("carol", "X-Forwarded-For", "203.0.113.7"); resp.StatusCode != http.StatusCreated {
+		t.Errorf("claim from another address = %d", resp.StatusCode)
+	}
+	add := env.do(http.MethodPost, "/api/v1/users", `{"handle":"dave"}`, "Authorization", "Bearer "+testAdminKey)
+	if add.Code != http.StatusCreated {
+		t.Errorf("add with the admin key = %d", add.Code)
+	}
+	// Made-up keys do not.
+	if resp := claim("dave", "Authorization", "Bearer made-up"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("claim with a made-up key = %d, want 429", resp.StatusCode)
+	}
+
+	// Reads only take from the read bucket.
+	if rec := env.do(http.MethodGet, env.userPath(t, "dave"), ""); rec.Code != http.StatusOK {
+		t.Errorf("get user while claiming is limited = %d", rec.Code)
+	}
+
+	env.clock.Advance(10 * time.Second)
+	if resp := claim("dave"); resp.StatusCode != http.StatusCreated {
+		t.Errorf("claim after the refill = %d", resp.StatusCode)
+	}
+
+	if records := env.logs.records(t, "Response does not match the OpenAPI document"); len(records) != 0 {
+		t.Errorf("429 does not match the document: %v", records)
+	}
+}
+
+func TestRateLimitKeysOwnerKeysByHash(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "frank", 1700)
+	claim := env.claim(t, "frank", "first_name")
+	env.cf.SetFirstName("frank", claim.Token)
+	if status := env.claimStatus(t, claim.ID, true); status != domain.ClaimVerified {
+		t.Fatalf("claim status = %q, want verified", status)
+	}
+	env.rateLimits[handler.RateClassWrite] = ratelimit.Limit{PerMinute: 1, Burst: 1}
+
+	patch := func(headers ...string) int {
+		return env.do(http.MethodPatch, env.userPath(t, "frank"), `{"display_name":"Frank"}`, headers...).Code
+	}
+
+	if code := patch("Authorization", "Bearer "+testAdminKey); code != http.StatusOK {
+		t.Fatalf("first patch with the admin key = %d", code)
+	}
+	if code := patch("Authorization", "Bearer "+testAdminKey); code != http.StatusTooManyRequests {
+		t.Errorf("second patch with the admin key = %d, want 429", code)
+	}
+
+	// The owner key has a bucket of its own, apart from the admin key's and
+	// from the address's.
+	owner := []string{"Authorization", "Bearer " + claim.Key}
+	if code := patch(owner...); code != http.StatusOK {
+		t.Errorf("first patch with the owner key = %d", code)
+	}
+	if code := patch(owner...); code != http.StatusTooManyRequests {
+		t.Errorf("second patch with the owner key = %d, want 429", code)
+	}
+
+	// A key that does not check out shares the address's bucket.
+	if code := patch("Authorization", "Bearer made-up"); code != http.StatusForbidden {
+		t.Errorf("first patch with a made-up key = %d, want 403", code)
+	}
+	if code := patch("Authorization", "Bearer made-up"); code != http.StatusTooManyRequests {
+		t.Errorf("second patch with a made-up key = %d, want 429", code)
+	}
+	if code := patch(); code != http.StatusTooManyRequests {
+		t.Errorf("patch without a key after the made-up ones = %d, want 429", code)
+	}
+}
+
+func TestRateLimitCoversBotRegistrations(t *testing.T) {
+	env := newTestEnv(t)
+	for _, handle := range []string{"alice", "bob", "carol"} {
+		env.cf.AddUser(domain.CodeforcesUserInfo{Handle: handle})
+	}
+	chatBot := bot.New(env.userService, env.claimService, ratelimit.NewMemory(env.clock), ratelimit.Limit{PerMinute: 6, Burst: 1}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if reply := chatBot.Execute(t.Context(), "telegram:1", "/register alice"); !strings.Contains(reply, "set the first name") {
+		t.Fatalf("first register = %q", reply)
+	}
+	calls := env.cf.Calls("user.info")
+	if reply := chatBot.Execute(t.Context(), "telegram:1", "/register bob"); reply != "Too many registrations, please try again in 10 seconds." {
+		t.Errorf("second register = %q", reply)
+	}
+	if got := env.cf.Calls("user.info"); got != calls {
+		t.Errorf("a limited registration called Codeforces %d times", got-calls)
+	}
+
+	// Other chats have buckets of their own, and other commands are free.
+	if reply := chatBot.Execute(t.Context(), "telegram:2", "/register bob"); !strings.Contains(reply, "set the first name") {
+		t.Errorf("register from another chat = %q", reply)
+	}
+	if reply := chatBot.Execute(t.Context(), "telegram:1", "/top"); !strings.HasPrefix(reply, "Top streaks") {
+		t.Errorf("streak while registering is limited = %q", reply)
+	}
+
+	env.clock.Advance(10 * time.Second)
+	if reply := chatBot.Execute(t.Context(), "telegram:1", "/register carol"); !strings.Contains(reply, "set the first name") {
+		t.Errorf("register after the refill = %q", reply)
+	}
+}
+
+func TestRateLimitClassesHaveSeparateBuckets(t *testing.T) {
+	env := newTestEnv(t)
+	env.rateLimits[handler.RateClassRead] = ratelimit.Limit{PerMinute: 60, Burst: 1}
+	env.rateLimits[handler.RateClassWrite] = ratelimit.Limit{PerMinute: 60, Burst: 1}
+	env.addUser(t, "alice", 1500)
+
+	if rec := env.do(http.MethodGet, "/api/v1/leaderboard", ""); rec.Code != http.StatusOK {
+		t.Fatalf("first read = %d", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, env.userPath(t, "alice"), ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second read = %d, want 429", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/v1/groups", `{"name":"club"}`, "Authorization", "Bearer "+testAdminKey); rec.Code != http.StatusCreated {
+		t.Errorf("write after the reads ran out = %d", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"alice","method":"first_name"}`); rec.Code != http.StatusCreated {
+		t.Errorf("first write = %d", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/v1/claims", `{"codeforces_handle":"alice","method":"first_name"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second write = %d, want 429", rec.Code)
+	}
+
+	// Health checks and badges are not limited.
+	for _, path := range []string{"/health", fmt.Sprintf("/badge/%d.svg", env.reloadUser(t, "alice").ID)} {
+		if rec := env.do(http.MethodGet, path, ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+type leaderboardBody struct {
+	Users      []domain.UserResponse `json:"users"`
+	Total      int64                 `json:"total"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+func decode(t *testing.T, body []byte, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+}
+
+func TestAddAndGetUserOverHTTP(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "Tourist", Rating: 3800, Rank: "legendary grandmaster"})
+
+	rec := env.do(http.MethodPost, "/api/v1/users", `{"handle":"tourist"}`, "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /users = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = env.do(http.MethodGet, env.userPath(t, "Tourist"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET the added user = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Data domain.UserResponse `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &body)
+	if body.Data.Rating != 3800 || body.Data.LeaderboardRank != 1 {
+		t.Errorf("user = %+v, want rating 3800 at rank 1", body.Data)
+	}
+
+	if rec := env.do(http.MethodGet, "/api/v1/users/999", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET unknown user = %d, want 404", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/v1/users/tourist", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET a user by handle = %d, want 400", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/v1/users", `{"judge":"topcoder","handle":"tourist"}`, "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /users on an unknown judge = %d, want 400", rec.Code)
+	}
+}
+
+func TestAddUserNeedsAdminOrOwnerKey(t *testing.T) {
+	env := newTestEnv(t)
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "mallory"})
+	env.addUser(t, "frank", 1700)
+	frank := env.ownerKey(t, "frank")
+	calls := env.cf.Calls("user.info")
+
+	tests := []struct {
+		name    string
+		handle  string
+		headers []string
+		code    int
+	}{
+		{"no key", "mallory", nil, http.StatusUnauthorized},
+		{"made-up key", "mallory", []string{"Authorization", "Bearer made-up"}, http.StatusForbidden},
+		{"another handle's owner key", "mallory", []string{"Authorization", "Bearer " + frank}, http.StatusForbidden},
+		{"owner key", "frank", []string{"Authorization", "Bearer " + frank}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := env.do(http.MethodPost, "/api/v1/users", `{"handle":"`+tt.handle+`"}`, tt.headers...)
+		if rec.Code != tt.code {
+			t.Errorf("%s: POST /users = %d, want %d: %s", tt.name, rec.Code, tt.code, rec.Body)
+		}
+	}
+	if _, err := env.userRepo.FindByAccount(t.Context(), domain.JudgeCodeforces, "mallory"); err == nil {
+		t.Error("an unclaimed handle was added without the admin key")
+	}
+	if got := env.cf.Calls("user.info"); got != calls {
+		t.Errorf("rejected requests called Codeforces %d times", got-calls)
+	}
+}
+
+func TestAddUnknownHandleOverHTTP(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := env.do(http.MethodPost, "/api/v1/users", `{"handle":"ghost"}`, "Authorization", "Bearer "+testAdminKey)
+	if rec.Code == http.StatusCreated {
+		t.Fatalf("POST /users for an unknown handle = %d", rec.Code)
+	}
+	if _, err := env.userRepo.FindByAccount(t.Context(), domain.JudgeCodeforces, "ghost"); err == nil {
+		t.Error("unknown handle was stored")
+	}
+}
+
+func TestLeaderboardAfterSync(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "one", 1200, codeforcestest.Accepted(1, daysAgo(0)))
+	env.addUser(t, "three", 1400,
+		codeforcestest.Accepted(2, daysAgo(0)),
+		codeforcestest.Accepted(3, daysAgo(1)),
+		codeforcestest.Accepted(4, daysAgo(2)),
+	)
+	env.addUser(t, "two", 1300, codeforcestest.Accepted(5, daysAgo(1)), codeforcestest.Accepted(6, daysAgo(2)))
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	rec := env.do(http.MethodGet, "/api/v1/leaderboard?page_size=2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /leaderboard = %d: %s", rec.Code, rec.Body)
+	}
+	var page leaderboardBody
+	decode(t, rec.Body.Bytes(), &page)
+
+	if page.Total != 3 || page.TotalPages != 2 {
+		t.Errorf("total = %d over %d pages, want 3 over 2", page.Total, page.TotalPages)
+	}
+	if len(page.Users) != 2 || page.Users[0].Name() != "three" || page.Users[1].Name() != "two" {
+		t.Fatalf("first page = %+v, want three, two", page.Users)
+	}
+	if page.Users[0].LeaderboardRank != 1 || page.Users[1].LeaderboardRank != 2 {
+		t.Errorf("ranks = %d, %d, want 1, 2", page.Users[0].LeaderboardRank, page.Users[1].LeaderboardRank)
+	}
+
+	rec = env.do(http.MethodGet, "/api/v1/leaderboard?page=2&page_size=2", "")
+	decode(t, rec.Body.Bytes(), &page)
+	if len(page.Users) != 1 || page.Users[0].Name() != "one" || page.Users[0].LeaderboardRank != 3 {
+		t.Errorf("second page = %+v, want one at rank 3", page.Users)
+	}
+}
+
+func TestGroupLeaderboardOverHTTP(t *testing.T) {
+	env := newTestEnv(t)
+	member := env.addUser(t, "member", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.addUser(t, "outsider", 2500,
+		codeforcestest.Accepted(2, daysAgo(0)),
+		codeforcestest.Accepted(3, daysAgo(1)),
+	)
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	auth := []string{"Authorization", "Bearer " + testAdminKey}
+	if rec := env.do(http.MethodPost, "/api/v1/groups", `{"name":"uni"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /groups without key = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/v1/groups", `{"name":"uni"}`, auth...); rec.Code != http.StatusCreated {
+		t.Fatalf("POST /groups = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := env.do(http.MethodPost, "/api/v1/groups/uni/members", fmt.Sprintf(`{"user_id":%d}`, member.ID), auth...); rec.Code != http.StatusOK {
+		t.Fatalf("POST /groups/uni/members = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := env.do(http.MethodGet, "/api/v1/leaderboard?group=uni", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /leaderboard?group=uni = %d: %s", rec.Code, rec.Body)
+	}
+	var page leaderboardBody
+	decode(t, rec.Body.Bytes(), &page)
+	if page.Total != 1 || len(page.Users) != 1 || page.Users[0].ID != member.ID || page.Users[0].LeaderboardRank != 1 {
+		t.Errorf("group leaderboard = %+v, want only member at rank 1", page)
+	}
+
+	if rec := env.do(http.MethodGet, "/api/v1/leaderboard?group=missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /leaderboard?group=missing = %d, want 404", rec.Code)
+	}
+}
+
+func TestUserAchievementsOverHTTP(t *testing.T) {
+	env := newTestEnv(t)
+	hard := codeforcestest.Accepted(1, daysAgo(0))
+	hard.Problem.Rating = 2400
+	user := env.addUser(t, "rated", 2000, hard)
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	rec := env.do(http.MethodGet, env.userPath(t, "rated")+"/achievements", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET achievements = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Data []domain.EarnedAchievement `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &body)
+	if len(body.Data) != 1 || body.Data[0].Code != "rated_1900" {
+		t.Errorf("achievements = %+v, want rated_1900", body.Data)
+	}
+}
+
+func TestHealthOverHTTP(t *testing.T) {
+	env := newTestEnv(t)
+
+	if rec := env.do(http.MethodGet, "/health", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /health = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMetricsOverHTTP(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "metered", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.addUser(t, "vanished", 1500)
+	env.cf.Rename("vanished", "reappeared")
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+	env.do(http.MethodGet, env.userPath(t, "metered"), "")
+
+	rec := env.do(http.MethodGet, "/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"codestreaks_sync_duration_seconds_count",
+		"codestreaks_last_sync_timestamp_seconds",
+		`codestreaks_sync_errors_total{judge="codeforces"}`,
+		"codestreaks_active_streak_users 1",
+		`codestreaks_codeforces_requests_total{code="200",method="user.status"}`,
+		`codestreaks_codeforces_requests_total{code="400",method="user.status"}`,
+		"codestreaks_codeforces_request_duration_seconds_bucket",
+		"codestreaks_codeforces_rate_limiter_wait_seconds_count",
+		`codestreaks_db_query_duration_seconds_count{operation="query"}`,
+		`codestreaks_http_request_duration_seconds_count{code="200",method="GET",route="/api/v1/users/:id"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics is missing %s", want)
+		}
+	}
+	// Handles are unbounded, so they belong in logs, not in label values.
+	if strings.Contains(body, "vanished") {
+		t.Error("/metrics names a handle")
+	}
+}
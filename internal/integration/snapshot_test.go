@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+// standings returns the whole leaderboard keyed by name, read through the
+// service so the response cache plays no part.
+func (env *testEnv) standings(t *testing.T) map[string]domain.UserResponse {
+	t.Helper()
+
+	users, _, err := env.userService.GetLeaderboard(t.Context(), 1, 50, "", "")
+	if err != nil {
+		t.Fatalf("leaderboard: %v", err)
+	}
+	byName := make(map[string]domain.UserResponse, len(users))
+	for _, user := range users {
+		byName[user.Name()] = user
+	}
+	return byName
+}
+
+func (env *testEnv) takeSnapshot(t *testing.T) {
+	t.Helper()
+	if err := env.snapshotService.TakeDailySnapshot(t.Context()); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+}
+
+func (env *testEnv) syncAll(t *testing.T) {
+	t.Helper()
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+}
+
+// storedSnapshotDates returns the dates snapshots are kept for, oldest first.
+func (env *testEnv) storedSnapshotDates(t *testing.T) []string {
+	t.Helper()
+
+	var snapshots []domain.LeaderboardSnapshot
+	if err := env.db.DB.Order("snapshot_date").Find(&snapshots).Error; err != nil {
+		t.Fatalf("read snapshots: %v", err)
+	}
+	var dates []string
+	for _, snapshot := range snapshots {
+		date := snapshot.SnapshotDate.Format(time.DateOnly)
+		if len(dates) == 0 || dates[len(dates)-1] != date {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// snapshotTestUsers adds alice, on a two-day streak, and bob, on a one-day
+// streak with the higher rating, and syncs them.
+func snapshotTestUsers(t *testing.T, env *testEnv) {
+	t.Helper()
+
+	env.addUser(t, "alice", 1500, codeforcestest.Accepted(1, daysAgo(0)), codeforcestest.Accepted(2, daysAgo(1)))
+	env.addUser(t, "bob", 1900, codeforcestest.Accepted(3, daysAgo(0)))
+	env.syncAll(t)
+}
+
+func TestFirstSnapshotHasNoPreviousDay(t *testing.T) {
+	env := newTestEnv(t)
+	snapshotTestUsers(t, env)
+
+	// Without any snapshot there is nothing to compare against.
+	for handle, user := range env.standings(t) {
+		if user.RankChange != 0 || user.StreakChange != 0 {
+			t.Errorf("%s before any snapshot: rank_change %d, streak_change %d", handle, user.RankChange, user.StreakChange)
+		}
+	}
+
+	// Just after midnight the day that ended is stored.
+	env.clock.Advance(12*time.Hour + 5*time.Minute)
+	env.takeSnapshot(t)
+
+	if got := env.storedSnapshotDates(t); len(got) != 1 || got[0] != "2024-03-15" {
+		t.Errorf("snapshot dates = %v, want [2024-03-15]", got)
+	}
+	standings := env.standings(t)
+	if alice, bob := standings["alice"], standings["bob"]; alice.LeaderboardRank != 1 || bob.LeaderboardRank != 2 {
+		t.Fatalf("ranks = alice %d, bob %d", alice.LeaderboardRank, bob.LeaderboardRank)
+	}
+	for handle, user := range standings {
+		if user.RankChange != 0 || user.StreakChange != 0 {
+			t.Errorf("%s against an unchanged snapshot: rank_change %d, streak_change %d", handle, user.RankChange, user.StreakChange)
+		}
+	}
+}
+
+func TestStandingChangesAcrossSnapshots(t *testing.T) {
+	env := newTestEnv(t)
+	snapshotTestUsers(t, env)
+
+	// 2024-03-16 00:05: the 15th is stored with alice first.
+	env.clock.Advance(12*time.Hour + 5*time.Minute)
+	env.takeSnapshot(t)
+
+	// bob solves on the 16th, ties alice's streak and passes her on rating.
+	env.cf.AddSubmissions("bob", codeforcestest.Accepted(4, env.clock.Now()))
+	env.syncAll(t)
+
+	standings := env.standings(t)
+	if bob := standings["bob"]; bob.LeaderboardRank != 1 || bob.RankChange != 1 || bob.StreakChange != 1 {
+		t.Errorf("bob = rank %d, rank_change %d, streak_change %d; want 1, 1, 1", bob.LeaderboardRank, bob.RankChange, bob.StreakChange)
+	}
+	if alice := standings["alice"]; alice.LeaderboardRank != 2 || alice.RankChange != -1 || alice.StreakChange != 0 {
+		t.Errorf("alice = rank %d, rank_change %d, streak_change %d; want 2, -1, 0", alice.LeaderboardRank, alice.RankChange, alice.StreakChange)
+	}
+
+	// 2024-03-17 00:05: the 16th is stored, and changes are measured from it
+	// rather than from the 15th. alice did not solve on the 16th.
+	env.clock.Advance(24 * time.Hour)
+	env.takeSnapshot(t)
+	env.syncAll(t)
+
+	if got := env.storedSnapshotDates(t); len(got) != 2 || got[1] != "2024-03-16" {
+		t.Errorf("snapshot dates = %v, want the 15th and the 16th", got)
+	}
+	standings = env.standings(t)
+	if bob := standings["bob"]; bob.RankChange != 0 || bob.StreakChange != 0 {
+		t.Errorf("bob = rank_change %d, streak_change %d; want 0, 0", bob.RankChange, bob.StreakChange)
+	}
+	if alice := standings["alice"]; alice.CurrentStreak != 0 || alice.RankChange != 0 || alice.StreakChange != -2 {
+		t.Errorf("alice = streak %d, rank_change %d, streak_change %d; want 0, 0, -2", alice.CurrentStreak, alice.RankChange, alice.StreakChange)
+	}
+}
+
+func TestPruneSnapshotsAtTheRetentionBoundary(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "alice", 1500)
+
+	// The test env keeps 90 days: on 2024-03-15 the oldest kept day is
+	// 2023-12-16.
+	var snapshots []domain.LeaderboardSnapshot
+	for _, date := range []string{"2023-12-15", "2023-12-16", "2023-12-17"} {
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, domain.LeaderboardSnapshot{UserID: user.ID, SnapshotDate: day, LeaderboardRank: 1})
+	}
+	if err := env.snapshotRepo.SaveAll(t.Context(), snapshots); err != nil {
+		t.Fatalf("save snapshots: %v", err)
+	}
+
+	if err := env.snapshotService.PruneSnapshots(t.Context()); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if got := env.storedSnapshotDates(t); len(got) != 2 || got[0] != "2023-12-16" {
+		t.Errorf("after pruning on the 15th = %v, want 2023-12-16 onwards", got)
+	}
+
+	env.clock.Advance(24 * time.Hour)
+	if err := env.snapshotService.PruneSnapshots(t.Context()); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if got := env.storedSnapshotDates(t); len(got) != 1 || got[0] != "2023-12-17" {
+		t.Errorf("after pruning on the 16th = %v, want only 2023-12-17", got)
+	}
+}
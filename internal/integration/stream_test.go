@@ -0,0 +1,198 @@
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+	"golang.org/x/net/websocket"
+)
+
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// readSSE parses events from an SSE body, skipping comments and the retry
+// hint. It fails the test if none arrives within the deadline.
+func readSSE(t *testing.T, r *bufio.Reader, want int) []sseEvent {
+	t.Helper()
+
+	events := make(chan []sseEvent, 1)
+	go func() {
+		var got []sseEvent
+		var current sseEvent
+		for len(got) < want {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimSuffix(line, "\n")
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				current.ID = value
+			case "event":
+				current.Type = value
+			case "data":
+				current.Data = value
+			case "":
+				if current.Type != "" {
+					got = append(got, current)
+				}
+				current = sseEvent{}
+			}
+		}
+		events <- got
+	}()
+
+	select {
+	case got := <-events:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %d stream events", want)
+		return nil
+	}
+}
+
+// activeStreakEnv has two users whose streaks both grow on the next sync.
+func activeStreakEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	env := newTestEnv(t)
+	env.addUser(t, "watched", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.addUser(t, "other", 1500, codeforcestest.Accepted(2, daysAgo(0)))
+	return env
+}
+
+func TestStreamOverSSE(t *testing.T) {
+	env := activeStreakEnv(t)
+	srv := httptest.NewServer(env.router)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + fmt.Sprintf("/api/v1/stream?user=%d", env.reloadUser(t, "watched").ID))
+	if err != nil {
+		t.Fatalf("GET /stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /stream = %d, %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	body := bufio.NewReader(resp.Body)
+	if line, _ := body.ReadString('\n'); !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("first line = %q, want a retry hint", line)
+	}
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	events := readSSE(t, body, 2)
+	types := map[string]sseEvent{}
+	for _, event := range events {
+		if !strings.Contains(event.Data, `"name":"watched"`) {
+			t.Errorf("event for another user leaked through the filter: %+v", event)
+		}
+		types[event.Type] = event
+	}
+
+	entry, ok := types[domain.EventLeaderboardEntry]
+	if !ok {
+		t.Fatalf("events = %+v, want a %s", events, domain.EventLeaderboardEntry)
+	}
+	var decoded struct {
+		ID   string                           `json:"id"`
+		Data domain.LeaderboardEntryEventData `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(entry.Data), &decoded); err != nil {
+		t.Fatalf("decode %s: %v", entry.Data, err)
+	}
+	if decoded.ID != entry.ID || decoded.Data.CurrentStreak != 1 || decoded.Data.CurrentRank < 1 {
+		t.Errorf("leaderboard entry = %+v (id %q)", decoded, entry.ID)
+	}
+	if _, ok := types[domain.EventStreakExtended]; !ok {
+		t.Errorf("events = %+v, want a %s", events, domain.EventStreakExtended)
+	}
+}
+
+func TestStreamRejectsUnknownType(t *testing.T) {
+	env := newTestEnv(t)
+
+	if rec := env.do(http.MethodGet, "/api/v1/stream?type=bogus", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /stream?type=bogus = %d, want 400", rec.Code)
+	}
+}
+
+func TestStreamOverWebSocket(t *testing.T) {
+	env := activeStreakEnv(t)
+	srv := httptest.NewServer(env.router)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/stream/ws?type=" + domain.EventStreakExtended
+	ws, err := websocket.Dial(wsURL, "", srv.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	// The subscription is registered after the handshake; wait for it.
+	deadline := time.Now().Add(5 * time.Second)
+	for env.hub.Subscribers() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	names := map[string]bool{}
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(names) < 2 {
+		var event struct {
+			Type string                 `json:"type"`
+			Data domain.StreakEventData `json:"data"`
+		}
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("receive: %v (got %v)", err, names)
+		}
+		if event.Type != domain.EventStreakExtended {
+			t.Errorf("received %s, want only %s", event.Type, domain.EventStreakExtended)
+		}
+		names[event.Data.Name] = true
+	}
+	if !names["watched"] || !names["other"] {
+		t.Errorf("streak events for %v, want watched and other", names)
+	}
+}
+
+func TestLeaderboardEventInvalidatesCache(t *testing.T) {
+	env := activeStreakEnv(t)
+
+	if rec := env.do(http.MethodGet, "/api/v1/leaderboard", ""); rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first GET X-Cache = %q, want MISS", rec.Header().Get("X-Cache"))
+	}
+
+	// A single-user sync, as from the chat bot, publishes the event without
+	// a full run finishing.
+	user := env.reloadUser(t, "watched")
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync user: %v", err)
+	}
+	if len(env.events.ofType(domain.EventLeaderboardEntry)) != 1 {
+		t.Fatalf("want one %s event", domain.EventLeaderboardEntry)
+	}
+
+	rec := env.do(http.MethodGet, "/api/v1/leaderboard", "")
+	if rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("GET after the event X-Cache = %q, want MISS", rec.Header().Get("X-Cache"))
+	}
+}
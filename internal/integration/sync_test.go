@@ -0,0 +1,423 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode/leetcodetest"
+)
+
+func TestSyncUserCalculatesStreak(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "tourist", 3800,
+		codeforcestest.Accepted(1, daysAgo(0)),
+		codeforcestest.Accepted(2, daysAgo(1)),
+		codeforcestest.Accepted(3, daysAgo(2)),
+		codeforcestest.Submission(4, daysAgo(3), "WRONG_ANSWER"),
+		codeforcestest.Accepted(5, daysAgo(5)),
+	)
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	got := env.reloadUser(t, "tourist")
+	if got.CurrentStreak != 3 || got.MaxStreak != 3 {
+		t.Errorf("streak = %d/%d, want 3/3", got.CurrentStreak, got.MaxStreak)
+	}
+	if got.TotalSubmissions != 5 {
+		t.Errorf("total submissions = %d, want 5", got.TotalSubmissions)
+	}
+	if got.LastCheckedAt == nil {
+		t.Error("last checked time not recorded")
+	}
+
+	stored, err := env.submissionRepo.GetUserSubmissions(t.Context(), user.ID, 100)
+	if err != nil {
+		t.Fatalf("get submissions: %v", err)
+	}
+	if len(stored) != 5 {
+		t.Errorf("stored %d submissions, want 5", len(stored))
+	}
+
+	if events := env.events.ofType(domain.EventStreakExtended); len(events) != 1 {
+		t.Errorf("got %d streak.extended events, want 1", len(events))
+	}
+}
+
+func TestSyncUserKeepsStreakUntilTodayEnds(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "petr", 3500,
+		codeforcestest.Accepted(1, daysAgo(1)),
+		codeforcestest.Accepted(2, daysAgo(2)),
+	)
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if got := env.reloadUser(t, "petr"); got.CurrentStreak != 2 {
+		t.Errorf("current streak = %d, want 2", got.CurrentStreak)
+	}
+}
+
+func TestSyncUserBreaksStreakAndKeepsMax(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "benq", 3700, codeforcestest.Accepted(1, daysAgo(2)))
+
+	user.CurrentStreak = 4
+	user.MaxStreak = 10
+	if err := env.userRepo.Update(t.Context(), user); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	got := env.reloadUser(t, "benq")
+	if got.CurrentStreak != 0 || got.MaxStreak != 10 {
+		t.Errorf("streak = %d/%d, want 0/10", got.CurrentStreak, got.MaxStreak)
+	}
+	if events := env.events.ofType(domain.EventStreakBroken); len(events) != 1 {
+		t.Errorf("got %d streak.broken events, want 1", len(events))
+	}
+}
+
+func TestSyncUserDoesNotDuplicateSubmissions(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "ecnerwala", 3400, codeforcestest.Accepted(1, daysAgo(0)))
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	env.cf.AddSubmissions("ecnerwala", codeforcestest.Accepted(2, daysAgo(0)))
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+
+	stored, err := env.submissionRepo.GetUserSubmissions(t.Context(), user.ID, 100)
+	if err != nil {
+		t.Fatalf("get submissions: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Errorf("stored %d submissions, want 2", len(stored))
+	}
+}
+
+// TestConcurrentSyncsOfOneUser runs several syncs of the same user at once,
+// each from its own copy of the row, as the scheduler and an admin sync can.
+func TestConcurrentSyncsOfOneUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "ecnerwala", 3400, codeforcestest.Accepted(1, daysAgo(0)), codeforcestest.Accepted(2, daysAgo(1)))
+
+	const syncs = 4
+	errs := make(chan error, syncs)
+	for range syncs {
+		user := env.reloadUser(t, "ecnerwala")
+		go func() { errs <- env.syncService.SyncUser(t.Context(), user) }()
+	}
+	for range syncs {
+		if err := <-errs; err != nil {
+			t.Errorf("sync: %v", err)
+		}
+	}
+
+	if got := env.events.ofType(domain.EventStreakExtended); len(got) != 1 {
+		t.Errorf("published %d streak.extended events, want 1", len(got))
+	}
+	stored, err := env.submissionRepo.GetUserSubmissions(t.Context(), env.reloadUser(t, "ecnerwala").ID, 100)
+	if err != nil {
+		t.Fatalf("get submissions: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Errorf("stored %d submissions, want 2", len(stored))
+	}
+}
+
+func TestSyncUserPublishesRankChanges(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.addUser(t, "alice", 1500, codeforcestest.Accepted(1, daysAgo(0)), codeforcestest.Accepted(2, daysAgo(1)))
+	bob := env.addUser(t, "bob", 1900, codeforcestest.Accepted(3, daysAgo(1)))
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	// Untracked, bob leads on rating; alice's two-day streak puts her first.
+	// bob is pushed down but only the synced user is announced.
+	want := []domain.RankChangedEventData{{UserID: alice.ID, Name: "alice", PreviousRank: 2, CurrentRank: 1}}
+	assertRankChanges(t, env.events.ofType(domain.EventLeaderboardRankMoved), want)
+
+	// bob solves today, ties alice's streak and passes her on rating.
+	env.cf.AddSubmissions("bob", codeforcestest.Accepted(4, daysAgo(0)))
+	if err := env.syncService.SyncUser(t.Context(), bob); err != nil {
+		t.Fatalf("sync bob: %v", err)
+	}
+	want = append(want, domain.RankChangedEventData{UserID: bob.ID, Name: "bob", PreviousRank: 2, CurrentRank: 1})
+	assertRankChanges(t, env.events.ofType(domain.EventLeaderboardRankMoved), want)
+
+	// Neither a sync that changes nothing nor the daily snapshot announces
+	// anything more.
+	env.syncAll(t)
+	env.clock.Advance(12*time.Hour + 5*time.Minute)
+	env.takeSnapshot(t)
+	assertRankChanges(t, env.events.ofType(domain.EventLeaderboardRankMoved), want)
+}
+
+func assertRankChanges(t *testing.T, events []domain.Event, want []domain.RankChangedEventData) {
+	t.Helper()
+
+	if len(events) != len(want) {
+		t.Fatalf("published %d rank_changed events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, event := range events {
+		if data := event.Data.(domain.RankChangedEventData); data != want[i] || !event.OccurredAt.Equal(testNow) {
+			t.Errorf("event %d = %+v at %v, want %+v at %v", i, data, event.OccurredAt, want[i], testNow)
+		}
+	}
+}
+
+// TestSyncUserBackfillsSubmissionDetails stores a submission the way it was
+// stored before problem details were kept and checks a sync fills them in.
+func TestSyncUserBackfillsSubmissionDetails(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "ecnerwala", 3400, codeforcestest.Accepted(1, daysAgo(0)))
+	old := &domain.Submission{
+		UserID:                 user.ID,
+		Judge:                  domain.JudgeCodeforces,
+		CodeforcesSubmissionID: 1,
+		Verdict:                "OK",
+		SubmittedAt:            daysAgo(0).UTC(),
+	}
+	if err := env.submissionRepo.Create(t.Context(), old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	stored, err := env.submissionRepo.GetUserSubmissions(t.Context(), user.ID, 100)
+	if err != nil {
+		t.Fatalf("get submissions: %v", err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("stored %d submissions, want 1", len(stored))
+	}
+	if got := stored[0]; got.ProblemID != "1001A" || got.ProblemRating != 800 || got.Language == "" {
+		t.Errorf("stored submission = %+v, want its problem details filled in", got)
+	}
+}
+
+func TestSyncUserUnlocksAchievements(t *testing.T) {
+	env := newTestEnv(t)
+
+	var submissions []domain.CodeforcesSubmission
+	for day := 0; day < 7; day++ {
+		submissions = append(submissions, codeforcestest.Accepted(day+1, daysAgo(day)))
+	}
+	user := env.addUser(t, "jiangly", 3900, submissions...)
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	earned, err := env.userService.GetUser(t.Context(), user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if earned.CurrentStreak != 7 {
+		t.Fatalf("current streak = %d, want 7", earned.CurrentStreak)
+	}
+
+	unlocked := map[string]bool{}
+	for _, event := range env.events.ofType(domain.EventAchievementUnlocked) {
+		data := event.Data.(domain.AchievementEventData)
+		unlocked[data.Achievement.Code] = true
+		if !event.OccurredAt.Equal(testNow) {
+			t.Errorf("%s event occurred at %v, want the sync's %v", data.Achievement.Code, event.OccurredAt, testNow)
+		}
+	}
+	if !unlocked["streak_7"] {
+		t.Errorf("streak_7 not unlocked; got %v", unlocked)
+	}
+
+	awards, err := env.achievementService.GetUserAchievements(t.Context(), user.ID)
+	if err != nil {
+		t.Fatalf("get achievements: %v", err)
+	}
+	for _, award := range awards {
+		if !award.AwardedAt.Equal(testNow) {
+			t.Errorf("%s awarded at %v, want the sync's %v", award.Code, award.AwardedAt, testNow)
+		}
+	}
+}
+
+// TestSyncUserAwardsAchievementsOnStoredHistory checks that achievements
+// count every stored submission, not only the few a judge reports at once.
+func TestSyncUserAwardsAchievementsOnStoredHistory(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.addUser(t, "alice", 1900)
+	env.lc.AddUser("alice_lc", 1700)
+	if _, err := env.userService.LinkAccount(t.Context(), alice.ID, "leetcode", "alice_lc"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	unlocked := func() bool {
+		for _, event := range env.events.ofType(domain.EventAchievementUnlocked) {
+			if event.Data.(domain.AchievementEventData).Achievement.Code == "monthly_50" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// LeetCode reports 20 solves at a time: sync after every batch, this
+	// month, of distinct problems.
+	for batch := range 3 {
+		for i := range leetcode.RecentLimit {
+			id := int64(batch*leetcode.RecentLimit + i)
+			env.lc.AddSubmissions("alice_lc", leetcodetest.Accepted(id, daysAgo(2-batch).Add(time.Duration(i)*time.Minute)))
+		}
+		if err := env.syncService.SyncUser(t.Context(), env.reloadUser(t, "alice")); err != nil {
+			t.Fatalf("sync: %v", err)
+		}
+		if batch < 2 && unlocked() {
+			t.Fatalf("monthly_50 unlocked after %d solves", (batch+1)*leetcode.RecentLimit)
+		}
+	}
+	if !unlocked() {
+		t.Errorf("monthly_50 not unlocked after %d stored solves", 3*leetcode.RecentLimit)
+	}
+}
+
+func TestSyncUserFailsOnAPIError(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*codeforcestest.Server)
+		want  string
+	}{
+		{
+			name: "server error",
+			setup: func(cf *codeforcestest.Server) {
+				cf.FailNext(codeforcestest.MethodUserStatus, http.StatusInternalServerError)
+			},
+			want: "500",
+		},
+		{
+			name:  "rate limited",
+			setup: func(cf *codeforcestest.Server) { cf.RateLimit(1) },
+			want:  "Call limit exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser(t, "um_nik", 3600, codeforcestest.Accepted(1, daysAgo(0)))
+
+			tt.setup(env.cf)
+			err := env.syncService.SyncUser(t.Context(), user)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("sync error = %v, want one mentioning %q", err, tt.want)
+			}
+
+			got := env.reloadUser(t, "um_nik")
+			if got.LastCheckedAt != nil || got.CurrentStreak != 0 {
+				t.Errorf("user modified by failed sync: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSyncUserToleratesUserInfoFailure(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "radewoosh", 3200, codeforcestest.Accepted(1, daysAgo(0)))
+
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "radewoosh", Rating: 3300}, codeforcestest.Accepted(1, daysAgo(0)))
+	env.cf.FailNext(codeforcestest.MethodUserInfo, http.StatusBadGateway)
+
+	if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	got := env.reloadUser(t, "radewoosh")
+	if got.CurrentStreak != 1 {
+		t.Errorf("current streak = %d, want 1", got.CurrentStreak)
+	}
+	if got.Rating != 3200 {
+		t.Errorf("rating = %d, want the previous 3200", got.Rating)
+	}
+}
+
+func TestRenamedHandle(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "oldname", 2000, codeforcestest.Accepted(1, daysAgo(0)))
+	env.cf.Rename("oldname", "newname")
+
+	if err := env.syncService.SyncUser(t.Context(), user); err == nil {
+		t.Error("sync of a renamed handle succeeded, want not found")
+	}
+
+	// Adding by the old handle resolves to the new one, as on Codeforces.
+	env.cf.AddUser(domain.CodeforcesUserInfo{Handle: "someone"})
+	env.cf.Rename("someone", "someone_else")
+	added, err := env.userService.AddUser(t.Context(), domain.JudgeCodeforces, "someone")
+	if err != nil {
+		t.Fatalf("add renamed user: %v", err)
+	}
+	if len(added.Accounts) != 1 || added.Accounts[0].Handle != "someone_else" {
+		t.Errorf("stored accounts = %+v, want someone_else", added.Accounts)
+	}
+}
+
+func TestSyncAllUsersContinuesPastFailures(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "alpha", 1500, codeforcestest.Accepted(1, daysAgo(0)))
+	env.addUser(t, "beta", 1600, codeforcestest.Accepted(2, daysAgo(0)), codeforcestest.Accepted(3, daysAgo(1)))
+	env.addUser(t, "gamma", 1700, codeforcestest.Accepted(4, daysAgo(0)))
+	env.cf.Rename("gamma", "delta")
+
+	if err := env.syncService.SyncAllUsers(t.Context()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+
+	for handle, want := range map[string]int{"alpha": 1, "beta": 2, "gamma": 0} {
+		if got := env.reloadUser(t, handle).CurrentStreak; got != want {
+			t.Errorf("%s streak = %d, want %d", handle, got, want)
+		}
+	}
+}
+
+func TestStreakAcrossMidnight(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, "nightowl", 1800, codeforcestest.Accepted(1, daysAgo(1)))
+
+	sync := func(step string, want int) {
+		t.Helper()
+
+		if err := env.syncService.SyncUser(t.Context(), user); err != nil {
+			t.Fatalf("%s: sync: %v", step, err)
+		}
+		if got := env.reloadUser(t, "nightowl").CurrentStreak; got != want {
+			t.Errorf("%s: current streak = %d, want %d", step, got, want)
+		}
+	}
+
+	sync("today not yet solved", 1)
+
+	env.clock.Advance(11*time.Hour + 59*time.Minute) // 23:59
+	env.cf.AddSubmissions("nightowl", codeforcestest.Accepted(2, env.clock.Now()))
+	sync("solved just before midnight", 2)
+
+	env.clock.Advance(2 * time.Minute)
+	sync("just after midnight", 2)
+
+	env.clock.Advance(24 * time.Hour)
+	sync("a full day without solving", 0)
+}
@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/service"
+)
+
+// webhookReceiver answers deliveries with the queued statuses, then 200, and
+// records whether each one carried a valid signature.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	secret   string
+	statuses []int
+	received []bool
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	t.Helper()
+
+	wr := &webhookReceiver{statuses: statuses}
+	wr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read delivery: %v", err)
+		}
+
+		wr.mu.Lock()
+		defer wr.mu.Unlock()
+
+		want := "sha256=" + service.SignWebhookPayload(wr.secret, r.Header.Get(service.WebhookTimestampHeader), body)
+		wr.received = append(wr.received, hmac.Equal([]byte(r.Header.Get(service.WebhookSignatureHeader)), []byte(want)))
+
+		status := http.StatusOK
+		if len(wr.statuses) > 0 {
+			status, wr.statuses = wr.statuses[0], wr.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(wr.Close)
+	return wr
+}
+
+// subscribe registers the receiver through the API and keeps the returned
+// signing secret.
+func (wr *webhookReceiver) subscribe(t *testing.T, env *testEnv) uint {
+	t.Helper()
+
+	rec := env.do(http.MethodPost, "/api/v1/webhooks", `{"url":"`+wr.URL+`","events":["user.added"]}`, "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("subscribe = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data struct {
+			ID     uint   `json:"id"`
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &resp)
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.secret = resp.Data.Secret
+	return resp.Data.ID
+}
+
+func (wr *webhookReceiver) deliveries() []bool {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]bool(nil), wr.received...)
+}
+
+func (env *testEnv) deliver(t *testing.T) {
+	t.Helper()
+	if err := env.webhookService.DeliverPending(t.Context()); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+}
+
+func (env *testEnv) deliveryLog(t *testing.T, id uint) []domain.WebhookDelivery {
+	t.Helper()
+
+	rec := env.do(http.MethodGet, "/api/v1/webhooks/"+strconv.FormatUint(uint64(id), 10)+"/deliveries", "", "Authorization", "Bearer "+testAdminKey)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("deliveries = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data []domain.WebhookDelivery `json:"data"`
+	}
+	decode(t, rec.Body.Bytes(), &resp)
+	return resp.Data
+}
+
+func TestWebhookDeliveryRetriesAfterServerError(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t, http.StatusBadGateway)
+	id := receiver.subscribe(t, env)
+
+	env.webhookService.Publish(t.Context(), domain.Event{ID: "evt-1", Type: domain.EventUserAdded, OccurredAt: testNow})
+	// Events the subscription did not ask for are not queued.
+	env.webhookService.Publish(t.Context(), domain.Event{ID: "evt-2", Type: domain.EventStreakBroken, OccurredAt: testNow})
+
+	env.deliver(t)
+	if got := receiver.deliveries(); len(got) != 1 || !got[0] {
+		t.Fatalf("deliveries after the first dispatch = %v, want one signed", got)
+	}
+
+	// The retry waits out the 30 second backoff.
+	env.clock.Advance(29 * time.Second)
+	env.deliver(t)
+	if got := receiver.deliveries(); len(got) != 1 {
+		t.Fatalf("retried before the backoff: %v", got)
+	}
+	env.clock.Advance(time.Second)
+	env.deliver(t)
+	if got := receiver.deliveries(); len(got) != 2 || !got[1] {
+		t.Fatalf("deliveries after the backoff = %v, want a second signed one", got)
+	}
+
+	// Delivered entries are not sent again.
+	env.clock.Advance(time.Hour)
+	env.deliver(t)
+	if got := receiver.deliveries(); len(got) != 2 {
+		t.Errorf("a delivered event was sent again: %v", got)
+	}
+
+	log := env.deliveryLog(t, id)
+	if len(log) != 2 {
+		t.Fatalf("delivery log = %+v, want two attempts", log)
+	}
+	statuses := map[int]int{}
+	for _, d := range log {
+		statuses[d.Attempt] = d.StatusCode
+	}
+	if statuses[1] != http.StatusBadGateway || statuses[2] != http.StatusOK {
+		t.Errorf("status by attempt = %v, want 502 then 200", statuses)
+	}
+}
+
+func TestWebhookDeliveryGivesUpAfterMaxAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t, 500, 500, 500, 500, 500)
+	id := receiver.subscribe(t, env)
+
+	env.webhookService.Publish(t.Context(), domain.Event{ID: "evt-1", Type: domain.EventUserAdded, OccurredAt: testNow})
+
+	// The test env allows three attempts; keep dispatching well past them.
+	for range 6 {
+		env.deliver(t)
+		env.clock.Advance(6 * time.Hour)
+	}
+
+	if got := receiver.deliveries(); len(got) != 3 {
+		t.Errorf("receiver got %d deliveries, want 3", len(got))
+	}
+	if log := env.deliveryLog(t, id); len(log) != 3 {
+		t.Errorf("delivery log has %d attempts, want 3", len(log))
+	}
+}
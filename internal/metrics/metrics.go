@@ -0,0 +1,120 @@
+// Package metrics defines the Prometheus collectors exported on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "codestreaks"
+
+var (
+	// Registry holds every collector below plus the Go runtime and process
+	// collectors. It is separate from the global registry so tests and
+	// libraries cannot leak metrics into it.
+	Registry = prometheus.NewRegistry()
+
+	SyncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of a full sync of all active users.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	})
+
+	LastSyncTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "Unix time at which the last full sync finished.",
+	})
+
+	SyncedUsers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "synced_users_total",
+		Help:      "Users synced, by result.",
+	}, []string{"result"})
+
+	SyncErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_errors_total",
+		Help:      "Judge accounts that could not be read during a sync, by judge. Failing users are named in the logs.",
+	}, []string{"judge"})
+
+	ActiveStreakUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streak_users",
+		Help:      "Active users whose current streak is above zero, as of the last sync.",
+	})
+
+	CodeforcesRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "codeforces_request_duration_seconds",
+		Help:      "Latency of Codeforces API calls, by API method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	CodeforcesRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "codeforces_requests_total",
+		Help:      "Codeforces API calls, by API method and HTTP status code (\"error\" when no response arrived).",
+	}, []string{"method", "code"})
+
+	RateLimiterWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "codeforces_rate_limiter_wait_seconds",
+		Help:      "Time Codeforces API calls spent waiting for the client-side rate limiter.",
+		Buckets:   []float64{0, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database statements, by operation.",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"operation"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	StreamSubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_subscribers",
+		Help:      "Clients connected to the live event stream over SSE or WebSocket.",
+	})
+
+	StreamDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_dropped_subscribers_total",
+		Help:      "Stream clients disconnected because they fell behind.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SyncDuration,
+		LastSyncTimestamp,
+		SyncedUsers,
+		SyncErrors,
+		ActiveStreakUsers,
+		CodeforcesRequestDuration,
+		CodeforcesRequests,
+		RateLimiterWait,
+		DBQueryDuration,
+		HTTPRequestDuration,
+		StreamSubscribers,
+		StreamDropped,
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,195 @@
+// Package ratelimit meters requests per client with token buckets. A bucket
+// holds up to Burst tokens and refills at PerMinute tokens a minute; every
+// request takes one token.
+//
+// Buckets are kept as GCRA theoretical arrival times, which behave exactly
+// like token buckets but take a single number per bucket to store.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis"
+)
+
+// Backends selectable through RATE_LIMIT_BACKEND.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendNone   = "none"
+)
+
+// Limit is the size and refill rate of a bucket. A PerMinute of 0 or less
+// means no limit.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Unlimited reports whether the limit lets every request through.
+func (l Limit) Unlimited() bool {
+	return l.PerMinute <= 0
+}
+
+// interval is the time it takes to refill one token.
+func (l Limit) interval() time.Duration {
+	return time.Minute / time.Duration(l.PerMinute)
+}
+
+// window is the time it takes to refill a whole bucket.
+func (l Limit) window() time.Duration {
+	return l.interval() * time.Duration(max(l.Burst, 1))
+}
+
+type Limiter interface {
+	// Allow takes a token from the bucket under key. When the bucket is
+	// empty it returns false and how long until a token is available.
+	Allow(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// New builds the limiter for a backend name. redisURL is only used by the
+// redis backend.
+func New(backend, redisURL string, clock clock.Clock) (Limiter, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemory(clock), nil
+	case BackendRedis:
+		client, err := redis.NewClient(redisURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedis(client, clock), nil
+	case BackendNone:
+		return Disabled(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", backend)
+	}
+}
+
+// minSweep is how many buckets the memory limiter holds before it first
+// drops the full ones.
+const minSweep = 1024
+
+type memoryLimiter struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	tats    map[string]time.Time
+	sweepAt int
+}
+
+// NewMemory keeps buckets in process, so each replica limits on its own.
+// Full buckets are dropped as the map grows, which bounds memory by the
+// number of clients active within one window.
+func NewMemory(clock clock.Clock) Limiter {
+	return &memoryLimiter{
+		clock:   clock,
+		tats:    make(map[string]time.Time),
+		sweepAt: minSweep,
+	}
+}
+
+func (l *memoryLimiter) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	tat := l.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	tat = tat.Add(limit.interval())
+	if ahead := tat.Sub(now); ahead > limit.window() {
+		return false, ahead - limit.window(), nil
+	}
+
+	if _, exists := l.tats[key]; !exists && len(l.tats) >= l.sweepAt {
+		l.sweep(now)
+	}
+	l.tats[key] = tat
+	return true, 0, nil
+}
+
+// sweep drops the buckets that have refilled completely. l.mu must be held.
+func (l *memoryLimiter) sweep(now time.Time) {
+	for key, tat := range l.tats {
+		if !tat.After(now) {
+			delete(l.tats, key)
+		}
+	}
+	l.sweepAt = max(2*len(l.tats), minSweep)
+}
+
+// redisLimiter shares buckets between replicas. Each bucket is an integer
+// key holding its theoretical arrival time in Unix milliseconds, expiring
+// when the bucket is full again. Replicas' clocks are assumed to agree to
+// well within a refill interval.
+type redisLimiter struct {
+	client *redis.Client
+	clock  clock.Clock
+}
+
+const redisKeyPrefix = "codestreaks:ratelimit:"
+
+func NewRedis(client *redis.Client, clock clock.Clock) Limiter {
+	return &redisLimiter{client: client, clock: clock}
+}
+
+// Allow reserves a token with a single INCRBY and hands it back if the
+// bucket was empty. Concurrent requests on a bucket that has just become
+// full may both reset it, letting one extra request through; that is the
+// price of not needing server-side scripts.
+func (l *redisLimiter) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	key = redisKeyPrefix + key
+	now := l.clock.Now().UnixMilli()
+	step := max(limit.interval().Milliseconds(), 1)
+	window := step * int64(max(limit.Burst, 1))
+
+	tat, err := l.client.IncrBy(ctx, key, step)
+	if err != nil {
+		return false, 0, err
+	}
+
+	// The bucket was full, or did not exist: start it over from now.
+	if tat-step < now {
+		tat = now + step
+		err := l.client.Set(ctx, key, []byte(strconv.FormatInt(tat, 10)), time.Duration(step)*time.Millisecond)
+		return err == nil, 0, err
+	}
+
+	if ahead := tat - now; ahead > window {
+		if _, err := l.client.IncrBy(ctx, key, -step); err != nil {
+			return false, 0, err
+		}
+		return false, time.Duration(ahead-window) * time.Millisecond, nil
+	}
+
+	if _, err := l.client.Expire(ctx, key, time.Duration(tat-now)*time.Millisecond); err != nil {
+		return false, 0, err
+	}
+	return true, 0, nil
+}
+
+type disabledLimiter struct{}
+
+// Disabled returns a limiter that lets every request through.
+func Disabled() Limiter {
+	return disabledLimiter{}
+}
+
+func (disabledLimiter) Allow(context.Context, string, Limit) (bool, time.Duration, error) {
+	return true, 0, nil
+}
@@ -0,0 +1,159 @@
+package ratelimit_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/ratelimit"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis/redistest"
+)
+
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC))
+}
+
+func newRedisLimiter(t *testing.T, srv *redistest.Server, clock clock.Clock) ratelimit.Limiter {
+	t.Helper()
+
+	client, err := redis.NewClient(srv.URL())
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return ratelimit.NewRedis(client, clock)
+}
+
+func mustAllow(t *testing.T, l ratelimit.Limiter, key string, limit ratelimit.Limit) (bool, time.Duration) {
+	t.Helper()
+
+	allowed, retryAfter, err := l.Allow(t.Context(), key, limit)
+	if err != nil {
+		t.Fatalf("allow %s: %v", key, err)
+	}
+	return allowed, retryAfter
+}
+
+func TestTokenBucket(t *testing.T) {
+	backends := map[string]func(t *testing.T, clock clock.Clock) ratelimit.Limiter{
+		"memory": func(_ *testing.T, clock clock.Clock) ratelimit.Limiter {
+			return ratelimit.NewMemory(clock)
+		},
+		"redis": func(t *testing.T, clock clock.Clock) ratelimit.Limiter {
+			srv := redistest.NewServer()
+			t.Cleanup(srv.Close)
+			return newRedisLimiter(t, srv, clock)
+		},
+	}
+
+	// A token every 10 seconds, up to 3 at once.
+	limit := ratelimit.Limit{PerMinute: 6, Burst: 3}
+
+	for name, newLimiter := range backends {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeClock()
+			l := newLimiter(t, fake)
+
+			for i := range limit.Burst {
+				if allowed, _ := mustAllow(t, l, "ip:a", limit); !allowed {
+					t.Fatalf("request %d of the burst was refused", i+1)
+				}
+			}
+			allowed, retryAfter := mustAllow(t, l, "ip:a", limit)
+			if allowed || retryAfter != 10*time.Second {
+				t.Errorf("request past the burst = %v, retry after %v; want refused, 10s", allowed, retryAfter)
+			}
+			if allowed, _ := mustAllow(t, l, "ip:b", limit); !allowed {
+				t.Error("another client's bucket was drained too")
+			}
+
+			// A refused request does not cost a token.
+			fake.Advance(4 * time.Second)
+			if _, retryAfter := mustAllow(t, l, "ip:a", limit); retryAfter != 6*time.Second {
+				t.Errorf("retry after = %v, want 6s", retryAfter)
+			}
+			fake.Advance(6 * time.Second)
+			if allowed, _ := mustAllow(t, l, "ip:a", limit); !allowed {
+				t.Error("the refilled token was refused")
+			}
+			if allowed, _ := mustAllow(t, l, "ip:a", limit); allowed {
+				t.Error("a second token was handed out after refilling one")
+			}
+
+			// A long pause refills the bucket, but no further than the burst.
+			fake.Advance(time.Hour)
+			for i := range limit.Burst {
+				if allowed, _ := mustAllow(t, l, "ip:a", limit); !allowed {
+					t.Fatalf("request %d after a pause was refused", i+1)
+				}
+			}
+			if allowed, _ := mustAllow(t, l, "ip:a", limit); allowed {
+				t.Error("the bucket held more than the burst after a pause")
+			}
+
+			if allowed, _ := mustAllow(t, l, "ip:a", ratelimit.Limit{}); !allowed {
+				t.Error("an unlimited request was refused")
+			}
+		})
+	}
+}
+
+func TestRedisBucketsSharedAcrossReplicas(t *testing.T) {
+	srv := redistest.NewServer()
+	t.Cleanup(srv.Close)
+	fake := newFakeClock()
+	a, b := newRedisLimiter(t, srv, fake), newRedisLimiter(t, srv, fake)
+	limit := ratelimit.Limit{PerMinute: 1, Burst: 2}
+
+	if allowed, _ := mustAllow(t, a, "ip:a", limit); !allowed {
+		t.Fatal("first request refused")
+	}
+	if allowed, _ := mustAllow(t, b, "ip:a", limit); !allowed {
+		t.Fatal("second request refused")
+	}
+	if allowed, _ := mustAllow(t, a, "ip:a", limit); allowed {
+		t.Error("replica a let a third request through")
+	}
+
+	keys := srv.Keys()
+	if len(keys) != 1 || keys[0] != "codestreaks:ratelimit:ip:a" {
+		t.Errorf("keys = %v, want the one bucket", keys)
+	}
+}
+
+func TestRedisLimiterUnavailable(t *testing.T) {
+	srv := redistest.NewServer()
+	l := newRedisLimiter(t, srv, clock.System())
+	srv.Close()
+
+	if _, _, err := l.Allow(t.Context(), "ip:a", ratelimit.Limit{PerMinute: 1, Burst: 1}); err == nil {
+		t.Error("allow against a stopped server succeeded")
+	}
+}
+
+func TestMemoryLimiterDropsFullBuckets(t *testing.T) {
+	fake := newFakeClock()
+	l := ratelimit.NewMemory(fake)
+	limit := ratelimit.Limit{PerMinute: 60, Burst: 1}
+
+	for i := range 2000 {
+		mustAllow(t, l, "ip:"+strconv.Itoa(i), limit)
+	}
+	fake.Advance(time.Second)
+	// Sweeping the refilled buckets must not forget a drained one.
+	mustAllow(t, l, "ip:drained", limit)
+	for i := range 2000 {
+		mustAllow(t, l, "ip:new"+strconv.Itoa(i), limit)
+	}
+	if allowed, _ := mustAllow(t, l, "ip:drained", limit); allowed {
+		t.Error("a drained bucket was dropped")
+	}
+}
+
+func TestNewUnknownBackend(t *testing.T) {
+	if _, err := ratelimit.New("leaky", "", clock.System()); err == nil {
+		t.Error("New accepted an unknown backend")
+	}
+}
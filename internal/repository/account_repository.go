@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	Create(ctx context.Context, account *domain.JudgeAccount) error
+	Update(ctx context.Context, account *domain.JudgeAccount) error
+	Delete(ctx context.Context, account *domain.JudgeAccount) error
+	FindByUser(ctx context.Context, userID uint) ([]domain.JudgeAccount, error)
+	FindByJudgeHandle(ctx context.Context, judge, handle string) (*domain.JudgeAccount, error)
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) Create(ctx context.Context, account *domain.JudgeAccount) error {
+	return r.db.WithContext(ctx).Create(account).Error
+}
+
+func (r *accountRepository) Update(ctx context.Context, account *domain.JudgeAccount) error {
+	return r.db.WithContext(ctx).Save(account).Error
+}
+
+func (r *accountRepository) Delete(ctx context.Context, account *domain.JudgeAccount) error {
+	return r.db.WithContext(ctx).Delete(account).Error
+}
+
+// FindByUser returns a user's accounts in the order they were linked, so the
+// Codeforces account the user was added with comes first.
+func (r *accountRepository) FindByUser(ctx context.Context, userID uint) ([]domain.JudgeAccount, error) {
+	var accounts []domain.JudgeAccount
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// FindByJudgeHandle looks up the account linked to a handle on a judge,
+// whichever user it belongs to.
+func (r *accountRepository) FindByJudgeHandle(ctx context.Context, judge, handle string) (*domain.JudgeAccount, error) {
+	var account domain.JudgeAccount
+	err := r.db.WithContext(ctx).Where("judge = ? AND handle = ?", judge, handle).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
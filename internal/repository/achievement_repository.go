@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AchievementRepository interface {
+	Award(ctx context.Context, achievements []domain.UserAchievement) error
+	GetUserAchievements(ctx context.Context, userID uint) ([]domain.UserAchievement, error)
+	GetAchievementsForUsers(ctx context.Context, userIDs []uint) ([]domain.UserAchievement, error)
+}
+
+type achievementRepository struct {
+	db *gorm.DB
+}
+
+func NewAchievementRepository(db *gorm.DB) AchievementRepository {
+	return &achievementRepository{db: db}
+}
+
+// Award stores new awards, silently skipping any the user already holds.
+func (r *achievementRepository) Award(ctx context.Context, achievements []domain.UserAchievement) error {
+	if len(achievements) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&achievements).Error
+}
+
+func (r *achievementRepository) GetUserAchievements(ctx context.Context, userID uint) ([]domain.UserAchievement, error) {
+	var achievements []domain.UserAchievement
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("awarded_at ASC").
+		Find(&achievements).Error
+	return achievements, err
+}
+
+func (r *achievementRepository) GetAchievementsForUsers(ctx context.Context, userIDs []uint) ([]domain.UserAchievement, error) {
+	var achievements []domain.UserAchievement
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).
+		Order("awarded_at ASC").
+		Find(&achievements).Error
+	return achievements, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+)
+
+type ClaimRepository interface {
+	Create(ctx context.Context, claim *domain.HandleClaim) error
+	Resolve(ctx context.Context, claim *domain.HandleClaim) (bool, error)
+	ExpireOthers(ctx context.Context, claim *domain.HandleClaim) error
+	FindByID(ctx context.Context, id uint) (*domain.HandleClaim, error)
+	FindPending(ctx context.Context) ([]domain.HandleClaim, error)
+	FindPendingByHandle(ctx context.Context, handle string) ([]domain.HandleClaim, error)
+	FindLatestByHandle(ctx context.Context, handle string) (*domain.HandleClaim, error)
+	FindLatestVerified(ctx context.Context, userID uint) (*domain.HandleClaim, error)
+}
+
+type claimRepository struct {
+	db *gorm.DB
+}
+
+func NewClaimRepository(db *gorm.DB) ClaimRepository {
+	return &claimRepository{db: db}
+}
+
+func (r *claimRepository) Create(ctx context.Context, claim *domain.HandleClaim) error {
+	return r.db.WithContext(ctx).Create(claim).Error
+}
+
+// Resolve stores a pending claim's new status. It reports false, and stores
+// nothing, if the claim was resolved in the meantime, so of two checks
+// racing on a claim only one wins.
+func (r *claimRepository) Resolve(ctx context.Context, claim *domain.HandleClaim) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.HandleClaim{}).
+		Where("id = ? AND status = ?", claim.ID, domain.ClaimPending).
+		Updates(map[string]any{
+			"status":      claim.Status,
+			"user_id":     claim.UserID,
+			"verified_at": claim.VerifiedAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// ExpireOthers expires every pending claim on claim's handle except claim
+// itself.
+func (r *claimRepository) ExpireOthers(ctx context.Context, claim *domain.HandleClaim) error {
+	return r.db.WithContext(ctx).Model(&domain.HandleClaim{}).
+		Where("handle = ? AND id <> ? AND status = ?", claim.Handle, claim.ID, domain.ClaimPending).
+		Update("status", domain.ClaimExpired).Error
+}
+
+func (r *claimRepository) FindByID(ctx context.Context, id uint) (*domain.HandleClaim, error) {
+	var claim domain.HandleClaim
+	err := r.db.WithContext(ctx).First(&claim, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// FindPending returns every claim still waiting for its challenge, oldest
+// first.
+func (r *claimRepository) FindPending(ctx context.Context) ([]domain.HandleClaim, error) {
+	var claims []domain.HandleClaim
+	err := r.db.WithContext(ctx).Where("status = ?", domain.ClaimPending).Order("id ASC").Find(&claims).Error
+	return claims, err
+}
+
+func (r *claimRepository) FindPendingByHandle(ctx context.Context, handle string) ([]domain.HandleClaim, error) {
+	var claims []domain.HandleClaim
+	err := r.db.WithContext(ctx).
+		Where("handle = ? AND status = ?", handle, domain.ClaimPending).
+		Order("id ASC").
+		Find(&claims).Error
+	return claims, err
+}
+
+// FindLatestByHandle returns the newest claim on a handle, whatever its
+// status.
+func (r *claimRepository) FindLatestByHandle(ctx context.Context, handle string) (*domain.HandleClaim, error) {
+	var claim domain.HandleClaim
+	err := r.db.WithContext(ctx).Where("handle = ?", handle).Order("id DESC").First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// FindLatestVerified returns the most recently verified claim on a user's
+// handle, the one whose key identifies the owner.
+func (r *claimRepository) FindLatestVerified(ctx context.Context, userID uint) (*domain.HandleClaim, error) {
+	var claim domain.HandleClaim
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, domain.ClaimVerified).
+		Order("verified_at DESC, id DESC").
+		First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
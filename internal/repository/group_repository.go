@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GroupRepository interface {
+	Create(ctx context.Context, group *domain.Group) error
+	FindByName(ctx context.Context, name string) (*domain.Group, error)
+	List(ctx context.Context) ([]domain.Group, error)
+	AddMember(ctx context.Context, groupID, userID uint) error
+	RemoveMember(ctx context.Context, groupID, userID uint) error
+	GetMemberships(ctx context.Context, userIDs []uint) ([]domain.GroupMember, error)
+	GetRankedMemberIDs(ctx context.Context, groupIDs []uint) (map[uint][]uint, error)
+}
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) GroupRepository {
+	return &groupRepository{db: db}
+}
+
+func (r *groupRepository) Create(ctx context.Context, group *domain.Group) error {
+	return r.db.WithContext(ctx).Create(group).Error
+}
+
+func (r *groupRepository) FindByName(ctx context.Context, name string) (*domain.Group, error) {
+	var group domain.Group
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *groupRepository) List(ctx context.Context) ([]domain.Group, error) {
+	var groups []domain.Group
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&groups).Error
+	return groups, err
+}
+
+func (r *groupRepository) AddMember(ctx context.Context, groupID, userID uint) error {
+	member := domain.GroupMember{GroupID: groupID, UserID: userID}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, userID uint) error {
+	result := r.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&domain.GroupMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetMemberships returns the group memberships of several users with their
+// groups loaded, ordered by group name.
+func (r *groupRepository) GetMemberships(ctx context.Context, userIDs []uint) ([]domain.GroupMember, error) {
+	var members []domain.GroupMember
+	err := r.db.WithContext(ctx).Joins("Group").
+		Where("group_members.user_id IN ?", userIDs).
+		Order("\"Group\".\"name\" ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// GetRankedMemberIDs returns the active members of each group in
+// leaderboard order, so a member's group rank is its index plus one.
+func (r *groupRepository) GetRankedMemberIDs(ctx context.Context, groupIDs []uint) (map[uint][]uint, error) {
+	var rows []struct {
+		GroupID uint
+		UserID  uint
+	}
+	err := r.db.WithContext(ctx).Table("group_members").
+		Select("group_members.group_id, users.id AS user_id").
+		Joins("JOIN users ON users.id = group_members.user_id").
+		Where("group_members.group_id IN ? AND users.is_active = ?", groupIDs, true).
+		Order("group_members.group_id ASC, " + qualifiedLeaderboardOrder("users")).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make(map[uint][]uint)
+	for _, row := range rows {
+		ranked[row.GroupID] = append(ranked[row.GroupID], row.UserID)
+	}
+	return ranked, nil
+}
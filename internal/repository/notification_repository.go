@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	Upsert(ctx context.Context, pref *domain.NotificationPreference) error
+	Delete(ctx context.Context, userID uint, channel string) error
+	GetUserPreferences(ctx context.Context, userID uint) ([]domain.NotificationPreference, error)
+	GetEnabledPreferences(ctx context.Context) ([]domain.NotificationPreference, error)
+	MarkNotified(ctx context.Context, id uint, date string) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Upsert(ctx context.Context, pref *domain.NotificationPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target", "hours_before", "enabled", "updated_at"}),
+	}).Create(pref).Error
+}
+
+func (r *notificationRepository) Delete(ctx context.Context, userID uint, channel string) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND channel = ?", userID, channel).
+		Delete(&domain.NotificationPreference{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetUserPreferences(ctx context.Context, userID uint) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("channel ASC").
+		Find(&prefs).Error
+	return prefs, err
+}
+
+// GetEnabledPreferences returns enabled preferences of active users with the
+// owning user and their accounts preloaded.
+func (r *notificationRepository) GetEnabledPreferences(ctx context.Context) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	err := r.db.WithContext(ctx).Joins("User").
+		Preload("User.Accounts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Where("notification_preferences.enabled = ? AND \"User\".is_active = ?", true, true).
+		Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *notificationRepository) MarkNotified(ctx context.Context, id uint, date string) error {
+	return r.db.WithContext(ctx).Model(&domain.NotificationPreference{}).
+		Where("id = ?", id).
+		Update("last_notified_on", date).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SnapshotRepository interface {
+	SaveAll(ctx context.Context, snapshots []domain.LeaderboardSnapshot) error
+	GetLatestBefore(ctx context.Context, date time.Time, userIDs []uint) (map[uint]domain.LeaderboardSnapshot, error)
+	DeleteBefore(ctx context.Context, date time.Time) (int64, error)
+}
+
+type snapshotRepository struct {
+	db *gorm.DB
+}
+
+func NewSnapshotRepository(db *gorm.DB) SnapshotRepository {
+	return &snapshotRepository{db: db}
+}
+
+// SaveAll upserts snapshots, so re-running the daily job for the same date
+// overwrites that day's rows instead of failing on the unique index.
+func (r *snapshotRepository) SaveAll(ctx context.Context, snapshots []domain.LeaderboardSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "snapshot_date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"leaderboard_rank", "current_streak", "max_streak", "rating"}),
+		}).CreateInBatches(snapshots, 100).Error
+	})
+}
+
+// GetLatestBefore returns, keyed by user ID, the rows of the most recent
+// snapshot taken strictly before date. Users missing from that snapshot are
+// absent from the map.
+func (r *snapshotRepository) GetLatestBefore(ctx context.Context, date time.Time, userIDs []uint) (map[uint]domain.LeaderboardSnapshot, error) {
+	result := make(map[uint]domain.LeaderboardSnapshot)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	var latest domain.LeaderboardSnapshot
+	err := r.db.WithContext(ctx).Where("snapshot_date < ?", date).
+		Order("snapshot_date DESC").
+		First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []domain.LeaderboardSnapshot
+	err = r.db.WithContext(ctx).Where("snapshot_date = ? AND user_id IN ?", latest.SnapshotDate, userIDs).
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapshot := range snapshots {
+		result[snapshot.UserID] = snapshot
+	}
+	return result, nil
+}
+
+func (r *snapshotRepository) DeleteBefore(ctx context.Context, date time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("snapshot_date < ?", date).Delete(&domain.LeaderboardSnapshot{})
+	return result.RowsAffected, result.Error
+}
@@ -1,19 +1,27 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SubmissionRepository interface {
-	Create(submission *domain.Submission) error
-	BulkCreate(submissions []domain.Submission) error
-	FindByCodeforcesID(cfID int64) (*domain.Submission, error)
-	GetUserSubmissions(userID uint, limit int) ([]domain.Submission, error)
-	GetLatestSubmissionForUser(userID uint) (*domain.Submission, error)
-	GetSubmissionsAfter(userID uint, after time.Time) ([]domain.Submission, error)
+	Create(ctx context.Context, submission *domain.Submission) error
+	BulkCreate(ctx context.Context, submissions []domain.Submission) error
+	FindByJudgeID(ctx context.Context, judge string, id int64) (*domain.Submission, error)
+	UpdateDetails(ctx context.Context, submission *domain.Submission) error
+	GetUserSubmissions(ctx context.Context, userID uint, limit int) ([]domain.Submission, error)
+	GetUserSubmissionsPage(ctx context.Context, userID uint, limit, offset int) ([]domain.Submission, error)
+	CountUserSubmissions(ctx context.Context, userID uint) (int64, error)
+	GetRecentSubmissionsForUsers(ctx context.Context, userIDs []uint, limit int) ([]domain.Submission, error)
+	GetSubmissionsSinceForUsers(ctx context.Context, userIDs []uint, since time.Time) ([]domain.Submission, error)
+	GetLatestSubmissionForUser(ctx context.Context, userID uint) (*domain.Submission, error)
+	GetSubmissionsAfter(ctx context.Context, userID uint, after time.Time) ([]domain.Submission, error)
+	HasAcceptedSince(ctx context.Context, userID uint, since time.Time) (bool, error)
 }
 
 type submissionRepository struct {
@@ -24,42 +32,92 @@ func NewSubmissionRepository(db *gorm.DB) SubmissionRepository {
 	return &submissionRepository{db: db}
 }
 
-func (r *submissionRepository) Create(submission *domain.Submission) error {
-	return r.db.Create(submission).Error
+func (r *submissionRepository) Create(ctx context.Context, submission *domain.Submission) error {
+	return r.db.WithContext(ctx).Create(submission).Error
 }
 
-func (r *submissionRepository) BulkCreate(submissions []domain.Submission) error {
+// BulkCreate stores submissions, skipping any that are already stored, as
+// another replica's sync of the same user may have just done.
+func (r *submissionRepository) BulkCreate(ctx context.Context, submissions []domain.Submission) error {
 	if len(submissions) == 0 {
 		return nil
 	}
 
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Use CreateInBatches for better performance
-		return tx.CreateInBatches(submissions, 100).Error
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(submissions, 100).Error
 	})
 }
 
-func (r *submissionRepository) FindByCodeforcesID(cfID int64) (*domain.Submission, error) {
+func (r *submissionRepository) FindByJudgeID(ctx context.Context, judge string, id int64) (*domain.Submission, error) {
 	var submission domain.Submission
-	err := r.db.Where("codeforces_submission_id = ?", cfID).First(&submission).Error
+	err := r.db.WithContext(ctx).Where("judge = ? AND codeforces_submission_id = ?", judge, id).First(&submission).Error
 	if err != nil {
 		return nil, err
 	}
 	return &submission, nil
 }
 
-func (r *submissionRepository) GetUserSubmissions(userID uint, limit int) ([]domain.Submission, error) {
+// UpdateDetails saves a stored submission's problem ID, problem rating and
+// language.
+func (r *submissionRepository) UpdateDetails(ctx context.Context, submission *domain.Submission) error {
+	return r.db.WithContext(ctx).Model(submission).
+		Select("problem_id", "problem_rating", "language").
+		Updates(submission).Error
+}
+
+func (r *submissionRepository) GetUserSubmissions(ctx context.Context, userID uint, limit int) ([]domain.Submission, error) {
 	var submissions []domain.Submission
-	err := r.db.Where("user_id = ?", userID).
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
 		Order("submitted_at DESC").
 		Limit(limit).
 		Find(&submissions).Error
 	return submissions, err
 }
 
-func (r *submissionRepository) GetLatestSubmissionForUser(userID uint) (*domain.Submission, error) {
+// GetUserSubmissionsPage returns one page of a user's submissions, newest
+// first. The id tiebreak keeps pages stable for equal timestamps.
+func (r *submissionRepository) GetUserSubmissionsPage(ctx context.Context, userID uint, limit, offset int) ([]domain.Submission, error) {
+	var submissions []domain.Submission
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("submitted_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *submissionRepository) CountUserSubmissions(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Submission{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// GetRecentSubmissionsForUsers returns up to limit of the newest submissions
+// of each user in one query, ordered by user and then newest first.
+func (r *submissionRepository) GetRecentSubmissionsForUsers(ctx context.Context, userIDs []uint, limit int) ([]domain.Submission, error) {
+	var submissions []domain.Submission
+	numbered := r.db.Model(&domain.Submission{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY submitted_at DESC, id DESC) AS row_num").
+		Where("user_id IN ?", userIDs)
+	err := r.db.WithContext(ctx).Table("(?) AS numbered", numbered).
+		Where("row_num <= ?", limit).
+		Order("user_id ASC, submitted_at DESC, id DESC").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *submissionRepository) GetSubmissionsSinceForUsers(ctx context.Context, userIDs []uint, since time.Time) ([]domain.Submission, error) {
+	var submissions []domain.Submission
+	err := r.db.WithContext(ctx).Where("user_id IN ? AND submitted_at >= ?", userIDs, since.UTC()).
+		Order("submitted_at ASC").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *submissionRepository) GetLatestSubmissionForUser(ctx context.Context, userID uint) (*domain.Submission, error) {
 	var submission domain.Submission
-	err := r.db.Where("user_id = ?", userID).
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
 		Order("submitted_at DESC").
 		First(&submission).Error
 	if err != nil {
@@ -68,10 +126,19 @@ func (r *submissionRepository) GetLatestSubmissionForUser(userID uint) (*domain.
 	return &submission, nil
 }
 
-func (r *submissionRepository) GetSubmissionsAfter(userID uint, after time.Time) ([]domain.Submission, error) {
+func (r *submissionRepository) GetSubmissionsAfter(ctx context.Context, userID uint, after time.Time) ([]domain.Submission, error) {
 	var submissions []domain.Submission
-	err := r.db.Where("user_id = ? AND submitted_at > ?", userID, after).
+	err := r.db.WithContext(ctx).Where("user_id = ? AND submitted_at > ?", userID, after.UTC()).
 		Order("submitted_at ASC").
 		Find(&submissions).Error
 	return submissions, err
 }
+
+func (r *submissionRepository) HasAcceptedSince(ctx context.Context, userID uint, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Submission{}).
+		Where("user_id = ? AND verdict = ? AND submitted_at >= ?", userID, "OK", since.UTC()).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
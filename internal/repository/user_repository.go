@@ -1,21 +1,88 @@
 package repository
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
-	Create(user *domain.User) error
-	Update(user *domain.User) error
-	FindByHandle(handle string) (*domain.User, error)
-	FindByID(id uint) (*domain.User, error)
-	GetLeaderboard(limit, offset int) ([]domain.User, error)
-	GetAllActiveUsers() ([]domain.User, error)
-	CountUsers() (int64, error)
-	BulkUpdate(users []domain.User) error
+	Create(ctx context.Context, user *domain.User, account *domain.JudgeAccount) error
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, user *domain.User) error
+	FindByID(ctx context.Context, id uint) (*domain.User, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]domain.User, error)
+	FindByAccount(ctx context.Context, judge, handle string) (*domain.User, error)
+	GetLeaderboard(ctx context.Context, filter LeaderboardFilter, limit, offset int) ([]domain.User, error)
+	GetRankedUsersAfter(ctx context.Context, filter LeaderboardFilter, afterID uint, limit int) ([]RankedUser, error)
+	GetRankedUsers(ctx context.Context) ([]domain.User, error)
+	GetUserRank(ctx context.Context, user *domain.User) (int, error)
+	GetUserRanks(ctx context.Context, userIDs []uint) (map[uint]int, error)
+	GetAllActiveUsers(ctx context.Context) ([]domain.User, error)
+	CountUsers(ctx context.Context, filter LeaderboardFilter) (int64, error)
+	BulkUpdate(ctx context.Context, users []domain.User) error
+}
+
+// leaderboardOrder is the ranking used everywhere a leaderboard position is
+// derived. The trailing id keeps ties stable between requests and snapshots.
+const leaderboardOrder = "current_streak DESC, max_streak DESC, rating DESC, id ASC"
+
+// qualifiedLeaderboardOrder is leaderboardOrder for a query that joins the
+// users table under the given name.
+func qualifiedLeaderboardOrder(table string) string {
+	terms := strings.Split(leaderboardOrder, ", ")
+	for i, term := range terms {
+		terms[i] = table + "." + term
+	}
+	return strings.Join(terms, ", ")
+}
+
+// leaderboardSortOrders are the orders a leaderboard page can be read in,
+// by domain sort name. Ties fall back to the other columns and then id.
+var leaderboardSortOrders = map[string]string{
+	domain.SortCurrentStreak: leaderboardOrder,
+	domain.SortMaxStreak:     "max_streak DESC, current_streak DESC, rating DESC, id ASC",
+	domain.SortRating:        "rating DESC, current_streak DESC, max_streak DESC, id ASC",
+}
+
+// LeaderboardFilter narrows the set of ranked users and picks the order they
+// are read in. The zero value ranks every active user by leaderboardOrder.
+type LeaderboardFilter struct {
+	GroupID uint
+	// Sort is one of domain.LeaderboardSorts; empty means SortCurrentStreak.
+	Sort string
+}
+
+// DefaultRanking reports whether the filter ranks every active user in
+// leaderboardOrder, the ranking snapshots store.
+func (f LeaderboardFilter) DefaultRanking() bool {
+	return f.GroupID == 0 && (f.Sort == "" || f.Sort == domain.SortCurrentStreak)
+}
+
+func (f LeaderboardFilter) order() string {
+	if order, ok := leaderboardSortOrders[f.Sort]; ok {
+		return order
+	}
+	return leaderboardOrder
+}
+
+func (f LeaderboardFilter) apply(db *gorm.DB) *gorm.DB {
+	db = db.Where("is_active = ?", true)
+	if f.GroupID != 0 {
+		db = db.Where("id IN (SELECT user_id FROM group_members WHERE group_id = ?)", f.GroupID)
+	}
+	return db
+}
+
+// RankedUser is a user together with their leaderboard position under a
+// LeaderboardFilter.
+type RankedUser struct {
+	domain.User
+	LeaderboardRank int
 }
 
 type userRepository struct {
@@ -26,59 +93,202 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *domain.User) error {
-	return r.db.Create(user).Error
+// withAccounts loads each user's judge accounts along with them, in the
+// order they were linked.
+func withAccounts(db *gorm.DB) *gorm.DB {
+	return db.Preload("Accounts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	})
+}
+
+// Create stores a person together with the first judge account they are
+// tracked by.
+func (r *userRepository) Create(ctx context.Context, user *domain.User, account *domain.JudgeAccount) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(user).Error; err != nil {
+			return err
+		}
+		account.UserID = user.ID
+		if err := tx.Create(account).Error; err != nil {
+			return err
+		}
+		user.Accounts = []domain.JudgeAccount{*account}
+		return nil
+	})
+}
+
+// Update saves the user's own columns; accounts are saved through the
+// AccountRepository.
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(user).Error
 }
 
-func (r *userRepository) Update(user *domain.User) error {
-	return r.db.Save(user).Error
+// Delete removes a user together with their judge accounts, submissions,
+// snapshots, achievements, notification preferences, group memberships and
+// handle claims.
+func (r *userRepository) Delete(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dependents := []any{
+			&domain.JudgeAccount{},
+			&domain.Submission{},
+			&domain.LeaderboardSnapshot{},
+			&domain.UserAchievement{},
+			&domain.NotificationPreference{},
+			&domain.GroupMember{},
+			&domain.HandleClaim{},
+		}
+		for _, model := range dependents {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(user).Error
+	})
 }
 
-func (r *userRepository) FindByHandle(handle string) (*domain.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, error) {
 	var user domain.User
-	err := r.db.Where("codeforces_handle = ?", handle).First(&user).Error
+	err := withAccounts(r.db.WithContext(ctx)).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByID(id uint) (*domain.User, error) {
+func (r *userRepository) FindByIDs(ctx context.Context, ids []uint) ([]domain.User, error) {
+	var users []domain.User
+	err := withAccounts(r.db.WithContext(ctx)).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// FindByAccount looks up the person a judge handle is linked to.
+func (r *userRepository) FindByAccount(ctx context.Context, judge, handle string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.First(&user, id).Error
+	err := withAccounts(r.db.WithContext(ctx)).
+		Where("id = (SELECT user_id FROM judge_accounts WHERE judge = ? AND handle = ?)", judge, handle).
+		First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetLeaderboard(limit, offset int) ([]domain.User, error) {
+func (r *userRepository) GetLeaderboard(ctx context.Context, filter LeaderboardFilter, limit, offset int) ([]domain.User, error) {
 	var users []domain.User
-	err := r.db.Where("is_active = ?", true).
-		Order("current_streak DESC, max_streak DESC, rating DESC").
+	err := filter.apply(withAccounts(r.db.WithContext(ctx))).
+		Order(filter.order()).
 		Limit(limit).
 		Offset(offset).
 		Find(&users).Error
 	return users, err
 }
 
-func (r *userRepository) GetAllActiveUsers() ([]domain.User, error) {
+// GetRankedUsersAfter returns up to limit users with IDs above afterID, in ID
+// order, each ranked under filter. Paging by ID rather than by position means
+// a caller walking the whole leaderboard sees every user exactly once, even
+// if a sync reorders it between pages; ranks are as of each page's read.
+func (r *userRepository) GetRankedUsersAfter(ctx context.Context, filter LeaderboardFilter, afterID uint, limit int) ([]RankedUser, error) {
+	var users []RankedUser
+	ranked := filter.apply(r.db.Model(&domain.User{})).
+		Select("*, ROW_NUMBER() OVER (ORDER BY " + filter.order() + ") AS leaderboard_rank")
+	err := r.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan does not preload, so the page's accounts are read separately.
+	ids := make([]uint, len(users))
+	byID := make(map[uint]*RankedUser, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+		byID[users[i].ID] = &users[i]
+		users[i].Accounts = []domain.JudgeAccount{}
+	}
+	var accounts []domain.JudgeAccount
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", ids).Order("id ASC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		byID[account.UserID].Accounts = append(byID[account.UserID].Accounts, account)
+	}
+	return users, nil
+}
+
+func (r *userRepository) GetRankedUsers(ctx context.Context) ([]domain.User, error) {
+	var users []domain.User
+	err := withAccounts(r.db.WithContext(ctx)).Where("is_active = ?", true).
+		Order(leaderboardOrder).
+		Find(&users).Error
+	return users, err
+}
+
+// GetUserRank returns the 1-based leaderboard position of user by counting the
+// active users ranked ahead of it under leaderboardOrder.
+func (r *userRepository) GetUserRank(ctx context.Context, user *domain.User) (int, error) {
+	var ahead int64
+	err := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("is_active = ?", true).
+		Where(
+			"current_streak > ? OR (current_streak = ? AND max_streak > ?) "+
+				"OR (current_streak = ? AND max_streak = ? AND rating > ?) "+
+				"OR (current_streak = ? AND max_streak = ? AND rating = ? AND id < ?)",
+			user.CurrentStreak,
+			user.CurrentStreak, user.MaxStreak,
+			user.CurrentStreak, user.MaxStreak, user.Rating,
+			user.CurrentStreak, user.MaxStreak, user.Rating, user.ID,
+		).
+		Count(&ahead).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(ahead) + 1, nil
+}
+
+// GetUserRanks returns the leaderboard positions of several users in one
+// query. Inactive users are not ranked and are missing from the result.
+func (r *userRepository) GetUserRanks(ctx context.Context, userIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		ID   uint
+		Rank int
+	}
+	ranked := r.db.Model(&domain.User{}).
+		Select("id, ROW_NUMBER() OVER (ORDER BY "+leaderboardOrder+") AS rank").
+		Where("is_active = ?", true)
+	err := r.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Where("id IN ?", userIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		ranks[row.ID] = row.Rank
+	}
+	return ranks, nil
+}
+
+func (r *userRepository) GetAllActiveUsers(ctx context.Context) ([]domain.User, error) {
 	var users []domain.User
-	err := r.db.Where("is_active = ?", true).Find(&users).Error
+	err := withAccounts(r.db.WithContext(ctx)).Where("is_active = ?", true).Find(&users).Error
 	return users, err
 }
 
-func (r *userRepository) CountUsers() (int64, error) {
+func (r *userRepository) CountUsers(ctx context.Context, filter LeaderboardFilter) (int64, error) {
 	var count int64
-	err := r.db.Model(&domain.User{}).Where("is_active = ?", true).Count(&count).Error
+	err := filter.apply(r.db.WithContext(ctx).Model(&domain.User{})).Count(&count).Error
 	return count, err
 }
 
-func (r *userRepository) BulkUpdate(users []domain.User) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *userRepository) BulkUpdate(ctx context.Context, users []domain.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		for _, user := range users {
 			user.UpdatedAt = time.Now()
-			if err := tx.Save(&user).Error; err != nil {
+			if err := tx.Omit(clause.Associations).Save(&user).Error; err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uint) error
+	FindSubscriptionByID(ctx context.Context, id uint) (*domain.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetActiveSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	Enqueue(ctx context.Context, entries []domain.WebhookOutbox) error
+	GetDueOutbox(ctx context.Context, now time.Time, limit int) ([]domain.WebhookOutbox, error)
+	UpdateOutbox(ctx context.Context, entry *domain.WebhookOutbox) error
+	LogDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]domain.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+// DeleteSubscription removes the subscription together with its undelivered
+// outbox rows. The delivery log is kept for auditing.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&domain.WebhookOutbox{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&domain.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *webhookRepository) FindSubscriptionByID(ctx context.Context, id uint) (*domain.WebhookSubscription, error) {
+	var sub domain.WebhookSubscription
+	err := r.db.WithContext(ctx).First(&sub, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *webhookRepository) GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	var subs []domain.WebhookSubscription
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	var subs []domain.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("is_active = ?", true).Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepository) Enqueue(ctx context.Context, entries []domain.WebhookOutbox) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&entries).Error
+}
+
+// GetDueOutbox returns pending entries whose next attempt is due, oldest
+// first, with their subscription preloaded.
+func (r *webhookRepository) GetDueOutbox(ctx context.Context, now time.Time, limit int) ([]domain.WebhookOutbox, error) {
+	var entries []domain.WebhookOutbox
+	err := r.db.WithContext(ctx).Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", domain.OutboxPending, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *webhookRepository) UpdateOutbox(ctx context.Context, entry *domain.WebhookOutbox) error {
+	return r.db.WithContext(ctx).Model(entry).Select("status", "attempts", "next_attempt_at", "last_error", "delivered_at").
+		Updates(entry).Error
+}
+
+func (r *webhookRepository) LogDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *webhookRepository) GetDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
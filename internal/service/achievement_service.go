@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+)
+
+type AchievementService interface {
+	Catalogue() []domain.Achievement
+	Evaluate(ctx context.Context, user *domain.User, submissions []domain.JudgeSubmission) ([]domain.Achievement, error)
+	GetUserAchievements(ctx context.Context, userID uint) ([]domain.EarnedAchievement, error)
+	GetAchievementsForUsers(ctx context.Context, userIDs []uint) (map[uint][]domain.EarnedAchievement, error)
+}
+
+// achievementRule decides whether a user has earned an achievement given the
+// submission history fetched during sync.
+type achievementRule struct {
+	achievement domain.Achievement
+	earned      func(user *domain.User, submissions []domain.JudgeSubmission) bool
+}
+
+var achievementRules = []achievementRule{
+	streakRule(7, "Week Warrior"),
+	streakRule(30, "Monthly Grinder"),
+	streakRule(100, "Centurion"),
+	streakRule(365, "Year of Code"),
+	{
+		achievement: domain.Achievement{
+			Code:        "rated_1900",
+			Name:        "Into the Purple",
+			Description: "Solve a problem rated 1900 or higher",
+		},
+		earned: func(_ *domain.User, submissions []domain.JudgeSubmission) bool {
+			for _, sub := range submissions {
+				if sub.Accepted() && sub.ProblemRating >= 1900 {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		achievement: domain.Achievement{
+			Code:        "polyglot_5",
+			Name:        "Polyglot",
+			Description: "Get accepted in 5 different programming languages",
+		},
+		earned: func(_ *domain.User, submissions []domain.JudgeSubmission) bool {
+			languages := make(map[string]bool)
+			for _, sub := range submissions {
+				if sub.Accepted() && sub.Language != "" {
+					languages[languageFamily(sub.Language)] = true
+				}
+			}
+			return len(languages) >= 5
+		},
+	},
+	{
+		achievement: domain.Achievement{
+			Code:        "monthly_50",
+			Name:        "Half Century",
+			Description: "Solve 50 distinct problems within one calendar month",
+		},
+		earned: func(user *domain.User, submissions []domain.JudgeSubmission) bool {
+			loc := userLocation(user)
+			solvedByMonth := make(map[string]map[string]bool)
+			for _, sub := range submissions {
+				if !sub.Accepted() {
+					continue
+				}
+				month := calendarDay(sub.SubmittedAt, loc).Format("2006-01")
+				if solvedByMonth[month] == nil {
+					solvedByMonth[month] = make(map[string]bool)
+				}
+				solvedByMonth[month][problemKey(sub)] = true
+				if len(solvedByMonth[month]) >= 50 {
+					return true
+				}
+			}
+			return false
+		},
+	},
+}
+
+func streakRule(days int, name string) achievementRule {
+	return achievementRule{
+		achievement: domain.Achievement{
+			Code:        fmt.Sprintf("streak_%d", days),
+			Name:        name,
+			Description: fmt.Sprintf("Reach a %d-day streak", days),
+		},
+		earned: func(user *domain.User, _ []domain.JudgeSubmission) bool {
+			return user.MaxStreak >= days
+		},
+	}
+}
+
+// problemKey identifies a problem independently of which submission solved it.
+func problemKey(sub domain.JudgeSubmission) string {
+	if sub.ProblemID == "" {
+		return fmt.Sprintf("%s:submission-%d", sub.Judge, sub.ID)
+	}
+	return sub.Judge + ":" + sub.ProblemID
+}
+
+// languageFamily collapses compiler variants such as "GNU G++17 7.3.0" and
+// "GNU G++20 11.2.0 (64 bit, winlibs)" into a single language.
+func languageFamily(language string) string {
+	lower := strings.ToLower(language)
+	families := []struct{ marker, family string }{
+		{"++", "c++"},
+		{"c#", "c#"},
+		{"pypy", "python"},
+		{"python", "python"},
+		{"kotlin", "kotlin"},
+		{"javascript", "javascript"},
+		{"node.js", "javascript"},
+		{"java", "java"},
+		{"rust", "rust"},
+		{"go", "go"},
+		{"haskell", "haskell"},
+		{"ruby", "ruby"},
+		{"pascal", "pascal"},
+		{"gcc", "c"},
+		{"gnu c", "c"},
+	}
+	for _, f := range families {
+		if strings.Contains(lower, f.marker) {
+			return f.family
+		}
+	}
+	return lower
+}
+
+type achievementService struct {
+	userRepo        repository.UserRepository
+	achievementRepo repository.AchievementRepository
+	clock           clock.Clock
+	logger          *slog.Logger
+}
+
+func NewAchievementService(
+	userRepo repository.UserRepository,
+	achievementRepo repository.AchievementRepository,
+	clock clock.Clock,
+	logger *slog.Logger,
+) AchievementService {
+	return &achievementService{
+		userRepo:        userRepo,
+		achievementRepo: achievementRepo,
+		clock:           clock,
+		logger:          logger,
+	}
+}
+
+func (s *achievementService) Catalogue() []domain.Achievement {
+	catalogue := make([]domain.Achievement, len(achievementRules))
+	for i, rule := range achievementRules {
+		catalogue[i] = rule.achievement
+	}
+	return catalogue
+}
+
+// Evaluate runs every rule the user has not already satisfied and stores the
+// new awards. It returns the achievements unlocked by this call.
+func (s *achievementService) Evaluate(ctx context.Context, user *domain.User, submissions []domain.JudgeSubmission) ([]domain.Achievement, error) {
+	existing, err := s.achievementRepo.GetUserAchievements(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[string]bool, len(existing))
+	for _, award := range existing {
+		held[award.AchievementCode] = true
+	}
+
+	now := s.clock.Now()
+	var unlocked []domain.Achievement
+	var awards []domain.UserAchievement
+	for _, rule := range achievementRules {
+		if held[rule.achievement.Code] || !rule.earned(user, submissions) {
+			continue
+		}
+		unlocked = append(unlocked, rule.achievement)
+		awards = append(awards, domain.UserAchievement{
+			UserID:          user.ID,
+			AchievementCode: rule.achievement.Code,
+			AwardedAt:       now,
+		})
+	}
+
+	if err := s.achievementRepo.Award(ctx, awards); err != nil {
+		return nil, err
+	}
+
+	for _, achievement := range unlocked {
+		s.logger.InfoContext(ctx, "User unlocked achievement", "user_id", user.ID, "achievement", achievement.Code)
+	}
+
+	return unlocked, nil
+}
+
+func (s *achievementService) GetUserAchievements(ctx context.Context, userID uint) ([]domain.EarnedAchievement, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	awards, err := s.achievementRepo.GetUserAchievements(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	earned := make([]domain.EarnedAchievement, 0, len(awards))
+	for _, award := range awards {
+		if achievement, ok := earnedAchievement(award); ok {
+			earned = append(earned, achievement)
+		}
+	}
+
+	return earned, nil
+}
+
+// GetAchievementsForUsers returns the achievements of several users, keyed by
+// user ID, in one query.
+func (s *achievementService) GetAchievementsForUsers(ctx context.Context, userIDs []uint) (map[uint][]domain.EarnedAchievement, error) {
+	awards, err := s.achievementRepo.GetAchievementsForUsers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	earned := make(map[uint][]domain.EarnedAchievement, len(userIDs))
+	for _, award := range awards {
+		if achievement, ok := earnedAchievement(award); ok {
+			earned[award.UserID] = append(earned[award.UserID], achievement)
+		}
+	}
+
+	return earned, nil
+}
+
+// earnedAchievement pairs an award with its catalogue entry. Awards for
+// achievements retired from the catalogue are left out of responses.
+func earnedAchievement(award domain.UserAchievement) (domain.EarnedAchievement, bool) {
+	for _, rule := range achievementRules {
+		if rule.achievement.Code == award.AchievementCode {
+			return domain.EarnedAchievement{
+				Achievement: rule.achievement,
+				AwardedAt:   award.AwardedAt,
+			}, true
+		}
+	}
+	return domain.EarnedAchievement{}, false
+}
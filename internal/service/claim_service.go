@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"gorm.io/gorm"
+)
+
+// OwnershipChecker reads what ownership challenges are checked against from
+// Codeforces: user.status for compilation errors and user.info for the
+// first name.
+type OwnershipChecker interface {
+	ValidateHandle(ctx context.Context, handle string) (string, error)
+	FetchRecentSubmissions(ctx context.Context, handle string, count int) ([]domain.JudgeSubmission, error)
+	FetchFirstName(ctx context.Context, handle string) (string, error)
+}
+
+type ClaimService interface {
+	CreateClaim(ctx context.Context, handle, method string) (*domain.HandleClaim, error)
+	GetClaim(ctx context.Context, id uint) (*domain.HandleClaim, error)
+	VerifyClaim(ctx context.Context, id uint) (*domain.HandleClaim, error)
+	VerifyPendingClaims(ctx context.Context) error
+	AuthorizeOwner(ctx context.Context, userID uint, key string) error
+}
+
+var (
+	ErrInvalidChallenge = errors.New("challenge must be compilation_error or first_name")
+	ErrNotOwner         = errors.New("not the verified owner of this user")
+	ErrClaimPending     = errors.New("another claim on this handle is still pending")
+)
+
+// challengeWindows is how long each challenge stays open. A compilation
+// error only counts if it was submitted within the window; a first name
+// has to be seen by a check before the window closes.
+var challengeWindows = map[string]time.Duration{
+	domain.ChallengeCompilationError: 5 * time.Minute,
+	domain.ChallengeFirstName:        time.Hour,
+}
+
+// verdictCompilationError is Codeforces' verdict for a submission that did
+// not compile.
+const verdictCompilationError = "COMPILATION_ERROR"
+
+// recentSubmissionsChecked is how many of the handle's latest submissions a
+// compilation error challenge looks through.
+const recentSubmissionsChecked = 20
+
+type claimService struct {
+	claimRepo   repository.ClaimRepository
+	userRepo    repository.UserRepository
+	userService UserService
+	checker     OwnershipChecker
+	problems    []string
+	clock       clock.Clock
+	logger      *slog.Logger
+}
+
+// NewClaimService returns a service whose compilation error challenges each
+// ask for a submission on one of problems, given as contest ID and index
+// such as "4A".
+func NewClaimService(
+	claimRepo repository.ClaimRepository,
+	userRepo repository.UserRepository,
+	userService UserService,
+	checker OwnershipChecker,
+	problems []string,
+	clock clock.Clock,
+	logger *slog.Logger,
+) ClaimService {
+	return &claimService{
+		claimRepo:   claimRepo,
+		userRepo:    userRepo,
+		userService: userService,
+		checker:     checker,
+		problems:    problems,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+// CreateClaim opens a claim on a Codeforces handle, tracked or not. The
+// returned claim carries the owner key, which is not exposed again.
+//
+// Only one claim on a handle is open at a time: otherwise someone could
+// claim a handle while its owner's claim is pending, and have the owner's
+// own submission verify both. It fails with ErrClaimPending then.
+func (s *claimService) CreateClaim(ctx context.Context, handle, method string) (*domain.HandleClaim, error) {
+	method = strings.ToLower(method)
+	window, ok := challengeWindows[method]
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+
+	handle, err := s.checker.ValidateHandle(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.settleOpenClaims(ctx, handle); err != nil {
+		return nil, err
+	}
+
+	key, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	claim := &domain.HandleClaim{
+		Handle:    handle,
+		Method:    method,
+		KeyHash:   hashKey(key),
+		Status:    domain.ClaimPending,
+		ExpiresAt: now.Add(window),
+		CreatedAt: now,
+		Key:       key,
+	}
+	switch method {
+	case domain.ChallengeCompilationError:
+		if claim.Problem, err = s.pickProblem(ctx, handle); err != nil {
+			return nil, err
+		}
+	case domain.ChallengeFirstName:
+		if claim.Token, err = nameToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.claimRepo.Create(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Opened handle claim", "id", claim.ID, "handle", claim.Handle, "method", claim.Method)
+	return claim, nil
+}
+
+// settleOpenClaims fails with ErrClaimPending if a claim on handle is still
+// open. Claims whose window has closed are checked one last time first, so
+// a challenge completed just before the deadline is not lost.
+func (s *claimService) settleOpenClaims(ctx context.Context, handle string) error {
+	open, err := s.claimRepo.FindPendingByHandle(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	for i := range open {
+		if !now.After(open[i].ExpiresAt) {
+			return ErrClaimPending
+		}
+		if err := s.verify(ctx, &open[i]); err != nil {
+			return err
+		}
+		if open[i].Status == domain.ClaimPending {
+			return ErrClaimPending
+		}
+	}
+	return nil
+}
+
+// pickProblem draws a compilation error problem at random, avoiding the one
+// the handle's previous claim asked for, so a submission made for that claim
+// cannot complete this one.
+func (s *claimService) pickProblem(ctx context.Context, handle string) (string, error) {
+	candidates := s.problems
+	previous, err := s.claimRepo.FindLatestByHandle(ctx, handle)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if previous != nil && len(s.problems) > 1 {
+		candidates = make([]string, 0, len(s.problems))
+		for _, problem := range s.problems {
+			if problem != previous.Problem {
+				candidates = append(candidates, problem)
+			}
+		}
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(candidates))))
+	if err != nil {
+		return "", err
+	}
+	return candidates[n.Int64()], nil
+}
+
+func (s *claimService) GetClaim(ctx context.Context, id uint) (*domain.HandleClaim, error) {
+	return s.claimRepo.FindByID(ctx, id)
+}
+
+// VerifyClaim checks a pending claim's challenge now rather than waiting for
+// the next scheduled check. Claims that are no longer pending are returned
+// as they are.
+func (s *claimService) VerifyClaim(ctx context.Context, id uint) (*domain.HandleClaim, error) {
+	claim, err := s.claimRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status == domain.ClaimPending {
+		if err := s.verify(ctx, claim); err != nil {
+			return nil, err
+		}
+	}
+	return claim, nil
+}
+
+// VerifyPendingClaims checks every pending claim. It is meant to run every
+// few seconds, so a challenge is noticed while it is still open. A claim
+// that fails to check is logged and retried next time.
+func (s *claimService) VerifyPendingClaims(ctx context.Context) error {
+	claims, err := s.claimRepo.FindPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range claims {
+		if err := s.verify(ctx, &claims[i]); err != nil {
+			s.logger.WarnContext(ctx, "Failed to check handle claim", "id", claims[i].ID, "handle", claims[i].Handle, "error", err)
+		}
+	}
+	return nil
+}
+
+// verify checks a pending claim's challenge. A completed challenge verifies
+// the claim, adding the handle as a user if it is not tracked yet, and
+// expires every other open claim on the handle; an uncompleted one expires
+// the claim once its window has closed.
+func (s *claimService) verify(ctx context.Context, claim *domain.HandleClaim) error {
+	now := s.clock.Now()
+
+	done, err := s.challengeCompleted(ctx, claim, now)
+	if err != nil {
+		return err
+	}
+	if !done {
+		if now.After(claim.ExpiresAt) {
+			claim.Status = domain.ClaimExpired
+			return s.resolve(ctx, claim)
+		}
+		return nil
+	}
+
+	user, err := s.userRepo.FindByAccount(ctx, domain.JudgeCodeforces, claim.Handle)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = s.userService.AddUser(ctx, domain.JudgeCodeforces, claim.Handle)
+	}
+	if err != nil {
+		return err
+	}
+
+	claim.UserID = &user.ID
+	claim.Status = domain.ClaimVerified
+	claim.VerifiedAt = &now
+	if err := s.resolve(ctx, claim); err != nil || claim.Status != domain.ClaimVerified {
+		return err
+	}
+	if err := s.claimRepo.ExpireOthers(ctx, claim); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Verified handle claim", "id", claim.ID, "handle", claim.Handle, "method", claim.Method)
+	return nil
+}
+
+// resolve stores a claim's new status. If another check resolved the claim
+// first, claim is reloaded with that outcome instead.
+func (s *claimService) resolve(ctx context.Context, claim *domain.HandleClaim) error {
+	stored, err := s.claimRepo.Resolve(ctx, claim)
+	if err != nil || stored {
+		return err
+	}
+
+	current, err := s.claimRepo.FindByID(ctx, claim.ID)
+	if err != nil {
+		return err
+	}
+	current.Key = claim.Key
+	*claim = *current
+	return nil
+}
+
+func (s *claimService) challengeCompleted(ctx context.Context, claim *domain.HandleClaim, now time.Time) (bool, error) {
+	switch claim.Method {
+	case domain.ChallengeCompilationError:
+		submissions, err := s.checker.FetchRecentSubmissions(ctx, claim.Handle, recentSubmissionsChecked)
+		if err != nil {
+			return false, err
+		}
+		for _, sub := range submissions {
+			if sub.ProblemID == claim.Problem && sub.Verdict == verdictCompilationError &&
+				!sub.SubmittedAt.Before(claim.CreatedAt.Truncate(time.Second)) && !sub.SubmittedAt.After(claim.ExpiresAt) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case domain.ChallengeFirstName:
+		if now.After(claim.ExpiresAt) {
+			return false, nil
+		}
+		firstName, err := s.checker.FetchFirstName(ctx, claim.Handle)
+		if err != nil {
+			return false, err
+		}
+		return strings.TrimSpace(firstName) == claim.Token, nil
+	default:
+		return false, fmt.Errorf("%w: %q", ErrInvalidChallenge, claim.Method)
+	}
+}
+
+// AuthorizeOwner checks that key is the key of the latest verified claim on
+// any of a user's Codeforces accounts. It fails with ErrNotOwner otherwise,
+// and with gorm.ErrRecordNotFound for users that do not exist.
+func (s *claimService) AuthorizeOwner(ctx context.Context, userID uint, key string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	claim, err := s.claimRepo.FindLatestVerified(ctx, user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotOwner
+	}
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(key)), []byte(claim.KeyHash)) != 1 {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+func randomKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// hashKey hashes an owner key for storage. The keys are random, so a plain
+// SHA-256 is enough.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// nameToken returns a first name token of lowercase letters only, which
+// Codeforces accepts as a name.
+func nameToken() (string, error) {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = letters[int(b[i])%len(letters)]
+	}
+	return "codestreaks" + string(b), nil
+}
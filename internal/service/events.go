@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+)
+
+// EventPublisher receives domain events as they happen. Implementations must
+// not block the caller for long; durable delivery happens elsewhere.
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.Event)
+}
+
+// MultiPublisher hands every event to each publisher in order, e.g. the
+// webhook outbox and the live stream.
+type MultiPublisher []EventPublisher
+
+func (m MultiPublisher) Publish(ctx context.Context, event domain.Event) {
+	for _, publisher := range m {
+		publisher.Publish(ctx, event)
+	}
+}
+
+// newEvent stamps an event with the time on clock, so events line up with
+// the sync or snapshot that raised them.
+func newEvent(clock clock.Clock, eventType string, data any) domain.Event {
+	return domain.Event{
+		ID:         newEventID(),
+		Type:       eventType,
+		OccurredAt: clock.Now().UTC(),
+		Data:       data,
+	}
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
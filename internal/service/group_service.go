@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidGroupName = errors.New("group name must be 1-64 letters, digits, '-' or '_'")
+	ErrGroupExists      = errors.New("group already exists")
+)
+
+var groupNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type GroupService interface {
+	CreateGroup(ctx context.Context, name, description string) (*domain.Group, error)
+	ListGroups(ctx context.Context) ([]domain.Group, error)
+	AddMember(ctx context.Context, groupName string, userID uint) error
+	RemoveMember(ctx context.Context, groupName string, userID uint) error
+	GetStandingsForUsers(ctx context.Context, userIDs []uint) (map[uint][]domain.GroupStanding, error)
+}
+
+type groupService struct {
+	groupRepo     repository.GroupRepository
+	userRepo      repository.UserRepository
+	responseCache CacheInvalidator
+	logger        *slog.Logger
+}
+
+func NewGroupService(groupRepo repository.GroupRepository, userRepo repository.UserRepository, responseCache CacheInvalidator, logger *slog.Logger) GroupService {
+	return &groupService{
+		groupRepo:     groupRepo,
+		userRepo:      userRepo,
+		responseCache: responseCache,
+		logger:        logger,
+	}
+}
+
+func (s *groupService) CreateGroup(ctx context.Context, name, description string) (*domain.Group, error) {
+	if !groupNamePattern.MatchString(name) {
+		return nil, ErrInvalidGroupName
+	}
+
+	if _, err := s.groupRepo.FindByName(ctx, name); err == nil {
+		return nil, ErrGroupExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	group := &domain.Group{
+		Name:        name,
+		Description: description,
+	}
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Created group", "group", name)
+	return group, nil
+}
+
+func (s *groupService) ListGroups(ctx context.Context) ([]domain.Group, error) {
+	return s.groupRepo.List(ctx)
+}
+
+func (s *groupService) AddMember(ctx context.Context, groupName string, userID uint) error {
+	group, user, err := s.resolve(ctx, groupName, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupRepo.AddMember(ctx, group.ID, user.ID); err != nil {
+		return err
+	}
+	invalidateResponses(ctx, s.responseCache, s.logger)
+	return nil
+}
+
+func (s *groupService) RemoveMember(ctx context.Context, groupName string, userID uint) error {
+	group, user, err := s.resolve(ctx, groupName, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupRepo.RemoveMember(ctx, group.ID, user.ID); err != nil {
+		return err
+	}
+	invalidateResponses(ctx, s.responseCache, s.logger)
+	return nil
+}
+
+// GetStandingsForUsers returns where each user stands in every group they
+// belong to, using one query for the memberships and one for the rankings.
+func (s *groupService) GetStandingsForUsers(ctx context.Context, userIDs []uint) (map[uint][]domain.GroupStanding, error) {
+	memberships, err := s.groupRepo.GetMemberships(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return map[uint][]domain.GroupStanding{}, nil
+	}
+
+	var groupIDs []uint
+	seen := make(map[uint]bool)
+	for _, m := range memberships {
+		if !seen[m.GroupID] {
+			seen[m.GroupID] = true
+			groupIDs = append(groupIDs, m.GroupID)
+		}
+	}
+
+	ranked, err := s.groupRepo.GetRankedMemberIDs(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := make(map[uint][]domain.GroupStanding, len(userIDs))
+	for _, m := range memberships {
+		members := ranked[m.GroupID]
+		standing := domain.GroupStanding{Group: m.Group, Members: len(members)}
+		if i := slices.Index(members, m.UserID); i >= 0 {
+			standing.Rank = i + 1
+		}
+		standings[m.UserID] = append(standings[m.UserID], standing)
+	}
+	return standings, nil
+}
+
+func (s *groupService) resolve(ctx context.Context, groupName string, userID uint) (*domain.Group, *domain.User, error) {
+	group, err := s.groupRepo.FindByName(ctx, groupName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("group %s: %w", groupName, err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("user %d: %w", userID, err)
+	}
+
+	return group, user, nil
+}
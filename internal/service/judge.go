@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// JudgeProvider reads handles on one judge. Each judge's client package in
+// pkg implements it.
+type JudgeProvider interface {
+	// Judge is the name accounts on this judge are stored under, such as
+	// domain.JudgeCodeforces.
+	Judge() string
+	// ValidateHandle checks that a handle exists and returns it as the judge
+	// spells it. Unknown handles fail with domain.ErrHandleNotFound.
+	ValidateHandle(ctx context.Context, handle string) (string, error)
+	FetchProfile(ctx context.Context, handle string) (*domain.JudgeProfile, error)
+	// FetchSubmissions returns the handle's submissions, newest first.
+	FetchSubmissions(ctx context.Context, handle string) ([]domain.JudgeSubmission, error)
+}
+
+// JudgeProviders looks up providers by judge name.
+type JudgeProviders map[string]JudgeProvider
+
+func NewJudgeProviders(providers ...JudgeProvider) JudgeProviders {
+	judges := make(JudgeProviders, len(providers))
+	for _, p := range providers {
+		judges[p.Judge()] = p
+	}
+	return judges
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/notifier"
+)
+
+var (
+	ErrUnsupportedChannel = errors.New("notification channel is not configured")
+	ErrInvalidPreference  = errors.New("invalid notification preference")
+)
+
+type NotificationService interface {
+	GetPreferences(ctx context.Context, userID uint) ([]domain.NotificationPreference, error)
+	SetPreference(ctx context.Context, userID uint, pref *domain.NotificationPreference) error
+	DeletePreference(ctx context.Context, userID uint, channel string) error
+	NotifyStreaksAtRisk(ctx context.Context) error
+}
+
+type notificationService struct {
+	userRepo           repository.UserRepository
+	submissionRepo     repository.SubmissionRepository
+	notificationRepo   repository.NotificationRepository
+	notifiers          map[string]notifier.Notifier
+	defaultHoursBefore int
+	clock              clock.Clock
+	logger             *slog.Logger
+}
+
+func NewNotificationService(
+	userRepo repository.UserRepository,
+	submissionRepo repository.SubmissionRepository,
+	notificationRepo repository.NotificationRepository,
+	notifiers []notifier.Notifier,
+	defaultHoursBefore int,
+	clock clock.Clock,
+	logger *slog.Logger,
+) NotificationService {
+	byChannel := make(map[string]notifier.Notifier, len(notifiers))
+	for _, n := range notifiers {
+		byChannel[n.Channel()] = n
+	}
+
+	return &notificationService{
+		userRepo:           userRepo,
+		submissionRepo:     submissionRepo,
+		notificationRepo:   notificationRepo,
+		notifiers:          byChannel,
+		defaultHoursBefore: defaultHoursBefore,
+		clock:              clock,
+		logger:             logger,
+	}
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) ([]domain.NotificationPreference, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.notificationRepo.GetUserPreferences(ctx, user.ID)
+}
+
+func (s *notificationService) SetPreference(ctx context.Context, userID uint, pref *domain.NotificationPreference) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.notifiers[pref.Channel]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedChannel, pref.Channel)
+	}
+
+	if pref.HoursBefore == 0 {
+		pref.HoursBefore = s.defaultHoursBefore
+	}
+	if pref.HoursBefore < 1 || pref.HoursBefore > 23 {
+		return fmt.Errorf("%w: hours_before must be between 1 and 23", ErrInvalidPreference)
+	}
+
+	if pref.Channel == notifier.ChannelDiscord || pref.Channel == notifier.ChannelWebhook {
+		u, err := url.Parse(pref.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: target must be an http(s) URL", ErrInvalidPreference)
+		}
+	}
+
+	pref.UserID = user.ID
+	return s.notificationRepo.Upsert(ctx, pref)
+}
+
+func (s *notificationService) DeletePreference(ctx context.Context, userID uint, channel string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.notificationRepo.Delete(ctx, user.ID, channel)
+}
+
+// NotifyStreaksAtRisk alerts users who have a running streak, no accepted
+// submission yet on their streak day, and less than HoursBefore hours until
+// midnight in their time zone. It is meant to run every few minutes; each preference
+// fires at most once per local day.
+func (s *notificationService) NotifyStreaksAtRisk(ctx context.Context) error {
+	prefs, err := s.notificationRepo.GetEnabledPreferences(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	sent := 0
+	for _, pref := range prefs {
+		if pref.User.CurrentStreak == 0 {
+			continue
+		}
+
+		loc := userLocation(&pref.User)
+		local := now.In(loc)
+		startOfDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		midnight := startOfDay.AddDate(0, 0, 1)
+		today := startOfDay.Format("2006-01-02")
+
+		if pref.LastNotifiedOn == today || midnight.Sub(local) > time.Duration(pref.HoursBefore)*time.Hour {
+			continue
+		}
+
+		solved, err := s.submissionRepo.HasAcceptedSince(ctx, pref.UserID, startOfDay)
+		if err != nil {
+			return err
+		}
+		if solved {
+			continue
+		}
+
+		n, ok := s.notifiers[pref.Channel]
+		if !ok {
+			continue
+		}
+
+		if err := n.Send(ctx, pref.Target, streakAtRiskMessage(&pref.User, midnight.Sub(local))); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to send notification", "user_id", pref.UserID, "channel", pref.Channel, "error", err)
+			continue
+		}
+
+		if err := s.notificationRepo.MarkNotified(ctx, pref.ID, today); err != nil {
+			return err
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		s.logger.InfoContext(ctx, "Sent streak-at-risk notifications", "sent", sent)
+	}
+	return nil
+}
+
+func streakAtRiskMessage(user *domain.User, remaining time.Duration) notifier.Message {
+	hours := int(remaining.Hours())
+	minutes := int(remaining.Minutes()) % 60
+
+	return notifier.Message{
+		Subject: fmt.Sprintf("Your %d-day streak is at risk", user.CurrentStreak),
+		Body: fmt.Sprintf(
+			"Hi %s, you have no accepted submission today. Solve a problem within the next %dh %02dm to keep your %d-day streak alive.",
+			user.Name(), hours, minutes, user.CurrentStreak,
+		),
+	}
+}
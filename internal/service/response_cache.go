@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+)
+
+// CacheInvalidator drops cached API responses once the data behind them
+// changes.
+type CacheInvalidator interface {
+	Invalidate(ctx context.Context) error
+}
+
+// invalidateResponses is called after every write that cached responses can
+// show. A failure is only logged: the write itself has happened, and stale
+// entries still expire with the cache TTL.
+func invalidateResponses(ctx context.Context, responseCache CacheInvalidator, logger *slog.Logger) {
+	if err := responseCache.Invalidate(ctx); err != nil {
+		logger.ErrorContext(ctx, "Failed to invalidate response cache", "error", err)
+	}
+}
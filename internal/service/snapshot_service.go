@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+)
+
+type SnapshotService interface {
+	TakeDailySnapshot(ctx context.Context) error
+	PruneSnapshots(ctx context.Context) error
+}
+
+type snapshotService struct {
+	userRepo      repository.UserRepository
+	snapshotRepo  repository.SnapshotRepository
+	responseCache CacheInvalidator
+	retentionDays int
+	clock         clock.Clock
+	logger        *slog.Logger
+}
+
+func NewSnapshotService(
+	userRepo repository.UserRepository,
+	snapshotRepo repository.SnapshotRepository,
+	responseCache CacheInvalidator,
+	retentionDays int,
+	clock clock.Clock,
+	logger *slog.Logger,
+) SnapshotService {
+	return &snapshotService{
+		userRepo:      userRepo,
+		snapshotRepo:  snapshotRepo,
+		responseCache: responseCache,
+		retentionDays: retentionDays,
+		clock:         clock,
+		logger:        logger,
+	}
+}
+
+// TakeDailySnapshot stores every active user's current standing under the
+// server's day that has just ended. It is meant to run shortly after midnight
+// in streakLocation, before the first sync of the new day changes any streaks.
+// Snapshots only back the daily rank_change and streak_change; rank moves are
+// announced by the sync as they happen.
+func (s *snapshotService) TakeDailySnapshot(ctx context.Context) error {
+	users, err := s.userRepo.GetRankedUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	date := snapshotDate(s.clock.Now()).AddDate(0, 0, -1)
+
+	snapshots := make([]domain.LeaderboardSnapshot, len(users))
+	for i, user := range users {
+		snapshots[i] = domain.LeaderboardSnapshot{
+			UserID:          user.ID,
+			SnapshotDate:    date,
+			LeaderboardRank: i + 1,
+			CurrentStreak:   user.CurrentStreak,
+			MaxStreak:       user.MaxStreak,
+			Rating:          user.Rating,
+		}
+	}
+
+	if err := s.snapshotRepo.SaveAll(ctx, snapshots); err != nil {
+		return err
+	}
+	// rank_change and streak_change are now measured from the new snapshot.
+	invalidateResponses(ctx, s.responseCache, s.logger)
+
+	s.logger.InfoContext(ctx, "Stored leaderboard snapshot", "date", date.Format("2006-01-02"), "users", len(snapshots))
+	return nil
+}
+
+// PruneSnapshots deletes snapshots older than the configured retention.
+// A non-positive retention keeps history forever.
+func (s *snapshotService) PruneSnapshots(ctx context.Context) error {
+	if s.retentionDays <= 0 {
+		return nil
+	}
+
+	cutoff := snapshotDate(s.clock.Now()).AddDate(0, 0, -s.retentionDays)
+	deleted, err := s.snapshotRepo.DeleteBefore(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		s.logger.InfoContext(ctx, "Pruned leaderboard snapshots", "deleted", deleted, "before", cutoff.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// applyStandingChanges fills in RankChange and StreakChange on responses from
+// the latest snapshot taken before the server's today. Snapshots hold global
+// ranks in the default order, so includeRank must be false when responses are
+// ranked within a group or in another order.
+func applyStandingChanges(ctx context.Context, snapshotRepo repository.SnapshotRepository, now time.Time, responses []domain.UserResponse, includeRank bool) error {
+	userIDs := make([]uint, len(responses))
+	for i, response := range responses {
+		userIDs[i] = response.ID
+	}
+
+	previous, err := snapshotRepo.GetLatestBefore(ctx, snapshotDate(now), userIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range responses {
+		snapshot, ok := previous[responses[i].ID]
+		if !ok {
+			continue
+		}
+		if includeRank {
+			responses[i].RankChange = snapshot.LeaderboardRank - responses[i].LeaderboardRank
+		}
+		responses[i].StreakChange = responses[i].CurrentStreak - snapshot.CurrentStreak
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// streakLocation is the server's time zone: the default for users' streak
+// days, and the one leaderboard snapshots are dated in.
+var streakLocation = mustLoadLocation("Asia/Tehran")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// userLocation is the time zone a user's streak days are counted in: their
+// own, or streakLocation for users without a valid one.
+func userLocation(user *domain.User) *time.Location {
+	if user.TimeZone == "" {
+		return streakLocation
+	}
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		return streakLocation
+	}
+	return loc
+}
+
+// snapshotDate returns the calendar day of t in streakLocation, expressed as
+// UTC midnight so it round-trips through a DATE column unchanged.
+//
+// Snapshots deliberately stay on the server's day rather than each user's: a
+// snapshot ranks everyone against each other at one moment, and a rank taken
+// at a different instant for every user would compare streaks that had run
+// for different lengths of time. A user in another zone therefore sees
+// rank_change and streak_change measured from the server's midnight.
+func snapshotDate(t time.Time) time.Time {
+	return calendarDay(t, streakLocation)
+}
+
+func calendarDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// solvedDays is the set of calendar days in loc with at least one accepted
+// submission, on any judge.
+func solvedDays(submissions []domain.JudgeSubmission, loc *time.Location) map[time.Time]bool {
+	solved := make(map[time.Time]bool)
+	for _, sub := range submissions {
+		if sub.Accepted() {
+			solved[calendarDay(sub.SubmittedAt, loc)] = true
+		}
+	}
+	return solved
+}
+
+// calculateStreak counts the consecutive calendar days in loc, up to now,
+// with at least one accepted submission. Today only breaks the streak once it
+// is over: until then a streak that reaches yesterday is still running.
+func calculateStreak(submissions []domain.JudgeSubmission, now time.Time, loc *time.Location) int {
+	solved := solvedDays(submissions, loc)
+
+	// Days are walked as UTC dates, which are always 24 hours long, so a DST
+	// change in loc can neither skip nor repeat a day.
+	day := calendarDay(now, loc)
+	if !solved[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for solved[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return streak
+}
+
+// longestStreak is the longest run of consecutive calendar days in loc with
+// at least one accepted submission.
+func longestStreak(submissions []domain.JudgeSubmission, loc *time.Location) int {
+	solved := solvedDays(submissions, loc)
+
+	longest := 0
+	for day := range solved {
+		// Only count runs from their first day.
+		if solved[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		length := 0
+		for d := day; solved[d]; d = d.AddDate(0, 0, 1) {
+			length++
+		}
+		longest = max(longest, length)
+	}
+
+	return longest
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+func TestCalculateStreak(t *testing.T) {
+	tehran := mustLoadLocation("Asia/Tehran")
+	newYork := mustLoadLocation("America/New_York")
+	santiago := mustLoadLocation("America/Santiago")
+
+	at := func(loc *time.Location, value string) time.Time {
+		t.Helper()
+
+		parsed, err := time.ParseInLocation("2006-01-02 15:04:05", value, loc)
+		if err != nil {
+			t.Fatalf("parse %q: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		now      string
+		accepted []string
+		rejected []string
+		want     int
+	}{
+		{
+			name: "no submissions",
+			loc:  tehran, now: "2024-03-15 12:00:00",
+			want: 0,
+		},
+		{
+			name: "only rejected submissions",
+			loc:  tehran, now: "2024-03-15 12:00:00",
+			rejected: []string{"2024-03-15 09:00:00", "2024-03-14 09:00:00"},
+			want:     0,
+		},
+		{
+			name: "solved today only",
+			loc:  tehran, now: "2024-03-15 12:00:00",
+			accepted: []string{"2024-03-15 08:00:00"},
+			want:     1,
+		},
+		{
+			name: "today not yet solved keeps yesterday's streak",
+			loc:  tehran, now: "2024-03-15 23:59:59",
+			accepted: []string{"2024-03-14 10:00:00", "2024-03-13 10:00:00"},
+			want:     2,
+		},
+		{
+			name: "today and the days before",
+			loc:  tehran, now: "2024-03-15 12:00:00",
+			accepted: []string{"2024-03-15 08:00:00", "2024-03-14 22:00:00", "2024-03-13 01:00:00"},
+			want:     3,
+		},
+		{
+			name: "several solves on one day count once",
+			loc:  tehran, now: "2024-03-15 12:00:00",
+			accepted: []string{"2024-03-15 08:00:00", "2024-03-15 09:00:00", "2024-03-15 10:00:00"},
+			want:     1,
+		},
+		{
+			name: "last solve two days ago",
+			loc:  tehran, now: "2024-03-15 00:00:01",
+			accepted: []string{"2024-03-13 23:59:59", "2024-03-12 12:00:00"},
+			want:     0,
+		},
+		{
+			name: "gap stops the count",
+			loc:  tehran, now: "2024-03-15 12:00:00",
+			accepted: []string{"2024-03-15 08:00:00", "2024-03-14 08:00:00", "2024-03-12 08:00:00"},
+			want:     2,
+		},
+		{
+			name: "solve one second before midnight counts for that day",
+			loc:  tehran, now: "2024-03-15 00:00:30",
+			accepted: []string{"2024-03-14 23:59:59"},
+			want:     1,
+		},
+		{
+			name: "solve one second after midnight counts for the new day",
+			loc:  tehran, now: "2024-03-15 00:00:30",
+			accepted: []string{"2024-03-15 00:00:01", "2024-03-14 12:00:00"},
+			want:     2,
+		},
+		{
+			name: "days follow the streak zone, not UTC",
+			loc:  tehran, now: "2024-03-15 02:00:00", // still 2024-03-14 in UTC
+			accepted: []string{"2024-03-15 00:30:00"},
+			want:     1,
+		},
+		{
+			name: "Tehran's last spring-forward skipped midnight",
+			loc:  tehran, now: "2022-03-23 10:00:00",
+			accepted: []string{"2022-03-21 23:30:00", "2022-03-22 01:30:00", "2022-03-23 09:00:00"},
+			want:     3,
+		},
+		{
+			name: "23-hour day at spring-forward",
+			loc:  newYork, now: "2024-03-11 09:00:00",
+			accepted: []string{"2024-03-09 23:30:00", "2024-03-10 23:30:00", "2024-03-11 00:30:00"},
+			want:     3,
+		},
+		{
+			name: "25-hour day at fall-back with today unsolved",
+			loc:  newYork, now: "2024-11-04 08:00:00",
+			accepted: []string{"2024-11-02 00:30:00", "2024-11-03 23:30:00"},
+			want:     2,
+		},
+		{
+			name: "fall-back repeated hour is a single day",
+			loc:  newYork, now: "2024-11-03 22:00:00",
+			accepted: []string{"2024-11-03 01:30:00", "2024-11-02 12:00:00"},
+			want:     2,
+		},
+		{
+			name: "zone where DST skips midnight",
+			loc:  santiago, now: "2024-09-09 12:00:00",
+			accepted: []string{"2024-09-07 12:00:00", "2024-09-08 12:00:00", "2024-09-09 11:00:00"},
+			want:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var submissions []domain.JudgeSubmission
+			for _, value := range tt.accepted {
+				submissions = append(submissions, domain.JudgeSubmission{
+					SubmittedAt: at(tt.loc, value),
+					Verdict:     "OK",
+				})
+			}
+			for _, value := range tt.rejected {
+				submissions = append(submissions, domain.JudgeSubmission{
+					SubmittedAt: at(tt.loc, value),
+					Verdict:     "WRONG_ANSWER",
+				})
+			}
+
+			if got := calculateStreak(submissions, at(tt.loc, tt.now), tt.loc); got != tt.want {
+				t.Errorf("calculateStreak() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotDate(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2024, 3, 14, 20, 29, 59, 0, time.UTC), "2024-03-14"},
+		{time.Date(2024, 3, 14, 20, 30, 0, 0, time.UTC), "2024-03-15"}, // Tehran midnight
+		{time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), "2024-03-15"},
+	}
+
+	for _, tt := range tests {
+		got := snapshotDate(tt.at)
+		if got.Format("2006-01-02") != tt.want || got.Location() != time.UTC || got.Hour() != 0 {
+			t.Errorf("snapshotDate(%v) = %v, want %s at UTC midnight", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestLongestStreak(t *testing.T) {
+	tehran := mustLoadLocation("Asia/Tehran")
+	day := func(d, hour int, verdict string) domain.JudgeSubmission {
+		return domain.JudgeSubmission{
+			SubmittedAt: time.Date(2024, 3, d, hour, 0, 0, 0, tehran),
+			Verdict:     verdict,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		submissions []domain.JudgeSubmission
+		want        int
+	}{
+		{"no submissions", nil, 0},
+		{"single day", []domain.JudgeSubmission{day(1, 10, "OK"), day(1, 11, "OK")}, 1},
+		{"longest run in the past", []domain.JudgeSubmission{
+			day(1, 10, "OK"), day(2, 10, "OK"), day(3, 23, "OK"),
+			day(5, 10, "OK"), day(6, 10, "OK"),
+		}, 3},
+		{"rejected day breaks the run", []domain.JudgeSubmission{
+			day(1, 10, "OK"), day(2, 10, "WRONG_ANSWER"), day(3, 10, "OK"),
+		}, 1},
+		{"solves on another judge fill the gap", []domain.JudgeSubmission{
+			day(1, 10, "OK"), day(2, 10, "WRONG_ANSWER"), day(3, 10, "OK"),
+			{Judge: domain.JudgeAtCoder, SubmittedAt: time.Date(2024, 3, 2, 21, 0, 0, 0, tehran), Verdict: "OK"},
+		}, 3},
+	}
+
+	for _, tt := range tests {
+		if got := longestStreak(tt.submissions, tehran); got != tt.want {
+			t.Errorf("%s: longestStreak() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,38 +1,111 @@
 package service
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/logging"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/metrics"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
-	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
 )
 
 type SyncService interface {
-	SyncAllUsers() error
-	SyncUser(user *domain.User) error
+	SyncAllUsers(ctx context.Context) error
+	SyncUser(ctx context.Context, user *domain.User) error
+	// RecomputeStreaks recalculates every user's current and longest streak
+	// from the stored submissions, without calling any judge, and returns
+	// how many users changed.
+	RecomputeStreaks(ctx context.Context) (int, error)
+	// LastSuccessfulSync reports when the last SyncAllUsers run finished
+	// with at least one user synced; ok is false until one has.
+	LastSuccessfulSync() (at time.Time, ok bool)
 }
 
 type syncService struct {
-	userRepo       repository.UserRepository
-	submissionRepo repository.SubmissionRepository
-	cfClient       *codeforces.Client
-	workerPoolSize int
+	userRepo           repository.UserRepository
+	submissionRepo     repository.SubmissionRepository
+	accountRepo        repository.AccountRepository
+	achievementService AchievementService
+	events             EventPublisher
+	responseCache      CacheInvalidator
+	judges             JudgeProviders
+	workerPoolSize     int
+	clock              clock.Clock
+	logger             *slog.Logger
+	lastSuccess        atomic.Pointer[time.Time]
+	userLocks          userLocks
 }
 
 func NewSyncService(
 	userRepo repository.UserRepository,
 	submissionRepo repository.SubmissionRepository,
-	cfClient *codeforces.Client,
+	accountRepo repository.AccountRepository,
+	achievementService AchievementService,
+	events EventPublisher,
+	responseCache CacheInvalidator,
+	judges JudgeProviders,
 	workerPoolSize int,
+	clock clock.Clock,
+	logger *slog.Logger,
 ) SyncService {
 	return &syncService{
-		userRepo:       userRepo,
-		submissionRepo: submissionRepo,
-		cfClient:       cfClient,
-		workerPoolSize: workerPoolSize,
+		userRepo:           userRepo,
+		submissionRepo:     submissionRepo,
+		accountRepo:        accountRepo,
+		achievementService: achievementService,
+		events:             events,
+		responseCache:      responseCache,
+		judges:             judges,
+		workerPoolSize:     workerPoolSize,
+		clock:              clock,
+		logger:             logger,
+	}
+}
+
+// userLocks serialises syncs of the same user, so the scheduled sync, the
+// initial sync and an admin sync cannot both read the same previous streak
+// and announce the change twice.
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[uint]*userLock
+}
+
+type userLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// lock blocks until no other sync of userID is running and returns the
+// function that releases it.
+func (l *userLocks) lock(userID uint) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[uint]*userLock)
+	}
+	lock, ok := l.locks[userID]
+	if !ok {
+		lock = &userLock{}
+		l.locks[userID] = lock
+	}
+	lock.waiters++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.waiters--; lock.waiters == 0 {
+			delete(l.locks, userID)
+		}
 	}
 }
 
@@ -45,19 +118,24 @@ type syncResult struct {
 	err  error
 }
 
-func (s *syncService) SyncAllUsers() error {
-	users, err := s.userRepo.GetAllActiveUsers()
+// SyncAllUsers syncs every active user. Its log records share a sync_run_id
+// so one run can be followed through the worker logs.
+func (s *syncService) SyncAllUsers(ctx context.Context) error {
+	ctx = logging.WithSyncRunID(ctx, logging.NewID())
+
+	users, err := s.userRepo.GetAllActiveUsers(ctx)
 	if err != nil {
 		return err
 	}
 
 	if len(users) == 0 {
-		log.Println("No active users to sync")
+		s.logger.InfoContext(ctx, "No active users to sync")
+		s.recordSuccess(s.clock.Now())
 		return nil
 	}
 
-	log.Printf("Starting sync for %d users with %d workers", len(users), s.workerPoolSize)
-	startTime := time.Now()
+	s.logger.InfoContext(ctx, "Starting sync", "users", len(users), "workers", s.workerPoolSize)
+	startTime := s.clock.Now()
 
 	// Create channels
 	jobs := make(chan syncJob, len(users))
@@ -67,7 +145,7 @@ func (s *syncService) SyncAllUsers() error {
 	var wg sync.WaitGroup
 	for w := 0; w < s.workerPoolSize; w++ {
 		wg.Add(1)
-		go s.worker(w, jobs, results, &wg)
+		go s.worker(ctx, w, jobs, results, &wg)
 	}
 
 	// Send jobs
@@ -87,27 +165,62 @@ func (s *syncService) SyncAllUsers() error {
 	errorCount := 0
 	for result := range results {
 		if result.err != nil {
-			log.Printf("Error syncing user %s: %v", result.user.CodeforcesHandle, result.err)
+			s.logger.ErrorContext(ctx, "Error syncing user", "user_id", result.user.ID, "error", result.err)
 			errorCount++
 		} else {
 			successCount++
 		}
 	}
 
-	duration := time.Since(startTime)
-	log.Printf("Sync completed: %d successful, %d errors in %v", successCount, errorCount, duration)
+	finishedAt := s.clock.Now()
+	duration := finishedAt.Sub(startTime)
+	s.logger.InfoContext(ctx, "Sync completed", "successful", successCount, "errors", errorCount, "duration", duration)
+
+	metrics.SyncDuration.Observe(duration.Seconds())
+	metrics.LastSyncTimestamp.Set(float64(finishedAt.Unix()))
+	metrics.SyncedUsers.WithLabelValues("success").Add(float64(successCount))
+	metrics.SyncedUsers.WithLabelValues("error").Add(float64(errorCount))
+
+	// Users that failed to sync keep their previous streak, which is what
+	// the leaderboard shows too.
+	activeStreaks := 0
+	for _, user := range users {
+		if user.CurrentStreak > 0 {
+			activeStreaks++
+		}
+	}
+	metrics.ActiveStreakUsers.Set(float64(activeStreaks))
 
+	invalidateResponses(ctx, s.responseCache, s.logger)
+
+	// Per-user failures, usually Codeforces errors, do not fail the run, but
+	// a run in which nobody synced is not a success: readiness then reports
+	// the sync as stale once the failures last.
+	if successCount == 0 {
+		return fmt.Errorf("all %d users failed to sync", errorCount)
+	}
+	s.recordSuccess(finishedAt)
 	return nil
 }
 
-func (s *syncService) worker(id int, jobs <-chan syncJob, results chan<- syncResult, wg *sync.WaitGroup) {
+func (s *syncService) recordSuccess(at time.Time) {
+	s.lastSuccess.Store(&at)
+}
+
+func (s *syncService) LastSuccessfulSync() (time.Time, bool) {
+	at := s.lastSuccess.Load()
+	if at == nil {
+		return time.Time{}, false
+	}
+	return *at, true
+}
+
+func (s *syncService) worker(ctx context.Context, id int, jobs <-chan syncJob, results chan<- syncResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for job := range jobs {
-		// Add small delay to respect API rate limits
-		time.Sleep(200 * time.Millisecond)
-
-		err := s.SyncUser(job.user)
+		// Each judge client paces requests across all workers
+		err := s.SyncUser(ctx, job.user)
 		results <- syncResult{
 			user: job.user,
 			err:  err,
@@ -115,121 +228,359 @@ func (s *syncService) worker(id int, jobs <-chan syncJob, results chan<- syncRes
 	}
 }
 
-func (s *syncService) SyncUser(user *domain.User) error {
-	// Fetch recent submissions
-	submissions, err := s.cfClient.GetUserSubmissions(user.CodeforcesHandle, 5000)
+// SyncUser reads every judge account of the user. A solve on any of them
+// counts towards the streak; the user's rating and rank are those of their
+// first Codeforces account, if they have one. An account that cannot be read is
+// logged and left as it was, and its stored submissions still count, so one
+// judge being down does not hold back the others. Only when no account can
+// be read is nothing changed. Syncs of the same user run one at a time, each
+// starting from the stored row, so user is reloaded first.
+func (s *syncService) SyncUser(ctx context.Context, user *domain.User) error {
+	unlock := s.userLocks.lock(user.ID)
+	defer unlock()
+
+	stored, err := s.userRepo.FindByID(ctx, user.ID)
 	if err != nil {
 		return err
 	}
+	*user = *stored
+	before := *user
 
-	// Fetch updated user info
-	userInfo, err := s.cfClient.GetUserInfo(user.CodeforcesHandle)
+	all, err := s.accountRepo.FindByUser(ctx, user.ID)
 	if err != nil {
-		log.Printf("Warning: Could not fetch user info for %s: %v", user.CodeforcesHandle, err)
-	} else {
-		user.Rating = userInfo.Rating
-		user.Rank = userInfo.Rank
+		return err
+	}
+
+	rated := ratingAccount(all)
+
+	// Fetch recent submissions of every account
+	var accounts []domain.JudgeAccount
+	var fetched [][]domain.JudgeSubmission
+	var lastErr error
+	for _, account := range all {
+		submissions, err := s.fetchSubmissions(ctx, account)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Could not fetch submissions", "user_id", user.ID,
+				"judge", account.Judge, "account", account.Handle, "error", err)
+			metrics.SyncErrors.WithLabelValues(account.Judge).Inc()
+			lastErr = err
+			continue
+		}
+		accounts = append(accounts, account)
+		fetched = append(fetched, submissions)
+	}
+	if len(accounts) == 0 && lastErr != nil {
+		return lastErr
+	}
+
+	// Fetch updated profiles
+	now := s.clock.Now()
+	for i := range accounts {
+		account := &accounts[i]
+		profile, err := s.judges[account.Judge].FetchProfile(ctx, account.Handle)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Could not fetch profile", "user_id", user.ID,
+				"judge", account.Judge, "account", account.Handle, "error", err)
+		} else {
+			account.Rating = profile.Rating
+			account.Rank = profile.Rank
+			if rated != nil && account.ID == rated.ID {
+				user.Rating = profile.Rating
+				user.Rank = profile.Rank
+			}
+		}
+		account.LastCheckedAt = &now
 	}
 
 	// Store new submissions
-	if err := s.storeSubmissions(user.ID, submissions); err != nil {
+	var submissions []domain.JudgeSubmission
+	for i := range accounts {
+		if err := s.storeSubmissions(ctx, user.ID, fetched[i]); err != nil {
+			return err
+		}
+		submissions = append(submissions, fetched[i]...)
+	}
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].SubmittedAt.After(submissions[j].SubmittedAt)
+	})
+
+	for i := range accounts {
+		if err := s.accountRepo.Update(ctx, &accounts[i]); err != nil {
+			return err
+		}
+	}
+
+	// Some judges only report recent submissions, so the streak is
+	// calculated over everything stored, with fetched verdicts taking
+	// precedence in case of rejudges.
+	history, err := s.mergeStoredSubmissions(ctx, user.ID, submissions)
+	if err != nil {
 		return err
 	}
 
 	// Calculate and update streak
-	streak := s.calculateStreak(submissions)
-	now := time.Now()
+	previousStreak := user.CurrentStreak
+	streak := calculateStreak(history, now, userLocation(user))
 
 	user.CurrentStreak = streak
 	if streak > user.MaxStreak {
 		user.MaxStreak = streak
 	}
 
-	if len(submissions) > 0 {
-		latestSubmission := submissions[0]
-		submissionTime := time.Unix(latestSubmission.CreationTimeSeconds, 0)
+	if len(history) > 0 {
+		submissionTime := history[0].SubmittedAt
 		user.LastSubmissionAt = &submissionTime
 	}
 
 	user.LastCheckedAt = &now
-	user.TotalSubmissions = len(submissions)
+	user.TotalSubmissions = len(history)
+
+	// The previous rank has to be read while the stored row is unchanged.
+	rowChanged := user.CurrentStreak != before.CurrentStreak ||
+		user.MaxStreak != before.MaxStreak ||
+		user.Rating != before.Rating
+	previousRank := 0
+	if rowChanged {
+		if previousRank, err = s.userRepo.GetUserRank(ctx, &before); err != nil {
+			s.logger.WarnContext(ctx, "Could not read previous rank", "user_id", user.ID, "error", err)
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.publishStreakChange(ctx, user, previousStreak)
+	if rowChanged {
+		// Live clients refetch on this event, so the cached leaderboard
+		// must not outlive it.
+		invalidateResponses(ctx, s.responseCache, s.logger)
+		s.publishLeaderboardEntry(ctx, user, previousRank)
+	}
+
+	// Achievements look at the whole history too: a month's solves can
+	// outnumber what a judge reports at once.
+	unlocked, err := s.achievementService.Evaluate(ctx, user, history)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Could not evaluate achievements", "user_id", user.ID, "error", err)
+	}
+	for _, achievement := range unlocked {
+		s.events.Publish(ctx, newEvent(s.clock, domain.EventAchievementUnlocked, domain.AchievementEventData{
+			UserID:      user.ID,
+			Name:        user.Name(),
+			Achievement: achievement,
+		}))
+	}
 
-	return s.userRepo.Update(user)
+	return nil
 }
 
-func (s *syncService) storeSubmissions(userID uint, cfSubmissions []domain.CodeforcesSubmission) error {
-	var newSubmissions []domain.Submission
+func (s *syncService) fetchSubmissions(ctx context.Context, account domain.JudgeAccount) ([]domain.JudgeSubmission, error) {
+	judge, ok := s.judges[account.Judge]
+	if !ok {
+		return nil, fmt.Errorf("no provider for judge %q", account.Judge)
+	}
+	submissions, err := judge.FetchSubmissions(ctx, account.Handle)
+	if err != nil {
+		return nil, fmt.Errorf("%s account %s: %w", account.Judge, account.Handle, err)
+	}
+	return submissions, nil
+}
 
-	for _, cfSub := range cfSubmissions {
-		// Check if submission already exists
-		_, err := s.submissionRepo.FindByCodeforcesID(int64(cfSub.ID))
-		if err == nil {
-			continue // Already exists
+func (s *syncService) RecomputeStreaks(ctx context.Context) (int, error) {
+	users, err := s.userRepo.GetAllActiveUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.clock.Now()
+	updated := 0
+	for i := range users {
+		user := &users[i]
+
+		stored, err := s.submissionRepo.GetSubmissionsAfter(ctx, user.ID, time.Time{})
+		if err != nil {
+			return updated, err
+		}
+		submissions := make([]domain.JudgeSubmission, len(stored))
+		for j, sub := range stored {
+			submissions[j] = storedSubmission(sub)
 		}
 
-		submission := domain.Submission{
-			UserID:                 userID,
-			CodeforcesSubmissionID: int64(cfSub.ID),
-			//ProblemName:            cfSub.Problem.Name,
-			//ContestID:              cfSub.ContestID,
-			//ProblemIndex:           cfSub.Problem.Index,
-			Verdict:                cfSub.Verdict,
-			//ProgrammingLanguage:    cfSub.ProgrammingLanguage,
-			SubmittedAt:            time.Unix(cfSub.CreationTimeSeconds, 0),
+		loc := userLocation(user)
+		current := calculateStreak(submissions, now, loc)
+		longest := max(longestStreak(submissions, loc), current)
+		if current == user.CurrentStreak && longest == user.MaxStreak {
+			continue
 		}
 
-		newSubmissions = append(newSubmissions, submission)
+		s.logger.InfoContext(ctx, "Recomputed streak", "user_id", user.ID,
+			"previous_streak", user.CurrentStreak, "current_streak", current,
+			"previous_max_streak", user.MaxStreak, "max_streak", longest)
+		user.CurrentStreak = current
+		user.MaxStreak = longest
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return updated, err
+		}
+		updated++
 	}
 
-	if len(newSubmissions) > 0 {
-		return s.submissionRepo.BulkCreate(newSubmissions)
+	if updated > 0 {
+		invalidateResponses(ctx, s.responseCache, s.logger)
+	}
+	return updated, nil
+}
+
+func (s *syncService) publishStreakChange(ctx context.Context, user *domain.User, previousStreak int) {
+	eventType := ""
+	switch {
+	case user.CurrentStreak > previousStreak:
+		eventType = domain.EventStreakExtended
+	case user.CurrentStreak < previousStreak:
+		eventType = domain.EventStreakBroken
+	default:
+		return
 	}
 
-	return nil
+	s.events.Publish(ctx, newEvent(s.clock, eventType, domain.StreakEventData{
+		UserID:         user.ID,
+		Name:           user.Name(),
+		PreviousStreak: previousStreak,
+		CurrentStreak:  user.CurrentStreak,
+		MaxStreak:      user.MaxStreak,
+	}))
 }
 
-func (s *syncService) calculateStreak(submissions []domain.CodeforcesSubmission) int {
-	if len(submissions) == 0 {
-		return 0
+// publishLeaderboardEntry announces a user's new leaderboard row, and their
+// move if it changed their rank. A previousRank of 0 means it could not be
+// read.
+func (s *syncService) publishLeaderboardEntry(ctx context.Context, user *domain.User, previousRank int) {
+	rank, err := s.userRepo.GetUserRank(ctx, user)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Could not read rank", "user_id", user.ID, "error", err)
+		return
+	}
+
+	s.events.Publish(ctx, newEvent(s.clock, domain.EventLeaderboardEntry, domain.LeaderboardEntryEventData{
+		UserID:        user.ID,
+		Name:          user.Name(),
+		PreviousRank:  previousRank,
+		CurrentRank:   rank,
+		CurrentStreak: user.CurrentStreak,
+		MaxStreak:     user.MaxStreak,
+		Rating:        user.Rating,
+	}))
+
+	if previousRank != 0 && previousRank != rank {
+		s.events.Publish(ctx, newEvent(s.clock, domain.EventLeaderboardRankMoved, domain.RankChangedEventData{
+			UserID:       user.ID,
+			Name:         user.Name(),
+			PreviousRank: previousRank,
+			CurrentRank:  rank,
+		}))
 	}
+}
 
-	tehranLoc, err := time.LoadLocation("Asia/Tehran")
+// mergeStoredSubmissions adds the user's stored submissions that were not
+// fetched to fetched, which must be newest first, and returns the result
+// newest first.
+func (s *syncService) mergeStoredSubmissions(ctx context.Context, userID uint, fetched []domain.JudgeSubmission) ([]domain.JudgeSubmission, error) {
+	stored, err := s.submissionRepo.GetSubmissionsAfter(ctx, userID, time.Time{})
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	type key struct {
+		judge string
+		id    int64
+	}
+	seen := make(map[key]bool, len(fetched))
+	for _, sub := range fetched {
+		seen[key{sub.Judge, sub.ID}] = true
 	}
 
-	submissionsByDate := make(map[string]bool)
-	for _, sub := range submissions {
-		if sub.Verdict == "OK" {
-			t := time.Unix(sub.CreationTimeSeconds, 0)
-			localDate := t.In(tehranLoc).Format("2006-01-02")
-			submissionsByDate[localDate] = true
+	merged := append([]domain.JudgeSubmission(nil), fetched...)
+	for _, sub := range stored {
+		if !seen[key{sub.Judge, sub.CodeforcesSubmissionID}] {
+			merged = append(merged, storedSubmission(sub))
 		}
 	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].SubmittedAt.After(merged[j].SubmittedAt)
+	})
+	return merged, nil
+}
 
-	now := time.Now().In(tehranLoc)
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, tehranLoc)
-	yesterday := today.AddDate(0, 0, -1)
+// missingDetails reports whether a stored submission lacks problem details
+// that a fetch of it has.
+func missingDetails(stored *domain.Submission, fetched domain.JudgeSubmission) bool {
+	return stored.ProblemID == "" && stored.ProblemRating == 0 && stored.Language == "" &&
+		(fetched.ProblemID != "" || fetched.ProblemRating != 0 || fetched.Language != "")
+}
 
-	streak := 0
-	currentDate := yesterday
+// storedSubmission converts a stored submission for the streak and
+// achievement rules.
+func storedSubmission(sub domain.Submission) domain.JudgeSubmission {
+	return domain.JudgeSubmission{
+		Judge:         sub.Judge,
+		ID:            sub.CodeforcesSubmissionID,
+		ProblemID:     sub.ProblemID,
+		ProblemRating: sub.ProblemRating,
+		Language:      sub.Language,
+		Verdict:       sub.Verdict,
+		SubmittedAt:   sub.SubmittedAt,
+	}
+}
 
-	for {
-		dateStr := currentDate.Format("2006-01-02")
+// ratingAccount returns the account a user's rating and rank are taken
+// from: the first Codeforces account in accounts, or nil without one.
+func ratingAccount(accounts []domain.JudgeAccount) *domain.JudgeAccount {
+	for i := range accounts {
+		if accounts[i].Judge == domain.JudgeCodeforces {
+			return &accounts[i]
+		}
+	}
+	return nil
+}
 
-		if submissionsByDate[dateStr] {
-			streak++
-			currentDate = currentDate.AddDate(0, 0, -1)
-		} else {
-			// Gap found → stop counting backward
-			break
+// storeSubmissions stores the submissions not stored yet. Stored ones that
+// predate problem details being kept get them filled in from the fetch, so
+// achievements see them on older history too.
+func (s *syncService) storeSubmissions(ctx context.Context, userID uint, judgeSubmissions []domain.JudgeSubmission) error {
+	var newSubmissions []domain.Submission
+
+	for _, judgeSub := range judgeSubmissions {
+		// Check if submission already exists
+		existing, err := s.submissionRepo.FindByJudgeID(ctx, judgeSub.Judge, judgeSub.ID)
+		if err == nil {
+			if missingDetails(existing, judgeSub) {
+				existing.ProblemID = judgeSub.ProblemID
+				existing.ProblemRating = judgeSub.ProblemRating
+				existing.Language = judgeSub.Language
+				if err := s.submissionRepo.UpdateDetails(ctx, existing); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		submission := domain.Submission{
+			UserID:                 userID,
+			Judge:                  judgeSub.Judge,
+			CodeforcesSubmissionID: judgeSub.ID,
+			Verdict:                judgeSub.Verdict,
+			ProblemID:              judgeSub.ProblemID,
+			ProblemRating:          judgeSub.ProblemRating,
+			Language:               judgeSub.Language,
+			SubmittedAt:            judgeSub.SubmittedAt.UTC(),
 		}
+
+		newSubmissions = append(newSubmissions, submission)
 	}
 
-	// Finally, check if today has a submission — if yes, extend the streak
-	if submissionsByDate[today.Format("2006-01-02")] {
-		streak++
+	if len(newSubmissions) > 0 {
+		return s.submissionRepo.BulkCreate(ctx, newSubmissions)
 	}
 
-	return streak
+	return nil
 }
@@ -1,44 +1,105 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
-	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
 	"gorm.io/gorm"
 )
 
 type UserService interface {
-	AddUser(handle string) (*domain.User, error)
-	GetLeaderboard(page, pageSize int) ([]domain.UserResponse, int64, error)
-	GetUserByHandle(handle string) (*domain.User, error)
-	UpdateUserStreaks(user *domain.User, submissions []domain.CodeforcesSubmission) error
+	AddUser(ctx context.Context, judge, handle string) (*domain.User, error)
+	RemoveUser(ctx context.Context, userID uint) error
+	GetLeaderboard(ctx context.Context, page, pageSize int, group, sort string) ([]domain.UserResponse, int64, error)
+	GetUser(ctx context.Context, userID uint) (*domain.User, error)
+	GetUserByAccount(ctx context.Context, judge, handle string) (*domain.User, error)
+	GetUserStanding(ctx context.Context, userID uint) (*domain.UserResponse, error)
+	GetUsersByIDs(ctx context.Context, userIDs []uint) ([]domain.User, error)
+	GetLeaderboardRanks(ctx context.Context, userIDs []uint) (map[uint]int, error)
+	GetUserSubmissions(ctx context.Context, userID uint, page, pageSize int) ([]domain.Submission, int64, error)
+	ExportLeaderboard(ctx context.Context, group, sort string) iter.Seq2[domain.UserResponse, error]
+	ExportUserSubmissions(ctx context.Context, userID uint) iter.Seq2[domain.Submission, error]
+	GetRecentSubmissions(ctx context.Context, userIDs []uint, limit int) (map[uint][]domain.Submission, error)
+	GetActivityHeatmaps(ctx context.Context, users []*domain.User, days int) (map[uint][]domain.HeatmapDay, error)
+	UpdateUserStreaks(ctx context.Context, user *domain.User, submissions []domain.JudgeSubmission) error
+	GetAccounts(ctx context.Context, userID uint) ([]domain.JudgeAccount, error)
+	LinkAccount(ctx context.Context, userID uint, judge, accountHandle string) (*domain.JudgeAccount, error)
+	UnlinkAccount(ctx context.Context, userID uint, judge, accountHandle string) error
+	UpdateProfile(ctx context.Context, userID uint, update domain.ProfileUpdate) (*domain.User, error)
 }
 
+var (
+	ErrUnknownJudge   = errors.New("unknown judge")
+	ErrAccountLinked  = errors.New("account is already linked")
+	ErrLastAccount    = errors.New("a user's only account cannot be unlinked")
+	ErrInvalidProfile = errors.New("invalid profile")
+	ErrInvalidSort    = errors.New("invalid sort")
+)
+
+// maxDisplayNameLength is the longest display name, in characters.
+const maxDisplayNameLength = 64
+
 type userService struct {
 	userRepo       repository.UserRepository
 	submissionRepo repository.SubmissionRepository
-	cfClient       *codeforces.Client
+	snapshotRepo   repository.SnapshotRepository
+	groupRepo      repository.GroupRepository
+	accountRepo    repository.AccountRepository
+	events         EventPublisher
+	responseCache  CacheInvalidator
+	judges         JudgeProviders
+	clock          clock.Clock
+	logger         *slog.Logger
 }
 
 func NewUserService(
 	userRepo repository.UserRepository,
 	submissionRepo repository.SubmissionRepository,
-	cfClient *codeforces.Client,
+	snapshotRepo repository.SnapshotRepository,
+	groupRepo repository.GroupRepository,
+	accountRepo repository.AccountRepository,
+	events EventPublisher,
+	responseCache CacheInvalidator,
+	judges JudgeProviders,
+	clock clock.Clock,
+	logger *slog.Logger,
 ) UserService {
 	return &userService{
 		userRepo:       userRepo,
 		submissionRepo: submissionRepo,
-		cfClient:       cfClient,
+		snapshotRepo:   snapshotRepo,
+		groupRepo:      groupRepo,
+		accountRepo:    accountRepo,
+		events:         events,
+		responseCache:  responseCache,
+		judges:         judges,
+		clock:          clock,
+		logger:         logger,
 	}
 }
 
-func (s *userService) AddUser(handle string) (*domain.User, error) {
+// AddUser starts tracking the person a handle on judge belongs to, with that
+// handle as their first account. A handle that is already tracked returns
+// its person unchanged.
+func (s *userService) AddUser(ctx context.Context, judge, handle string) (*domain.User, error) {
+	provider, ok := s.judges[strings.ToLower(judge)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJudge, judge)
+	}
+
 	// Check if user already exists
-	existingUser, err := s.userRepo.FindByHandle(handle)
+	existingUser, err := s.userRepo.FindByAccount(ctx, provider.Judge(), handle)
 	if err == nil {
 		return existingUser, nil
 	}
@@ -46,36 +107,84 @@ func (s *userService) AddUser(handle string) (*domain.User, error) {
 		return nil, err
 	}
 
-	// Validate handle with Codeforces API
-	userInfo, err := s.cfClient.GetUserInfo(handle)
+	// Validate handle with the judge, which may spell it differently
+	profile, err := provider.FetchProfile(ctx, handle)
 	if err != nil {
 		return nil, err
 	}
+	if profile.Handle != handle {
+		existingUser, err := s.userRepo.FindByAccount(ctx, provider.Judge(), profile.Handle)
+		if err == nil {
+			return existingUser, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
 
 	// Create new user
-	user := &domain.User{
-		CodeforcesHandle: userInfo.Handle,
-		Rating:           userInfo.Rating,
-		Rank:             userInfo.Rank,
+	account := &domain.JudgeAccount{
+		Judge:  provider.Judge(),
+		Handle: profile.Handle,
+		Rating: profile.Rating,
+		Rank:   profile.Rank,
+	}
+	user := &domain.User{}
+	if account.Judge == domain.JudgeCodeforces {
+		user.Rating = profile.Rating
+		user.Rank = profile.Rank
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user, account); err != nil {
 		return nil, err
 	}
 
-	log.Printf("Added new user: %s", handle)
+	invalidateResponses(ctx, s.responseCache, s.logger)
+	s.logger.InfoContext(ctx, "Added new user", "user_id", user.ID, "judge", account.Judge, "account", account.Handle)
+
+	s.events.Publish(ctx, newEvent(s.clock, domain.EventUserAdded, domain.UserAddedEventData{
+		UserID: user.ID,
+		Name:   user.Name(),
+		Rating: user.Rating,
+		Rank:   user.Rank,
+	}))
+
 	return user, nil
 }
 
-func (s *userService) GetLeaderboard(page, pageSize int) ([]domain.UserResponse, int64, error) {
+// RemoveUser stops tracking a person and deletes everything stored for them.
+func (s *userService) RemoveUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(ctx, user); err != nil {
+		return err
+	}
+
+	invalidateResponses(ctx, s.responseCache, s.logger)
+	s.logger.InfoContext(ctx, "Removed user", "user_id", user.ID)
+	return nil
+}
+
+// GetLeaderboard returns one page of the leaderboard. A non-empty group ranks
+// only that group's members, and sort, one of domain.LeaderboardSorts or
+// empty for the default, picks the order ranks are counted in.
+func (s *userService) GetLeaderboard(ctx context.Context, page, pageSize int, group, sort string) ([]domain.UserResponse, int64, error) {
 	offset := (page - 1) * pageSize
 
-	users, err := s.userRepo.GetLeaderboard(pageSize, offset)
+	filter, err := s.leaderboardFilter(ctx, group, sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := s.userRepo.GetLeaderboard(ctx, filter, pageSize, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	total, err := s.userRepo.CountUsers()
+	total, err := s.userRepo.CountUsers(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -85,20 +194,233 @@ func (s *userService) GetLeaderboard(page, pageSize int) ([]domain.UserResponse,
 		responses[i] = user.ToResponse(offset + i + 1)
 	}
 
+	if err := applyStandingChanges(ctx, s.snapshotRepo, s.clock.Now(), responses, filter.DefaultRanking()); err != nil {
+		return nil, 0, err
+	}
+
 	return responses, total, nil
 }
 
-func (s *userService) GetUserByHandle(handle string) (*domain.User, error) {
-	return s.userRepo.FindByHandle(handle)
+// exportBatchSize is how many rows an export reads from the database at a
+// time, so memory stays flat however large the export is.
+const exportBatchSize = 500
+
+// ExportLeaderboard iterates over the whole leaderboard, or one group's,
+// ranked by sort as in GetLeaderboard. Rows are read in batches of user IDs as
+// the caller consumes them, so each user is exported once even if a sync
+// reorders the leaderboard part way through; a missing group or unknown sort
+// is reported before the first row.
+func (s *userService) ExportLeaderboard(ctx context.Context, group, sort string) iter.Seq2[domain.UserResponse, error] {
+	return func(yield func(domain.UserResponse, error) bool) {
+		filter, err := s.leaderboardFilter(ctx, group, sort)
+		if err != nil {
+			yield(domain.UserResponse{}, err)
+			return
+		}
+
+		var afterID uint
+		for {
+			users, err := s.userRepo.GetRankedUsersAfter(ctx, filter, afterID, exportBatchSize)
+			if err != nil {
+				yield(domain.UserResponse{}, err)
+				return
+			}
+
+			responses := make([]domain.UserResponse, len(users))
+			for i, user := range users {
+				responses[i] = user.ToResponse(user.LeaderboardRank)
+			}
+			if err := applyStandingChanges(ctx, s.snapshotRepo, s.clock.Now(), responses, filter.DefaultRanking()); err != nil {
+				yield(domain.UserResponse{}, err)
+				return
+			}
+
+			for _, response := range responses {
+				if !yield(response, nil) {
+					return
+				}
+			}
+			if len(users) < exportBatchSize {
+				return
+			}
+			afterID = users[len(users)-1].ID
+		}
+	}
+}
+
+func (s *userService) leaderboardFilter(ctx context.Context, group, sort string) (repository.LeaderboardFilter, error) {
+	filter := repository.LeaderboardFilter{Sort: sort}
+	if sort != "" && !slices.Contains(domain.LeaderboardSorts, sort) {
+		return filter, fmt.Errorf("%w %q: want one of %s", ErrInvalidSort, sort, strings.Join(domain.LeaderboardSorts, ", "))
+	}
+	if group != "" {
+		g, err := s.groupRepo.FindByName(ctx, group)
+		if err != nil {
+			return filter, err
+		}
+		filter.GroupID = g.ID
+	}
+	return filter, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, userID uint) (*domain.User, error) {
+	return s.userRepo.FindByID(ctx, userID)
+}
+
+// GetUserByAccount finds the person a handle on a judge is linked to.
+func (s *userService) GetUserByAccount(ctx context.Context, judge, handle string) (*domain.User, error) {
+	return s.userRepo.FindByAccount(ctx, strings.ToLower(judge), handle)
+}
+
+func (s *userService) GetUserStanding(ctx context.Context, userID uint) (*domain.UserResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rank, err := s.userRepo.GetUserRank(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []domain.UserResponse{user.ToResponse(rank)}
+	if err := applyStandingChanges(ctx, s.snapshotRepo, s.clock.Now(), responses, true); err != nil {
+		return nil, err
+	}
+
+	return &responses[0], nil
+}
+
+// The batch lookups below serve the GraphQL loaders: each answers for many
+// users with a single query.
+
+func (s *userService) GetUsersByIDs(ctx context.Context, userIDs []uint) ([]domain.User, error) {
+	return s.userRepo.FindByIDs(ctx, userIDs)
+}
+
+func (s *userService) GetLeaderboardRanks(ctx context.Context, userIDs []uint) (map[uint]int, error) {
+	return s.userRepo.GetUserRanks(ctx, userIDs)
+}
+
+// GetUserSubmissions returns one page of a user's stored submissions,
+// newest first, and how many there are in total.
+func (s *userService) GetUserSubmissions(ctx context.Context, userID uint, page, pageSize int) ([]domain.Submission, int64, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	submissions, err := s.submissionRepo.GetUserSubmissionsPage(ctx, user.ID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.submissionRepo.CountUserSubmissions(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return submissions, total, nil
+}
+
+// ExportUserSubmissions iterates over all of a user's submissions, newest
+// first, reading them in batches as the caller consumes them.
+func (s *userService) ExportUserSubmissions(ctx context.Context, userID uint) iter.Seq2[domain.Submission, error] {
+	return func(yield func(domain.Submission, error) bool) {
+		user, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			yield(domain.Submission{}, err)
+			return
+		}
+
+		for offset := 0; ; offset += exportBatchSize {
+			submissions, err := s.submissionRepo.GetUserSubmissionsPage(ctx, user.ID, exportBatchSize, offset)
+			if err != nil {
+				yield(domain.Submission{}, err)
+				return
+			}
+			for _, submission := range submissions {
+				if !yield(submission, nil) {
+					return
+				}
+			}
+			if len(submissions) < exportBatchSize {
+				return
+			}
+		}
+	}
+}
+
+func (s *userService) GetRecentSubmissions(ctx context.Context, userIDs []uint, limit int) (map[uint][]domain.Submission, error) {
+	submissions, err := s.submissionRepo.GetRecentSubmissionsForUsers(ctx, userIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint][]domain.Submission, len(userIDs))
+	for _, sub := range submissions {
+		byUser[sub.UserID] = append(byUser[sub.UserID], sub)
+	}
+	return byUser, nil
 }
 
-func (s *userService) UpdateUserStreaks(user *domain.User, submissions []domain.CodeforcesSubmission) error {
+// GetActivityHeatmaps counts each user's submissions per streak day, in the
+// user's own time zone, over the last days days, today included. Every day
+// is present, oldest first.
+func (s *userService) GetActivityHeatmaps(ctx context.Context, users []*domain.User, days int) (map[uint][]domain.HeatmapDay, error) {
+	now := s.clock.Now()
+	userIDs := make([]uint, len(users))
+	locations := make(map[uint]*time.Location, len(users))
+	firstDays := make(map[uint]time.Time, len(users))
+	heatmaps := make(map[uint][]domain.HeatmapDay, len(users))
+	since := now
+	for i, user := range users {
+		userIDs[i] = user.ID
+		loc := userLocation(user)
+		first := calendarDay(now, loc).AddDate(0, 0, -(days - 1))
+		if start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); start.Before(since) {
+			since = start
+		}
+		locations[user.ID] = loc
+		firstDays[user.ID] = first
+
+		heatmap := make([]domain.HeatmapDay, days)
+		for i := range heatmap {
+			heatmap[i].Date = first.AddDate(0, 0, i).Format("2006-01-02")
+		}
+		heatmaps[user.ID] = heatmap
+	}
+
+	submissions, err := s.submissionRepo.GetSubmissionsSinceForUsers(ctx, userIDs, since)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range submissions {
+		heatmap, ok := heatmaps[sub.UserID]
+		if !ok {
+			continue
+		}
+		day := calendarDay(sub.SubmittedAt, locations[sub.UserID])
+		i := int(day.Sub(firstDays[sub.UserID]).Hours() / 24)
+		if i < 0 || i >= days {
+			continue
+		}
+		heatmap[i].Submissions++
+		if sub.Verdict == "OK" {
+			heatmap[i].Accepted++
+		}
+	}
+	return heatmaps, nil
+}
+
+func (s *userService) UpdateUserStreaks(ctx context.Context, user *domain.User, submissions []domain.JudgeSubmission) error {
 	if len(submissions) == 0 {
 		return nil
 	}
 
 	// Calculate streak
-	streak := s.calculateStreak(submissions)
+	streak := calculateStreak(submissions, s.clock.Now(), userLocation(user))
 
 	// Update user fields
 	user.CurrentStreak = streak
@@ -106,45 +428,164 @@ func (s *userService) UpdateUserStreaks(user *domain.User, submissions []domain.
 		user.MaxStreak = streak
 	}
 
-	latestSubmission := submissions[0]
-	submissionTime := time.Unix(latestSubmission.CreationTimeSeconds, 0)
+	submissionTime := submissions[0].SubmittedAt
 	user.LastSubmissionAt = &submissionTime
 
-	return s.userRepo.Update(user)
+	return s.userRepo.Update(ctx, user)
 }
 
-func (s *userService) calculateStreak(submissions []domain.CodeforcesSubmission) int {
-	if len(submissions) == 0 {
-		return 0
+// GetAccounts lists a user's judge accounts in the order they were linked.
+func (s *userService) GetAccounts(ctx context.Context, userID uint) ([]domain.JudgeAccount, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
+	return s.accountRepo.FindByUser(ctx, user.ID)
+}
 
-	// Group submissions by date
-	submissionsByDate := make(map[string]bool)
-	for _, sub := range submissions {
-		if sub.Verdict == "OK" {
-			date := time.Unix(sub.CreationTimeSeconds, 0).Format("2006-01-02")
-			submissionsByDate[date] = true
+// LinkAccount adds a handle on a judge to a user. Its solves count towards
+// the user's streak from the next sync on.
+func (s *userService) LinkAccount(ctx context.Context, userID uint, judge, accountHandle string) (*domain.JudgeAccount, error) {
+	provider, ok := s.judges[strings.ToLower(judge)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJudge, judge)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountHandle, err = provider.ValidateHandle(ctx, accountHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.accountRepo.FindByJudgeHandle(ctx, provider.Judge(), accountHandle)
+	if err == nil {
+		if existing.UserID == user.ID {
+			return existing, nil
 		}
+		return nil, ErrAccountLinked
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
 	}
 
-	// Calculate consecutive days
-	streak := 0
-	currentDate := time.Now()
+	account := &domain.JudgeAccount{
+		UserID: user.ID,
+		Judge:  provider.Judge(),
+		Handle: accountHandle,
+	}
+	if err := s.accountRepo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+
+	invalidateResponses(ctx, s.responseCache, s.logger)
+	s.logger.InfoContext(ctx, "Linked account", "user_id", user.ID, "judge", account.Judge, "account", account.Handle)
+	return account, nil
+}
+
+// UnlinkAccount removes a judge account from a user, who must keep at least
+// one. Submissions already stored from it are kept. Unlinking the account
+// the user's rating comes from moves it to their next Codeforces account, or
+// clears it.
+func (s *userService) UnlinkAccount(ctx context.Context, userID uint, judge, accountHandle string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
 
-	for {
-		dateStr := currentDate.Format("2006-01-02")
-		if submissionsByDate[dateStr] {
-			streak++
-			currentDate = currentDate.AddDate(0, 0, -1)
-		} else {
-			// Allow one day gap for today
-			if streak == 0 && dateStr == time.Now().Format("2006-01-02") {
-				currentDate = currentDate.AddDate(0, 0, -1)
-				continue
+	accounts, err := s.accountRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for i := range accounts {
+		account := &accounts[i]
+		if account.Judge != strings.ToLower(judge) || !strings.EqualFold(account.Handle, accountHandle) {
+			continue
+		}
+		if len(accounts) == 1 {
+			return ErrLastAccount
+		}
+		rated := ratingAccount(accounts)
+		if err := s.accountRepo.Delete(ctx, account); err != nil {
+			return err
+		}
+		if rated != nil && rated.ID == account.ID {
+			user.Rating, user.Rank = 0, ""
+			if next := ratingAccount(slices.Delete(slices.Clone(accounts), i, i+1)); next != nil {
+				user.Rating, user.Rank = next.Rating, next.Rank
+			}
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				return err
 			}
-			break
 		}
+		invalidateResponses(ctx, s.responseCache, s.logger)
+		s.logger.InfoContext(ctx, "Unlinked account", "user_id", user.ID, "judge", account.Judge, "account", account.Handle)
+		return nil
 	}
+	return gorm.ErrRecordNotFound
+}
 
-	return streak
+// UpdateProfile changes a user's display name, avatar or time zone. A new
+// time zone moves the boundaries of their streak days, so their streaks are
+// recalculated from the stored submissions straight away.
+func (s *userService) UpdateProfile(ctx context.Context, userID uint, update domain.ProfileUpdate) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.DisplayName != nil {
+		name := strings.TrimSpace(*update.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength {
+			return nil, fmt.Errorf("%w: display_name must be at most %d characters", ErrInvalidProfile, maxDisplayNameLength)
+		}
+		user.DisplayName = name
+	}
+
+	if update.AvatarURL != nil {
+		if *update.AvatarURL != "" {
+			u, err := url.Parse(*update.AvatarURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("%w: avatar_url must be an http(s) URL", ErrInvalidProfile)
+			}
+		}
+		user.AvatarURL = *update.AvatarURL
+	}
+
+	timeZoneChanged := false
+	if update.TimeZone != nil {
+		if *update.TimeZone == "" {
+			return nil, fmt.Errorf("%w: time_zone must not be empty", ErrInvalidProfile)
+		}
+		if _, err := time.LoadLocation(*update.TimeZone); err != nil {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidProfile, *update.TimeZone)
+		}
+		timeZoneChanged = *update.TimeZone != user.TimeZone
+		user.TimeZone = *update.TimeZone
+	}
+
+	if timeZoneChanged {
+		stored, err := s.submissionRepo.GetSubmissionsAfter(ctx, user.ID, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		submissions := make([]domain.JudgeSubmission, len(stored))
+		for i, sub := range stored {
+			submissions[i] = storedSubmission(sub)
+		}
+		loc := userLocation(user)
+		user.CurrentStreak = calculateStreak(submissions, s.clock.Now(), loc)
+		user.MaxStreak = max(longestStreak(submissions, loc), user.CurrentStreak)
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	invalidateResponses(ctx, s.responseCache, s.logger)
+	s.logger.InfoContext(ctx, "Updated profile", "user_id", user.ID, "time_zone", user.TimeZone)
+	return user, nil
 }
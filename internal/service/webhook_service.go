@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/repository"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/clock"
+)
+
+// Headers sent with every webhook delivery. The signature is the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the subscription secret.
+const (
+	WebhookEventHeader     = "X-CodeStreaks-Event"
+	WebhookDeliveryHeader  = "X-CodeStreaks-Delivery"
+	WebhookTimestampHeader = "X-CodeStreaks-Timestamp"
+	WebhookSignatureHeader = "X-CodeStreaks-Signature"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook subscription")
+
+type WebhookService interface {
+	EventPublisher
+	Subscribe(ctx context.Context, targetURL, description string, events []string) (*domain.WebhookSubscription, error)
+	Unsubscribe(ctx context.Context, id uint) error
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetDeliveries(ctx context.Context, id uint, limit int) ([]domain.WebhookDelivery, error)
+	DeliverPending(ctx context.Context) error
+}
+
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	httpClient  *http.Client
+	maxAttempts int
+	clock       clock.Clock
+	logger      *slog.Logger
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, maxAttempts int, clock clock.Clock, logger *slog.Logger) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxAttempts: maxAttempts,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+// Subscribe registers a new subscription. The returned subscription carries
+// the generated signing secret; it is not exposed again afterwards.
+func (s *webhookService) Subscribe(ctx context.Context, targetURL, description string, events []string) (*domain.WebhookSubscription, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an http(s) URL", ErrInvalidWebhook)
+	}
+
+	for _, event := range events {
+		if !slices.Contains(domain.EventTypes, event) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	sub := &domain.WebhookSubscription{
+		URL:         targetURL,
+		Secret:      hex.EncodeToString(secret),
+		Description: description,
+		Events:      strings.Join(events, ","),
+		IsActive:    true,
+	}
+
+	if err := s.webhookRepo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Registered webhook", "id", sub.ID, "host", u.Host)
+	return sub, nil
+}
+
+func (s *webhookService) Unsubscribe(ctx context.Context, id uint) error {
+	return s.webhookRepo.DeleteSubscription(ctx, id)
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return s.webhookRepo.GetSubscriptions(ctx)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, id uint, limit int) ([]domain.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.FindSubscriptionByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetDeliveries(ctx, id, limit)
+}
+
+// Publish writes the event to the outbox of every interested subscription.
+// Failures are logged rather than returned so that a broken outbox never
+// fails the sync that produced the event.
+func (s *webhookService) Publish(ctx context.Context, event domain.Event) {
+	subs, err := s.webhookRepo.GetActiveSubscriptions(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load webhook subscriptions", "event", event.Type, "error", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to encode event", "event", event.Type, "error", err)
+		return
+	}
+
+	now := s.clock.Now().UTC()
+	var entries []domain.WebhookOutbox
+	for _, sub := range subs {
+		if sub.Events != "" && !slices.Contains(strings.Split(sub.Events, ","), event.Type) {
+			continue
+		}
+		entries = append(entries, domain.WebhookOutbox{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         domain.OutboxPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if err := s.webhookRepo.Enqueue(ctx, entries); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to enqueue event", "event", event.Type, "error", err)
+	}
+}
+
+// DeliverPending sends every due outbox entry once. Failed entries are
+// rescheduled with exponential backoff until maxAttempts is reached.
+func (s *webhookService) DeliverPending(ctx context.Context) error {
+	entries, err := s.webhookRepo.GetDueOutbox(ctx, s.clock.Now().UTC(), 100)
+	if err != nil {
+		return err
+	}
+
+	delivered, failed := 0, 0
+	for i := range entries {
+		entry := &entries[i]
+		statusCode, duration, sendErr := s.send(ctx, entry)
+		entry.Attempts++
+
+		delivery := &domain.WebhookDelivery{
+			OutboxID:       entry.ID,
+			SubscriptionID: entry.SubscriptionID,
+			EventType:      entry.EventType,
+			Attempt:        entry.Attempts,
+			StatusCode:     statusCode,
+			DurationMs:     duration.Milliseconds(),
+		}
+
+		if sendErr == nil {
+			now := s.clock.Now()
+			entry.Status = domain.OutboxDelivered
+			entry.DeliveredAt = &now
+			entry.LastError = ""
+			delivered++
+		} else {
+			delivery.Error = sendErr.Error()
+			entry.LastError = sendErr.Error()
+			if entry.Attempts >= s.maxAttempts {
+				entry.Status = domain.OutboxFailed
+			} else {
+				entry.NextAttemptAt = s.clock.Now().UTC().Add(webhookBackoff(entry.Attempts))
+			}
+			failed++
+		}
+
+		if err := s.webhookRepo.LogDelivery(ctx, delivery); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to log webhook delivery", "outbox_id", entry.ID, "error", err)
+		}
+		if err := s.webhookRepo.UpdateOutbox(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	if delivered+failed > 0 {
+		s.logger.InfoContext(ctx, "Webhook dispatch finished", "delivered", delivered, "failed", failed)
+	}
+	return nil
+}
+
+func (s *webhookService) send(ctx context.Context, entry *domain.WebhookOutbox) (int, time.Duration, error) {
+	timestamp := strconv.FormatInt(s.clock.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, entry.Subscription.URL, bytes.NewBufferString(entry.Payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CodeStreaks-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, entry.EventType)
+	req.Header.Set(WebhookDeliveryHeader, entry.EventID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(entry.Subscription.Secret, timestamp, []byte(entry.Payload)))
+
+	start := time.Now()
+	resp, err := s.httpClient.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, duration, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, duration, fmt.Errorf("subscriber returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, duration, nil
+}
+
+// SignWebhookPayload computes the hex signature subscribers should compare
+// against the X-CodeStreaks-Signature header (minus its "sha256=" prefix).
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the delay before the next attempt: 30s doubling per
+// attempt, capped at six hours.
+func webhookBackoff(attempts int) time.Duration {
+	delay := 30 * time.Second << min(attempts-1, 10)
+	return min(delay, 6*time.Hour)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Computed independently with Python's hmac module.
+	const want = "985381259bd90bec7c5e632c7f9ea4a9a4a45655ade985b5acf2041d91979701"
+
+	if got := SignWebhookPayload("secret", "1710491400", []byte(`{"id":"evt-1"}`)); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	if got := SignWebhookPayload("other", "1710491400", []byte(`{"id":"evt-1"}`)); got == want {
+		t.Error("a different secret gave the same signature")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{50, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package stream fans domain events out to live clients connected over
+// Server-Sent Events or WebSocket.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/metrics"
+)
+
+var (
+	// ErrSlowConsumer ends a subscription whose buffer filled up.
+	ErrSlowConsumer = errors.New("subscriber fell behind and was dropped")
+	// ErrClosed ends every subscription when the hub shuts down.
+	ErrClosed = errors.New("stream hub closed")
+)
+
+// Message is an event encoded once for every subscriber.
+type Message struct {
+	ID   string
+	Type string
+	Data []byte // the JSON-encoded domain.Event
+}
+
+// Filter narrows a subscription. Empty fields match everything.
+type Filter struct {
+	Types   []string
+	UserIDs []uint
+}
+
+func (f Filter) matches(event domain.Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.UserIDs) == 0 {
+		return true
+	}
+	return slices.Contains(f.UserIDs, eventUserID(event))
+}
+
+// Subscription receives matching messages on C. The hub never blocks on a
+// subscriber: once its buffer is full it is dropped, C is closed and Err
+// reports ErrSlowConsumer, so the client can reconnect and refetch.
+type Subscription struct {
+	C <-chan Message
+
+	c      chan Message
+	filter Filter
+	err    error // set before c is closed
+}
+
+// Err explains why C was closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+type Hub struct {
+	buffer int
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub returns a hub that buffers up to buffer messages per subscriber.
+func NewHub(buffer int, logger *slog.Logger) *Hub {
+	return &Hub{
+		buffer: buffer,
+		logger: logger,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber. Callers must Unsubscribe when done.
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	c := make(chan Message, h.buffer)
+	sub := &Subscription{C: c, c: c, filter: filter}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.err = ErrClosed
+		close(c)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	metrics.StreamSubscribers.Inc()
+	return sub
+}
+
+// Unsubscribe removes sub. It is safe to call after the hub dropped it.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		h.remove(sub, nil)
+	}
+}
+
+// Subscribers reports how many clients are connected.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+// Publish implements service.EventPublisher. It encodes the event once and
+// hands it to every matching subscriber without blocking.
+func (h *Hub) Publish(ctx context.Context, event domain.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to encode stream event", "event", event.Type, "error", err)
+		return
+	}
+	msg := Message{ID: event.ID, Type: event.Type, Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.c <- msg:
+		default:
+			h.logger.WarnContext(ctx, "Dropping slow stream subscriber", "buffer", h.buffer)
+			metrics.StreamDropped.Inc()
+			h.remove(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// Close ends every subscription so that streaming handlers return, which
+// lets the HTTP server shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub, ErrClosed)
+	}
+}
+
+// remove unregisters sub and closes its channel. h.mu must be held.
+func (h *Hub) remove(sub *Subscription, err error) {
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.c)
+	metrics.StreamSubscribers.Dec()
+}
+
+// eventUserID returns the ID of the user an event is about, or 0 for events
+// that are not about a single user.
+func eventUserID(event domain.Event) uint {
+	switch data := event.Data.(type) {
+	case domain.StreakEventData:
+		return data.UserID
+	case domain.UserAddedEventData:
+		return data.UserID
+	case domain.AchievementEventData:
+		return data.UserID
+	case domain.RankChangedEventData:
+		return data.UserID
+	case domain.LeaderboardEntryEventData:
+		return data.UserID
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,100 @@
+package stream_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/logging"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/stream"
+)
+
+func streakEvent(id string, userID uint) domain.Event {
+	return domain.Event{
+		ID:   id,
+		Type: domain.EventStreakExtended,
+		Data: domain.StreakEventData{UserID: userID, CurrentStreak: 2},
+	}
+}
+
+func TestHubFilters(t *testing.T) {
+	hub := stream.NewHub(8, logging.Discard())
+	defer hub.Close()
+
+	all := hub.Subscribe(stream.Filter{})
+	alice := hub.Subscribe(stream.Filter{UserIDs: []uint{1}})
+	broken := hub.Subscribe(stream.Filter{Types: []string{domain.EventStreakBroken}})
+
+	hub.Publish(t.Context(), streakEvent("1", 1))
+	hub.Publish(t.Context(), streakEvent("2", 2))
+
+	if got := len(all.C); got != 2 {
+		t.Errorf("unfiltered subscriber got %d messages, want 2", got)
+	}
+	if got := len(broken.C); got != 0 {
+		t.Errorf("streak.broken subscriber got %d messages, want 0", got)
+	}
+	if got := len(alice.C); got != 1 {
+		t.Fatalf("alice subscriber got %d messages, want 1", got)
+	}
+
+	msg := <-alice.C
+	var event struct {
+		ID   string                 `json:"id"`
+		Data domain.StreakEventData `json:"data"`
+	}
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	if msg.ID != "1" || msg.Type != domain.EventStreakExtended || event.Data.UserID != 1 {
+		t.Errorf("message = %+v, event %+v", msg, event)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := stream.NewHub(2, logging.Discard())
+	defer hub.Close()
+
+	slow := hub.Subscribe(stream.Filter{})
+	fast := hub.Subscribe(stream.Filter{})
+
+	for i := range 3 {
+		hub.Publish(t.Context(), streakEvent(string(rune('a'+i)), 1))
+		if i < 2 {
+			<-fast.C
+		}
+	}
+	<-fast.C
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != 2 || !errors.Is(slow.Err(), stream.ErrSlowConsumer) {
+		t.Errorf("slow subscriber got %d messages and err %v, want 2 and ErrSlowConsumer", received, slow.Err())
+	}
+
+	// Publishing never blocked, and the fast subscriber stays connected.
+	hub.Publish(t.Context(), streakEvent("d", 1))
+	if msg := <-fast.C; msg.ID != "d" {
+		t.Errorf("fast subscriber got %q, want d", msg.ID)
+	}
+	hub.Unsubscribe(slow)
+}
+
+func TestHubClose(t *testing.T) {
+	hub := stream.NewHub(2, logging.Discard())
+	sub := hub.Subscribe(stream.Filter{})
+
+	hub.Close()
+	if _, ok := <-sub.C; ok || !errors.Is(sub.Err(), stream.ErrClosed) {
+		t.Errorf("subscription after Close: open %v, err %v", ok, sub.Err())
+	}
+
+	late := hub.Subscribe(stream.Filter{})
+	if _, ok := <-late.C; ok {
+		t.Error("subscribing to a closed hub returned an open subscription")
+	}
+	hub.Unsubscribe(late)
+}
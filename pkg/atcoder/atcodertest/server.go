@@ -0,0 +1,189 @@
+// Package atcodertest provides an in-process stand-in for atcoder.jp and the
+// AtCoder Problems API, for tests that exercise code built on atcoder.Client.
+// Pass URL() as both of the client's base URLs.
+package atcodertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder"
+)
+
+// Endpoints as counted by Calls.
+const (
+	EndpointHistory     = "history"
+	EndpointSubmissions = "submissions"
+)
+
+// PageSize is how many submissions the fake returns per request, as AtCoder
+// Problems does.
+const PageSize = 500
+
+// Server serves scripted contest histories and submissions. Like AtCoder
+// Problems, it answers an unknown user's submissions with an empty list; only
+// the history endpoint reports unknown users. All methods are safe for
+// concurrent use.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	users    map[string]*user
+	failures map[string][]int
+	calls    map[string]int
+}
+
+type user struct {
+	history     []atcoder.ContestResult
+	submissions []atcoder.Submission
+}
+
+// NewServer starts a fake. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		users:    make(map[string]*user),
+		failures: make(map[string][]int),
+		calls:    make(map[string]int),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// AddUser registers a user with a rating and submissions. A zero rating
+// leaves the user without rated contests.
+func (s *Server) AddUser(name string, rating int, submissions ...atcoder.Submission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := &user{}
+	if rating > 0 {
+		u.history = []atcoder.ContestResult{{IsRated: true, NewRating: rating, ContestScreenName: "abc300.contest.atcoder.jp"}}
+	}
+	for _, sub := range submissions {
+		sub.UserID = name
+		u.submissions = append(u.submissions, sub)
+	}
+	s.users[name] = u
+}
+
+// AddSubmissions appends submissions to an existing user.
+func (s *Server) AddSubmissions(name string, submissions ...atcoder.Submission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[name]
+	if !ok {
+		panic("atcodertest: unknown user " + name)
+	}
+	for _, sub := range submissions {
+		sub.UserID = name
+		u.submissions = append(u.submissions, sub)
+	}
+}
+
+// FailNext makes the next request to endpoint respond with the given HTTP
+// status. Repeated calls queue further failures.
+func (s *Server) FailNext(endpoint string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[endpoint] = append(s.failures[endpoint], status)
+}
+
+// Calls returns how many requests endpoint has received, including failed
+// ones.
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[endpoint]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var endpoint, name string
+	switch {
+	case r.URL.Path == "/atcoder-api/v3/user/submissions":
+		endpoint, name = EndpointSubmissions, r.URL.Query().Get("user")
+	case strings.HasPrefix(r.URL.Path, "/users/") && strings.HasSuffix(r.URL.Path, "/history/json"):
+		endpoint = EndpointHistory
+		name = strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/history/json")
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[endpoint]++
+
+	if queued := s.failures[endpoint]; len(queued) > 0 {
+		s.failures[endpoint] = queued[1:]
+		http.Error(w, http.StatusText(queued[0]), queued[0])
+		return
+	}
+
+	u, ok := s.users[name]
+	switch {
+	case endpoint == EndpointHistory && !ok:
+		http.NotFound(w, r)
+	case endpoint == EndpointHistory:
+		writeJSON(w, append([]atcoder.ContestResult{}, u.history...))
+	case !ok:
+		writeJSON(w, []atcoder.Submission{})
+	default:
+		from, _ := strconv.ParseInt(r.URL.Query().Get("from_second"), 10, 64)
+		writeJSON(w, page(u.submissions, from))
+	}
+}
+
+// page returns up to PageSize submissions from fromSecond on, oldest first.
+func page(submissions []atcoder.Submission, fromSecond int64) []atcoder.Submission {
+	result := []atcoder.Submission{}
+	for _, sub := range submissions {
+		if sub.EpochSecond >= fromSecond {
+			result = append(result, sub)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].EpochSecond < result[j].EpochSecond
+	})
+	return result[:min(len(result), PageSize)]
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Accepted builds an accepted submission at the given time.
+func Accepted(id int64, at time.Time) atcoder.Submission {
+	return Submission(id, at, "AC")
+}
+
+// Submission builds a submission with an arbitrary result, such as "WA".
+func Submission(id int64, at time.Time, result string) atcoder.Submission {
+	return atcoder.Submission{
+		ID:          id,
+		EpochSecond: at.Unix(),
+		ProblemID:   "abc" + strconv.FormatInt(300+id, 10) + "_a",
+		ContestID:   "abc" + strconv.FormatInt(300+id, 10),
+		Language:    "C++ 20 (gcc 12.2)",
+		Point:       100,
+		Result:      result,
+	}
+}
@@ -0,0 +1,130 @@
+// Package atcoder reads AtCoder handles for the sync. AtCoder has no
+// submissions API, so submissions come from AtCoder Problems
+// (kenkoooo.com/atcoder), which mirrors them; ratings come from the contest
+// history atcoder.jp publishes for every user.
+package atcoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"golang.org/x/time/rate"
+)
+
+// pageSize is how many submissions AtCoder Problems returns per request.
+const pageSize = 500
+
+type Client struct {
+	baseURL     string
+	problemsURL string
+	httpClient  *http.Client
+	limiter     *rate.Limiter
+}
+
+// NewClient returns a client for atcoder.jp at baseURL and the AtCoder
+// Problems API at problemsURL. It makes at most requestsPerSecond calls
+// across all goroutines; AtCoder Problems asks for no more than one. A
+// non-positive rate disables the limit.
+func NewClient(baseURL, problemsURL string, requestsPerSecond float64) *Client {
+	limit := rate.Inf
+	if requestsPerSecond > 0 {
+		limit = rate.Limit(requestsPerSecond)
+	}
+
+	return &Client{
+		baseURL:     baseURL,
+		problemsURL: problemsURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		limiter: rate.NewLimiter(limit, 1),
+	}
+}
+
+// Submission is a submission as AtCoder Problems reports it.
+type Submission struct {
+	ID          int64   `json:"id"`
+	EpochSecond int64   `json:"epoch_second"`
+	ProblemID   string  `json:"problem_id"`
+	ContestID   string  `json:"contest_id"`
+	UserID      string  `json:"user_id"`
+	Language    string  `json:"language"`
+	Point       float64 `json:"point"`
+	Result      string  `json:"result"` // "AC", "WA", "CE", ...
+}
+
+// ContestResult is one contest in a user's history on atcoder.jp.
+type ContestResult struct {
+	IsRated           bool   `json:"IsRated"`
+	Place             int    `json:"Place"`
+	OldRating         int    `json:"OldRating"`
+	NewRating         int    `json:"NewRating"`
+	ContestScreenName string `json:"ContestScreenName"`
+	EndTime           string `json:"EndTime"`
+}
+
+// getJSON waits for the rate limiter, fetches rawURL and decodes the body
+// into v. A 404 is reported as domain.ErrHandleNotFound.
+func (c *Client) getJSON(ctx context.Context, rawURL string, v any) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return domain.ErrHandleNotFound
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// GetSubmissions returns a user's submissions from fromSecond on, oldest
+// first, reading as many pages as it takes.
+func (c *Client) GetSubmissions(ctx context.Context, user string, fromSecond int64) ([]Submission, error) {
+	var all []Submission
+	for {
+		query := url.Values{"user": {user}, "from_second": {fmt.Sprint(fromSecond)}}
+		var page []Submission
+		if err := c.getJSON(ctx, c.problemsURL+"/atcoder-api/v3/user/submissions?"+query.Encode(), &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch submissions: %w", err)
+		}
+		all = append(all, page...)
+		if len(page) < pageSize {
+			return all, nil
+		}
+		fromSecond = page[len(page)-1].EpochSecond + 1
+	}
+}
+
+// GetHistory returns a user's contest history, oldest first. Users who never
+// entered a contest have an empty history; unknown users fail with
+// domain.ErrHandleNotFound.
+func (c *Client) GetHistory(ctx context.Context, user string) ([]ContestResult, error) {
+	var history []ContestResult
+	if err := c.getJSON(ctx, c.baseURL+"/users/"+url.PathEscape(user)+"/history/json", &history); err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,107 @@
+package atcoder_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/atcoder/atcodertest"
+)
+
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newClient(t *testing.T) (*atcoder.Client, *atcodertest.Server) {
+	t.Helper()
+
+	ac := atcodertest.NewServer()
+	t.Cleanup(ac.Close)
+	return atcoder.NewClient(ac.URL(), ac.URL(), 0), ac
+}
+
+func TestFetchSubmissionsPagesNewestFirst(t *testing.T) {
+	client, ac := newClient(t)
+	var submissions []atcoder.Submission
+	for i := range atcodertest.PageSize + 2 {
+		submissions = append(submissions, atcodertest.Accepted(int64(i+1), base.Add(time.Duration(i)*time.Minute)))
+	}
+	submissions[1].Result = "WA"
+	ac.AddUser("chokudai", 0, submissions...)
+
+	got, err := client.FetchSubmissions(t.Context(), "chokudai")
+	if err != nil {
+		t.Fatalf("FetchSubmissions: %v", err)
+	}
+	if len(got) != atcodertest.PageSize+2 {
+		t.Fatalf("got %d submissions, want %d", len(got), atcodertest.PageSize+2)
+	}
+	if calls := ac.Calls(atcodertest.EndpointSubmissions); calls != 2 {
+		t.Errorf("submissions endpoint called %d times, want 2", calls)
+	}
+
+	newest := got[0]
+	if newest.ID != atcodertest.PageSize+2 || newest.Judge != domain.JudgeAtCoder || !newest.Accepted() ||
+		newest.ProblemID != "abc802_a" || !newest.SubmittedAt.Equal(base.Add((atcodertest.PageSize+1)*time.Minute)) {
+		t.Errorf("newest = %+v", newest)
+	}
+	if wrong := got[len(got)-2]; wrong.ID != 2 || wrong.Verdict != "WA" {
+		t.Errorf("second oldest = %+v, want the WA submission 2", wrong)
+	}
+}
+
+func TestFetchProfile(t *testing.T) {
+	client, ac := newClient(t)
+	ac.AddUser("tourist", 3800)
+	ac.AddUser("newcomer", 0)
+
+	profile, err := client.FetchProfile(t.Context(), "tourist")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	if profile.Handle != "tourist" || profile.Rating != 3800 || profile.Rank != "red" {
+		t.Errorf("profile = %+v", profile)
+	}
+
+	profile, err = client.FetchProfile(t.Context(), "newcomer")
+	if err != nil || profile.Rating != 0 || profile.Rank != "" {
+		t.Errorf("unrated profile = %+v, %v", profile, err)
+	}
+
+	if _, err := client.ValidateHandle(t.Context(), "nobody"); !errors.Is(err, domain.ErrHandleNotFound) {
+		t.Errorf("ValidateHandle(nobody) error = %v, want ErrHandleNotFound", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	client, ac := newClient(t)
+	ac.AddUser("chokudai", 2500)
+	ac.FailNext(atcodertest.EndpointSubmissions, http.StatusServiceUnavailable)
+
+	_, err := client.FetchSubmissions(t.Context(), "chokudai")
+	if err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Fatalf("error = %v, want one mentioning status 503", err)
+	}
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		rating int
+		want   string
+	}{
+		{0, "gray"},
+		{399, "gray"},
+		{400, "brown"},
+		{1199, "green"},
+		{1600, "blue"},
+		{2799, "orange"},
+		{2800, "red"},
+	}
+	for _, tt := range tests {
+		if got := atcoder.Color(tt.rating); got != tt.want {
+			t.Errorf("Color(%d) = %q, want %q", tt.rating, got, tt.want)
+		}
+	}
+}
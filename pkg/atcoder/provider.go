@@ -0,0 +1,89 @@
+package atcoder
+
+import (
+	"context"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// The methods below make Client a judge provider for the sync.
+
+func (c *Client) Judge() string {
+	return domain.JudgeAtCoder
+}
+
+// ValidateHandle checks that a user exists on atcoder.jp. AtCoder Problems
+// answers any name with an empty list, so it cannot tell.
+func (c *Client) ValidateHandle(ctx context.Context, handle string) (string, error) {
+	if _, err := c.GetHistory(ctx, handle); err != nil {
+		return "", err
+	}
+	return handle, nil
+}
+
+// FetchProfile reports the rating after the user's last rated contest and
+// the color band it falls in, which is what AtCoder ranks users by.
+func (c *Client) FetchProfile(ctx context.Context, handle string) (*domain.JudgeProfile, error) {
+	history, err := c.GetHistory(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &domain.JudgeProfile{Handle: handle}
+	for _, result := range history {
+		if result.IsRated {
+			profile.Rating = result.NewRating
+			profile.Rank = Color(result.NewRating)
+		}
+	}
+	return profile, nil
+}
+
+// FetchSubmissions returns every submission of the handle, newest first.
+// Accepted ones carry domain.VerdictAccepted.
+func (c *Client) FetchSubmissions(ctx context.Context, handle string) ([]domain.JudgeSubmission, error) {
+	submissions, err := c.GetSubmissions(ctx, handle, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.JudgeSubmission, len(submissions))
+	for i, sub := range submissions {
+		verdict := sub.Result
+		if verdict == "AC" {
+			verdict = domain.VerdictAccepted
+		}
+		result[len(submissions)-1-i] = domain.JudgeSubmission{
+			Judge:       domain.JudgeAtCoder,
+			ID:          sub.ID,
+			ProblemID:   sub.ProblemID,
+			Language:    sub.Language,
+			Verdict:     verdict,
+			SubmittedAt: time.Unix(sub.EpochSecond, 0).UTC(),
+		}
+	}
+	return result, nil
+}
+
+// Color names the AtCoder rating band of rating.
+func Color(rating int) string {
+	colors := []struct {
+		below int
+		color string
+	}{
+		{400, "gray"},
+		{800, "brown"},
+		{1200, "green"},
+		{1600, "cyan"},
+		{2000, "blue"},
+		{2400, "yellow"},
+		{2800, "orange"},
+	}
+	for _, c := range colors {
+		if rating < c.below {
+			return c.color
+		}
+	}
+	return "red"
+}
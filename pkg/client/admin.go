@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Sync syncs every active user with their judges, or only the user with ID
+// id when it is not 0, and returns once the sync has finished. It needs the
+// admin API key.
+func (c *Client) Sync(ctx context.Context, id uint) error {
+	var query url.Values
+	if id != 0 {
+		query = url.Values{"user": {strconv.FormatUint(uint64(id), 10)}}
+	}
+	return c.do(ctx, http.MethodPost, "/admin/sync", query, nil, nil)
+}
+
+// RecomputeStreaks recalculates every user's streaks from the stored
+// submissions and returns how many users changed. It needs the admin API
+// key.
+func (c *Client) RecomputeStreaks(ctx context.Context) (int, error) {
+	var resp successResponse[struct {
+		Updated int `json:"updated"`
+	}]
+	if err := c.do(ctx, http.MethodPost, "/admin/streaks/recompute", nil, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Data.Updated, nil
+}
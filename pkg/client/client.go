@@ -0,0 +1,146 @@
+// Package client is a Go client for the CodeStreaks HTTP API under /api/v1.
+// It covers users, the leaderboard, submissions and groups:
+//
+//	c := client.NewClient("http://localhost:8080", os.Getenv("ADMIN_API_KEY"), nil)
+//	for user, err := range c.Leaderboard(ctx, "", client.ListOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name(), user.CurrentStreak)
+//	}
+//
+// Non-2xx responses are returned as *Error, which matches ErrNotFound,
+// ErrUnauthorized and the other sentinel errors under errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the server at baseURL, e.g.
+// http://localhost:8080. apiKey is sent as a bearer token and is only needed
+// for admin endpoints such as group management; it may be empty. A nil
+// httpClient uses one with a 30 second timeout.
+func NewClient(baseURL, apiKey string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/api/v1",
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+// Leaderboard orders accepted by ListOptions.Sort.
+const (
+	SortCurrentStreak = "current_streak"
+	SortMaxStreak     = "max_streak"
+	SortRating        = "rating"
+)
+
+// ListOptions selects a page of a paginated list. Zero values use the
+// server defaults: page 1 and 50 items per page. The server caps page sizes
+// at 100.
+type ListOptions struct {
+	Page     int
+	PageSize int
+	// Sort orders the leaderboard, SortCurrentStreak by default. Other
+	// lists ignore it.
+	Sort string
+}
+
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(o.PageSize))
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	return q
+}
+
+// successResponse is the envelope most endpoints wrap their result in.
+type successResponse[T any] struct {
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response
+// into out, unless out is nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("codestreaks: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("codestreaks: decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+func pathEscape(segment string) string {
+	return "/" + url.PathEscape(segment)
+}
+
+func userPath(id uint) string {
+	return "/users/" + strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors for the common failure statuses. Match them with
+// errors.Is; errors.As with *Error gives the status and server message.
+var (
+	ErrBadRequest   = errors.New("codestreaks: bad request")
+	ErrUnauthorized = errors.New("codestreaks: unauthorized")
+	ErrNotFound     = errors.New("codestreaks: not found")
+	ErrConflict     = errors.New("codestreaks: conflict")
+	// ErrRateLimited is a 429; Error.RetryAfter says when to try again.
+	ErrRateLimited = errors.New("codestreaks: rate limited")
+)
+
+// Error is a non-2xx response from the API.
+type Error struct {
+	StatusCode int
+	// Message is the server's error message, or the raw body when it did
+	// not send one.
+	Message string
+	// RetryAfter is how long the server asked the client to wait before
+	// retrying, from the Retry-After header of a 429 or 503. It is zero
+	// when the server did not say.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("codestreaks: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+func newError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var errResp errorResponse
+	message := string(body)
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// retryAfter parses a Retry-After header, either a number of seconds or an
+// HTTP date.
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
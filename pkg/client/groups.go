@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Group is a named subset of users with its own leaderboard.
+type Group struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (c *Client) ListGroups(ctx context.Context) ([]Group, error) {
+	var resp successResponse[[]Group]
+	if err := c.do(ctx, http.MethodGet, "/groups", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// CreateGroup needs the admin API key. It fails with ErrConflict when the
+// name is taken.
+func (c *Client) CreateGroup(ctx context.Context, name, description string) (*Group, error) {
+	body := map[string]string{"name": name, "description": description}
+
+	var resp successResponse[Group]
+	if err := c.do(ctx, http.MethodPost, "/groups", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// AddGroupMember adds a tracked user to a group. It needs the admin API key.
+func (c *Client) AddGroupMember(ctx context.Context, group string, id uint) error {
+	body := map[string]uint{"user_id": id}
+	return c.do(ctx, http.MethodPost, "/groups"+pathEscape(group)+"/members", nil, body, nil)
+}
+
+// RemoveGroupMember needs the admin API key.
+func (c *Client) RemoveGroupMember(ctx context.Context, group string, id uint) error {
+	return c.do(ctx, http.MethodDelete, "/groups"+pathEscape(group)+"/members/"+strconv.FormatUint(uint64(id), 10), nil, nil, nil)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+)
+
+// LeaderboardPage is one page of the leaderboard, best standing first.
+type LeaderboardPage struct {
+	Users      []User `json:"users"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+}
+
+// LeaderboardPage returns one page of the leaderboard in the order
+// opts.Sort picks. A non-empty group ranks only that group's members.
+func (c *Client) LeaderboardPage(ctx context.Context, group string, opts ListOptions) (*LeaderboardPage, error) {
+	query := opts.query()
+	if group != "" {
+		query.Set("group", group)
+	}
+
+	var page LeaderboardPage
+	if err := c.do(ctx, http.MethodGet, "/leaderboard", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Leaderboard iterates over the leaderboard from opts.Page on, fetching
+// further pages as needed. Iteration stops after the first error.
+func (c *Client) Leaderboard(ctx context.Context, group string, opts ListOptions) iter.Seq2[User, error] {
+	return paginate(opts, func(opts ListOptions) ([]User, int, int, error) {
+		page, err := c.LeaderboardPage(ctx, group, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return page.Users, page.Page, page.TotalPages, nil
+	})
+}
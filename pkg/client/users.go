@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// User is a tracked person, known by their ID, whose streak counts solves on
+// all of their judge accounts. The standing fields, LeaderboardRank,
+// RankChange and StreakChange, are only filled in by GetUser, FindUser and
+// the leaderboard.
+type User struct {
+	ID               uint       `json:"id"`
+	DisplayName      string     `json:"display_name"`
+	Accounts         []Account  `json:"accounts"`
+	AvatarURL        string     `json:"avatar_url"`
+	TimeZone         string     `json:"time_zone"`
+	CurrentStreak    int        `json:"current_streak"`
+	MaxStreak        int        `json:"max_streak"`
+	LastSubmissionAt *time.Time `json:"last_submission_at"`
+	Rating           int        `json:"rating"`
+	Rank             string     `json:"rank"`
+	TotalSubmissions int        `json:"total_submissions"`
+	LeaderboardRank  int        `json:"leaderboard_rank"`
+	RankChange       int        `json:"rank_change"`
+	StreakChange     int        `json:"streak_change"`
+}
+
+// Account is a user's handle on one judge, such as codeforces or atcoder.
+type Account struct {
+	Judge  string `json:"judge"`
+	Handle string `json:"handle"`
+	Rating int    `json:"rating"`
+	Rank   string `json:"rank"`
+}
+
+// Name is the user's display name, or the handle of their first account
+// without one.
+func (u User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if len(u.Accounts) > 0 {
+		return u.Accounts[0].Handle
+	}
+	return fmt.Sprintf("user %d", u.ID)
+}
+
+type Submission struct {
+	ID                     uint      `json:"id"`
+	UserID                 uint      `json:"user_id"`
+	CodeforcesSubmissionID int64     `json:"codeforces_submission_id"`
+	Verdict                string    `json:"verdict"`
+	SubmittedAt            time.Time `json:"submitted_at"`
+}
+
+// SubmissionPage is one page of a user's submissions, newest first.
+type SubmissionPage struct {
+	Submissions []Submission `json:"submissions"`
+	Page        int          `json:"page"`
+	PageSize    int          `json:"page_size"`
+	Total       int64        `json:"total"`
+	TotalPages  int          `json:"total_pages"`
+}
+
+// AddUser starts tracking the person a handle on judge, such as
+// "codeforces", belongs to. Adding a handle that is already tracked returns
+// the existing user.
+func (c *Client) AddUser(ctx context.Context, judge, handle string) (*User, error) {
+	var resp successResponse[User]
+	err := c.do(ctx, http.MethodPost, "/users", nil, map[string]string{"judge": judge, "handle": handle}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// RemoveUser stops tracking a user and deletes their data. It needs the
+// admin API key.
+func (c *Client) RemoveUser(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, userPath(id), nil, nil, nil)
+}
+
+// GetUser returns a user with their current leaderboard standing.
+func (c *Client) GetUser(ctx context.Context, id uint) (*User, error) {
+	var resp successResponse[User]
+	if err := c.do(ctx, http.MethodGet, userPath(id), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// FindUser returns the user a handle on judge is linked to, with their
+// current leaderboard standing.
+func (c *Client) FindUser(ctx context.Context, judge, handle string) (*User, error) {
+	var resp successResponse[User]
+	if err := c.do(ctx, http.MethodGet, "/accounts"+pathEscape(judge)+pathEscape(handle), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// SubmissionsPage returns one page of a user's synced submissions.
+func (c *Client) SubmissionsPage(ctx context.Context, id uint, opts ListOptions) (*SubmissionPage, error) {
+	var page SubmissionPage
+	if err := c.do(ctx, http.MethodGet, userPath(id)+"/submissions", opts.query(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Submissions iterates over a user's submissions, newest first, starting at
+// opts.Page and fetching further pages as needed. Iteration stops after the
+// first error.
+func (c *Client) Submissions(ctx context.Context, id uint, opts ListOptions) iter.Seq2[Submission, error] {
+	return paginate(opts, func(opts ListOptions) ([]Submission, int, int, error) {
+		page, err := c.SubmissionsPage(ctx, id, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return page.Submissions, page.Page, page.TotalPages, nil
+	})
+}
+
+// paginate turns a page fetcher into an iterator over the items of every
+// page from opts.Page on. fetch returns a page's items, its number and the
+// total number of pages.
+func paginate[T any](opts ListOptions, fetch func(ListOptions) (items []T, page, totalPages int, err error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			items, page, totalPages, err := fetch(opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) == 0 || page >= totalPages {
+				return
+			}
+			opts.Page = page + 1
+		}
+	}
+}
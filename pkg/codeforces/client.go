@@ -1,42 +1,86 @@
 package codeforces
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/metrics"
+	"golang.org/x/time/rate"
 )
 
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	limiter    *rate.Limiter
 }
 
-func NewClient(baseURL string) *Client {
+// NewClient returns a client that makes at most requestsPerSecond API calls
+// across all goroutines; Codeforces rejects callers that go much faster.
+// A non-positive rate disables the limit.
+func NewClient(baseURL string, requestsPerSecond float64) *Client {
+	limit := rate.Inf
+	if requestsPerSecond > 0 {
+		limit = rate.Limit(requestsPerSecond)
+	}
+
 	return &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		limiter: rate.NewLimiter(limit, 1),
 	}
 }
 
+// get waits for the rate limiter and calls an API method, recording latency
+// and status code.
+func (c *Client) get(ctx context.Context, method, query string) (*http.Response, error) {
+	waitStart := time.Now()
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	metrics.RateLimiterWait.Observe(time.Since(waitStart).Seconds())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+method+"?"+query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	metrics.CodeforcesRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.CodeforcesRequests.WithLabelValues(method, code).Inc()
+
+	return resp, err
+}
+
 // GetUserSubmissions fetches submissions for a user with a specified count
 func (c *Client) GetUserSubmissions(handle string, count int) ([]domain.CodeforcesSubmission, error) {
-	url := fmt.Sprintf("%s/user.status?handle=%s&from=1&count=%d", c.baseURL, handle, count)
+	return c.userStatus(context.Background(), handle, count)
+}
 
-	resp, err := c.httpClient.Get(url)
+func (c *Client) userStatus(ctx context.Context, handle string, count int) ([]domain.CodeforcesSubmission, error) {
+	resp, err := c.get(ctx, "user.status", fmt.Sprintf("handle=%s&from=1&count=%d", url.QueryEscape(handle), count))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch submissions: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp)
 	}
 
 	var apiResp domain.CodeforcesAPIResponse
@@ -53,17 +97,18 @@ func (c *Client) GetUserSubmissions(handle string, count int) ([]domain.Codeforc
 
 // GetUserInfo fetches user information from Codeforces
 func (c *Client) GetUserInfo(handle string) (*domain.CodeforcesUserInfo, error) {
-	url := fmt.Sprintf("%s/user.info?handles=%s", c.baseURL, handle)
+	return c.userInfo(context.Background(), handle)
+}
 
-	resp, err := c.httpClient.Get(url)
+func (c *Client) userInfo(ctx context.Context, handle string) (*domain.CodeforcesUserInfo, error) {
+	resp, err := c.get(ctx, "user.info", "handles="+url.QueryEscape(handle))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user info: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp)
 	}
 
 	var apiResp domain.CodeforcesUserAPIResponse
@@ -78,11 +123,35 @@ func (c *Client) GetUserInfo(handle string) (*domain.CodeforcesUserInfo, error)
 	return &apiResp.Result[0], nil
 }
 
-// ValidateHandle checks if a Codeforces handle exists
-func (c *Client) ValidateHandle(handle string) (bool, error) {
-	_, err := c.GetUserInfo(handle)
+// statusError describes a non-200 response. Codeforces answers 400 with a
+// comment such as "handle: User with handle x not found" for unknown
+// handles; those errors wrap domain.ErrHandleNotFound.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	err := fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "not found") {
+		return fmt.Errorf("%w: %w", domain.ErrHandleNotFound, err)
+	}
+	return err
+}
+
+// pingHandle is looked up by Ping. Whether it exists does not matter: any
+// answer from the API proves it is reachable.
+const pingHandle = "tourist"
+
+// Ping checks that the API answers. Client errors such as an unknown handle
+// count as reachable; transport errors and 5xx responses, including the 503
+// Codeforces sends while rate limiting, do not.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.get(ctx, "user.info", "handles="+pingHandle)
 	if err != nil {
-		return false, err
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
-	return true, nil
+	return nil
 }
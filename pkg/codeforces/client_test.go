@@ -0,0 +1,135 @@
+package codeforces_test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/codeforces/codeforcestest"
+)
+
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newClient(t *testing.T) (*codeforces.Client, *codeforcestest.Server) {
+	t.Helper()
+
+	cf := codeforcestest.NewServer()
+	t.Cleanup(cf.Close)
+	return codeforces.NewClient(cf.URL(), 0), cf
+}
+
+func TestGetUserSubmissionsReturnsNewestFirst(t *testing.T) {
+	client, cf := newClient(t)
+	cf.AddUser(domain.CodeforcesUserInfo{Handle: "tourist"},
+		codeforcestest.Accepted(1, base),
+		codeforcestest.Accepted(3, base.Add(2*time.Minute)),
+		codeforcestest.Accepted(2, base.Add(time.Minute)),
+	)
+
+	submissions, err := client.GetUserSubmissions("tourist", 2)
+	if err != nil {
+		t.Fatalf("GetUserSubmissions: %v", err)
+	}
+	if len(submissions) != 2 || submissions[0].ID != 3 || submissions[1].ID != 2 {
+		t.Errorf("submissions = %+v, want IDs 3, 2", submissions)
+	}
+}
+
+func TestGetUserInfo(t *testing.T) {
+	client, cf := newClient(t)
+	cf.AddUser(domain.CodeforcesUserInfo{Handle: "Petr", Rating: 3500, Rank: "legendary grandmaster"})
+
+	info, err := client.GetUserInfo("petr")
+	if err != nil {
+		t.Fatalf("GetUserInfo: %v", err)
+	}
+	if info.Handle != "Petr" || info.Rating != 3500 {
+		t.Errorf("info = %+v", info)
+	}
+
+	if handle, err := client.ValidateHandle(t.Context(), "petr"); handle != "Petr" || err != nil {
+		t.Errorf("ValidateHandle(petr) = %q, %v, want Petr", handle, err)
+	}
+	if _, err := client.ValidateHandle(t.Context(), "nobody"); !errors.Is(err, domain.ErrHandleNotFound) {
+		t.Errorf("ValidateHandle(nobody) error = %v, want ErrHandleNotFound", err)
+	}
+}
+
+func TestFetchSubmissions(t *testing.T) {
+	client, cf := newClient(t)
+	cf.AddUser(domain.CodeforcesUserInfo{Handle: "tourist"},
+		codeforcestest.Accepted(1, base),
+		codeforcestest.Submission(2, base.Add(time.Minute), "WRONG_ANSWER"),
+	)
+
+	submissions, err := client.FetchSubmissions(t.Context(), "tourist")
+	if err != nil {
+		t.Fatalf("FetchSubmissions: %v", err)
+	}
+	want := []domain.JudgeSubmission{
+		{Judge: "codeforces", ID: 2, ProblemID: "1002A", ProblemRating: 800, Language: "GNU G++17 7.3.0", Verdict: "WRONG_ANSWER", SubmittedAt: base.Add(time.Minute)},
+		{Judge: "codeforces", ID: 1, ProblemID: "1001A", ProblemRating: 800, Language: "GNU G++17 7.3.0", Verdict: "OK", SubmittedAt: base},
+	}
+	if !reflect.DeepEqual(submissions, want) {
+		t.Errorf("submissions = %+v, want %+v", submissions, want)
+	}
+
+	if _, err := client.FetchSubmissions(t.Context(), "nobody"); !errors.Is(err, domain.ErrHandleNotFound) {
+		t.Errorf("FetchSubmissions(nobody) error = %v, want ErrHandleNotFound", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*codeforcestest.Server)
+		want  string
+	}{
+		{"server error", func(cf *codeforcestest.Server) {
+			cf.FailNext(codeforcestest.MethodUserStatus, http.StatusInternalServerError)
+		}, "status 500"},
+		{"rate limited", func(cf *codeforcestest.Server) { cf.RateLimit(1) }, "Call limit exceeded"},
+		{"unknown handle", func(cf *codeforcestest.Server) { cf.Rename("tourist", "tourist2") }, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, cf := newClient(t)
+			cf.AddUser(domain.CodeforcesUserInfo{Handle: "tourist"})
+			tt.setup(cf)
+
+			_, err := client.GetUserSubmissions("tourist", 10)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one mentioning %q", err, tt.want)
+			}
+			if calls := cf.Calls(codeforcestest.MethodUserStatus); calls != 1 {
+				t.Errorf("user.status called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	cf := codeforcestest.NewServer()
+	t.Cleanup(cf.Close)
+	cf.AddUser(domain.CodeforcesUserInfo{Handle: "tourist"})
+
+	client := codeforces.NewClient(cf.URL(), 20)
+
+	start := time.Now()
+	for range 3 {
+		if _, err := client.GetUserInfo("tourist"); err != nil {
+			t.Fatalf("GetUserInfo: %v", err)
+		}
+	}
+
+	// The first call is free; the next two wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 calls at 20/s took %v, want at least 100ms", elapsed)
+	}
+}
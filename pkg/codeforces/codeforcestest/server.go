@@ -0,0 +1,272 @@
+// Package codeforcestest provides an in-process stand-in for the Codeforces
+// API, for tests that exercise code built on codeforces.Client.
+package codeforcestest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// Method names as they appear in the API path.
+const (
+	MethodUserInfo   = "user.info"
+	MethodUserStatus = "user.status"
+)
+
+// Server serves scripted user.info and user.status responses. Handles are
+// matched case-insensitively, as on Codeforces. All methods are safe for
+// concurrent use.
+type Server struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	users     map[string]*user
+	renames   map[string]string
+	failures  map[string][]int
+	rateLimit int
+	calls     map[string]int
+}
+
+type user struct {
+	info        domain.CodeforcesUserInfo
+	submissions []domain.CodeforcesSubmission
+}
+
+type apiResponse struct {
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+	Result  any    `json:"result,omitempty"`
+}
+
+// NewServer starts a fake API. Pass URL() as the client's base URL and call
+// Close when done.
+func NewServer() *Server {
+	s := &Server{
+		users:    make(map[string]*user),
+		renames:  make(map[string]string),
+		failures: make(map[string][]int),
+		calls:    make(map[string]int),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// AddUser registers a handle with its profile and submissions.
+func (s *Server) AddUser(info domain.CodeforcesUserInfo, submissions ...domain.CodeforcesSubmission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[strings.ToLower(info.Handle)] = &user{info: info, submissions: submissions}
+}
+
+// AddSubmissions appends submissions to an existing handle.
+func (s *Server) AddSubmissions(handle string, submissions ...domain.CodeforcesSubmission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[strings.ToLower(handle)]
+	if !ok {
+		panic("codeforcestest: unknown handle " + handle)
+	}
+	u.submissions = append(u.submissions, submissions...)
+}
+
+// SetFirstName changes the first name on a handle's profile, as its owner
+// would in their Codeforces settings.
+func (s *Server) SetFirstName(handle, firstName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[strings.ToLower(handle)]
+	if !ok {
+		panic("codeforcestest: unknown handle " + handle)
+	}
+	u.info.FirstName = firstName
+}
+
+// Rename moves a user to a new handle. Like Codeforces, user.info still
+// resolves the old handle to the renamed profile, while user.status only
+// accepts the new one.
+func (s *Server) Rename(oldHandle, newHandle string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(oldHandle)
+	u, ok := s.users[key]
+	if !ok {
+		panic("codeforcestest: unknown handle " + oldHandle)
+	}
+	delete(s.users, key)
+	u.info.Handle = newHandle
+	s.users[strings.ToLower(newHandle)] = u
+	s.renames[key] = strings.ToLower(newHandle)
+}
+
+// FailNext makes the next call to method respond with the given HTTP status.
+// Repeated calls queue further failures.
+func (s *Server) FailNext(method string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method] = append(s.failures[method], status)
+}
+
+// RateLimit makes the next n calls, to any method, fail the way Codeforces
+// does when its call limit is exceeded.
+func (s *Server) RateLimit(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimit = n
+}
+
+// Calls returns how many requests method has received, including failed ones.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[method]++
+
+	if s.rateLimit > 0 {
+		s.rateLimit--
+		writeJSON(w, http.StatusServiceUnavailable, apiResponse{Status: "FAILED", Comment: "Call limit exceeded"})
+		return
+	}
+
+	if queued := s.failures[method]; len(queued) > 0 {
+		s.failures[method] = queued[1:]
+		writeJSON(w, queued[0], apiResponse{Status: "FAILED", Comment: "Internal Server Error"})
+		return
+	}
+
+	switch method {
+	case MethodUserInfo:
+		s.userInfo(w, r)
+	case MethodUserStatus:
+		s.userStatus(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, apiResponse{Status: "FAILED", Comment: "Method not found"})
+	}
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	var result []domain.CodeforcesUserInfo
+	for _, handle := range strings.Split(r.URL.Query().Get("handles"), ";") {
+		key := strings.ToLower(handle)
+		if renamed, ok := s.renames[key]; ok {
+			key = renamed
+		}
+
+		u, ok := s.users[key]
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, apiResponse{
+				Status:  "FAILED",
+				Comment: fmt.Sprintf("handles: User with handle %s not found", handle),
+			})
+			return
+		}
+		result = append(result, u.info)
+	}
+
+	writeJSON(w, http.StatusOK, apiResponse{Status: "OK", Result: result})
+}
+
+func (s *Server) userStatus(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	handle := query.Get("handle")
+
+	u, ok := s.users[strings.ToLower(handle)]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, apiResponse{
+			Status:  "FAILED",
+			Comment: fmt.Sprintf("handle: User with handle %s not found", handle),
+		})
+		return
+	}
+
+	// Newest first, as the real API returns them
+	submissions := append([]domain.CodeforcesSubmission(nil), u.submissions...)
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].CreationTimeSeconds > submissions[j].CreationTimeSeconds
+	})
+
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil || from < 1 {
+		from = 1
+	}
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil || count < 1 {
+		count = len(submissions)
+	}
+
+	start := min(from-1, len(submissions))
+	end := min(start+count, len(submissions))
+
+	writeJSON(w, http.StatusOK, apiResponse{Status: "OK", Result: submissions[start:end]})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Accepted builds an accepted submission at the given time.
+func Accepted(id int, at time.Time) domain.CodeforcesSubmission {
+	return Submission(id, at, "OK")
+}
+
+// Submission builds a submission with an arbitrary verdict, such as
+// "WRONG_ANSWER".
+func Submission(id int, at time.Time, verdict string) domain.CodeforcesSubmission {
+	return domain.CodeforcesSubmission{
+		ID:                  id,
+		ContestID:           1000 + id,
+		CreationTimeSeconds: at.Unix(),
+		Problem: domain.CodeforcesProblem{
+			ContestID: 1000 + id,
+			Index:     "A",
+			Name:      fmt.Sprintf("Problem %d", id),
+			Rating:    800,
+		},
+		ProgrammingLanguage: "GNU G++17 7.3.0",
+		Verdict:             verdict,
+	}
+}
+
+// CompilationError builds a submission that failed to compile on the given
+// problem, such as contest 4 index "A".
+func CompilationError(id int, at time.Time, contestID int, index string) domain.CodeforcesSubmission {
+	sub := Submission(id, at, "COMPILATION_ERROR")
+	sub.ContestID = contestID
+	sub.Problem.ContestID = contestID
+	sub.Problem.Index = index
+	sub.Problem.Name = fmt.Sprintf("Problem %d%s", contestID, index)
+	return sub
+}
@@ -0,0 +1,82 @@
+package codeforces
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// submissionsPerSync is how many of a handle's most recent submissions
+// FetchSubmissions reads.
+const submissionsPerSync = 5000
+
+// The methods below make Client a judge provider for the sync.
+
+func (c *Client) Judge() string {
+	return domain.JudgeCodeforces
+}
+
+// ValidateHandle checks that a handle exists and returns it as Codeforces
+// spells it. Unknown handles fail with domain.ErrHandleNotFound.
+func (c *Client) ValidateHandle(ctx context.Context, handle string) (string, error) {
+	info, err := c.userInfo(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	return info.Handle, nil
+}
+
+func (c *Client) FetchProfile(ctx context.Context, handle string) (*domain.JudgeProfile, error) {
+	info, err := c.userInfo(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.JudgeProfile{Handle: info.Handle, Rating: info.Rating, Rank: info.Rank}, nil
+}
+
+// FetchSubmissions returns the handle's most recent submissions, newest
+// first.
+func (c *Client) FetchSubmissions(ctx context.Context, handle string) ([]domain.JudgeSubmission, error) {
+	return c.FetchRecentSubmissions(ctx, handle, submissionsPerSync)
+}
+
+// The methods below let the claim service check ownership challenges.
+
+// FetchRecentSubmissions returns the handle's count most recent submissions,
+// newest first.
+func (c *Client) FetchRecentSubmissions(ctx context.Context, handle string, count int) ([]domain.JudgeSubmission, error) {
+	submissions, err := c.userStatus(ctx, handle, count)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.JudgeSubmission, len(submissions))
+	for i, sub := range submissions {
+		problemID := ""
+		if sub.Problem.Index != "" {
+			problemID = fmt.Sprintf("%d%s", sub.Problem.ContestID, sub.Problem.Index)
+		}
+		result[i] = domain.JudgeSubmission{
+			Judge:         domain.JudgeCodeforces,
+			ID:            int64(sub.ID),
+			ProblemID:     problemID,
+			ProblemRating: sub.Problem.Rating,
+			Language:      sub.ProgrammingLanguage,
+			Verdict:       sub.Verdict,
+			SubmittedAt:   time.Unix(sub.CreationTimeSeconds, 0).UTC(),
+		}
+	}
+	return result, nil
+}
+
+// FetchFirstName returns the first name the handle's owner set on their
+// Codeforces profile, empty if they set none.
+func (c *Client) FetchFirstName(ctx context.Context, handle string) (string, error) {
+	info, err := c.userInfo(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	return info.FirstName, nil
+}
@@ -0,0 +1,605 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Request is a GraphQL request as clients post it.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is nil when the request was
+// rejected before execution: a syntax error, a validation error or bad
+// variables. Field errors leave the failing field null and execution goes
+// on.
+type Response struct {
+	Data   any      `json:"data"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Execute runs a query against the schema.
+//
+// Fields are executed breadth first: a field is resolved for every parent
+// object at its level before any Thunk is called, so a field backed by a
+// Loader costs one batch per level rather than one query per parent.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return requestError(&Error{
+				Message:   "Syntax error: " + syntaxErr.Message,
+				Locations: []Location{{Line: syntaxErr.Pos.line, Column: syntaxErr.Pos.column}},
+			})
+		}
+		return requestError(&Error{Message: err.Error()})
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return requestError(&Error{Message: err.Error()})
+	}
+
+	e := &executor{
+		ctx:       ctx,
+		schema:    s,
+		fragments: doc.fragments,
+		args:      make(map[*fieldNode]map[string]any),
+	}
+	if err := e.coerceVariables(op.vars, req.Variables); err != nil {
+		return requestError(err)
+	}
+	e.validate(s.Query, op.selections, 1, nil)
+	if len(e.errors) > 0 {
+		return &Response{Errors: e.errors}
+	}
+
+	root := &resultMap{}
+	e.executeFields(s.Query, []any{nil}, []*resultMap{root}, [][]any{{}}, op.selections)
+	return &Response{Data: root, Errors: e.errors}
+}
+
+func requestError(err *Error) *Response {
+	return &Response{Errors: []*Error{err}}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	var op *operation
+	switch {
+	case name != "":
+		for _, candidate := range doc.operations {
+			if candidate.name == name {
+				op = candidate
+			}
+		}
+		if op == nil {
+			return nil, fmt.Errorf("unknown operation %q", name)
+		}
+	case len(doc.operations) == 1:
+		op = doc.operations[0]
+	case len(doc.operations) == 0:
+		return nil, errors.New("the document has no operation")
+	default:
+		return nil, errors.New("operationName is required when the document has several operations")
+	}
+
+	if op.kind != "query" {
+		return nil, fmt.Errorf("%s operations are not supported", op.kind)
+	}
+	return op, nil
+}
+
+type executor struct {
+	ctx       context.Context
+	schema    *Schema
+	fragments map[string]*fragment
+	vars      map[string]any
+	// args holds each field's coerced arguments, computed while
+	// validating.
+	args   map[*fieldNode]map[string]any
+	errors []*Error
+}
+
+func (e *executor) addError(err error, path []any, pos position) {
+	gqlErr := &Error{Message: err.Error()}
+	if pos.line > 0 {
+		gqlErr.Locations = []Location{{Line: pos.line, Column: pos.column}}
+	}
+	if path != nil {
+		gqlErr.Path = append([]any(nil), path...)
+	}
+	e.errors = append(e.errors, gqlErr)
+}
+
+func (e *executor) coerceVariables(defs []varDef, values map[string]any) *Error {
+	e.vars = make(map[string]any, len(defs))
+	for _, def := range defs {
+		t, err := inputType(def.typ)
+		if err != nil {
+			return &Error{Message: fmt.Sprintf("variable $%s: %v", def.name, err)}
+		}
+
+		value, provided := values[def.name]
+		if !provided && def.hasDefault {
+			value, provided = def.def, true
+		}
+		if !provided {
+			if _, required := t.(nonNull); required {
+				return &Error{Message: fmt.Sprintf("variable $%s of type %s is required", def.name, t)}
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(t, value, nil)
+		if err != nil {
+			return &Error{Message: fmt.Sprintf("variable $%s: %v", def.name, err)}
+		}
+		e.vars[def.name] = coerced
+	}
+	return nil
+}
+
+// inputType resolves a variable's declared type. Only scalars can be
+// inputs.
+func inputType(ref *typeRef) (Type, error) {
+	var t Type
+	if ref.elem != nil {
+		elem, err := inputType(ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = List(elem)
+	} else {
+		scalar, ok := builtinScalars[ref.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown input type %q", ref.name)
+		}
+		t = scalar
+	}
+	if ref.nonNull {
+		t = NonNull(t)
+	}
+	return t, nil
+}
+
+// coerceInput converts a literal or variable value to the Go value for t.
+// vars is nil while coercing the variables themselves.
+func coerceInput(t Type, v any, vars map[string]any) (any, error) {
+	if ref, ok := v.(variableRef); ok {
+		v = vars[string(ref)]
+	}
+
+	switch t := t.(type) {
+	case nonNull:
+		if v == nil {
+			return nil, fmt.Errorf("expected a non-null %s", t.of)
+		}
+		return coerceInput(t.of, v, vars)
+	case list:
+		if v == nil {
+			return nil, nil
+		}
+		items, ok := v.([]any)
+		if !ok {
+			// A single value is accepted where a list is expected.
+			items = []any{v}
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			coerced, err := coerceInput(t.of, item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = coerced
+		}
+		return out, nil
+	case *Scalar:
+		if v == nil {
+			return nil, nil
+		}
+		if _, ok := v.(enumValue); ok {
+			return nil, fmt.Errorf("%v is not a %s", v, t.Name)
+		}
+		return t.Parse(v)
+	default:
+		return nil, fmt.Errorf("%s is not an input type", t)
+	}
+}
+
+// validate checks a selection set against obj before anything runs, and
+// coerces field arguments along the way.
+func (e *executor) validate(obj *Object, sels []selection, depth int, spreading []string) {
+	if e.schema.MaxDepth > 0 && depth > e.schema.MaxDepth {
+		e.addError(fmt.Errorf("query is nested deeper than the maximum depth of %d", e.schema.MaxDepth), nil, position{})
+		return
+	}
+
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *fieldNode:
+			e.validateField(obj, sel, depth, spreading)
+		case *inlineFragment:
+			if sel.typeCond != "" && sel.typeCond != obj.Name {
+				e.addError(fmt.Errorf("fragment on %s cannot apply to type %s", sel.typeCond, obj.Name), nil, position{})
+				continue
+			}
+			e.validate(obj, sel.selections, depth, spreading)
+		case *fragmentSpread:
+			frag, ok := e.fragments[sel.name]
+			if !ok {
+				e.addError(fmt.Errorf("unknown fragment %q", sel.name), nil, sel.pos)
+				continue
+			}
+			for _, name := range spreading {
+				if name == sel.name {
+					e.addError(fmt.Errorf("fragment %q spreads itself", sel.name), nil, sel.pos)
+					return
+				}
+			}
+			if frag.typeCond != obj.Name {
+				e.addError(fmt.Errorf("fragment %q on %s cannot apply to type %s", frag.name, frag.typeCond, obj.Name), nil, sel.pos)
+				continue
+			}
+			e.validate(obj, frag.selections, depth, append(spreading, sel.name))
+		}
+	}
+}
+
+func (e *executor) validateField(obj *Object, node *fieldNode, depth int, spreading []string) {
+	if node.name == "__typename" {
+		if len(node.selections) > 0 {
+			e.addError(errors.New("field \"__typename\" cannot have a selection"), nil, node.pos)
+		}
+		return
+	}
+
+	def, ok := obj.Fields[node.name]
+	if !ok {
+		e.addError(fmt.Errorf("cannot query field %q on type %q", node.name, obj.Name), nil, node.pos)
+		return
+	}
+
+	if _, done := e.args[node]; !done {
+		args, err := e.coerceArgs(def, node)
+		if err != nil {
+			e.addError(fmt.Errorf("field %q: %w", node.name, err), nil, node.pos)
+		}
+		e.args[node] = args
+	}
+
+	switch named := namedType(def.Type).(type) {
+	case *Object:
+		if len(node.selections) == 0 {
+			e.addError(fmt.Errorf("field %q of type %s must have a selection of subfields", node.name, def.Type), nil, node.pos)
+			return
+		}
+		e.validate(named, node.selections, depth+1, spreading)
+	default:
+		if len(node.selections) > 0 {
+			e.addError(fmt.Errorf("field %q of type %s cannot have a selection", node.name, def.Type), nil, node.pos)
+		}
+	}
+}
+
+func (e *executor) coerceArgs(def *Field, node *fieldNode) (map[string]any, error) {
+	given := make(map[string]any, len(node.args))
+	for _, arg := range node.args {
+		given[arg.name] = arg.value
+	}
+
+	args := make(map[string]any, len(def.Args))
+	for _, arg := range def.Args {
+		value, ok := given[arg.Name]
+		delete(given, arg.Name)
+		if ref, isVar := value.(variableRef); ok && isVar {
+			_, ok = e.vars[string(ref)]
+		}
+		if !ok {
+			if arg.Default != nil {
+				args[arg.Name] = arg.Default
+				continue
+			}
+			if _, required := arg.Type.(nonNull); required {
+				return nil, fmt.Errorf("argument %q of type %s is required", arg.Name, arg.Type)
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(arg.Type, value, e.vars)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", arg.Name, err)
+		}
+		args[arg.Name] = coerced
+	}
+	for name := range given {
+		return nil, fmt.Errorf("unknown argument %q", name)
+	}
+	return args, nil
+}
+
+// collectedField is one response key and every field node merged into it.
+type collectedField struct {
+	key   string
+	nodes []*fieldNode
+}
+
+func (e *executor) collectFields(obj *Object, sels []selection, fields []*collectedField) []*collectedField {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *fieldNode:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.responseKey()
+			merged := false
+			for _, f := range fields {
+				if f.key == key {
+					f.nodes = append(f.nodes, sel)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				fields = append(fields, &collectedField{key: key, nodes: []*fieldNode{sel}})
+			}
+		case *inlineFragment:
+			if e.included(sel.directives) {
+				fields = e.collectFields(obj, sel.selections, fields)
+			}
+		case *fragmentSpread:
+			if e.included(sel.directives) {
+				fields = e.collectFields(obj, e.fragments[sel.name].selections, fields)
+			}
+		}
+	}
+	return fields
+}
+
+// included applies @skip and @include.
+func (e *executor) included(directives []directive) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		var cond bool
+		for _, arg := range d.args {
+			if arg.name != "if" {
+				continue
+			}
+			value := arg.value
+			if ref, ok := value.(variableRef); ok {
+				value = e.vars[string(ref)]
+			}
+			cond, _ = value.(bool)
+		}
+		if d.name == "skip" && cond || d.name == "include" && !cond {
+			return false
+		}
+	}
+	return true
+}
+
+// executeFields resolves the selection on obj for every source at once and
+// stores the results in the matching outs.
+func (e *executor) executeFields(obj *Object, sources []any, outs []*resultMap, paths [][]any, sels []selection) {
+	for _, field := range e.collectFields(obj, sels, nil) {
+		node := field.nodes[0]
+		if node.name == "__typename" {
+			for _, out := range outs {
+				out.set(field.key, obj.Name)
+			}
+			continue
+		}
+
+		def := obj.Fields[node.name]
+		values := make([]any, len(sources))
+		failed := make([]bool, len(sources))
+		fieldPaths := make([][]any, len(sources))
+		for i, source := range sources {
+			fieldPaths[i] = appendPath(paths[i], field.key)
+			value, err := e.resolve(def, ResolveParams{Context: e.ctx, Source: source, Args: e.args[node]})
+			if err != nil {
+				e.addError(err, fieldPaths[i], node.pos)
+				failed[i] = true
+				continue
+			}
+			values[i] = value
+		}
+
+		// Every sibling has queued its loader keys; now run the thunks.
+		for i, value := range values {
+			thunk, ok := value.(Thunk)
+			if !ok {
+				continue
+			}
+			value, err := thunk()
+			if err != nil {
+				e.addError(err, fieldPaths[i], node.pos)
+				failed[i] = true
+				value = nil
+			}
+			values[i] = value
+		}
+
+		var sub []selection
+		for _, n := range field.nodes {
+			sub = append(sub, n.selections...)
+		}
+		completed := e.completeValues(def.Type, values, failed, fieldPaths, sub, node.pos)
+		for i, out := range outs {
+			out.set(field.key, completed[i])
+		}
+	}
+}
+
+func (e *executor) resolve(def *Field, p ResolveParams) (value any, err error) {
+	if def.Resolve == nil {
+		return nil, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return def.Resolve(p)
+}
+
+// completeValues turns resolved values into their JSON form according to
+// t. failed marks values whose error has already been reported.
+func (e *executor) completeValues(t Type, values []any, failed []bool, paths [][]any, sels []selection, pos position) []any {
+	out := make([]any, len(values))
+
+	switch t := t.(type) {
+	case nonNull:
+		out = e.completeValues(t.of, values, failed, paths, sels, pos)
+		for i, v := range out {
+			if v == nil && !failed[i] {
+				e.addError(fmt.Errorf("cannot return null for non-null type %s", t), paths[i], pos)
+			}
+		}
+
+	case list:
+		// Flatten every list into one batch so items are completed
+		// together, then split the results back up.
+		var items []any
+		var itemPaths [][]any
+		lengths := make([]int, len(values))
+		for i, v := range values {
+			if isNull(v) {
+				lengths[i] = -1
+				continue
+			}
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				e.addError(fmt.Errorf("expected a list, got %T", v), paths[i], pos)
+				failed[i] = true
+				lengths[i] = -1
+				continue
+			}
+			lengths[i] = rv.Len()
+			for j := 0; j < rv.Len(); j++ {
+				items = append(items, rv.Index(j).Interface())
+				itemPaths = append(itemPaths, appendPath(paths[i], j))
+			}
+		}
+		completed := e.completeValues(t.of, items, make([]bool, len(items)), itemPaths, sels, pos)
+		offset := 0
+		for i, n := range lengths {
+			if n < 0 {
+				continue
+			}
+			out[i] = append([]any{}, completed[offset:offset+n]...)
+			offset += n
+		}
+
+	case *Scalar:
+		for i, v := range values {
+			if isNull(v) {
+				continue
+			}
+			serialized, err := t.Serialize(v)
+			if err != nil {
+				e.addError(err, paths[i], pos)
+				failed[i] = true
+				continue
+			}
+			out[i] = serialized
+		}
+
+	case *Object:
+		var sources []any
+		var maps []*resultMap
+		var objPaths [][]any
+		for i, v := range values {
+			if isNull(v) {
+				continue
+			}
+			m := &resultMap{}
+			out[i] = m
+			sources = append(sources, v)
+			maps = append(maps, m)
+			objPaths = append(objPaths, paths[i])
+		}
+		if len(sources) > 0 {
+			e.executeFields(t, sources, maps, objPaths, sels)
+		}
+	}
+	return out
+}
+
+func isNull(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func appendPath(path []any, elem any) []any {
+	out := make([]any, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// resultMap is a JSON object that keeps the order fields were requested in.
+type resultMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *resultMap) set(key string, value any) {
+	if m.values == nil {
+		m.values = make(map[string]any)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *resultMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,202 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testAuthor struct {
+	ID   int
+	Name string
+}
+
+type testBook struct {
+	Title    string
+	AuthorID int
+}
+
+// testSchema serves books whose authors are fetched through a loader that
+// counts its batches.
+func testSchema(authors *Loader[int, *testAuthor]) *Schema {
+	author := &Object{
+		Name: "Author",
+		Fields: Fields{
+			"id":   {Type: NonNull(ID), Resolve: func(p ResolveParams) (any, error) { return p.Source.(*testAuthor).ID, nil }},
+			"name": {Type: String, Resolve: func(p ResolveParams) (any, error) { return p.Source.(*testAuthor).Name, nil }},
+		},
+	}
+	book := &Object{
+		Name: "Book",
+		Fields: Fields{
+			"title": {Type: NonNull(String), Resolve: func(p ResolveParams) (any, error) { return p.Source.(testBook).Title, nil }},
+			"author": {Type: author, Resolve: func(p ResolveParams) (any, error) {
+				return authors.Load(p.Context, p.Source.(testBook).AuthorID), nil
+			}},
+			"broken": {Type: String, Resolve: func(p ResolveParams) (any, error) { return nil, errors.New("boom") }},
+		},
+	}
+	books := []testBook{{"Dune", 1}, {"Emma", 2}, {"Persuasion", 2}, {"Orphan", 9}}
+	return &Schema{
+		Query: &Object{
+			Name: "Query",
+			Fields: Fields{
+				"books": {
+					Type: NonNull(List(NonNull(book))),
+					Args: []Arg{{Name: "first", Type: Int, Default: 10}},
+					Resolve: func(p ResolveParams) (any, error) {
+						n := min(p.Args["first"].(int), len(books))
+						return books[:n], nil
+					},
+				},
+				"greet": {
+					Type: String,
+					Args: []Arg{{Name: "name", Type: NonNull(String)}},
+					Resolve: func(p ResolveParams) (any, error) {
+						return "hello " + p.Args["name"].(string), nil
+					},
+				},
+			},
+		},
+	}
+}
+
+func newAuthorLoader() *Loader[int, *testAuthor] {
+	known := map[int]*testAuthor{1: {1, "Herbert"}, 2: {2, "Austen"}}
+	return NewLoader(func(ctx context.Context, ids []int) (map[int]*testAuthor, error) {
+		out := make(map[int]*testAuthor)
+		for _, id := range ids {
+			if a, ok := known[id]; ok {
+				out[id] = a
+			}
+		}
+		return out, nil
+	})
+}
+
+func execJSON(t *testing.T, s *Schema, req Request) (string, *Response) {
+	t.Helper()
+	resp := s.Execute(context.Background(), req)
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatalf("marshal data: %v", err)
+	}
+	return string(data), resp
+}
+
+func TestExecuteBatchesLoaderPerLevel(t *testing.T) {
+	authors := newAuthorLoader()
+	s := testSchema(authors)
+
+	data, resp := execJSON(t, s, Request{Query: `
+		query Books($n: Int) {
+			books(first: $n) { title writer: author { name } author { id } }
+		}`,
+		Variables: map[string]any{"n": float64(4)},
+	})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+	want := `{"books":[` +
+		`{"title":"Dune","writer":{"name":"Herbert"},"author":{"id":"1"}},` +
+		`{"title":"Emma","writer":{"name":"Austen"},"author":{"id":"2"}},` +
+		`{"title":"Persuasion","writer":{"name":"Austen"},"author":{"id":"2"}},` +
+		`{"title":"Orphan","writer":null,"author":null}]}`
+	if data != want {
+		t.Fatalf("data = %s\nwant  %s", data, want)
+	}
+	if got := authors.Batches(); got != 1 {
+		t.Fatalf("loader batches = %d, want 1", got)
+	}
+}
+
+func TestExecuteFragmentsAndDirectives(t *testing.T) {
+	s := testSchema(newAuthorLoader())
+
+	data, resp := execJSON(t, s, Request{Query: `
+		query ($skip: Boolean!) {
+			books(first: 1) { ...bookFields author @skip(if: $skip) { name } ... on Book { __typename } }
+		}
+		fragment bookFields on Book { title }`,
+		Variables: map[string]any{"skip": true},
+	})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+	if want := `{"books":[{"title":"Dune","__typename":"Book"}]}`; data != want {
+		t.Fatalf("data = %s, want %s", data, want)
+	}
+}
+
+func TestExecuteFieldErrorHasPath(t *testing.T) {
+	s := testSchema(newAuthorLoader())
+
+	data, resp := execJSON(t, s, Request{Query: `{ books(first: 2) { title broken } }`})
+	if want := `{"books":[{"title":"Dune","broken":null},{"title":"Emma","broken":null}]}`; data != want {
+		t.Fatalf("data = %s, want %s", data, want)
+	}
+	if len(resp.Errors) != 2 {
+		t.Fatalf("errors = %v, want 2", resp.Errors)
+	}
+	path, _ := json.Marshal(resp.Errors[1].Path)
+	if string(path) != `["books",1,"broken"]` || resp.Errors[1].Message != "boom" {
+		t.Fatalf("error = %+v path %s", resp.Errors[1], path)
+	}
+}
+
+func TestExecuteRejectsInvalidQueries(t *testing.T) {
+	s := testSchema(newAuthorLoader())
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"syntax", `{ books { title }`, "Syntax error"},
+		{"unknown field", `{ books { isbn } }`, `cannot query field "isbn" on type "Book"`},
+		{"missing argument", `{ greet }`, `argument "name" of type String! is required`},
+		{"unknown argument", `{ greet(name: "x", loud: true) }`, `unknown argument "loud"`},
+		{"bad argument", `{ books(first: "ten") { title } }`, `argument "first"`},
+		{"missing selection", `{ books }`, "must have a selection"},
+		{"scalar selection", `{ greet(name: "x") { length } }`, "cannot have a selection"},
+		{"mutation", `mutation { books { title } }`, "mutation operations are not supported"},
+		{"fragment cycle", `{ books { ...a } } fragment a on Book { ...a }`, "spreads itself"},
+		{"required variable", `query ($n: Int!) { books(first: $n) { title } }`, "$n of type Int! is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Execute(context.Background(), Request{Query: tt.query})
+			if resp.Data != nil {
+				t.Fatalf("data = %v, want none", resp.Data)
+			}
+			if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.want) {
+				t.Fatalf("errors = %v, want one containing %q", resp.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteDepthLimit(t *testing.T) {
+	s := testSchema(newAuthorLoader())
+	s.MaxDepth = 2
+
+	resp := s.Execute(context.Background(), Request{Query: `{ books { author { name } } }`})
+	if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "maximum depth of 2") {
+		t.Fatalf("response = %+v, want a depth error", resp)
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema(newAuthorLoader()).SDL()
+	for _, want := range []string{
+		"type Author {\n  id: ID!\n  name: String\n}",
+		"books(first: Int = 10): [Book!]!",
+		"greet(name: String!): String",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL missing %q:\n%s", want, sdl)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+)
+
+// Thunk is a field value computed later. The executor resolves a field for
+// every parent object before calling any thunk, so all the keys a level of
+// the query needs are queued on a Loader before the first one is fetched.
+type Thunk func() (any, error)
+
+// Loader batches and caches lookups by key for one request, in the manner
+// of the dataloader pattern: Load only queues a key, and the first thunk to
+// run fetches every queued key in one call. Create one per request.
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+	batches int
+}
+
+// NewLoader returns a loader that calls fetch with the keys of each batch.
+// Keys missing from the returned map load as the zero V.
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// Load queues key and returns a thunk for its value.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) Thunk {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		return l.get(ctx, key)
+	}
+}
+
+// Batches reports how many times fetch has been called.
+func (l *Loader[K, V]) Batches() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.batches
+}
+
+func (l *Loader[K, V]) get(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, fetched := l.results[key]; !fetched && l.errs[key] == nil {
+		l.dispatch(ctx)
+	}
+	return l.results[key], l.errs[key]
+}
+
+// dispatch fetches every pending key. l.mu must be held.
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	l.batches++
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.results[key] = values[key]
+	}
+}
@@ -0,0 +1,554 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The parser covers the executable subset of GraphQL: queries with
+// variables, aliases, arguments, fragments, inline fragments and the
+// @skip/@include directives. Type system definitions are not accepted.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // "query", "mutation" or "subscription"
+	name       string
+	vars       []varDef
+	selections []selection
+}
+
+type varDef struct {
+	name       string
+	typ        *typeRef
+	def        any
+	hasDefault bool
+}
+
+type typeRef struct {
+	name    string
+	elem    *typeRef // set for list types
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type fragment struct {
+	name       string
+	typeCond   string
+	selections []selection
+}
+
+type selection interface{}
+
+type fieldNode struct {
+	alias      string
+	name       string
+	args       []argument
+	directives []directive
+	selections []selection
+	pos        position
+}
+
+func (f *fieldNode) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+	pos        position
+}
+
+type inlineFragment struct {
+	typeCond   string
+	directives []directive
+	selections []selection
+}
+
+type argument struct {
+	name  string
+	value any
+}
+
+type directive struct {
+	name string
+	args []argument
+}
+
+// Value literals parse to Go values: int, float64, string, bool, nil,
+// []any, map[string]any, plus the two wrappers below.
+type variableRef string
+type enumValue string
+
+type position struct {
+	line, column int
+}
+
+func (p position) String() string {
+	return fmt.Sprintf("%d:%d", p.line, p.column)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   position
+}
+
+type parser struct {
+	src  string
+	off  int
+	line int
+	col  int
+	tok  token
+}
+
+// SyntaxError reports a malformed query.
+type SyntaxError struct {
+	Message string
+	Pos     position
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %s: %s", e.Pos, e.Message)
+}
+
+func parse(src string) (doc *document, err error) {
+	p := &parser{src: src, line: 1, col: 1}
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, syntaxErr
+		}
+	}()
+
+	p.next()
+	doc = &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peekPunct("{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: p.selectionSet()})
+		case p.tok.kind == tokName && p.tok.value == "fragment":
+			frag := p.fragmentDefinition()
+			if _, dup := doc.fragments[frag.name]; dup {
+				p.fail("duplicate fragment %q", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.tok.kind == tokName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			doc.operations = append(doc.operations, p.operationDefinition())
+		default:
+			p.fail("unexpected %q", p.tok.value)
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) fail(format string, args ...any) {
+	panic(&SyntaxError{Message: fmt.Sprintf(format, args...), Pos: p.tok.pos})
+}
+
+func (p *parser) operationDefinition() *operation {
+	op := &operation{kind: p.tok.value}
+	p.next()
+	if p.tok.kind == tokName {
+		op.name = p.name()
+	}
+	if p.skipPunct("(") {
+		for !p.skipPunct(")") {
+			p.expectPunct("$")
+			v := varDef{name: p.name()}
+			p.expectPunct(":")
+			v.typ = p.typeReference()
+			if p.skipPunct("=") {
+				v.def, v.hasDefault = p.value(true), true
+			}
+			op.vars = append(op.vars, v)
+		}
+	}
+	p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) fragmentDefinition() *fragment {
+	p.next() // fragment
+	frag := &fragment{name: p.name()}
+	if frag.name == "on" {
+		p.fail("fragment cannot be named \"on\"")
+	}
+	p.expectKeyword("on")
+	frag.typeCond = p.name()
+	p.directives()
+	frag.selections = p.selectionSet()
+	return frag
+}
+
+func (p *parser) typeReference() *typeRef {
+	var t *typeRef
+	if p.skipPunct("[") {
+		t = &typeRef{elem: p.typeReference()}
+		p.expectPunct("]")
+	} else {
+		t = &typeRef{name: p.name()}
+	}
+	if p.skipPunct("!") {
+		t.nonNull = true
+	}
+	return t
+}
+
+func (p *parser) selectionSet() []selection {
+	p.expectPunct("{")
+	var selections []selection
+	for !p.skipPunct("}") {
+		if p.tok.kind == tokEOF {
+			p.fail("unterminated selection set")
+		}
+		selections = append(selections, p.selection())
+	}
+	if len(selections) == 0 {
+		p.fail("empty selection set")
+	}
+	return selections
+}
+
+func (p *parser) selection() selection {
+	pos := p.tok.pos
+	if p.skipPunct("...") {
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			return &fragmentSpread{name: p.name(), directives: p.directives(), pos: pos}
+		}
+		inline := &inlineFragment{}
+		if p.tok.kind == tokName && p.tok.value == "on" {
+			p.next()
+			inline.typeCond = p.name()
+		}
+		inline.directives = p.directives()
+		inline.selections = p.selectionSet()
+		return inline
+	}
+
+	f := &fieldNode{name: p.name(), pos: pos}
+	if p.skipPunct(":") {
+		f.alias, f.name = f.name, p.name()
+	}
+	f.args = p.arguments(false)
+	f.directives = p.directives()
+	if p.peekPunct("{") {
+		f.selections = p.selectionSet()
+	}
+	return f
+}
+
+func (p *parser) arguments(constant bool) []argument {
+	if !p.skipPunct("(") {
+		return nil
+	}
+	var args []argument
+	for !p.skipPunct(")") {
+		name := p.name()
+		p.expectPunct(":")
+		args = append(args, argument{name: name, value: p.value(constant)})
+	}
+	return args
+}
+
+func (p *parser) directives() []directive {
+	var dirs []directive
+	for p.skipPunct("@") {
+		dirs = append(dirs, directive{name: p.name(), args: p.arguments(false)})
+	}
+	return dirs
+}
+
+func (p *parser) value(constant bool) any {
+	tok := p.tok
+	switch tok.kind {
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				p.fail("variables are not allowed here")
+			}
+			p.next()
+			return variableRef(p.name())
+		case "[":
+			p.next()
+			list := []any{}
+			for !p.skipPunct("]") {
+				list = append(list, p.value(constant))
+			}
+			return list
+		case "{":
+			p.next()
+			obj := map[string]any{}
+			for !p.skipPunct("}") {
+				name := p.name()
+				p.expectPunct(":")
+				obj[name] = p.value(constant)
+			}
+			return obj
+		}
+	case tokInt:
+		p.next()
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			p.fail("integer %s out of range", tok.value)
+		}
+		return n
+	case tokFloat:
+		p.next()
+		f, _ := strconv.ParseFloat(tok.value, 64)
+		return f
+	case tokString:
+		p.next()
+		return tok.value
+	case tokName:
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		default:
+			return enumValue(tok.value)
+		}
+	}
+	p.fail("unexpected %q, want a value", tok.value)
+	return nil
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.fail("unexpected %q, want a name", p.tok.value)
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+func (p *parser) expectKeyword(keyword string) {
+	if p.tok.kind != tokName || p.tok.value != keyword {
+		p.fail("unexpected %q, want %q", p.tok.value, keyword)
+	}
+	p.next()
+}
+
+func (p *parser) peekPunct(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == punct
+}
+
+func (p *parser) skipPunct(punct string) bool {
+	if p.peekPunct(punct) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(punct string) {
+	if !p.skipPunct(punct) {
+		if p.tok.kind == tokEOF {
+			p.fail("unexpected end of query, want %q", punct)
+		}
+		p.fail("unexpected %q, want %q", p.tok.value, punct)
+	}
+}
+
+// next reads the following token into p.tok, skipping whitespace, commas
+// and comments.
+func (p *parser) next() {
+	for p.off < len(p.src) {
+		ch := p.src[p.off]
+		if ch == '#' {
+			for p.off < len(p.src) && p.src[p.off] != '\n' {
+				p.advance(1)
+			}
+			continue
+		}
+		if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',' {
+			p.advance(1)
+			continue
+		}
+		break
+	}
+
+	pos := position{p.line, p.col}
+	if p.off >= len(p.src) {
+		p.tok = token{kind: tokEOF, value: "<EOF>", pos: pos}
+		return
+	}
+
+	ch := p.src[p.off]
+	switch {
+	case strings.HasPrefix(p.src[p.off:], "..."):
+		p.advance(3)
+		p.tok = token{kind: tokPunct, value: "...", pos: pos}
+	case strings.IndexByte("!$()[]{}:=@|&", ch) >= 0:
+		p.advance(1)
+		p.tok = token{kind: tokPunct, value: string(ch), pos: pos}
+	case ch == '_' || isLetter(ch):
+		start := p.off
+		for p.off < len(p.src) && (p.src[p.off] == '_' || isLetter(p.src[p.off]) || isDigit(p.src[p.off])) {
+			p.advance(1)
+		}
+		p.tok = token{kind: tokName, value: p.src[start:p.off], pos: pos}
+	case ch == '-' || isDigit(ch):
+		p.tok = p.number(pos)
+	case ch == '"':
+		p.tok = token{kind: tokString, value: p.stringLiteral(), pos: pos}
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.off:])
+		p.tok = token{pos: pos}
+		p.fail("unexpected character %q", r)
+	}
+}
+
+func (p *parser) advance(n int) {
+	for i := 0; i < n; i++ {
+		if p.src[p.off] == '\n' {
+			p.line++
+			p.col = 1
+		} else {
+			p.col++
+		}
+		p.off++
+	}
+}
+
+func (p *parser) number(pos position) token {
+	start := p.off
+	kind := tokInt
+	if p.src[p.off] == '-' {
+		p.advance(1)
+	}
+	digits := func() {
+		begin := p.off
+		for p.off < len(p.src) && isDigit(p.src[p.off]) {
+			p.advance(1)
+		}
+		if p.off == begin {
+			p.tok = token{pos: pos}
+			p.fail("malformed number")
+		}
+	}
+	digits()
+	if p.off < len(p.src) && p.src[p.off] == '.' {
+		kind = tokFloat
+		p.advance(1)
+		digits()
+	}
+	if p.off < len(p.src) && (p.src[p.off] == 'e' || p.src[p.off] == 'E') {
+		kind = tokFloat
+		p.advance(1)
+		if p.off < len(p.src) && (p.src[p.off] == '+' || p.src[p.off] == '-') {
+			p.advance(1)
+		}
+		digits()
+	}
+	return token{kind: kind, value: p.src[start:p.off], pos: pos}
+}
+
+func (p *parser) stringLiteral() string {
+	if strings.HasPrefix(p.src[p.off:], `"""`) {
+		p.advance(3)
+		end := strings.Index(p.src[p.off:], `"""`)
+		if end < 0 {
+			p.fail("unterminated block string")
+		}
+		raw := p.src[p.off : p.off+end]
+		p.advance(end + 3)
+		return strings.TrimSpace(raw)
+	}
+
+	p.advance(1)
+	var b strings.Builder
+	for {
+		if p.off >= len(p.src) || p.src[p.off] == '\n' {
+			p.fail("unterminated string")
+		}
+		ch := p.src[p.off]
+		if ch == '"' {
+			p.advance(1)
+			return b.String()
+		}
+		if ch != '\\' {
+			b.WriteByte(ch)
+			p.advance(1)
+			continue
+		}
+
+		if p.off+1 >= len(p.src) {
+			p.fail("unterminated string")
+		}
+		esc := p.src[p.off+1]
+		p.advance(2)
+		switch esc {
+		case '"', '\\', '/':
+			b.WriteByte(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.off+4 > len(p.src) {
+				p.fail("bad unicode escape")
+			}
+			code, err := strconv.ParseUint(p.src[p.off:p.off+4], 16, 32)
+			if err != nil {
+				p.fail("bad unicode escape")
+			}
+			b.WriteRune(rune(code))
+			p.advance(4)
+		default:
+			p.fail("bad escape \\%c", esc)
+		}
+	}
+}
+
+func isLetter(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
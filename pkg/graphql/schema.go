@@ -0,0 +1,348 @@
+// Package graphql is a small GraphQL engine: it parses, validates and
+// executes queries against a schema built from Go values. It supports what
+// a read-only API needs and leaves out mutations, subscriptions,
+// interfaces, unions, input objects and introspection; SDL renders the
+// schema for clients instead.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Type is an output or input type: a *Scalar, an *Object, or a List or
+// NonNull wrapper around one.
+type Type interface {
+	String() string
+}
+
+type Scalar struct {
+	Name string
+	// Serialize converts a resolved Go value to its JSON form.
+	Serialize func(v any) (any, error)
+	// Parse converts an argument or variable value to the Go value
+	// resolvers receive.
+	Parse func(v any) (any, error)
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+// Object is an output type with fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      Fields
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+type Fields map[string]*Field
+
+type Field struct {
+	Type        Type
+	Description string
+	Args        []Arg
+	// Resolve returns the field value for p.Source, or a Thunk to defer
+	// work until every sibling has been resolved, which is how loaders
+	// batch. A nil Resolve reads nothing and returns nil.
+	Resolve func(p ResolveParams) (any, error)
+}
+
+type Arg struct {
+	Name    string
+	Type    Type
+	Default any // used when the argument is absent; nil for none
+}
+
+type ResolveParams struct {
+	Context context.Context
+	Source  any
+	Args    map[string]any
+}
+
+type list struct {
+	of Type
+}
+
+func (l list) String() string {
+	return "[" + l.of.String() + "]"
+}
+
+// List wraps a type as a list of it.
+func List(of Type) Type {
+	return list{of: of}
+}
+
+type nonNull struct {
+	of Type
+}
+
+func (n nonNull) String() string {
+	return n.of.String() + "!"
+}
+
+// NonNull marks a type as never null. A null from a non-null field is
+// reported as an error; the field is still left null in the data.
+func NonNull(of Type) Type {
+	return nonNull{of: of}
+}
+
+// Schema is the query root. Only queries are supported.
+type Schema struct {
+	Query *Object
+	// MaxDepth rejects queries that nest fields deeper than this; zero
+	// means no limit.
+	MaxDepth int
+}
+
+// Built-in scalars.
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(v any) (any, error) {
+			switch n := v.(type) {
+			case int:
+				return n, nil
+			case int32:
+				return int(n), nil
+			case int64:
+				return n, nil
+			case uint:
+				return n, nil
+			case uint32:
+				return n, nil
+			case uint64:
+				return n, nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Int", v)
+		},
+		Parse: func(v any) (any, error) {
+			switch n := v.(type) {
+			case int:
+				return n, nil
+			case float64: // JSON variables decode as float64
+				if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+					return int(n), nil
+				}
+			}
+			return nil, fmt.Errorf("%v is not an Int", v)
+		},
+	}
+
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(v any) (any, error) {
+			switch n := v.(type) {
+			case float64:
+				return n, nil
+			case float32:
+				return float64(n), nil
+			case int:
+				return float64(n), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Float", v)
+		},
+		Parse: func(v any) (any, error) {
+			switch n := v.(type) {
+			case float64:
+				return n, nil
+			case int:
+				return float64(n), nil
+			}
+			return nil, fmt.Errorf("%v is not a Float", v)
+		},
+	}
+
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(v any) (any, error) {
+			switch s := v.(type) {
+			case string:
+				return s, nil
+			case fmt.Stringer:
+				return s.String(), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as String", v)
+		},
+		Parse: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("%v is not a String", v)
+		},
+	}
+
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Boolean", v)
+		},
+		Parse: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("%v is not a Boolean", v)
+		},
+	}
+
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(v any) (any, error) {
+			switch id := v.(type) {
+			case string:
+				return id, nil
+			case int, int64, uint, uint32, uint64:
+				return fmt.Sprint(id), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as ID", v)
+		},
+		Parse: func(v any) (any, error) {
+			switch id := v.(type) {
+			case string:
+				return id, nil
+			case int:
+				return fmt.Sprint(id), nil
+			}
+			return nil, fmt.Errorf("%v is not an ID", v)
+		},
+	}
+
+	// DateTime is an RFC 3339 timestamp.
+	DateTime = &Scalar{
+		Name: "DateTime",
+		Serialize: func(v any) (any, error) {
+			switch t := v.(type) {
+			case time.Time:
+				return t.Format(time.RFC3339), nil
+			case *time.Time:
+				if t == nil {
+					return nil, nil
+				}
+				return t.Format(time.RFC3339), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as DateTime", v)
+		},
+		Parse: func(v any) (any, error) {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%v is not a DateTime", v)
+			}
+			return time.Parse(time.RFC3339, s)
+		},
+	}
+)
+
+var builtinScalars = map[string]*Scalar{
+	"Int":      Int,
+	"Float":    Float,
+	"String":   String,
+	"Boolean":  Boolean,
+	"ID":       ID,
+	"DateTime": DateTime,
+}
+
+// namedType strips List and NonNull wrappers.
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case list:
+			t = w.of
+		case nonNull:
+			t = w.of
+		default:
+			return t
+		}
+	}
+}
+
+// SDL renders the schema in the GraphQL schema definition language, for
+// clients that generate code or want to browse the types.
+func (s *Schema) SDL() string {
+	objects := map[string]*Object{}
+	scalars := map[string]bool{}
+	var visit func(t Type)
+	visit = func(t Type) {
+		switch named := namedType(t).(type) {
+		case *Object:
+			if _, seen := objects[named.Name]; seen {
+				return
+			}
+			objects[named.Name] = named
+			for _, f := range named.Fields {
+				visit(f.Type)
+				for _, arg := range f.Args {
+					visit(arg.Type)
+				}
+			}
+		case *Scalar:
+			scalars[named.Name] = true
+		}
+	}
+	visit(s.Query)
+
+	var b strings.Builder
+	for _, name := range sortedKeys(scalars) {
+		switch name {
+		case "Int", "Float", "String", "Boolean", "ID":
+		default:
+			fmt.Fprintf(&b, "scalar %s\n\n", name)
+		}
+	}
+
+	for _, name := range sortedKeys(objects) {
+		obj := objects[name]
+		writeDescription(&b, "", obj.Description)
+		fmt.Fprintf(&b, "type %s {\n", obj.Name)
+		for _, fieldName := range sortedKeys(obj.Fields) {
+			f := obj.Fields[fieldName]
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + fieldName)
+			if len(f.Args) > 0 {
+				args := make([]string, len(f.Args))
+				for i, arg := range f.Args {
+					args[i] = arg.Name + ": " + arg.Type.String()
+					if arg.Default != nil {
+						args[i] += " = " + literal(arg.Default)
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + f.Type.String() + "\n")
+		}
+		b.WriteString("}\n\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%q\n", indent, description)
+	}
+}
+
+func literal(v any) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
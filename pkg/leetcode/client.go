@@ -0,0 +1,189 @@
+// Package leetcode reads LeetCode handles for the sync through the GraphQL
+// API that leetcode.com's own pages use. The API is undocumented and only
+// offers a user's most recent accepted submissions without logging in.
+package leetcode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"golang.org/x/time/rate"
+)
+
+// RecentLimit is the most recent accepted submissions LeetCode returns,
+// however many are asked for.
+const RecentLimit = 20
+
+// Operation names, as sent in the request and matched by leetcodetest.
+const (
+	OperationRecentAcSubmissions = "recentAcSubmissions"
+	OperationUserProfile         = "userProfile"
+)
+
+const recentAcSubmissionsQuery = `query recentAcSubmissions($username: String!, $limit: Int!) {
+  recentAcSubmissionList(username: $username, limit: $limit) {
+    id
+    title
+    titleSlug
+    timestamp
+  }
+}`
+
+const userProfileQuery = `query userProfile($username: String!) {
+  matchedUser(username: $username) {
+    username
+  }
+  userContestRanking(username: $username) {
+    rating
+    badge {
+      name
+    }
+  }
+}`
+
+type Client struct {
+	url        string
+	httpClient *http.Client
+	limiter    *rate.Limiter
+}
+
+// NewClient returns a client for the GraphQL endpoint at url, usually
+// https://leetcode.com/graphql. It makes at most requestsPerSecond calls
+// across all goroutines; a non-positive rate disables the limit.
+func NewClient(url string, requestsPerSecond float64) *Client {
+	limit := rate.Inf
+	if requestsPerSecond > 0 {
+		limit = rate.Limit(requestsPerSecond)
+	}
+
+	return &Client{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		limiter: rate.NewLimiter(limit, 1),
+	}
+}
+
+// AcSubmission is an accepted submission as recentAcSubmissionList reports
+// it. LeetCode sends the numbers as strings.
+type AcSubmission struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	TitleSlug string `json:"titleSlug"`
+	Timestamp string `json:"timestamp"` // Unix seconds
+}
+
+// Profile is what userProfile reports. ContestRating is nil for users who
+// never entered a contest.
+type Profile struct {
+	Username      string
+	ContestRating *ContestRanking
+}
+
+type ContestRanking struct {
+	Rating float64 `json:"rating"`
+	Badge  *struct {
+		Name string `json:"name"` // "Knight" or "Guardian"
+	} `json:"badge"`
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+// query waits for the rate limiter, runs a GraphQL operation and decodes its
+// data into v. LeetCode answers queries about unknown users with 200 and an
+// error saying the user does not exist; those fail with
+// domain.ErrHandleNotFound.
+func (c *Client) query(ctx context.Context, operation, query string, variables map[string]any, v any) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables, OperationName: operation})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Requests without a LeetCode referer are rejected as cross-site.
+	req.Header.Set("Referer", "https://leetcode.com/")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		message := result.Errors[0].Message
+		if strings.Contains(message, "does not exist") {
+			return fmt.Errorf("%w: %s", domain.ErrHandleNotFound, message)
+		}
+		return fmt.Errorf("API returned an error: %s", message)
+	}
+	if err := json.Unmarshal(result.Data, v); err != nil {
+		return fmt.Errorf("failed to decode data: %w", err)
+	}
+	return nil
+}
+
+// GetRecentAcSubmissions returns a user's last RecentLimit accepted
+// submissions, newest first.
+func (c *Client) GetRecentAcSubmissions(ctx context.Context, username string) ([]AcSubmission, error) {
+	var data struct {
+		RecentAcSubmissionList []AcSubmission `json:"recentAcSubmissionList"`
+	}
+	variables := map[string]any{"username": username, "limit": RecentLimit}
+	if err := c.query(ctx, OperationRecentAcSubmissions, recentAcSubmissionsQuery, variables, &data); err != nil {
+		return nil, fmt.Errorf("failed to fetch submissions: %w", err)
+	}
+	return data.RecentAcSubmissionList, nil
+}
+
+// GetProfile looks up a user. Unknown users fail with
+// domain.ErrHandleNotFound.
+func (c *Client) GetProfile(ctx context.Context, username string) (*Profile, error) {
+	var data struct {
+		MatchedUser *struct {
+			Username string `json:"username"`
+		} `json:"matchedUser"`
+		UserContestRanking *ContestRanking `json:"userContestRanking"`
+	}
+	if err := c.query(ctx, OperationUserProfile, userProfileQuery, map[string]any{"username": username}, &data); err != nil {
+		return nil, fmt.Errorf("failed to fetch profile: %w", err)
+	}
+	if data.MatchedUser == nil {
+		return nil, fmt.Errorf("failed to fetch profile: %w", domain.ErrHandleNotFound)
+	}
+	return &Profile{Username: data.MatchedUser.Username, ContestRating: data.UserContestRanking}, nil
+}
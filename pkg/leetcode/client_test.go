@@ -0,0 +1,121 @@
+package leetcode_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode/leetcodetest"
+)
+
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newClient(t *testing.T) (*leetcode.Client, *leetcodetest.Server) {
+	t.Helper()
+
+	lc := leetcodetest.NewServer()
+	t.Cleanup(lc.Close)
+	return leetcode.NewClient(lc.URL(), 0), lc
+}
+
+// replay serves a response recorded from leetcode.com, kept in testdata.
+func replay(t *testing.T, lc *leetcodetest.Server, operation, username, fixture string) {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc.Replay(operation, username, body)
+}
+
+func TestFetchSubmissionsFromRecording(t *testing.T) {
+	client, lc := newClient(t)
+	replay(t, lc, leetcode.OperationRecentAcSubmissions, "lee215", "recent_ac_submissions.json")
+
+	submissions, err := client.FetchSubmissions(t.Context(), "lee215")
+	if err != nil {
+		t.Fatalf("FetchSubmissions: %v", err)
+	}
+	want := []domain.JudgeSubmission{
+		{Judge: "leetcode", ID: 1203871552, ProblemID: "two-sum", Verdict: "OK", SubmittedAt: time.Unix(1709294400, 0).UTC()},
+		{Judge: "leetcode", ID: 1203456789, ProblemID: "valid-parentheses", Verdict: "OK", SubmittedAt: time.Unix(1709208000, 0).UTC()},
+		{Judge: "leetcode", ID: 1199012345, ProblemID: "merge-two-sorted-lists", Verdict: "OK", SubmittedAt: time.Unix(1708862400, 0).UTC()},
+	}
+	if !reflect.DeepEqual(submissions, want) {
+		t.Errorf("submissions = %+v, want %+v", submissions, want)
+	}
+}
+
+func TestFetchProfileFromRecording(t *testing.T) {
+	tests := []struct {
+		username, fixture string
+		want              *domain.JudgeProfile
+		err               error
+	}{
+		{"LEE215", "user_profile.json", &domain.JudgeProfile{Handle: "lee215", Rating: 2488, Rank: "Guardian"}, nil},
+		{"newcomer", "user_profile_unrated.json", &domain.JudgeProfile{Handle: "newcomer"}, nil},
+		{"nobody", "user_profile_unknown.json", nil, domain.ErrHandleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			client, lc := newClient(t)
+			replay(t, lc, leetcode.OperationUserProfile, tt.username, tt.fixture)
+
+			profile, err := client.FetchProfile(t.Context(), tt.username)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(profile, tt.want) {
+				t.Errorf("profile = %+v, want %+v", profile, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptedUser(t *testing.T) {
+	client, lc := newClient(t)
+	var submissions []leetcode.AcSubmission
+	for i := range leetcode.RecentLimit + 5 {
+		submissions = append(submissions, leetcodetest.Accepted(int64(i+1), base.Add(time.Duration(i)*time.Hour)))
+	}
+	lc.AddUser("Alice", 1650.4, submissions...)
+
+	got, err := client.FetchSubmissions(t.Context(), "alice")
+	if err != nil {
+		t.Fatalf("FetchSubmissions: %v", err)
+	}
+	if len(got) != leetcode.RecentLimit || got[0].ID != leetcode.RecentLimit+5 {
+		t.Errorf("got %d submissions starting with %+v, want the %d newest", len(got), got[0], leetcode.RecentLimit)
+	}
+
+	handle, err := client.ValidateHandle(t.Context(), "alice")
+	if handle != "Alice" || err != nil {
+		t.Errorf("ValidateHandle(alice) = %q, %v, want Alice", handle, err)
+	}
+	if _, err := client.ValidateHandle(t.Context(), "nobody"); !errors.Is(err, domain.ErrHandleNotFound) {
+		t.Errorf("ValidateHandle(nobody) error = %v, want ErrHandleNotFound", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	client, lc := newClient(t)
+	lc.AddUser("alice", 0)
+	lc.FailNext(leetcode.OperationRecentAcSubmissions, http.StatusTooManyRequests)
+
+	_, err := client.FetchSubmissions(t.Context(), "alice")
+	if err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Fatalf("error = %v, want one mentioning status 429", err)
+	}
+	if calls := lc.Calls(leetcode.OperationRecentAcSubmissions); calls != 1 {
+		t.Errorf("recentAcSubmissions called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,215 @@
+// Package leetcodetest provides an in-process stand-in for LeetCode's GraphQL
+// endpoint, for tests that exercise code built on leetcode.Client. It answers
+// from scripted users, or replays recorded responses verbatim.
+package leetcodetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/leetcode"
+)
+
+// Server serves the recentAcSubmissions and userProfile operations. Like
+// LeetCode, it rejects requests without a leetcode.com referer. All methods
+// are safe for concurrent use.
+type Server struct {
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	users      map[string]*user
+	recordings map[recordingKey][]byte
+	failures   map[string][]int
+	calls      map[string]int
+}
+
+type user struct {
+	username    string
+	rating      float64
+	submissions []leetcode.AcSubmission
+}
+
+type recordingKey struct {
+	operation, username string
+}
+
+// NewServer starts a fake endpoint. Pass URL() as the client's URL and call
+// Close when done.
+func NewServer() *Server {
+	s := &Server{
+		users:      make(map[string]*user),
+		recordings: make(map[recordingKey][]byte),
+		failures:   make(map[string][]int),
+		calls:      make(map[string]int),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) URL() string {
+	return s.srv.URL + "/graphql"
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// AddUser registers a username with accepted submissions. A zero rating
+// leaves the user without a contest ranking. Usernames are matched
+// case-insensitively, as on LeetCode.
+func (s *Server) AddUser(username string, rating float64, submissions ...leetcode.AcSubmission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[strings.ToLower(username)] = &user{username: username, rating: rating, submissions: submissions}
+}
+
+// AddSubmissions appends accepted submissions to an existing user.
+func (s *Server) AddSubmissions(username string, submissions ...leetcode.AcSubmission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[strings.ToLower(username)]
+	if !ok {
+		panic("leetcodetest: unknown user " + username)
+	}
+	u.submissions = append(u.submissions, submissions...)
+}
+
+// Replay makes the server answer operation for username with body, a
+// response recorded from leetcode.com, instead of a scripted one.
+func (s *Server) Replay(operation, username string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordings[recordingKey{operation, strings.ToLower(username)}] = body
+}
+
+// FailNext makes the next request for operation respond with the given HTTP
+// status. Repeated calls queue further failures.
+func (s *Server) FailNext(operation string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[operation] = append(s.failures[operation], status)
+}
+
+// Calls returns how many requests operation has received, including failed
+// ones.
+func (s *Server) Calls(operation string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[operation]
+}
+
+type request struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+	Variables     struct {
+		Username string `json:"username"`
+		Limit    int    `json:"limit"`
+	} `json:"variables"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+		http.NotFound(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Referer"), "https://leetcode.com") {
+		http.Error(w, "CSRF verification failed", http.StatusForbidden)
+		return
+	}
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[req.OperationName]++
+
+	if queued := s.failures[req.OperationName]; len(queued) > 0 {
+		s.failures[req.OperationName] = queued[1:]
+		http.Error(w, http.StatusText(queued[0]), queued[0])
+		return
+	}
+
+	key := strings.ToLower(req.Variables.Username)
+	if body, ok := s.recordings[recordingKey{req.OperationName, key}]; ok {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+		return
+	}
+
+	u := s.users[key]
+	switch req.OperationName {
+	case leetcode.OperationRecentAcSubmissions:
+		writeJSON(w, map[string]any{"data": map[string]any{"recentAcSubmissionList": recent(u, req.Variables.Limit)}})
+	case leetcode.OperationUserProfile:
+		s.userProfile(w, u)
+	default:
+		writeJSON(w, map[string]any{"errors": []map[string]any{{"message": "Unknown operation " + req.OperationName}}})
+	}
+}
+
+// recent returns up to limit, and at most leetcode.RecentLimit, of the
+// user's submissions, newest first. Unknown users have none.
+func recent(u *user, limit int) []leetcode.AcSubmission {
+	result := []leetcode.AcSubmission{}
+	if u == nil {
+		return result
+	}
+	result = append(result, u.submissions...)
+	sort.SliceStable(result, func(i, j int) bool {
+		ti, _ := strconv.ParseInt(result[i].Timestamp, 10, 64)
+		tj, _ := strconv.ParseInt(result[j].Timestamp, 10, 64)
+		return ti > tj
+	})
+	return result[:min(len(result), limit, leetcode.RecentLimit)]
+}
+
+func (s *Server) userProfile(w http.ResponseWriter, u *user) {
+	if u == nil {
+		writeJSON(w, map[string]any{
+			"data":   map[string]any{"matchedUser": nil, "userContestRanking": nil},
+			"errors": []map[string]any{{"message": "That user does not exist.", "path": []string{"matchedUser"}}},
+		})
+		return
+	}
+
+	// Badges depend on the whole contest population, so scripted users
+	// have none; replay a recording to test them.
+	var ranking any
+	if u.rating > 0 {
+		ranking = map[string]any{"rating": u.rating, "badge": nil}
+	}
+	writeJSON(w, map[string]any{"data": map[string]any{
+		"matchedUser":        map[string]string{"username": u.username},
+		"userContestRanking": ranking,
+	}})
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Accepted builds an accepted submission at the given time.
+func Accepted(id int64, at time.Time) leetcode.AcSubmission {
+	return leetcode.AcSubmission{
+		ID:        strconv.FormatInt(id, 10),
+		Title:     "Problem " + strconv.FormatInt(id, 10),
+		TitleSlug: "problem-" + strconv.FormatInt(id, 10),
+		Timestamp: strconv.FormatInt(at.Unix(), 10),
+	}
+}
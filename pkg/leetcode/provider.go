@@ -0,0 +1,75 @@
+package leetcode
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/internal/domain"
+)
+
+// The methods below make Client a judge provider for the sync.
+
+func (c *Client) Judge() string {
+	return domain.JudgeLeetCode
+}
+
+// ValidateHandle checks that a user exists and returns the username as
+// LeetCode spells it.
+func (c *Client) ValidateHandle(ctx context.Context, handle string) (string, error) {
+	profile, err := c.GetProfile(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	return profile.Username, nil
+}
+
+// FetchProfile reports the user's contest rating, rounded, and contest badge
+// as the rank. Both are empty for users who never entered a contest.
+func (c *Client) FetchProfile(ctx context.Context, handle string) (*domain.JudgeProfile, error) {
+	profile, err := c.GetProfile(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.JudgeProfile{Handle: profile.Username}
+	if ranking := profile.ContestRating; ranking != nil {
+		result.Rating = int(math.Round(ranking.Rating))
+		if ranking.Badge != nil {
+			result.Rank = ranking.Badge.Name
+		}
+	}
+	return result, nil
+}
+
+// FetchSubmissions returns the handle's last RecentLimit accepted
+// submissions, newest first. LeetCode does not report rejected submissions
+// or the language without logging in.
+func (c *Client) FetchSubmissions(ctx context.Context, handle string) ([]domain.JudgeSubmission, error) {
+	submissions, err := c.GetRecentAcSubmissions(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.JudgeSubmission, len(submissions))
+	for i, sub := range submissions {
+		id, err := strconv.ParseInt(sub.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("submission id %q: %w", sub.ID, err)
+		}
+		seconds, err := strconv.ParseInt(sub.Timestamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("submission %s timestamp %q: %w", sub.ID, sub.Timestamp, err)
+		}
+		result[i] = domain.JudgeSubmission{
+			Judge:       domain.JudgeLeetCode,
+			ID:          id,
+			ProblemID:   sub.TitleSlug,
+			Verdict:     domain.VerdictAccepted,
+			SubmittedAt: time.Unix(seconds, 0).UTC(),
+		}
+	}
+	return result, nil
+}
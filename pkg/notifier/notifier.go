@@ -0,0 +1,113 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// Channel names used in notification preferences.
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelDiscord  = "discord"
+	ChannelWebhook  = "webhook"
+)
+
+// sendTimeout bounds a single delivery, connecting included.
+const sendTimeout = 10 * time.Second
+
+// ErrPrivateTarget is returned when a user-supplied target resolves to an
+// address on the server's own host or network.
+var ErrPrivateTarget = errors.New("target is not a public address")
+
+type Message struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers a message to a channel-specific target: an email address,
+// a Telegram chat ID or a webhook URL.
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, target string, msg Message) error
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: sendTimeout,
+	}
+}
+
+// newTargetHTTPClient returns a client for URLs users supply. Unless
+// allowPrivate is set, it refuses to connect to loopback, private,
+// link-local and unspecified addresses, so a target cannot reach the
+// server's host, its network or a cloud metadata endpoint. The check runs on
+// every address actually dialled, redirects included, so a hostname cannot
+// resolve to one of them after being looked at.
+func newTargetHTTPClient(allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return newHTTPClient()
+	}
+
+	dialer := &net.Dialer{Timeout: sendTimeout, Control: rejectPrivateAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled in place of the target and defeat the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   sendTimeout,
+		Transport: transport,
+	}
+}
+
+func rejectPrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, ip)
+	}
+	return nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
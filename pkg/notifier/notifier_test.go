@@ -0,0 +1,282 @@
+package notifier_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/notifier"
+)
+
+var testMessage = notifier.Message{Subject: "Streak at risk", Body: "Solve a problem before midnight."}
+
+// smtpServer accepts one connection and speaks just enough SMTP for
+// net/smtp.SendMail, without offering STARTTLS or AUTH.
+type smtpServer struct {
+	listener net.Listener
+	done     chan struct{}
+
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpServer{listener: l, done: make(chan struct{})}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			s.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			s.to = append(s.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	srv := newSMTPServer(t)
+	n := notifier.NewSMTPNotifier("127.0.0.1", srv.port(), "", "", "streaks@example.com")
+
+	if err := n.Send(t.Context(), "alice@example.com", testMessage); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	<-srv.done
+
+	if srv.from != "streaks@example.com" {
+		t.Errorf("MAIL FROM = %q", srv.from)
+	}
+	if len(srv.to) != 1 || srv.to[0] != "alice@example.com" {
+		t.Errorf("RCPT TO = %v", srv.to)
+	}
+	for _, want := range []string{
+		"From: streaks@example.com\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: Streak at risk\r\n",
+		"\r\n\r\nSolve a problem before midnight.",
+	} {
+		if !strings.Contains(srv.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, srv.data)
+		}
+	}
+}
+
+func TestSMTPNotifierGivesUpOnASilentServer(t *testing.T) {
+	// The server accepts the connection but never greets.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	n := notifier.NewSMTPNotifier("127.0.0.1", l.Addr().(*net.TCPAddr).Port, "", "", "streaks@example.com")
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := n.Send(ctx, "alice@example.com", testMessage); err == nil {
+		t.Fatal("send to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("send took %v, want it bounded by the context", elapsed)
+	}
+}
+
+func TestSMTPNotifierRejectsHeaderInjection(t *testing.T) {
+	n := notifier.NewSMTPNotifier("127.0.0.1", 1, "", "", "streaks@example.com")
+
+	if err := n.Send(t.Context(), "alice@example.com\r\nBcc: eve@example.com", testMessage); err == nil {
+		t.Error("send to an address with a line break succeeded")
+	}
+}
+
+// receiver records the path and JSON body of each request and answers with
+// status.
+func receiver(t *testing.T, status int) (*httptest.Server, *[]string, *[]map[string]string) {
+	t.Helper()
+
+	var paths []string
+	var bodies []map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &paths, &bodies
+}
+
+func TestHTTPNotifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		send     func(url string) error
+		wantPath string
+		wantBody map[string]string
+	}{
+		{
+			name: "telegram",
+			send: func(url string) error {
+				return notifier.NewTelegramNotifier(url, "123:abc").Send(t.Context(), "42", testMessage)
+			},
+			wantPath: "/bot123:abc/sendMessage",
+			wantBody: map[string]string{"chat_id": "42", "text": "Streak at risk\n\nSolve a problem before midnight."},
+		},
+		{
+			name: "discord",
+			send: func(url string) error {
+				return notifier.NewDiscordNotifier(true).Send(t.Context(), url+"/api/webhooks/1/token", testMessage)
+			},
+			wantPath: "/api/webhooks/1/token",
+			wantBody: map[string]string{"content": "**Streak at risk**\nSolve a problem before midnight."},
+		},
+		{
+			name: "webhook",
+			send: func(url string) error {
+				return notifier.NewWebhookNotifier(true).Send(t.Context(), url+"/hook", testMessage)
+			},
+			wantPath: "/hook",
+			wantBody: map[string]string{"subject": "Streak at risk", "body": "Solve a problem before midnight."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, paths, bodies := receiver(t, http.StatusOK)
+
+			if err := tt.send(srv.URL); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if len(*paths) != 1 || (*paths)[0] != tt.wantPath {
+				t.Errorf("paths = %v, want [%s]", *paths, tt.wantPath)
+			}
+			if len(*bodies) == 1 {
+				for key, want := range tt.wantBody {
+					if got := (*bodies)[0][key]; got != want {
+						t.Errorf("%s = %q, want %q", key, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPNotifiersRejectPrivateTargets(t *testing.T) {
+	srv, paths, _ := receiver(t, http.StatusOK)
+
+	senders := map[string]notifier.Notifier{
+		"discord": notifier.NewDiscordNotifier(false),
+		"webhook": notifier.NewWebhookNotifier(false),
+	}
+	targets := []string{
+		srv.URL + "/hook",
+		strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/hook",
+		"http://[::1]:1/hook",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0:1/hook",
+		"http://[::ffff:127.0.0.1]:1/hook",
+	}
+	for name, n := range senders {
+		for _, target := range targets {
+			if err := n.Send(t.Context(), target, testMessage); !errors.Is(err, notifier.ErrPrivateTarget) {
+				t.Errorf("%s to %s = %v, want ErrPrivateTarget", name, target, err)
+			}
+		}
+	}
+	if len(*paths) != 0 {
+		t.Errorf("private targets received %v", *paths)
+	}
+}
+
+func TestHTTPNotifierErrorStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusInternalServerError} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			srv, _, _ := receiver(t, status)
+
+			err := notifier.NewWebhookNotifier(true).Send(t.Context(), srv.URL, testMessage)
+			if err == nil || !strings.Contains(err.Error(), strconv.Itoa(status)) {
+				t.Errorf("send = %v, want an error naming status %d", err, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type TelegramNotifier struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewTelegramNotifier creates a Bot API notifier. baseURL is normally
+// https://api.telegram.org and only differs when pointing at a stand-in server.
+func NewTelegramNotifier(baseURL, token string) *TelegramNotifier {
+	return &TelegramNotifier{
+		baseURL:    baseURL,
+		token:      token,
+		httpClient: newHTTPClient(),
+	}
+}
+
+func (n *TelegramNotifier) Channel() string {
+	return ChannelTelegram
+}
+
+// Send posts the message to the chat whose ID is target.
+func (n *TelegramNotifier) Send(ctx context.Context, target string, msg Message) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", n.baseURL, n.token)
+
+	payload := map[string]string{
+		"chat_id": target,
+		"text":    msg.Subject + "\n\n" + msg.Body,
+	}
+
+	if err := postJSON(ctx, n.httpClient, url, payload); err != nil {
+		return fmt.Errorf("telegram: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type DiscordNotifier struct {
+	httpClient *http.Client
+}
+
+// NewDiscordNotifier posts to Discord webhook URLs that users supply. Only
+// public addresses are reached unless allowPrivate is set.
+func NewDiscordNotifier(allowPrivate bool) *DiscordNotifier {
+	return &DiscordNotifier{httpClient: newTargetHTTPClient(allowPrivate)}
+}
+
+func (n *DiscordNotifier) Channel() string {
+	return ChannelDiscord
+}
+
+// Send posts the message to the Discord webhook URL given as target.
+func (n *DiscordNotifier) Send(ctx context.Context, target string, msg Message) error {
+	payload := map[string]string{
+		"content": fmt.Sprintf("**%s**\n%s", msg.Subject, msg.Body),
+	}
+
+	if err := postJSON(ctx, n.httpClient, target, payload); err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return nil
+}
+
+type WebhookNotifier struct {
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier posts to URLs that users supply. Only public addresses
+// are reached unless allowPrivate is set.
+func NewWebhookNotifier(allowPrivate bool) *WebhookNotifier {
+	return &WebhookNotifier{httpClient: newTargetHTTPClient(allowPrivate)}
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return ChannelWebhook
+}
+
+// Send posts the message as JSON to the URL given as target.
+func (n *WebhookNotifier) Send(ctx context.Context, target string, msg Message) error {
+	if err := postJSON(ctx, n.httpClient, target, msg); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
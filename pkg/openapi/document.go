@@ -0,0 +1,350 @@
+// Package openapi builds an OpenAPI 3 document from operation descriptions,
+// deriving the JSON schemas of request and response bodies from their Go
+// types, and validates requests and responses against it. The operation
+// table itself is generated from swaggo-style handler annotations by
+// cmd/openapi-gen.
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.0.3"
+
+// Operation describes one route as declared by handler annotations.
+type Operation struct {
+	ID          string
+	Method      string // lower case
+	Path        string // with {name} path parameters
+	Summary     string
+	Description string
+	Tags        []string
+	Consumes    []string
+	Produces    []string
+	Security    []string
+	Params      []Param
+	Responses   []Response
+}
+
+// Param is a path, query, header or body parameter. There is at most one
+// body parameter per operation.
+type Param struct {
+	Name        string
+	In          string // path, query, header or body
+	Type        reflect.Type
+	Required    bool
+	Description string
+	Default     any
+}
+
+// Response is one documented status. Type is nil when there is no body.
+type Response struct {
+	Status      int
+	Type        reflect.Type
+	Description string
+}
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []*Parameter               `json:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+}
+
+// Build assembles a document from operations. Struct types become shared
+// component schemas named after the Go type.
+func Build(info Info, security map[string]*SecurityScheme, operations []Operation) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: security,
+		},
+	}
+	g := &schemaGen{schemas: doc.Components.Schemas, names: make(map[reflect.Type]string)}
+
+	for _, op := range operations {
+		obj := &OperationObject{
+			OperationID: op.ID,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Tags:        op.Tags,
+			Responses:   make(map[string]*ResponseObject),
+		}
+		for _, name := range op.Security {
+			obj.Security = append(obj.Security, map[string][]string{name: {}})
+		}
+
+		for _, p := range op.Params {
+			if p.In == "body" {
+				obj.RequestBody = &RequestBody{
+					Description: p.Description,
+					Required:    p.Required,
+					Content:     content(op.Consumes, g.schema(p.Type)),
+				}
+				continue
+			}
+			param := &Parameter{
+				Name:        p.Name,
+				In:          p.In,
+				Description: p.Description,
+				Required:    p.Required || p.In == "path",
+				Schema:      g.schema(p.Type),
+			}
+			param.Schema.Nullable = false
+			param.Schema.Default = p.Default
+			if param.Schema.Type == "array" && p.In == "query" {
+				explode := true
+				param.Style, param.Explode = "form", &explode
+			}
+			obj.Parameters = append(obj.Parameters, param)
+		}
+
+		for _, r := range op.Responses {
+			resp := &ResponseObject{Description: r.Description}
+			if resp.Description == "" {
+				resp.Description = http.StatusText(r.Status)
+			}
+			if r.Type != nil {
+				resp.Content = content(op.Produces, g.schema(r.Type))
+			}
+			obj.Responses[strconv.Itoa(r.Status)] = resp
+		}
+
+		item := doc.Paths[op.Path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[op.Path] = item
+		}
+		(*item)[op.Method] = obj
+	}
+	return doc
+}
+
+func content(mimeTypes []string, schema *Schema) map[string]MediaType {
+	if len(mimeTypes) == 0 {
+		mimeTypes = []string{"application/json"}
+	}
+	out := make(map[string]MediaType, len(mimeTypes))
+	for _, mime := range mimeTypes {
+		out[mime] = MediaType{Schema: schema}
+	}
+	return out
+}
+
+type schemaGen struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schema describes how encoding/json renders values of t.
+func (g *schemaGen) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() != reflect.Pointer && t.Implements(jsonMarshalerType):
+		return &Schema{} // custom encoding; anything goes
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Pointer:
+		elem := g.schema(t.Elem())
+		if elem.Ref != "" {
+			return &Schema{AllOf: []*Schema{elem}, Nullable: true}
+		}
+		elem.Nullable = true
+		return elem
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		// A nil slice encodes as null.
+		return &Schema{Type: "array", Items: g.schema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		panic(fmt.Sprintf("openapi: no JSON schema for %s", t))
+	}
+}
+
+// component registers the schema of a named struct type and returns its
+// component name.
+func (g *schemaGen) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{} // reserve the name for recursive types
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+// addFields follows encoding/json's rules: unexported and "-" fields are
+// skipped and untagged embedded structs are flattened. binding:"required"
+// is what gin enforces on requests, so it marks required properties.
+func (g *schemaGen) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schema(f.Type)
+		if strings.Contains(f.Tag.Get("json"), ",string") {
+			prop = &Schema{Type: "string"}
+		}
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+			if prop.Type == "string" {
+				one := 1
+				prop.MinLength = &one
+			}
+		}
+		s.Properties[name] = prop
+	}
+}
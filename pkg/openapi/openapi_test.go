@@ -0,0 +1,143 @@
+package openapi_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/openapi"
+)
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type widget struct {
+	base
+	Name    string            `json:"name" binding:"required"`
+	Owner   *owner            `json:"owner"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Created time.Time         `json:"created_at"`
+	secret  string
+}
+
+type owner struct {
+	Handle string `json:"handle"`
+}
+
+func newDocument() *openapi.Document {
+	return openapi.Build(openapi.Info{Title: "Widgets", Version: "1.0"}, nil, []openapi.Operation{{
+		ID:       "CreateWidget",
+		Method:   "post",
+		Path:     "/widgets/{id}",
+		Consumes: []string{"application/json"},
+		Produces: []string{"application/json"},
+		Params: []openapi.Param{
+			{Name: "id", In: "path", Type: reflect.TypeFor[int](), Required: true},
+			{Name: "dry_run", In: "query", Type: reflect.TypeFor[bool]()},
+			{Name: "widget", In: "body", Type: reflect.TypeFor[widget](), Required: true},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Type: reflect.TypeFor[widget]()},
+			{Status: http.StatusNoContent},
+		},
+	}})
+}
+
+func TestBuildSchemas(t *testing.T) {
+	doc := newDocument()
+
+	s := doc.Components.Schemas["widget"]
+	if s == nil {
+		t.Fatal("widget schema missing")
+	}
+	for _, name := range []string{"id", "name", "owner", "tags", "labels", "created_at"} {
+		if s.Properties[name] == nil {
+			t.Errorf("property %q missing", name)
+		}
+	}
+	if s.Properties["secret"] != nil {
+		t.Error("unexported field documented")
+	}
+	if len(s.Required) != 1 || s.Required[0] != "name" || *s.Properties["name"].MinLength != 1 {
+		t.Errorf("required = %v", s.Required)
+	}
+	if p := s.Properties["created_at"]; p.Type != "string" || p.Format != "date-time" {
+		t.Errorf("created_at = %+v", p)
+	}
+	if p := s.Properties["owner"]; !p.Nullable || len(p.AllOf) != 1 || p.AllOf[0].Ref != "#/components/schemas/owner" {
+		t.Errorf("owner = %+v", p)
+	}
+	if p := s.Properties["tags"]; p.Type != "array" || !p.Nullable || p.Items.Type != "string" {
+		t.Errorf("tags = %+v", p)
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	v := openapi.NewValidator(newDocument())
+	op := v.Operation(http.MethodPost, "/widgets/{id}")
+	if op == nil {
+		t.Fatal("operation not found")
+	}
+
+	tests := []struct {
+		id, query, body string
+		want            string // error substring, or "" for valid
+	}{
+		{"1", "", `{"name":"a","owner":null,"tags":["x"]}`, ""},
+		{"1", "dry_run=true", `{"name":"a","extra":1}`, ""},
+		{"x", "", `{"name":"a"}`, `path parameter "id": "x" is not a number`},
+		{"1", "dry_run=maybe", `{"name":"a"}`, `query parameter "dry_run": "maybe" is not a boolean`},
+		{"1", "", ``, "request body is required"},
+		{"1", "", `{"name":"a","tags":[1]}`, "body.tags[0]: must be a string, not a number"},
+		{"1", "", `{"name":"a","owner":{"handle":true}}`, "body.owner.handle: must be a string, not a boolean"},
+		{"1", "", `{"id":1.5,"name":"a"}`, "body.id: 1.5 is not an integer"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/widgets/"+tt.id+"?"+tt.query, strings.NewReader(tt.body))
+		err := v.ValidateRequest(op, r, func(string) string { return tt.id })
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.body, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: error = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+}
+
+func TestValidateRequestRestoresBody(t *testing.T) {
+	v := openapi.NewValidator(newDocument())
+	op := v.Operation(http.MethodPost, "/widgets/{id}")
+
+	r := httptest.NewRequest(http.MethodPost, "/widgets/1", strings.NewReader(`{"name":"a"}`))
+	if err := v.ValidateRequest(op, r, func(string) string { return "1" }); err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil || string(body) != `{"name":"a"}` {
+		t.Errorf("body after validation = %q, %v", body, err)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	v := openapi.NewValidator(newDocument())
+	op := v.Operation(http.MethodPost, "/widgets/{id}")
+
+	if err := v.ValidateResponse(op, http.StatusCreated, "application/json", []byte(`{"id":1,"name":"a","created_at":"2024-03-15T12:00:00Z"}`)); err != nil {
+		t.Errorf("valid response: %v", err)
+	}
+	if err := v.ValidateResponse(op, http.StatusNoContent, "", nil); err != nil {
+		t.Errorf("empty response: %v", err)
+	}
+	if err := v.ValidateResponse(op, http.StatusNotFound, "application/json", []byte(`{}`)); err == nil || err.Error() != "status 404 is not documented" {
+		t.Errorf("undocumented status: %v", err)
+	}
+	if err := v.ValidateResponse(op, http.StatusCreated, "application/json", []byte(`{"name":5}`)); err == nil {
+		t.Error("expected a schema mismatch")
+	}
+}
@@ -0,0 +1,26 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+)
+
+//go:embed ui.html
+var uiHTML string
+
+var uiTemplate = template.Must(template.New("ui").Parse(uiHTML))
+
+// UI renders a Swagger UI page for the document at specURL. The page is
+// self-contained apart from the swagger-ui-dist assets, which are loaded
+// from assetsURL so they can be pointed at a CDN or a local copy.
+func UI(title, specURL, assetsURL string) []byte {
+	var buf bytes.Buffer
+	err := uiTemplate.Execute(&buf, struct {
+		Title, SpecURL, AssetsURL string
+	}{title, specURL, assetsURL})
+	if err != nil {
+		panic(err) // the template and its inputs are all strings
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,303 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validator checks requests and responses against a document.
+type Validator struct {
+	doc *Document
+}
+
+func NewValidator(doc *Document) *Validator {
+	return &Validator{doc: doc}
+}
+
+// Operation finds the operation for a method and a path template such as
+// /api/v1/users/{handle}, or nil if the document does not describe it.
+func (v *Validator) Operation(method, path string) *OperationObject {
+	item := v.doc.Paths[path]
+	if item == nil {
+		return nil
+	}
+	return (*item)[strings.ToLower(method)]
+}
+
+// ValidateRequest checks the parameters and JSON body of r. pathParam
+// returns the value of a path parameter. The body is read and replaced so
+// handlers can still read it.
+func (v *Validator) ValidateRequest(op *OperationObject, r *http.Request, pathParam func(name string) string) error {
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var raw []string
+		switch p.In {
+		case "path":
+			if value := pathParam(p.Name); value != "" {
+				raw = []string{value}
+			}
+		case "query":
+			raw = query[p.Name]
+		case "header":
+			raw = r.Header.Values(p.Name)
+		}
+
+		if len(raw) == 0 {
+			if p.Required {
+				return fmt.Errorf("missing required %s parameter %q", p.In, p.Name)
+			}
+			continue
+		}
+
+		value, err := parseParam(p.Schema, raw)
+		if err == nil {
+			err = v.validate(p.Schema, value, p.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: %w", p.In, p.Name, err)
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return nil
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = readAndRestore(r)
+		if err != nil {
+			return fmt.Errorf("reading request body: %w", err)
+		}
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return errors.New("request body is required")
+		}
+		return nil
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return fmt.Errorf("request body is not valid JSON: %w", err)
+	}
+	return v.validate(media.Schema, value, "body")
+}
+
+// ValidateResponse checks that status is documented for op and that a JSON
+// body matches its schema.
+func (v *Validator) ValidateResponse(op *OperationObject, status int, contentType string, body []byte) error {
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if len(body) == 0 || mediaType != "application/json" {
+		return nil
+	}
+	media, ok := resp.Content["application/json"]
+	if !ok {
+		return fmt.Errorf("status %d is documented without a JSON body", status)
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return fmt.Errorf("response body is not valid JSON: %w", err)
+	}
+	return v.validate(media.Schema, value, "body")
+}
+
+func readAndRestore(r *http.Request) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = readCloser{bytes.NewReader(buf.Bytes())}
+	return buf.Bytes(), nil
+}
+
+type readCloser struct {
+	*bytes.Reader
+}
+
+func (readCloser) Close() error { return nil }
+
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseParam converts the raw strings of a parameter to JSON-like values.
+func parseParam(s *Schema, raw []string) (any, error) {
+	if s.Type == "array" {
+		items := make([]any, len(raw))
+		for i, r := range raw {
+			item, err := parseScalar(s.Items, r)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return parseScalar(s, raw[0])
+}
+
+func parseScalar(s *Schema, raw string) (any, error) {
+	switch s.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return b, nil
+	default:
+		return raw, nil
+	}
+}
+
+func (v *Validator) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		s = v.doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// validate checks a decoded JSON value against a schema. Unknown object
+// properties are allowed.
+func (v *Validator) validate(s *Schema, value any, path string) error {
+	if s == nil {
+		return nil
+	}
+	nullable := s.Nullable
+	s = v.resolve(s)
+	nullable = nullable || s.Nullable
+
+	if value == nil {
+		if nullable || (s.Type == "" && len(s.AllOf) == 0) {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", path)
+	}
+
+	for _, sub := range s.AllOf {
+		if err := v.validate(sub, value, path); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return typeError(path, "an object", value)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if err := v.validate(prop, obj[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return typeError(path, "an array", value)
+		}
+		for i, item := range items {
+			if err := v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return typeError(path, "a string", value)
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			return fmt.Errorf("%s: must not be empty", path)
+		}
+
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(path, "a number", value)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return typeError(path, "a number", value)
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			return fmt.Errorf("%s: %s is not an integer", path, n)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: %s is less than %v", path, n, *s.Minimum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(path, "a boolean", value)
+		}
+	}
+	return nil
+}
+
+func typeError(path, want string, got any) error {
+	var kind string
+	switch got.(type) {
+	case map[string]any:
+		kind = "an object"
+	case []any:
+		kind = "an array"
+	case string:
+		kind = "a string"
+	case json.Number:
+		kind = "a number"
+	case bool:
+		kind = "a boolean"
+	default:
+		kind = fmt.Sprintf("%T", got)
+	}
+	return fmt.Errorf("%s: must be %s, not %s", path, want, kind)
+}
@@ -0,0 +1,120 @@
+package redis_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis"
+	"github.com/pouyatavakoli/CodeStreaks-web/pkg/redis/redistest"
+)
+
+func newClient(t *testing.T) (*redis.Client, *redistest.Server) {
+	t.Helper()
+
+	srv := redistest.NewServer()
+	t.Cleanup(srv.Close)
+
+	client, err := redis.NewClient(srv.URL())
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, srv
+}
+
+func TestClientCommands(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := t.Context()
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	if _, err := client.Get(ctx, "missing"); !errors.Is(err, redis.ErrNil) {
+		t.Errorf("GET missing key: err = %v, want ErrNil", err)
+	}
+
+	value := []byte("binary\r\nsafe\x00value")
+	if err := client.Set(ctx, "key", value, 0); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	got, err := client.Get(ctx, "key")
+	if err != nil || string(got) != string(value) {
+		t.Errorf("GET key = %q, %v; want %q", got, err, value)
+	}
+
+	for want := int64(1); want <= 3; want++ {
+		if n, err := client.Incr(ctx, "counter"); err != nil || n != want {
+			t.Errorf("INCR = %d, %v; want %d", n, err, want)
+		}
+	}
+
+	if n, err := client.IncrBy(ctx, "counter", -5); err != nil || n != -2 {
+		t.Errorf("INCRBY -5 = %d, %v; want -2", n, err)
+	}
+
+	if _, err := client.Incr(ctx, "key"); !errors.As(err, new(redis.Error)) {
+		t.Errorf("INCR on a string: err = %v, want a server error", err)
+	}
+	// A server error leaves the connection usable.
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("ping after server error: %v", err)
+	}
+}
+
+func TestClientSetTTL(t *testing.T) {
+	client, srv := newClient(t)
+	ctx := t.Context()
+
+	if err := client.Set(ctx, "short", []byte("v"), 20*time.Millisecond); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	reply, err := client.Do(ctx, "PTTL", "short")
+	if ttl, ok := reply.(int64); err != nil || !ok || ttl <= 0 {
+		t.Errorf("PTTL = %v, %v; want a positive TTL", reply, err)
+	}
+
+	if ok, err := client.Expire(ctx, "missing", time.Second); err != nil || ok {
+		t.Errorf("PEXPIRE missing key = %v, %v; want false", ok, err)
+	}
+	if err := client.Set(ctx, "kept", []byte("v"), 0); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if ok, err := client.Expire(ctx, "kept", 20*time.Millisecond); err != nil || !ok {
+		t.Errorf("PEXPIRE = %v, %v; want true", ok, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := client.Get(ctx, "short"); !errors.Is(err, redis.ErrNil) {
+		t.Errorf("GET expired key: err = %v, want ErrNil", err)
+	}
+	if keys := srv.Keys(); len(keys) != 0 {
+		t.Errorf("keys after expiry = %v, want none", keys)
+	}
+}
+
+func TestClientServerGone(t *testing.T) {
+	client, srv := newClient(t)
+	ctx := t.Context()
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	srv.Close()
+
+	if err := client.Ping(ctx); err == nil {
+		t.Error("ping after the server closed succeeded")
+	}
+}
+
+func TestNewClientURL(t *testing.T) {
+	for _, rawURL := range []string{"http://localhost:6379", "redis://localhost/abc", "::"} {
+		if _, err := redis.NewClient(rawURL); err == nil {
+			t.Errorf("NewClient(%q) succeeded, want an error", rawURL)
+		}
+	}
+	if _, err := redis.NewClient("redis://:secret@localhost:6380/2"); err != nil {
+		t.Errorf("NewClient with password and db: %v", err)
+	}
+}
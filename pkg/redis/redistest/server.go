@@ -0,0 +1,306 @@
+// Package redistest provides an in-process stand-in for a Redis server,
+// for tests that exercise code built on redis.Client.
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server understands PING, AUTH, SELECT, GET, SET (with EX, PX and NX), DEL,
+// INCR, INCRBY, PEXPIRE and PTTL on a single keyspace. Expiry follows the wall clock.
+type Server struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	data     map[string]entry
+	commands map[string]int
+	closed   bool
+}
+
+type entry struct {
+	value     string
+	expiresAt time.Time // zero when the key does not expire
+}
+
+// NewServer listens on a random local port. Pass URL() to redis.NewClient
+// and call Close when done.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("redistest: listen: %v", err))
+	}
+
+	s := &Server{
+		ln:       ln,
+		data:     make(map[string]entry),
+		commands: make(map[string]int),
+	}
+	go s.accept()
+	return s
+}
+
+func (s *Server) URL() string {
+	return "redis://" + s.ln.Addr().String()
+}
+
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.ln.Close()
+}
+
+// Commands reports how many times a command, e.g. "GET", was received.
+func (s *Server) Commands(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commands[name]
+}
+
+// Keys lists the keys that have not expired.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.data {
+		if _, ok := s.lookup(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *Server) accept() {
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(nc)
+	}
+}
+
+func (s *Server) serve(nc net.Conn) {
+	defer nc.Close()
+
+	r := bufio.NewReader(nc)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(nc, "-ERR %v\r\n", err)
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		reply := s.exec(args)
+		s.mu.Unlock()
+
+		if _, err := io.WriteString(nc, reply); err != nil {
+			return
+		}
+	}
+}
+
+// exec runs one command with s.mu held and returns the encoded reply.
+func (s *Server) exec(args []string) string {
+	name := strings.ToUpper(args[0])
+	s.commands[name]++
+
+	switch name {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		if len(args) != 2 {
+			return wrongArgs(name)
+		}
+		e, ok := s.lookup(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(e.value)
+	case "SET":
+		return s.set(args)
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.lookup(key); ok {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return integer(int64(deleted))
+	case "INCR":
+		if len(args) != 2 {
+			return wrongArgs(name)
+		}
+		return s.incrBy(args[1], 1)
+	case "INCRBY":
+		if len(args) != 3 {
+			return wrongArgs(name)
+		}
+		by, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		return s.incrBy(args[1], by)
+	case "PEXPIRE":
+		if len(args) != 3 {
+			return wrongArgs(name)
+		}
+		ms, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		e, ok := s.lookup(args[1])
+		if !ok {
+			return integer(0)
+		}
+		e.expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		s.data[args[1]] = e
+		return integer(1)
+	case "PTTL":
+		if len(args) != 2 {
+			return wrongArgs(name)
+		}
+		e, ok := s.lookup(args[1])
+		switch {
+		case !ok:
+			return integer(-2)
+		case e.expiresAt.IsZero():
+			return integer(-1)
+		default:
+			return integer(time.Until(e.expiresAt).Milliseconds())
+		}
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func (s *Server) set(args []string) string {
+	if len(args) < 3 {
+		return wrongArgs("SET")
+	}
+
+	e := entry{value: args[2]}
+	nx := false
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return "-ERR syntax error\r\n"
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return "-ERR invalid expire time in 'set' command\r\n"
+			}
+			unit := time.Millisecond
+			if strings.EqualFold(args[i], "EX") {
+				unit = time.Second
+			}
+			e.expiresAt = time.Now().Add(time.Duration(n) * unit)
+			i++
+		default:
+			return "-ERR syntax error\r\n"
+		}
+	}
+
+	if _, exists := s.lookup(args[1]); nx && exists {
+		return "$-1\r\n"
+	}
+	s.data[args[1]] = e
+	return "+OK\r\n"
+}
+
+func (s *Server) incrBy(key string, by int64) string {
+	e, _ := s.lookup(key)
+	n := int64(0)
+	if e.value != "" {
+		var err error
+		if n, err = strconv.ParseInt(e.value, 10, 64); err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+	}
+	n += by
+	e.value = strconv.FormatInt(n, 10)
+	s.data[key] = e
+	return integer(n)
+}
+
+// lookup returns the live entry for key, dropping it if it has expired.
+func (s *Server) lookup(key string) (entry, bool) {
+	e, ok := s.data[key]
+	if ok && !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		delete(s.data, key)
+		return entry{}, false
+	}
+	return e, ok
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad array length %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimSuffix(header, "\r\n")
+		if !strings.HasPrefix(header, "$") {
+			return nil, fmt.Errorf("expected bulk string, got %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("bad bulk length %q", header)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func integer(n int64) string {
+	return ":" + strconv.FormatInt(n, 10) + "\r\n"
+}
+
+func wrongArgs(name string) string {
+	return fmt.Sprintf("-ERR wrong number of arguments for '%s' command\r\n", strings.ToLower(name))
+}
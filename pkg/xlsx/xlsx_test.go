@@ -0,0 +1,128 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+)
+
+type sheetXML struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  string `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Leader<board>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var never *time.Time
+	if err := w.WriteRow("rank", "handle", "last_submission_at"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(1, "tourist & co", &submitted); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(int64(2), "petr", never); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = body
+
+		// Every part must be well-formed XML.
+		dec := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if !bytes.Contains(parts["xl/workbook.xml"], []byte(`name="Leader&lt;board&gt;"`)) {
+		t.Errorf("sheet name not escaped: %s", parts["xl/workbook.xml"])
+	}
+
+	var sheet sheetXML
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(sheet.Rows))
+	}
+
+	header := sheet.Rows[0].Cells
+	if len(header) != 3 || header[2].Ref != "C1" || header[2].Type != "inlineStr" || header[2].Inline != "last_submission_at" {
+		t.Errorf("header = %+v", header)
+	}
+
+	row := sheet.Rows[1].Cells
+	if row[0].Type != "" || row[0].Value != "1" {
+		t.Errorf("rank cell = %+v, want the number 1", row[0])
+	}
+	if row[1].Inline != "tourist & co" {
+		t.Errorf("handle cell = %+v", row[1])
+	}
+	// 2024-03-01 is day 45352; noon adds half a day.
+	if row[2].Style != "1" || row[2].Value != "45352.5" {
+		t.Errorf("date cell = %+v, want 45352.5 in the date style", row[2])
+	}
+
+	if cells := sheet.Rows[2].Cells; len(cells) != 2 || cells[0].Value != "2" {
+		t.Errorf("row with a nil time = %+v, want two cells", cells)
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	tests := []struct {
+		col, row int
+		want     string
+	}{
+		{0, 1, "A1"},
+		{25, 2, "Z2"},
+		{26, 3, "AA3"},
+		{701, 4, "ZZ4"},
+		{702, 5, "AAA5"},
+	}
+	for _, tt := range tests {
+		if got := cellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("cellRef(%d, %d) = %s, want %s", tt.col, tt.row, got, tt.want)
+		}
+	}
+}